@@ -1,7 +1,12 @@
 package app
 
 import (
+	"github.com/panagiotisptr/job-scheduler/audit"
+	"github.com/panagiotisptr/job-scheduler/authz"
+	"github.com/panagiotisptr/job-scheduler/events"
+	"github.com/panagiotisptr/job-scheduler/notifier"
 	"github.com/panagiotisptr/job-scheduler/service"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 )
 
@@ -9,16 +14,34 @@ type App struct {
 	logger         *zap.Logger
 	cronJobService *service.CronJobService
 	kubeService    *service.KubernetesService
+	promotions     *service.PromotionService
+	bus            *events.Bus
+	notifier       *notifier.Notifier
+	authorizer     *authz.Authorizer
+	auditor        *audit.Auditor
+	tracer         trace.Tracer
 }
 
 func ProvideApp(
 	logger *zap.Logger,
 	cronJobService *service.CronJobService,
 	kubeService *service.KubernetesService,
+	promotions *service.PromotionService,
+	bus *events.Bus,
+	notifier *notifier.Notifier,
+	authorizer *authz.Authorizer,
+	auditor *audit.Auditor,
+	tp trace.TracerProvider,
 ) *App {
 	return &App{
 		logger:         logger,
 		cronJobService: cronJobService,
 		kubeService:    kubeService,
+		promotions:     promotions,
+		bus:            bus,
+		notifier:       notifier,
+		authorizer:     authorizer,
+		auditor:        auditor,
+		tracer:         tp.Tracer("github.com/panagiotisptr/job-scheduler/app"),
 	}
 }
@@ -0,0 +1,109 @@
+package app
+
+import (
+	"context"
+	"errors"
+
+	"github.com/panagiotisptr/job-scheduler/audit"
+	"github.com/panagiotisptr/job-scheduler/auth"
+	"github.com/panagiotisptr/job-scheduler/authz"
+	"github.com/panagiotisptr/job-scheduler/requestid"
+	"github.com/panagiotisptr/job-scheduler/tracing"
+)
+
+// recordAudit records the outcome of a mutating operation
+func (a *App) recordAudit(
+	ctx context.Context,
+	op authz.Operation,
+	jobName string,
+	err error,
+) {
+	a.recordAuditFrom(ctx, op, "", jobName, a.cronJobService.GetCronJobSource, err)
+}
+
+// recordTaskAudit records the outcome of a mutating operation on a
+// task
+func (a *App) recordTaskAudit(
+	ctx context.Context,
+	op authz.Operation,
+	taskName string,
+	err error,
+) {
+	a.recordAuditFrom(ctx, op, audit.KindTask, taskName, a.cronJobService.GetTaskSource, err)
+}
+
+func (a *App) recordAuditFrom(
+	ctx context.Context,
+	op authz.Operation,
+	kind string,
+	jobName string,
+	getSource sourceFunc,
+	err error,
+) {
+	r := audit.Record{
+		Actor:     auth.SubjectFromContext(ctx),
+		Operation: string(op),
+		Kind:      kind,
+		Job:       jobName,
+		Namespace: a.kubeService.GetNamespace(),
+		RequestID: requestid.FromContext(ctx),
+		Outcome:   audit.OutcomeSuccess,
+	}
+	if identity, ok := auth.IdentityFromContext(ctx); ok {
+		r.Groups = identity.Groups
+		r.AuthMethod = identity.Method
+	}
+	if source, err := getSource(ctx, jobName); err == nil {
+		r.CommitSHA = source.Commit
+	}
+
+	var forbidden *authz.ForbiddenError
+	if errors.As(err, &forbidden) {
+		r.Outcome = audit.OutcomeDenied
+		r.Error = err.Error()
+	} else if err != nil {
+		r.Outcome = audit.OutcomeFailure
+		r.Error = err.Error()
+	}
+
+	a.auditor.Record(ctx, r)
+}
+
+// ListAuditRecords returns the audit records of the jobs the caller
+// can view, newest first
+func (a *App) ListAuditRecords(
+	ctx context.Context,
+	f audit.Filter,
+) ([]audit.Record, error) {
+	ctx, span := a.tracer.Start(ctx, "App.ListAuditRecords")
+	defer span.End()
+
+	records, err := a.auditor.Query(ctx, f)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return nil, err
+	}
+	if !a.authorizer.Enabled() {
+		return records, nil
+	}
+
+	allowed := map[string]bool{}
+	res := []audit.Record{}
+	for _, r := range records {
+		key := r.Kind + "/" + r.Job
+		ok, checked := allowed[key]
+		if !checked {
+			filter := a.filterAuthorized
+			if r.Kind == audit.KindTask {
+				filter = a.filterAuthorizedTasks
+			}
+			ok = len(filter(ctx, authz.OperationView, []string{r.Job})) == 1
+			allowed[key] = ok
+		}
+		if ok {
+			res = append(res, r)
+		}
+	}
+
+	return res, nil
+}
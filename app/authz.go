@@ -0,0 +1,259 @@
+package app
+
+import (
+	"context"
+	"path"
+
+	"github.com/panagiotisptr/job-scheduler/apperror"
+	"github.com/panagiotisptr/job-scheduler/auth"
+	"github.com/panagiotisptr/job-scheduler/authz"
+	"github.com/panagiotisptr/job-scheduler/repository"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// sourceFunc looks up the location a manifest was indexed from
+type sourceFunc func(ctx context.Context, name string) (*repository.Source, error)
+
+// authzRequest fills in the namespace and the manifest location of
+// the job. Jobs that are not in the index have no location
+func (a *App) authzRequest(
+	ctx context.Context,
+	op authz.Operation,
+	jobName string,
+) authz.Request {
+	return a.authzRequestFrom(ctx, op, jobName, a.cronJobService.GetCronJobSource)
+}
+
+// authzRequestFrom is authzRequest for a manifest of any kind, tasks
+// are authorized with the same rules as cronjobs
+func (a *App) authzRequestFrom(
+	ctx context.Context,
+	op authz.Operation,
+	jobName string,
+	getSource sourceFunc,
+) authz.Request {
+	req := authz.Request{
+		Operation: op,
+		Job:       jobName,
+		Namespace: a.kubeService.GetNamespace(),
+	}
+	if jobName == "" {
+		return req
+	}
+	source, err := getSource(ctx, jobName)
+	if err == nil {
+		req.Location = path.Join(source.Owner, source.Name, source.Path)
+	}
+
+	return req
+}
+
+// authorize returns a ForbiddenError if the caller is not allowed to
+// perform the operation on the job
+func (a *App) authorize(
+	ctx context.Context,
+	op authz.Operation,
+	jobName string,
+) error {
+	return a.authorizeFrom(ctx, op, jobName, a.cronJobService.GetCronJobSource)
+}
+
+// authorizeTask is authorize for tasks
+func (a *App) authorizeTask(
+	ctx context.Context,
+	op authz.Operation,
+	taskName string,
+) error {
+	return a.authorizeFrom(ctx, op, taskName, a.cronJobService.GetTaskSource)
+}
+
+func (a *App) authorizeFrom(
+	ctx context.Context,
+	op authz.Operation,
+	jobName string,
+	getSource sourceFunc,
+) error {
+	if !a.authorizer.Enabled() {
+		return nil
+	}
+	identity, _ := auth.IdentityFromContext(ctx)
+	req := a.authzRequestFrom(ctx, op, jobName, getSource)
+	decision := a.authorizer.Authorize(identity, req)
+	trace.SpanFromContext(ctx).SetAttributes(
+		attribute.Bool("authz.allowed", decision.Allowed),
+	)
+	if decision.Allowed {
+		return nil
+	}
+
+	subject := auth.SubjectFromContext(ctx)
+	a.logger.Sugar().Warnw(
+		"request denied",
+		"actor", subject,
+		"operation", op,
+		"job", jobName,
+	)
+
+	forbidden := &authz.ForbiddenError{
+		Subject: subject,
+		Request: req,
+		Reason:  decision.Reason,
+	}
+
+	return apperror.Wrap(apperror.CodeForbidden, forbidden).
+		WithDetail("subject", subject).
+		WithDetail("operation", op).
+		WithDetail("job", jobName).
+		WithDetail("reason", decision.Reason)
+}
+
+// filterAuthorized keeps the jobs the caller is allowed to perform
+// the operation on
+func (a *App) filterAuthorized(
+	ctx context.Context,
+	op authz.Operation,
+	jobNames []string,
+) []string {
+	return a.filterAuthorizedFrom(ctx, op, jobNames, a.cronJobService.GetCronJobSource)
+}
+
+// filterAuthorizedTasks is filterAuthorized for tasks
+func (a *App) filterAuthorizedTasks(
+	ctx context.Context,
+	op authz.Operation,
+	taskNames []string,
+) []string {
+	return a.filterAuthorizedFrom(ctx, op, taskNames, a.cronJobService.GetTaskSource)
+}
+
+func (a *App) filterAuthorizedFrom(
+	ctx context.Context,
+	op authz.Operation,
+	jobNames []string,
+	getSource sourceFunc,
+) []string {
+	if !a.authorizer.Enabled() {
+		return jobNames
+	}
+	allowed := []string{}
+	for _, name := range jobNames {
+		if a.allowedFrom(ctx, op, name, getSource) {
+			allowed = append(allowed, name)
+		}
+	}
+
+	return allowed
+}
+
+// allowedFrom whether the caller can perform the operation on the
+// job, without logging a denial like authorizeFrom
+func (a *App) allowedFrom(
+	ctx context.Context,
+	op authz.Operation,
+	jobName string,
+	getSource sourceFunc,
+) bool {
+	if !a.authorizer.Enabled() {
+		return true
+	}
+	identity, _ := auth.IdentityFromContext(ctx)
+
+	return a.authorizer.Authorize(
+		identity,
+		a.authzRequestFrom(ctx, op, jobName, getSource),
+	).Allowed
+}
+
+// AuthorizationCheck a dry-run of the authorization of a request.
+// Subject and Groups default to the caller and can only narrow it
+// down, Namespace and Location default to the ones of the job
+type AuthorizationCheck struct {
+	Subject   string   `json:"subject"`
+	Groups    []string `json:"groups"`
+	Operation string   `json:"operation"`
+	Job       string   `json:"job"`
+	Namespace string   `json:"namespace"`
+	Location  string   `json:"location"`
+}
+
+type AuthorizationCheckResult struct {
+	Subject  string         `json:"subject"`
+	Groups   []string       `json:"groups"`
+	Request  authz.Request  `json:"request"`
+	Decision authz.Decision `json:"decision"`
+}
+
+// CheckAuthorization evaluates the policy for the caller without
+// performing the operation. The caller can't check the policy for
+// other subjects or for jobs it can't view, which would reveal their
+// location
+func (a *App) CheckAuthorization(
+	ctx context.Context,
+	check AuthorizationCheck,
+) (*AuthorizationCheckResult, error) {
+	ctx, span := a.tracer.Start(ctx, "App.CheckAuthorization")
+	defer span.End()
+
+	op, err := authz.ParseOperation(check.Operation)
+	if err != nil {
+		return nil, apperror.Wrap(apperror.CodeInvalid, err)
+	}
+
+	identity := &auth.Identity{Subject: auth.Anonymous}
+	if caller, ok := auth.IdentityFromContext(ctx); ok {
+		identity = caller
+	}
+	if check.Subject != "" && check.Subject != identity.Subject {
+		return nil, apperror.Forbidden(
+			"%s can't check the authorization of %s",
+			identity.Subject,
+			check.Subject,
+		)
+	}
+	if check.Groups != nil {
+		for _, g := range check.Groups {
+			if !containsGroup(identity.Groups, g) {
+				return nil, apperror.Forbidden(
+					"%s is not in group %s",
+					identity.Subject,
+					g,
+				)
+			}
+		}
+		identity = &auth.Identity{
+			Subject: identity.Subject,
+			Groups:  check.Groups,
+		}
+	}
+	if check.Job != "" {
+		if err := a.authorize(ctx, authz.OperationView, check.Job); err != nil {
+			return nil, err
+		}
+	}
+
+	req := a.authzRequest(ctx, op, check.Job)
+	if check.Namespace != "" {
+		req.Namespace = check.Namespace
+	}
+	if check.Location != "" {
+		req.Location = check.Location
+	}
+
+	return &AuthorizationCheckResult{
+		Subject:  identity.Subject,
+		Groups:   identity.Groups,
+		Request:  req,
+		Decision: a.authorizer.Authorize(identity, req),
+	}, nil
+}
+
+func containsGroup(groups []string, group string) bool {
+	for _, g := range groups {
+		if g == group {
+			return true
+		}
+	}
+
+	return false
+}
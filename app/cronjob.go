@@ -3,23 +3,102 @@ package app
 import (
 	"context"
 
+	"github.com/panagiotisptr/job-scheduler/authz"
+	"github.com/panagiotisptr/job-scheduler/repository"
+	"github.com/panagiotisptr/job-scheduler/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	batchv1 "k8s.io/api/batch/v1"
 )
 
 func (a *App) ListAvailableCronJobNames(
 	ctx context.Context,
 ) ([]string, error) {
-	return a.cronJobService.ListAvailableCronJobs(
-		context.Background(),
+	ctx, span := a.tracer.Start(ctx, "App.ListAvailableCronJobNames")
+	defer span.End()
+
+	names, err := a.cronJobService.ListAvailableCronJobs(
+		ctx,
 	)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return nil, err
+	}
+
+	return a.filterAuthorized(ctx, authz.OperationList, names), nil
 }
 
 func (a *App) GetCronJobConfig(
 	ctx context.Context,
 	jobName string,
 ) (*batchv1.CronJob, error) {
-	return a.cronJobService.GetCronJob(
-		context.Background(),
+	ctx, span := a.tracer.Start(
+		ctx,
+		"App.GetCronJobConfig",
+		trace.WithAttributes(attribute.String("job.name", jobName)),
+	)
+	defer span.End()
+
+	if err := a.authorize(ctx, authz.OperationView, jobName); err != nil {
+		tracing.RecordError(span, err)
+		return nil, err
+	}
+	cj, err := a.cronJobService.GetCronJob(
+		ctx,
 		jobName,
 	)
+	tracing.RecordError(span, err)
+
+	return cj, err
+}
+
+// GetCronJobSource returns where the manifest of the cronjob was read
+// from, with the last commit that changed it. The commit is left out
+// if GitHub can't be reached
+func (a *App) GetCronJobSource(
+	ctx context.Context,
+	jobName string,
+) (*repository.Source, error) {
+	ctx, span := a.tracer.Start(
+		ctx,
+		"App.GetCronJobSource",
+		trace.WithAttributes(attribute.String("job.name", jobName)),
+	)
+	defer span.End()
+
+	if err := a.authorize(ctx, authz.OperationView, jobName); err != nil {
+		tracing.RecordError(span, err)
+		return nil, err
+	}
+	source, err := a.cronJobService.GetCronJobSource(ctx, jobName)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return nil, err
+	}
+
+	res := *source
+	commit, err := a.cronJobService.GetLastCommit(ctx, source)
+	if err != nil {
+		a.logger.Sugar().Warnw(
+			"failed to get the last commit of the manifest",
+			"job", jobName,
+			"path", source.Path,
+			"error", err,
+		)
+	}
+	res.LastCommit = commit
+
+	return &res, nil
+}
+
+func (a *App) ListSyncHistory(
+	ctx context.Context,
+) ([]repository.SyncRecord, error) {
+	ctx, span := a.tracer.Start(ctx, "App.ListSyncHistory")
+	defer span.End()
+
+	history, err := a.cronJobService.GetSyncHistory(ctx)
+	tracing.RecordError(span, err)
+
+	return history, err
 }
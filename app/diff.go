@@ -0,0 +1,84 @@
+package app
+
+import (
+	"context"
+
+	"github.com/panagiotisptr/job-scheduler/apperror"
+	"github.com/panagiotisptr/job-scheduler/authz"
+	"github.com/panagiotisptr/job-scheduler/managed"
+	"github.com/panagiotisptr/job-scheduler/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	batchv1 "k8s.io/api/batch/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// JobDiff the manifest of a cronjob in GitHub and in the cluster.
+// Live is nil when the cronjob is not in the cluster
+type JobDiff struct {
+	JobName string           `json:"jobName"`
+	Desired *batchv1.CronJob `json:"desired"`
+	Live    *batchv1.CronJob `json:"live"`
+}
+
+// DiffJob returns the cronjob as starting it would create it and as
+// it is in the cluster. Only the labels, annotations and spec are
+// kept so that the two can be compared
+func (a *App) DiffJob(
+	ctx context.Context,
+	jobName string,
+) (*JobDiff, error) {
+	ctx, span := a.tracer.Start(
+		ctx,
+		"App.DiffJob",
+		trace.WithAttributes(attribute.String("job.name", jobName)),
+	)
+	defer span.End()
+
+	if err := a.authorize(ctx, authz.OperationView, jobName); err != nil {
+		tracing.RecordError(span, err)
+		return nil, err
+	}
+	desired, err := a.cronJobService.GetCronJob(ctx, jobName)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return nil, err
+	}
+	// the commit annotation starting it would set
+	commit := ""
+	if source, err := a.cronJobService.GetCronJobSource(ctx, jobName); err == nil {
+		commit = source.Commit
+	}
+	diff := &JobDiff{
+		JobName: jobName,
+		Desired: comparableCronJob(managed.Mark(desired, commit)),
+	}
+
+	live, err := a.kubeService.GetCronJob(ctx, jobName)
+	if apperror.CodeOf(err) == apperror.CodeNotFound {
+		return diff, nil
+	}
+	if err != nil {
+		tracing.RecordError(span, err)
+		return nil, err
+	}
+	diff.Live = comparableCronJob(live)
+
+	return diff, nil
+}
+
+// comparableCronJob drops the fields set by the cluster
+func comparableCronJob(cj *batchv1.CronJob) *batchv1.CronJob {
+	return &batchv1.CronJob{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "batch/v1",
+			Kind:       "CronJob",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        cj.Name,
+			Labels:      cj.Labels,
+			Annotations: cj.Annotations,
+		},
+		Spec: cj.Spec,
+	}
+}
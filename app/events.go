@@ -0,0 +1,53 @@
+package app
+
+import (
+	"context"
+	"strings"
+
+	"github.com/panagiotisptr/job-scheduler/auth"
+	"github.com/panagiotisptr/job-scheduler/authz"
+	"github.com/panagiotisptr/job-scheduler/events"
+)
+
+// SubscribeEvents returns a channel with every job event published
+// from now on and a function to cancel the subscription
+func (a *App) SubscribeEvents() (<-chan events.Event, func()) {
+	return a.bus.Subscribe()
+}
+
+// CanViewEvent whether the caller can view the cronjob or task the
+// event is about. Subscribers check every event before passing it on
+func (a *App) CanViewEvent(
+	ctx context.Context,
+	e events.Event,
+) bool {
+	return a.canView(ctx, e.Type, e.JobName)
+}
+
+// canView whether the caller can view the cronjob, or the task for
+// the task events
+func (a *App) canView(
+	ctx context.Context,
+	t events.Type,
+	name string,
+) bool {
+	getSource := a.cronJobService.GetCronJobSource
+	if strings.HasPrefix(string(t), "task.") {
+		getSource = a.cronJobService.GetTaskSource
+	}
+
+	return a.allowedFrom(ctx, authz.OperationView, name, getSource)
+}
+
+func (a *App) publishJobEvent(
+	ctx context.Context,
+	t events.Type,
+	jobName string,
+) {
+	a.bus.Publish(events.Event{
+		Type:      t,
+		JobName:   jobName,
+		Namespace: a.kubeService.GetNamespace(),
+		Actor:     auth.SubjectFromContext(ctx),
+	})
+}
@@ -2,19 +2,73 @@ package app
 
 import (
 	"context"
+
+	"github.com/panagiotisptr/job-scheduler/auth"
+	"github.com/panagiotisptr/job-scheduler/authz"
+	"github.com/panagiotisptr/job-scheduler/events"
+	"github.com/panagiotisptr/job-scheduler/repository"
+	"github.com/panagiotisptr/job-scheduler/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 func (a *App) ListRunningJobs(
 	ctx context.Context,
 ) ([]string, error) {
-	return a.kubeService.ListRunningCronJobs(
+	ctx, span := a.tracer.Start(ctx, "App.ListRunningJobs")
+	defer span.End()
+
+	names, err := a.kubeService.ListRunningCronJobs(
 		ctx,
 	)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return nil, err
+	}
+
+	return a.filterAuthorized(ctx, authz.OperationList, names), nil
 }
 
 func (a *App) StartJob(
 	ctx context.Context,
 	jobName string,
+) (err error) {
+	ctx, span := a.tracer.Start(
+		ctx,
+		"App.StartJob",
+		trace.WithAttributes(
+			attribute.String("job.name", jobName),
+			attribute.String("enduser.id", auth.SubjectFromContext(ctx)),
+		),
+	)
+	defer span.End()
+	defer func() {
+		a.recordAudit(ctx, authz.OperationStart, jobName, err)
+	}()
+	if err = a.authorize(ctx, authz.OperationStart, jobName); err != nil {
+		tracing.RecordError(span, err)
+		return err
+	}
+	a.logger.Sugar().Infow(
+		"starting job",
+		"job", jobName,
+		"actor", auth.SubjectFromContext(ctx),
+	)
+
+	if err = a.applyCronJob(ctx, jobName); err != nil {
+		tracing.RecordError(span, err)
+		return err
+	}
+	a.publishJobEvent(ctx, events.JobStarted, jobName)
+
+	return nil
+}
+
+// applyCronJob creates or resumes the cronjob with its indexed
+// manifest and companions
+func (a *App) applyCronJob(
+	ctx context.Context,
+	jobName string,
 ) error {
 	cronJob, err := a.cronJobService.GetCronJob(
 		ctx,
@@ -24,26 +78,200 @@ func (a *App) StartJob(
 		return err
 	}
 
+	companions, err := a.cronJobService.GetCompanions(
+		ctx,
+		jobName,
+	)
+	if err != nil {
+		return err
+	}
+
+	// kept with the revision and on the cronjob, it doesn't stop the
+	// cronjob from being applied
+	commit := ""
+	source, err := a.cronJobService.GetCronJobSource(ctx, jobName)
+	if err != nil {
+		a.logger.Sugar().Warnw(
+			"failed to get the source of job",
+			"job", jobName,
+			"error", err,
+		)
+	} else {
+		commit = source.Commit
+	}
+
 	return a.kubeService.StartCronJob(
 		ctx,
 		cronJob,
+		companions,
+		commit,
+		auth.SubjectFromContext(ctx),
 	)
 }
 
 func (a *App) StopJob(
 	ctx context.Context,
 	jobName string,
-) error {
+) (err error) {
+	ctx, span := a.tracer.Start(
+		ctx,
+		"App.StopJob",
+		trace.WithAttributes(
+			attribute.String("job.name", jobName),
+			attribute.String("enduser.id", auth.SubjectFromContext(ctx)),
+		),
+	)
+	defer span.End()
+	defer func() {
+		a.recordAudit(ctx, authz.OperationStop, jobName, err)
+	}()
+	if err = a.authorize(ctx, authz.OperationStop, jobName); err != nil {
+		tracing.RecordError(span, err)
+		return err
+	}
+	a.logger.Sugar().Infow(
+		"stopping job",
+		"job", jobName,
+		"actor", auth.SubjectFromContext(ctx),
+	)
+
 	cronJob, err := a.cronJobService.GetCronJob(
 		ctx,
 		jobName,
 	)
 	if err != nil {
+		tracing.RecordError(span, err)
 		return err
 	}
 
-	return a.kubeService.StopCronJob(
+	err = a.kubeService.StopCronJob(
 		ctx,
 		cronJob,
 	)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return err
+	}
+	a.publishJobEvent(ctx, events.JobStopped, jobName)
+
+	return nil
+}
+
+func (a *App) DeleteJob(
+	ctx context.Context,
+	jobName string,
+) (err error) {
+	ctx, span := a.tracer.Start(
+		ctx,
+		"App.DeleteJob",
+		trace.WithAttributes(
+			attribute.String("job.name", jobName),
+			attribute.String("enduser.id", auth.SubjectFromContext(ctx)),
+		),
+	)
+	defer span.End()
+	defer func() {
+		a.recordAudit(ctx, authz.OperationDelete, jobName, err)
+	}()
+	if err = a.authorize(ctx, authz.OperationDelete, jobName); err != nil {
+		tracing.RecordError(span, err)
+		return err
+	}
+	a.logger.Sugar().Infow(
+		"deleting job",
+		"job", jobName,
+		"actor", auth.SubjectFromContext(ctx),
+	)
+
+	err = a.kubeService.DeleteCronJob(
+		ctx,
+		jobName,
+	)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return err
+	}
+	a.publishJobEvent(ctx, events.JobDeleted, jobName)
+
+	return nil
+}
+
+// RunJob creates a job from the template of the cronjob without
+// waiting for its schedule and returns the name of the job
+func (a *App) RunJob(
+	ctx context.Context,
+	jobName string,
+) (runName string, err error) {
+	ctx, span := a.tracer.Start(
+		ctx,
+		"App.RunJob",
+		trace.WithAttributes(
+			attribute.String("job.name", jobName),
+			attribute.String("enduser.id", auth.SubjectFromContext(ctx)),
+		),
+	)
+	defer span.End()
+	defer func() {
+		a.recordAudit(ctx, authz.OperationRun, jobName, err)
+	}()
+	if err = a.authorize(ctx, authz.OperationRun, jobName); err != nil {
+		tracing.RecordError(span, err)
+		return "", err
+	}
+	a.logger.Sugar().Infow(
+		"running job",
+		"job", jobName,
+		"actor", auth.SubjectFromContext(ctx),
+	)
+
+	cronJob, err := a.cronJobService.GetCronJob(
+		ctx,
+		jobName,
+	)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return "", err
+	}
+
+	job, err := a.kubeService.RunCronJob(
+		ctx,
+		cronJob,
+	)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return "", err
+	}
+	a.bus.Publish(events.Event{
+		Type:         events.JobRun,
+		JobName:      jobName,
+		Namespace:    a.kubeService.GetNamespace(),
+		ChildJobName: job.Name,
+		Actor:        auth.SubjectFromContext(ctx),
+	})
+
+	return job.Name, nil
+}
+
+// GetJobLogs returns the logs of a job spawned by the cronjob. The
+// caller has to close them
+func (a *App) GetJobLogs(
+	ctx context.Context,
+	jobName string,
+	opts repository.LogOptions,
+) (*repository.JobLogs, error) {
+	ctx, span := a.tracer.Start(
+		ctx,
+		"App.GetJobLogs",
+		trace.WithAttributes(attribute.String("job.name", jobName)),
+	)
+	defer span.End()
+
+	if err := a.authorize(ctx, authz.OperationView, jobName); err != nil {
+		tracing.RecordError(span, err)
+		return nil, err
+	}
+	logs, err := a.kubeService.GetJobLogs(ctx, jobName, opts)
+	tracing.RecordError(span, err)
+
+	return logs, err
 }
@@ -0,0 +1,24 @@
+package app
+
+import (
+	"context"
+
+	"github.com/panagiotisptr/job-scheduler/notifier"
+)
+
+func (a *App) ListNotificationDeliveries(
+	ctx context.Context,
+) ([]notifier.Delivery, error) {
+	all, err := a.notifier.ListDeliveries(ctx)
+	if err != nil {
+		return nil, err
+	}
+	deliveries := []notifier.Delivery{}
+	for _, d := range all {
+		if a.canView(ctx, d.EventType, d.JobName) {
+			deliveries = append(deliveries, d)
+		}
+	}
+
+	return deliveries, nil
+}
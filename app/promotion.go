@@ -0,0 +1,179 @@
+package app
+
+import (
+	"context"
+	"time"
+
+	"github.com/panagiotisptr/job-scheduler/apperror"
+	"github.com/panagiotisptr/job-scheduler/auth"
+	"github.com/panagiotisptr/job-scheduler/authz"
+	"github.com/panagiotisptr/job-scheduler/repository"
+	"github.com/panagiotisptr/job-scheduler/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// how long reverting a promotion may take, it pins the previous
+// commit again through GitHub
+const revertPromotionTimeout = 10 * time.Second
+
+// PromoteJob pins the cronjob to the commit it runs at in the
+// environment it is promoted from, or to commit if it is set. A
+// cronjob running in the cluster is updated right away, the others
+// get the pinned manifest when they are started. If the update fails
+// the promotion is reverted
+func (a *App) PromoteJob(
+	ctx context.Context,
+	jobName string,
+	from string,
+	commit string,
+) (promotion *repository.Promotion, err error) {
+	ctx, span := a.tracer.Start(
+		ctx,
+		"App.PromoteJob",
+		trace.WithAttributes(
+			attribute.String("job.name", jobName),
+			attribute.String("promotion.from", from),
+			attribute.String("enduser.id", auth.SubjectFromContext(ctx)),
+		),
+	)
+	defer span.End()
+	defer func() {
+		a.recordAudit(ctx, authz.OperationPromote, jobName, err)
+	}()
+	if err = a.authorize(ctx, authz.OperationPromote, jobName); err != nil {
+		tracing.RecordError(span, err)
+		return nil, err
+	}
+
+	promotion, err = a.promotions.Promote(
+		ctx,
+		jobName,
+		from,
+		commit,
+		auth.SubjectFromContext(ctx),
+	)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return nil, err
+	}
+	a.logger.Sugar().Infow(
+		"promoted job",
+		"job", jobName,
+		"from", from,
+		"commit", promotion.Commit,
+		"actor", auth.SubjectFromContext(ctx),
+	)
+
+	live, err := a.kubeService.GetCronJob(ctx, jobName)
+	if apperror.CodeOf(err) == apperror.CodeNotFound {
+		return promotion, nil
+	}
+	if err != nil {
+		tracing.RecordError(span, err)
+		return nil, err
+	}
+	if live.Spec.Suspend != nil && *live.Spec.Suspend {
+		return promotion, nil
+	}
+	if err = a.applyCronJob(ctx, jobName); err != nil {
+		tracing.RecordError(span, err)
+		a.revertPromotion(promotion)
+		return nil, err
+	}
+
+	return promotion, nil
+}
+
+// revertPromotion reverts a promotion whose manifest failed to be
+// applied. It doesn't use the context of the request, which may be
+// the reason the apply failed
+func (a *App) revertPromotion(promotion *repository.Promotion) {
+	ctx, cancel := context.WithTimeout(
+		context.Background(),
+		revertPromotionTimeout,
+	)
+	defer cancel()
+
+	if err := a.promotions.Revert(ctx, promotion); err != nil {
+		a.logger.Sugar().Errorw(
+			"failed to revert promotion",
+			"job", promotion.JobName,
+			"commit", promotion.Commit,
+			"previousCommit", promotion.PreviousCommit,
+			"error", err,
+		)
+		return
+	}
+	a.logger.Sugar().Infow(
+		"reverted promotion",
+		"job", promotion.JobName,
+		"commit", promotion.Commit,
+	)
+}
+
+// ReleaseJob makes a promoted cronjob follow the ref of its location
+// again. The cluster is not changed until the cronjob is started
+func (a *App) ReleaseJob(
+	ctx context.Context,
+	jobName string,
+) (err error) {
+	ctx, span := a.tracer.Start(
+		ctx,
+		"App.ReleaseJob",
+		trace.WithAttributes(
+			attribute.String("job.name", jobName),
+			attribute.String("enduser.id", auth.SubjectFromContext(ctx)),
+		),
+	)
+	defer span.End()
+	defer func() {
+		a.recordAudit(ctx, authz.OperationPromote, jobName, err)
+	}()
+	if err = a.authorize(ctx, authz.OperationPromote, jobName); err != nil {
+		tracing.RecordError(span, err)
+		return err
+	}
+
+	err = a.promotions.Release(ctx, jobName)
+	tracing.RecordError(span, err)
+
+	return err
+}
+
+// ListPromotions returns the promotions of the cronjobs the caller
+// can view, newest first
+func (a *App) ListPromotions(
+	ctx context.Context,
+) ([]repository.Promotion, error) {
+	ctx, span := a.tracer.Start(ctx, "App.ListPromotions")
+	defer span.End()
+
+	promotions, err := a.promotions.ListPromotions(ctx)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return nil, err
+	}
+
+	names := []string{}
+	seen := map[string]struct{}{}
+	for _, p := range promotions {
+		if _, ok := seen[p.JobName]; !ok {
+			seen[p.JobName] = struct{}{}
+			names = append(names, p.JobName)
+		}
+	}
+	allowed := map[string]struct{}{}
+	for _, name := range a.filterAuthorized(ctx, authz.OperationView, names) {
+		allowed[name] = struct{}{}
+	}
+
+	res := []repository.Promotion{}
+	for _, p := range promotions {
+		if _, ok := allowed[p.JobName]; ok {
+			res = append(res, p)
+		}
+	}
+
+	return res, nil
+}
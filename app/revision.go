@@ -0,0 +1,82 @@
+package app
+
+import (
+	"context"
+
+	"github.com/panagiotisptr/job-scheduler/auth"
+	"github.com/panagiotisptr/job-scheduler/authz"
+	"github.com/panagiotisptr/job-scheduler/events"
+	"github.com/panagiotisptr/job-scheduler/repository"
+	"github.com/panagiotisptr/job-scheduler/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ListJobRevisions returns the specs the cronjob was applied with,
+// newest first
+func (a *App) ListJobRevisions(
+	ctx context.Context,
+	jobName string,
+) ([]repository.Revision, error) {
+	ctx, span := a.tracer.Start(
+		ctx,
+		"App.ListJobRevisions",
+		trace.WithAttributes(attribute.String("job.name", jobName)),
+	)
+	defer span.End()
+
+	if err := a.authorize(ctx, authz.OperationView, jobName); err != nil {
+		tracing.RecordError(span, err)
+		return nil, err
+	}
+	revisions, err := a.kubeService.ListRevisions(ctx, jobName)
+	tracing.RecordError(span, err)
+
+	return revisions, err
+}
+
+// RollbackJob applies an earlier revision of the cronjob. The cronjob
+// keeps running the spec of that revision until it is started again
+func (a *App) RollbackJob(
+	ctx context.Context,
+	jobName string,
+	number uint64,
+) (revision *repository.Revision, err error) {
+	ctx, span := a.tracer.Start(
+		ctx,
+		"App.RollbackJob",
+		trace.WithAttributes(
+			attribute.String("job.name", jobName),
+			attribute.Int64("job.revision", int64(number)),
+			attribute.String("enduser.id", auth.SubjectFromContext(ctx)),
+		),
+	)
+	defer span.End()
+	defer func() {
+		a.recordAudit(ctx, authz.OperationRollback, jobName, err)
+	}()
+	if err = a.authorize(ctx, authz.OperationRollback, jobName); err != nil {
+		tracing.RecordError(span, err)
+		return nil, err
+	}
+	a.logger.Sugar().Infow(
+		"rolling back job",
+		"job", jobName,
+		"revision", number,
+		"actor", auth.SubjectFromContext(ctx),
+	)
+
+	revision, err = a.kubeService.RollbackCronJob(
+		ctx,
+		jobName,
+		number,
+		auth.SubjectFromContext(ctx),
+	)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return nil, err
+	}
+	a.publishJobEvent(ctx, events.JobRolledBack, jobName)
+
+	return revision, nil
+}
@@ -0,0 +1,134 @@
+package app
+
+import (
+	"context"
+
+	"github.com/panagiotisptr/job-scheduler/auth"
+	"github.com/panagiotisptr/job-scheduler/authz"
+	"github.com/panagiotisptr/job-scheduler/events"
+	"github.com/panagiotisptr/job-scheduler/repository"
+	"github.com/panagiotisptr/job-scheduler/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	batchv1 "k8s.io/api/batch/v1"
+)
+
+func (a *App) ListAvailableTaskNames(
+	ctx context.Context,
+) ([]string, error) {
+	ctx, span := a.tracer.Start(ctx, "App.ListAvailableTaskNames")
+	defer span.End()
+
+	names, err := a.cronJobService.ListAvailableTasks(
+		ctx,
+	)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return nil, err
+	}
+
+	return a.filterAuthorizedTasks(ctx, authz.OperationList, names), nil
+}
+
+func (a *App) GetTaskConfig(
+	ctx context.Context,
+	taskName string,
+) (*batchv1.Job, error) {
+	ctx, span := a.tracer.Start(
+		ctx,
+		"App.GetTaskConfig",
+		trace.WithAttributes(attribute.String("task.name", taskName)),
+	)
+	defer span.End()
+
+	if err := a.authorizeTask(ctx, authz.OperationView, taskName); err != nil {
+		tracing.RecordError(span, err)
+		return nil, err
+	}
+	task, err := a.cronJobService.GetTask(
+		ctx,
+		taskName,
+	)
+	tracing.RecordError(span, err)
+
+	return task, err
+}
+
+// RunTask creates a job from the task manifest and returns its
+// name. Its completion is published as a task.succeeded or
+// task.failed event
+func (a *App) RunTask(
+	ctx context.Context,
+	taskName string,
+) (runName string, err error) {
+	ctx, span := a.tracer.Start(
+		ctx,
+		"App.RunTask",
+		trace.WithAttributes(
+			attribute.String("task.name", taskName),
+			attribute.String("enduser.id", auth.SubjectFromContext(ctx)),
+		),
+	)
+	defer span.End()
+	defer func() {
+		a.recordTaskAudit(ctx, authz.OperationRun, taskName, err)
+	}()
+	if err = a.authorizeTask(ctx, authz.OperationRun, taskName); err != nil {
+		tracing.RecordError(span, err)
+		return "", err
+	}
+	a.logger.Sugar().Infow(
+		"running task",
+		"task", taskName,
+		"actor", auth.SubjectFromContext(ctx),
+	)
+
+	task, err := a.cronJobService.GetTask(
+		ctx,
+		taskName,
+	)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return "", err
+	}
+
+	job, err := a.kubeService.RunTask(
+		ctx,
+		task,
+	)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return "", err
+	}
+	a.bus.Publish(events.Event{
+		Type:         events.TaskRun,
+		JobName:      taskName,
+		Namespace:    a.kubeService.GetNamespace(),
+		ChildJobName: job.Name,
+		Actor:        auth.SubjectFromContext(ctx),
+	})
+
+	return job.Name, nil
+}
+
+// ListTaskRuns returns the jobs created from the task, newest first
+func (a *App) ListTaskRuns(
+	ctx context.Context,
+	taskName string,
+) ([]repository.TaskRun, error) {
+	ctx, span := a.tracer.Start(
+		ctx,
+		"App.ListTaskRuns",
+		trace.WithAttributes(attribute.String("task.name", taskName)),
+	)
+	defer span.End()
+
+	if err := a.authorizeTask(ctx, authz.OperationView, taskName); err != nil {
+		tracing.RecordError(span, err)
+		return nil, err
+	}
+	runs, err := a.kubeService.GetTaskRuns(ctx, taskName)
+	tracing.RecordError(span, err)
+
+	return runs, err
+}
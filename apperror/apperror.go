@@ -0,0 +1,117 @@
+package apperror
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Code the kind of failure, independent of the transport
+type Code string
+
+const (
+	CodeNotFound     Code = "not_found"
+	CodeConflict     Code = "conflict"
+	CodeInvalid      Code = "invalid"
+	CodeUnauthorized Code = "unauthorized"
+	CodeForbidden    Code = "forbidden"
+	// CodeUnavailable an upstream (GitHub, the cluster) failed or timed out
+	CodeUnavailable Code = "unavailable"
+	CodeInternal    Code = "internal"
+)
+
+// Error a failure with a code and optional details about it
+type Error struct {
+	Code    Code
+	Message string
+	Details map[string]interface{}
+	// Err the underlying error, if any
+	Err error
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil && e.Message == "" {
+		return e.Err.Error()
+	}
+	if e.Err != nil {
+		return fmt.Sprintf("%s: %s", e.Message, e.Err.Error())
+	}
+
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// WithDetail returns the error with the detail added
+func (e *Error) WithDetail(key string, value interface{}) *Error {
+	if e.Details == nil {
+		e.Details = make(map[string]interface{})
+	}
+	e.Details[key] = value
+
+	return e
+}
+
+func newf(code Code, format string, args ...interface{}) *Error {
+	return &Error{
+		Code:    code,
+		Message: fmt.Sprintf(format, args...),
+	}
+}
+
+func NotFound(format string, args ...interface{}) *Error {
+	return newf(CodeNotFound, format, args...)
+}
+
+func Conflict(format string, args ...interface{}) *Error {
+	return newf(CodeConflict, format, args...)
+}
+
+func Invalid(format string, args ...interface{}) *Error {
+	return newf(CodeInvalid, format, args...)
+}
+
+func Unauthorized(format string, args ...interface{}) *Error {
+	return newf(CodeUnauthorized, format, args...)
+}
+
+func Forbidden(format string, args ...interface{}) *Error {
+	return newf(CodeForbidden, format, args...)
+}
+
+func Unavailable(format string, args ...interface{}) *Error {
+	return newf(CodeUnavailable, format, args...)
+}
+
+// Wrap attaches a code to err. The message of err is kept
+func Wrap(code Code, err error) *Error {
+	return &Error{
+		Code: code,
+		Err:  err,
+	}
+}
+
+// As returns the first *Error in the chain of err
+func As(err error) (*Error, bool) {
+	var e *Error
+	if errors.As(err, &e) {
+		return e, true
+	}
+
+	return nil, false
+}
+
+// CodeOf the code of err. Deadlines are reported as unavailable and
+// errors without a code as internal
+func CodeOf(err error) Code {
+	if e, ok := As(err); ok {
+		return e.Code
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return CodeUnavailable
+	}
+
+	return CodeInternal
+}
@@ -0,0 +1,30 @@
+package apperror
+
+import (
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var grpcCodes = map[Code]codes.Code{
+	CodeNotFound:     codes.NotFound,
+	CodeConflict:     codes.Aborted,
+	CodeInvalid:      codes.InvalidArgument,
+	CodeUnauthorized: codes.Unauthenticated,
+	CodeForbidden:    codes.PermissionDenied,
+	CodeUnavailable:  codes.Unavailable,
+	CodeInternal:     codes.Internal,
+}
+
+// GRPCStatus converts err to a gRPC status error with the code
+// matching its apperror code
+func GRPCStatus(err error) error {
+	if err == nil {
+		return nil
+	}
+	code, ok := grpcCodes[CodeOf(err)]
+	if !ok {
+		code = codes.Internal
+	}
+
+	return status.Error(code, err.Error())
+}
@@ -0,0 +1,48 @@
+package apperror
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestGRPCStatus(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want codes.Code
+	}{
+		{name: "not found", err: NotFound("no job %s", "backup"), want: codes.NotFound},
+		{name: "conflict", err: Conflict("exists"), want: codes.Aborted},
+		{name: "invalid", err: Invalid("bad name"), want: codes.InvalidArgument},
+		{name: "unauthorized", err: Unauthorized("no token"), want: codes.Unauthenticated},
+		{name: "forbidden", err: Forbidden("denied"), want: codes.PermissionDenied},
+		{name: "unavailable", err: Unavailable("down"), want: codes.Unavailable},
+		{name: "wrapped", err: fmt.Errorf("starting: %w", Forbidden("denied")), want: codes.PermissionDenied},
+		{name: "wrapped with a code", err: Wrap(CodeUnavailable, context.DeadlineExceeded), want: codes.Unavailable},
+		{name: "plain error", err: errors.New("boom"), want: codes.Internal},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := GRPCStatus(tt.err)
+			s, ok := status.FromError(err)
+			if !ok {
+				t.Fatalf("%v is not a status error", err)
+			}
+			if s.Code() != tt.want {
+				t.Errorf("code = %s, want %s", s.Code(), tt.want)
+			}
+			if s.Message() != tt.err.Error() {
+				t.Errorf("message = %q, want %q", s.Message(), tt.err.Error())
+			}
+		})
+	}
+
+	if err := GRPCStatus(nil); err != nil {
+		t.Errorf("GRPCStatus(nil) = %v, want nil", err)
+	}
+}
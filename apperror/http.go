@@ -0,0 +1,74 @@
+package apperror
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/panagiotisptr/job-scheduler/requestid"
+	"go.uber.org/zap"
+)
+
+var statusCodes = map[Code]int{
+	CodeNotFound:     http.StatusNotFound,
+	CodeConflict:     http.StatusConflict,
+	CodeInvalid:      http.StatusBadRequest,
+	CodeUnauthorized: http.StatusUnauthorized,
+	CodeForbidden:    http.StatusForbidden,
+	CodeUnavailable:  http.StatusServiceUnavailable,
+	CodeInternal:     http.StatusInternalServerError,
+}
+
+// StatusCode the HTTP status code of err
+func StatusCode(err error) int {
+	if code, ok := statusCodes[CodeOf(err)]; ok {
+		return code
+	}
+
+	return http.StatusInternalServerError
+}
+
+// Body the JSON body of every error response of the API
+type Body struct {
+	Code      Code                   `json:"code"`
+	Message   string                 `json:"message"`
+	Details   map[string]interface{} `json:"details,omitempty"`
+	RequestID string                 `json:"requestId,omitempty"`
+}
+
+// WriteHTTP writes err with the status code matching its code
+func WriteHTTP(
+	w http.ResponseWriter,
+	r *http.Request,
+	err error,
+	logger *zap.Logger,
+) {
+	body := Body{
+		Code:      CodeOf(err),
+		Message:   err.Error(),
+		RequestID: requestid.FromContext(r.Context()),
+	}
+	if e, ok := As(err); ok {
+		body.Details = e.Details
+	}
+	status := StatusCode(err)
+	if status >= http.StatusInternalServerError {
+		logger.Sugar().Errorw(
+			"request failed",
+			"error", err,
+			"path", r.URL.Path,
+			"requestId", body.RequestID,
+		)
+	}
+
+	b, err := json.Marshal(body)
+	if err != nil {
+		logger.Sugar().Error("failed to marshal error: ", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if _, err = w.Write(b); err != nil {
+		logger.Sugar().Error("failed to write to response: ", err)
+	}
+}
@@ -0,0 +1,75 @@
+// Package archive extracts repository archives to in-memory file
+// systems, for the manifest sources that need a whole repository
+package archive
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	"sigs.k8s.io/kustomize/kyaml/filesys"
+)
+
+// maxArchiveSize the largest repository archive that is extracted
+const maxArchiveSize = 256 << 20
+
+// root the directory archives are extracted to
+const root = "/repository"
+
+// FromTarball extracts a gzipped repository archive, as served by
+// GitHub, to an in-memory file system. The top-level directory of
+// the archive is dropped so that the paths of the repository are
+// relative to Root
+func FromTarball(r io.Reader) (filesys.FileSystem, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	defer gz.Close()
+
+	fSys := filesys.MakeFsInMemory()
+	tr := tar.NewReader(io.LimitReader(gz, maxArchiveSize))
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return fSys, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read archive: %w", err)
+		}
+
+		name := header.Name
+		i := strings.Index(name, "/")
+		if i < 0 {
+			continue
+		}
+		p := Root(name[i+1:])
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := fSys.MkdirAll(p); err != nil {
+				return nil, err
+			}
+		case tar.TypeReg:
+			b, err := io.ReadAll(tr)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read %s: %w", name, err)
+			}
+			if err := fSys.MkdirAll(path.Dir(p)); err != nil {
+				return nil, err
+			}
+			if err := fSys.WriteFile(p, b); err != nil {
+				return nil, err
+			}
+		}
+	}
+}
+
+// Root the path of a file of the repository in the file system
+// returned by FromTarball
+func Root(p string) string {
+	return path.Join(root, path.Clean("/"+p))
+}
@@ -0,0 +1,121 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/panagiotisptr/job-scheduler/config"
+	"github.com/panagiotisptr/job-scheduler/store"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+)
+
+const defaultMemoryLimit = 1000
+
+// Auditor writes audit records to every configured sink and reads
+// them back from the first sink that supports queries
+type Auditor struct {
+	logger  *zap.Logger
+	sinks   []Sink
+	querier Querier
+}
+
+func ProvideAuditor(
+	lc fx.Lifecycle,
+	cfg *config.Config,
+	logger *zap.Logger,
+	st store.Store,
+) (*Auditor, error) {
+	a := &Auditor{
+		logger: logger,
+	}
+
+	sinkConfigs := cfg.Audit.Sinks
+	if len(sinkConfigs) == 0 {
+		sinkConfigs = []config.AuditSinkConfig{{Type: "memory"}}
+	}
+	for _, sc := range sinkConfigs {
+		var sink Sink
+		switch sc.Type {
+		case "file":
+			if sc.Path == "" {
+				return nil, fmt.Errorf("file audit sink needs a path")
+			}
+			fileSink, err := NewFileSink(sc.Path)
+			if err != nil {
+				return nil, err
+			}
+			sink = fileSink
+		case "store":
+			sink = NewStoreSink(st)
+		case "stdout":
+			sink = NewStdoutSink()
+		case "memory":
+			limit := sc.Limit
+			if limit <= 0 {
+				limit = defaultMemoryLimit
+			}
+			sink = NewMemorySink(limit)
+		default:
+			return nil, fmt.Errorf("unknown audit sink: %s", sc.Type)
+		}
+		a.AddSink(sink)
+	}
+
+	lc.Append(fx.Hook{
+		OnStop: func(ctx context.Context) error {
+			for _, s := range a.sinks {
+				if err := s.Close(); err != nil {
+					a.logger.Sugar().Error("failed to close audit sink: ", err)
+				}
+			}
+
+			return nil
+		},
+	})
+
+	return a, nil
+}
+
+// AddSink registers another sink. The first sink that supports
+// queries is used to serve them
+func (a *Auditor) AddSink(s Sink) {
+	a.sinks = append(a.sinks, s)
+	if q, ok := s.(Querier); ok && a.querier == nil {
+		a.querier = q
+	}
+}
+
+// Record writes the record to every sink. Failing to write is logged
+// but does not fail the audited operation
+func (a *Auditor) Record(
+	ctx context.Context,
+	r Record,
+) {
+	if r.Timestamp.IsZero() {
+		r.Timestamp = time.Now().UTC()
+	}
+	for _, s := range a.sinks {
+		if err := s.Write(ctx, r); err != nil {
+			a.logger.Sugar().Errorw(
+				"failed to write audit record",
+				"error", err,
+				"actor", r.Actor,
+				"operation", r.Operation,
+				"job", r.Job,
+			)
+		}
+	}
+}
+
+func (a *Auditor) Query(
+	ctx context.Context,
+	f Filter,
+) ([]Record, error) {
+	if a.querier == nil {
+		return nil, fmt.Errorf("no audit sink supports queries")
+	}
+
+	return a.querier.Query(ctx, f)
+}
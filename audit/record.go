@@ -0,0 +1,75 @@
+package audit
+
+import (
+	"strings"
+	"time"
+)
+
+type Outcome string
+
+const (
+	OutcomeSuccess Outcome = "success"
+	OutcomeFailure Outcome = "failure"
+	// OutcomeDenied the caller was not authorized
+	OutcomeDenied Outcome = "denied"
+)
+
+// KindTask the Kind of the records of operations on tasks
+const KindTask = "task"
+
+// Record a mutating operation performed through the API
+type Record struct {
+	Timestamp time.Time `json:"timestamp"`
+	Actor     string    `json:"actor"`
+	Groups    []string  `json:"groups,omitempty"`
+	// AuthMethod the authenticator that verified the actor
+	AuthMethod string `json:"authMethod,omitempty"`
+	Operation  string `json:"operation"`
+	// Kind is KindTask for operations on tasks, empty for cronjobs
+	Kind      string  `json:"kind,omitempty"`
+	Job       string  `json:"job"`
+	Namespace string  `json:"namespace"`
+	CommitSHA string  `json:"commitSha,omitempty"`
+	RequestID string  `json:"requestId,omitempty"`
+	Outcome   Outcome `json:"outcome"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// Filter selects records. Empty fields match every record
+type Filter struct {
+	Job   string
+	Actor string
+	Since time.Time
+	// Limit the maximum number of records returned, newest first
+	Limit int
+}
+
+func (f Filter) matches(r Record) bool {
+	if f.Job != "" && f.Job != r.Job {
+		return false
+	}
+	if f.Actor != "" && !strings.EqualFold(f.Actor, r.Actor) {
+		return false
+	}
+	if !f.Since.IsZero() && r.Timestamp.Before(f.Since) {
+		return false
+	}
+
+	return true
+}
+
+// filterRecords returns the matching records newest first
+func filterRecords(records []Record, f Filter) []Record {
+	res := []Record{}
+	for i := len(records) - 1; i >= 0; i-- {
+		if !f.matches(records[i]) {
+			continue
+		}
+		res = append(res, records[i])
+		if f.Limit > 0 && len(res) >= f.Limit {
+			break
+		}
+	}
+
+	return res
+}
@@ -0,0 +1,201 @@
+package audit
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/panagiotisptr/job-scheduler/store"
+)
+
+// Sink stores audit records
+type Sink interface {
+	Write(ctx context.Context, r Record) error
+	Close() error
+}
+
+// Querier a sink the records can be read back from
+type Querier interface {
+	Query(ctx context.Context, f Filter) ([]Record, error)
+}
+
+// writerSink writes every record as a line of JSON
+type writerSink struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewStdoutSink writes the records to stdout as JSON lines
+func NewStdoutSink() Sink {
+	return &writerSink{w: os.Stdout}
+}
+
+func (s *writerSink) Write(
+	ctx context.Context,
+	r Record,
+) error {
+	b, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err = s.w.Write(append(b, '\n'))
+
+	return err
+}
+
+func (s *writerSink) Close() error {
+	return nil
+}
+
+// FileSink appends the records to a JSON lines file
+type FileSink struct {
+	writerSink
+	path string
+	f    *os.File
+}
+
+func NewFileSink(path string) (*FileSink, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+
+	return &FileSink{
+		writerSink: writerSink{w: f},
+		path:       path,
+		f:          f,
+	}, nil
+}
+
+func (s *FileSink) Close() error {
+	return s.f.Close()
+}
+
+// Query scans the whole file
+func (s *FileSink) Query(
+	ctx context.Context,
+	f Filter,
+) ([]Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	file, err := os.Open(s.path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	records := []Record{}
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var r Record
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			continue
+		}
+		if f.matches(r) {
+			records = append(records, r)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return filterRecords(records, f), nil
+}
+
+// MemorySink keeps the most recent records in memory
+type MemorySink struct {
+	mu      sync.RWMutex
+	limit   int
+	records []Record
+}
+
+func NewMemorySink(limit int) *MemorySink {
+	return &MemorySink{limit: limit}
+}
+
+func (s *MemorySink) Write(
+	ctx context.Context,
+	r Record,
+) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.records = append(s.records, r)
+	if s.limit > 0 && len(s.records) > s.limit {
+		s.records = s.records[len(s.records)-s.limit:]
+	}
+
+	return nil
+}
+
+func (s *MemorySink) Close() error {
+	return nil
+}
+
+func (s *MemorySink) Query(
+	ctx context.Context,
+	f Filter,
+) ([]Record, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return filterRecords(s.records, f), nil
+}
+
+const auditBucket = "audit"
+
+// StoreSink persists the records in the state store
+type StoreSink struct {
+	store store.Store
+}
+
+func NewStoreSink(s store.Store) *StoreSink {
+	return &StoreSink{store: s}
+}
+
+func (s *StoreSink) Write(
+	ctx context.Context,
+	r Record,
+) error {
+	seq, err := s.store.NextSequence(ctx, auditBucket)
+	if err != nil {
+		return err
+	}
+
+	return store.PutJSON(ctx, s.store, auditBucket, store.SequenceKey(seq), r)
+}
+
+// Close is a no-op, the store is closed by its owner
+func (s *StoreSink) Close() error {
+	return nil
+}
+
+func (s *StoreSink) Query(
+	ctx context.Context,
+	f Filter,
+) ([]Record, error) {
+	entries, err := s.store.List(ctx, auditBucket)
+	if err != nil {
+		return nil, err
+	}
+
+	records := []Record{}
+	for _, e := range entries {
+		var r Record
+		if err := json.Unmarshal(e.Value, &r); err != nil {
+			continue
+		}
+		records = append(records, r)
+	}
+
+	return filterRecords(records, f), nil
+}
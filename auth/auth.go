@@ -0,0 +1,151 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/panagiotisptr/job-scheduler/apperror"
+	"github.com/panagiotisptr/job-scheduler/config"
+	"go.uber.org/zap"
+)
+
+// Authenticator verifies a bearer token and returns the identity it
+// belongs to
+type Authenticator interface {
+	Authenticate(ctx context.Context, token string) (*Identity, error)
+}
+
+var defaultPublicPaths = []string{
+	"/healthz",
+	"/readyz",
+	"/metrics",
+	"/api/v1/openapi.json",
+}
+
+// Auth authenticates the callers of the API with the configured
+// authenticators. The first authenticator accepting the token wins
+type Auth struct {
+	logger         *zap.Logger
+	enabled        bool
+	publicPaths    map[string]struct{}
+	authenticators []Authenticator
+}
+
+func ProvideAuth(
+	cfg *config.Config,
+	logger *zap.Logger,
+) (*Auth, error) {
+	ac := cfg.Auth
+	a := &Auth{
+		logger:      logger,
+		enabled:     ac.Enabled,
+		publicPaths: make(map[string]struct{}),
+	}
+	if !a.enabled {
+		logger.Sugar().Warn("authentication is disabled. Anyone can call the API")
+		return a, nil
+	}
+
+	publicPaths := ac.PublicPaths
+	if len(publicPaths) == 0 {
+		publicPaths = defaultPublicPaths
+	}
+	for _, p := range publicPaths {
+		a.publicPaths[p] = struct{}{}
+	}
+
+	if len(ac.StaticTokens) > 0 {
+		static, err := NewStaticTokenAuthenticator(ac.StaticTokens)
+		if err != nil {
+			return nil, err
+		}
+		a.authenticators = append(a.authenticators, static)
+	}
+	if ac.HMAC.Secret != "" || ac.HMAC.SecretFile != "" {
+		hmac, err := NewHMACAuthenticator(ac)
+		if err != nil {
+			return nil, err
+		}
+		a.authenticators = append(a.authenticators, hmac)
+	}
+	if ac.OIDC.Issuer != "" || ac.OIDC.JWKSURL != "" || ac.OIDC.JWKSFile != "" {
+		oidc, err := NewOIDCAuthenticator(ac, logger)
+		if err != nil {
+			return nil, err
+		}
+		a.authenticators = append(a.authenticators, oidc)
+	}
+	if len(a.authenticators) == 0 {
+		return nil, fmt.Errorf("authentication is enabled but no authenticator is configured")
+	}
+
+	return a, nil
+}
+
+// Enabled whether callers have to authenticate
+func (a *Auth) Enabled() bool {
+	return a.enabled
+}
+
+// Authenticate tries every authenticator in turn
+func (a *Auth) Authenticate(
+	ctx context.Context,
+	token string,
+) (*Identity, error) {
+	if token == "" {
+		return nil, fmt.Errorf("missing bearer token")
+	}
+	for _, authenticator := range a.authenticators {
+		identity, err := authenticator.Authenticate(ctx, token)
+		if err == nil {
+			return identity, nil
+		}
+		a.logger.Sugar().Debug("authenticator rejected token: ", err)
+	}
+
+	return nil, fmt.Errorf("invalid bearer token")
+}
+
+// BearerToken extracts the token from an Authorization header value
+func BearerToken(header string) string {
+	const prefix = "bearer "
+	if len(header) < len(prefix) || !strings.EqualFold(header[:len(prefix)], prefix) {
+		return ""
+	}
+
+	return strings.TrimSpace(header[len(prefix):])
+}
+
+// Middleware rejects unauthenticated requests to non public paths
+// and stores the identity of the caller in the request context
+func (a *Auth) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !a.enabled {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if _, ok := a.publicPaths[r.URL.Path]; ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		identity, err := a.Authenticate(
+			r.Context(),
+			BearerToken(r.Header.Get("Authorization")),
+		)
+		if err != nil {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="job-scheduler"`)
+			apperror.WriteHTTP(
+				w,
+				r,
+				apperror.Wrap(apperror.CodeUnauthorized, err),
+				a.logger,
+			)
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(WithIdentity(r.Context(), identity)))
+	})
+}
@@ -0,0 +1,253 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/panagiotisptr/job-scheduler/config"
+	"go.uber.org/zap"
+	"gopkg.in/square/go-jose.v2"
+)
+
+func TestStaticTokenAuthenticator(t *testing.T) {
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(tokenFile, []byte("file-token\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	a, err := NewStaticTokenAuthenticator([]config.StaticTokenConfig{
+		{Token: "alice-token", Subject: "alice", Groups: []string{"team-a"}},
+		{TokenFile: tokenFile, Subject: "ci"},
+	})
+	if err != nil {
+		t.Fatalf("NewStaticTokenAuthenticator: %s", err)
+	}
+
+	tests := []struct {
+		token   string
+		subject string
+	}{
+		{token: "alice-token", subject: "alice"},
+		// read from the file without the trailing newline
+		{token: "file-token", subject: "ci"},
+		{token: "alice-token "},
+		{token: "alice"},
+		{token: "file-token\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.token, func(t *testing.T) {
+			identity, err := a.Authenticate(context.Background(), tt.token)
+			if tt.subject == "" {
+				if err == nil {
+					t.Fatalf("accepted the token as %+v", identity)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Authenticate: %s", err)
+			}
+			if identity.Subject != tt.subject || identity.Method != "static" {
+				t.Errorf("identity = %+v, want %s authenticated by static", identity, tt.subject)
+			}
+		})
+	}
+
+	// the identity can't be changed through a returned copy
+	identity, _ := a.Authenticate(context.Background(), "alice-token")
+	identity.Subject = "mallory"
+	if again, _ := a.Authenticate(context.Background(), "alice-token"); again.Subject != "alice" {
+		t.Errorf("subject = %q after changing a returned identity", again.Subject)
+	}
+}
+
+func TestStaticTokenConfig(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  config.StaticTokenConfig
+	}{
+		{name: "no token", cfg: config.StaticTokenConfig{Subject: "alice"}},
+		{name: "no subject", cfg: config.StaticTokenConfig{Token: "token"}},
+		{name: "missing file", cfg: config.StaticTokenConfig{TokenFile: filepath.Join(t.TempDir(), "missing"), Subject: "alice"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewStaticTokenAuthenticator([]config.StaticTokenConfig{tt.cfg}); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestBearerToken(t *testing.T) {
+	tests := map[string]string{
+		"Bearer abc":    "abc",
+		"bearer abc":    "abc",
+		"BEARER  abc  ": "abc",
+		"Basic abc":     "",
+		"Bearer":        "",
+		"":              "",
+	}
+	for header, want := range tests {
+		if got := BearerToken(header); got != want {
+			t.Errorf("BearerToken(%q) = %q, want %q", header, got, want)
+		}
+	}
+}
+
+func TestProvideAuth(t *testing.T) {
+	keys := newTestKeys(t)
+	jwksFile := writeJWKS(t, keys.jwks(t))
+
+	tests := []struct {
+		name    string
+		cfg     config.AuthConfig
+		methods int
+		wantErr bool
+	}{
+		{name: "disabled", cfg: config.AuthConfig{}},
+		{name: "enabled without authenticators", cfg: config.AuthConfig{Enabled: true}, wantErr: true},
+		{
+			name: "every authenticator",
+			cfg: config.AuthConfig{
+				Enabled:      true,
+				StaticTokens: []config.StaticTokenConfig{{Token: "token", Subject: "alice"}},
+				HMAC:         config.HMACAuthConfig{Secret: testSecret},
+				OIDC:         oidcConfig(jwksFile, "").OIDC,
+			},
+			methods: 3,
+		},
+		{
+			name: "short HMAC secret",
+			cfg: config.AuthConfig{
+				Enabled: true,
+				HMAC:    config.HMACAuthConfig{Secret: "short"},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, err := ProvideAuth(&config.Config{Auth: tt.cfg}, zap.NewNop())
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ProvideAuth: %s", err)
+			}
+			if a.Enabled() != tt.cfg.Enabled {
+				t.Errorf("Enabled() = %t, want %t", a.Enabled(), tt.cfg.Enabled)
+			}
+			if len(a.authenticators) != tt.methods {
+				t.Errorf("got %d authenticators, want %d", len(a.authenticators), tt.methods)
+			}
+		})
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	keys := newTestKeys(t)
+	cfg := &config.Config{
+		Auth: config.AuthConfig{
+			Enabled:      true,
+			StaticTokens: []config.StaticTokenConfig{{Token: "static-token", Subject: "ci"}},
+			HMAC: config.HMACAuthConfig{
+				Secret:   testSecret,
+				Issuer:   testIssuer,
+				Audience: testAudience,
+			},
+			OIDC: oidcConfig(writeJWKS(t, keys.jwks(t)), "").OIDC,
+		},
+	}
+	a, err := ProvideAuth(cfg, zap.NewNop())
+	if err != nil {
+		t.Fatalf("ProvideAuth: %s", err)
+	}
+	handler := a.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		identity, ok := IdentityFromContext(r.Context())
+		if !ok {
+			identity = &Identity{Subject: SubjectFromContext(r.Context())}
+		}
+		_ = json.NewEncoder(w).Encode(identity)
+	}))
+
+	tests := []struct {
+		name       string
+		path       string
+		header     string
+		wantStatus int
+		wantMethod string
+	}{
+		{name: "static token", path: "/api/v1/static/jobs", header: "Bearer static-token", wantStatus: http.StatusOK, wantMethod: "static"},
+		{
+			name:       "hmac token",
+			path:       "/api/v1/static/jobs",
+			header:     "Bearer " + signToken(t, jose.HS256, []byte(testSecret), "", testClaims()),
+			wantStatus: http.StatusOK,
+			wantMethod: "hmac",
+		},
+		{
+			name:       "oidc token",
+			path:       "/api/v1/static/jobs",
+			header:     "Bearer " + signToken(t, jose.ES256, keys.ec, "ec", testClaims()),
+			wantStatus: http.StatusOK,
+			wantMethod: "oidc",
+		},
+		{name: "no token", path: "/api/v1/static/jobs", wantStatus: http.StatusUnauthorized},
+		{name: "unknown token", path: "/api/v1/static/jobs", header: "Bearer nope", wantStatus: http.StatusUnauthorized},
+		{name: "basic auth", path: "/api/v1/static/jobs", header: "Basic c3RhdGljLXRva2Vu", wantStatus: http.StatusUnauthorized},
+		{name: "public path", path: "/healthz", wantStatus: http.StatusOK},
+		{name: "openapi document", path: "/api/v1/openapi.json", wantStatus: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if rec.Code == http.StatusUnauthorized {
+				if rec.Header().Get("WWW-Authenticate") == "" {
+					t.Error("missing WWW-Authenticate header")
+				}
+				return
+			}
+			var identity Identity
+			if err := json.NewDecoder(rec.Body).Decode(&identity); err != nil {
+				t.Fatal(err)
+			}
+			if identity.Method != tt.wantMethod {
+				t.Errorf("method = %q, want %q", identity.Method, tt.wantMethod)
+			}
+			if tt.wantMethod == "" && identity.Subject != Anonymous {
+				t.Errorf("subject = %q on a public path, want %s", identity.Subject, Anonymous)
+			}
+		})
+	}
+}
+
+func TestMiddlewareWhenDisabled(t *testing.T) {
+	a, err := ProvideAuth(&config.Config{}, zap.NewNop())
+	if err != nil {
+		t.Fatalf("ProvideAuth: %s", err)
+	}
+	called := false
+	handler := a.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/static/jobs", nil))
+	if !called || rec.Code != http.StatusOK {
+		t.Errorf("status = %d, called = %t, want the request to be served", rec.Code, called)
+	}
+}
@@ -0,0 +1,82 @@
+package auth
+
+import (
+	"context"
+	"strings"
+
+	"github.com/panagiotisptr/job-scheduler/apperror"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// reflection is served without authentication like the public paths
+const reflectionServicePrefix = "/grpc.reflection."
+
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
+
+// authenticateGRPC reads the bearer token from the authorization
+// metadata and stores the identity of the caller in the context
+func (a *Auth) authenticateGRPC(
+	ctx context.Context,
+	fullMethod string,
+) (context.Context, error) {
+	if !a.enabled || strings.HasPrefix(fullMethod, reflectionServicePrefix) {
+		return ctx, nil
+	}
+
+	token := ""
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get("authorization"); len(values) > 0 {
+			token = BearerToken(values[0])
+		}
+	}
+	identity, err := a.Authenticate(ctx, token)
+	if err != nil {
+		return nil, apperror.GRPCStatus(
+			apperror.Wrap(apperror.CodeUnauthorized, err),
+		)
+	}
+
+	return WithIdentity(ctx, identity), nil
+}
+
+// UnaryServerInterceptor rejects unauthenticated calls
+func (a *Auth) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req interface{},
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (interface{}, error) {
+		ctx, err := a.authenticateGRPC(ctx, info.FullMethod)
+		if err != nil {
+			return nil, err
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor rejects unauthenticated streams
+func (a *Auth) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(
+		srv interface{},
+		ss grpc.ServerStream,
+		info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler,
+	) error {
+		ctx, err := a.authenticateGRPC(ss.Context(), info.FullMethod)
+		if err != nil {
+			return err
+		}
+
+		return handler(srv, &serverStream{ServerStream: ss, ctx: ctx})
+	}
+}
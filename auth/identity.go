@@ -0,0 +1,41 @@
+package auth
+
+import "context"
+
+// Anonymous the subject of unauthenticated callers
+const Anonymous = "anonymous"
+
+// Identity the authenticated caller of the API
+type Identity struct {
+	Subject string   `json:"subject"`
+	Groups  []string `json:"groups,omitempty"`
+	// Method the authenticator that verified the caller
+	Method string `json:"method"`
+}
+
+type identityKey struct{}
+
+// WithIdentity returns a copy of ctx carrying the identity
+func WithIdentity(
+	ctx context.Context,
+	identity *Identity,
+) context.Context {
+	return context.WithValue(ctx, identityKey{}, identity)
+}
+
+// IdentityFromContext returns the identity of the caller if the
+// request was authenticated
+func IdentityFromContext(ctx context.Context) (*Identity, bool) {
+	identity, ok := ctx.Value(identityKey{}).(*Identity)
+
+	return identity, ok && identity != nil
+}
+
+// SubjectFromContext the subject of the caller or anonymous
+func SubjectFromContext(ctx context.Context) string {
+	if identity, ok := IdentityFromContext(ctx); ok {
+		return identity.Subject
+	}
+
+	return Anonymous
+}
@@ -0,0 +1,161 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/panagiotisptr/job-scheduler/config"
+	"gopkg.in/square/go-jose.v2"
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+const (
+	defaultSubjectClaim = "sub"
+	defaultGroupsClaim  = "groups"
+	// tolerated clock skew when validating exp, nbf and iat
+	clockSkew = time.Minute
+)
+
+// claimsMapper extracts the identity from the claims of a verified JWT
+type claimsMapper struct {
+	subjectClaim string
+	groupsClaim  string
+}
+
+func newClaimsMapper(cfg config.AuthConfig) claimsMapper {
+	m := claimsMapper{
+		subjectClaim: cfg.SubjectClaim,
+		groupsClaim:  cfg.GroupsClaim,
+	}
+	if m.subjectClaim == "" {
+		m.subjectClaim = defaultSubjectClaim
+	}
+	if m.groupsClaim == "" {
+		m.groupsClaim = defaultGroupsClaim
+	}
+
+	return m
+}
+
+func (m claimsMapper) identity(
+	claims map[string]interface{},
+	method string,
+) (*Identity, error) {
+	subject, ok := claims[m.subjectClaim].(string)
+	if !ok || subject == "" {
+		return nil, fmt.Errorf("token has no %s claim", m.subjectClaim)
+	}
+
+	groups := []string{}
+	switch g := claims[m.groupsClaim].(type) {
+	case string:
+		groups = append(groups, g)
+	case []interface{}:
+		for _, v := range g {
+			if s, ok := v.(string); ok {
+				groups = append(groups, s)
+			}
+		}
+	}
+
+	return &Identity{
+		Subject: subject,
+		Groups:  groups,
+		Method:  method,
+	}, nil
+}
+
+// verifyJWT checks the signature, the algorithm and the registered
+// claims of the token and returns all of its claims
+func verifyJWT(
+	token string,
+	key interface{},
+	algorithms map[jose.SignatureAlgorithm]struct{},
+	issuer string,
+	audience string,
+) (map[string]interface{}, error) {
+	tok, err := jwt.ParseSigned(token)
+	if err != nil {
+		return nil, err
+	}
+	if len(tok.Headers) != 1 {
+		return nil, fmt.Errorf("expected a single signature")
+	}
+	if _, ok := algorithms[jose.SignatureAlgorithm(tok.Headers[0].Algorithm)]; !ok {
+		return nil, fmt.Errorf("unexpected signing algorithm: %s", tok.Headers[0].Algorithm)
+	}
+
+	var registered jwt.Claims
+	claims := map[string]interface{}{}
+	if err = tok.Claims(key, &registered, &claims); err != nil {
+		return nil, err
+	}
+
+	expected := jwt.Expected{
+		Issuer: issuer,
+		Time:   time.Now(),
+	}
+	if audience != "" {
+		expected.Audience = jwt.Audience{audience}
+	}
+	if err = registered.ValidateWithLeeway(expected, clockSkew); err != nil {
+		return nil, err
+	}
+	if registered.Expiry == nil {
+		return nil, fmt.Errorf("token has no expiry")
+	}
+
+	return claims, nil
+}
+
+var hmacAlgorithms = map[jose.SignatureAlgorithm]struct{}{
+	jose.HS256: {},
+	jose.HS384: {},
+	jose.HS512: {},
+}
+
+// HMACAuthenticator accepts JWTs signed with a shared secret
+type HMACAuthenticator struct {
+	secret   []byte
+	issuer   string
+	audience string
+	mapper   claimsMapper
+}
+
+func NewHMACAuthenticator(
+	cfg config.AuthConfig,
+) (*HMACAuthenticator, error) {
+	secret, err := readSecret(cfg.HMAC.Secret, cfg.HMAC.SecretFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read HMAC secret: %w", err)
+	}
+	if len(secret) < 32 {
+		return nil, fmt.Errorf("HMAC secret has to be at least 32 bytes long")
+	}
+
+	return &HMACAuthenticator{
+		secret:   []byte(secret),
+		issuer:   cfg.HMAC.Issuer,
+		audience: cfg.HMAC.Audience,
+		mapper:   newClaimsMapper(cfg),
+	}, nil
+}
+
+func (a *HMACAuthenticator) Authenticate(
+	ctx context.Context,
+	token string,
+) (*Identity, error) {
+	claims, err := verifyJWT(
+		token,
+		a.secret,
+		hmacAlgorithms,
+		a.issuer,
+		a.audience,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return a.mapper.identity(claims, "hmac")
+}
@@ -0,0 +1,301 @@
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/panagiotisptr/job-scheduler/config"
+	"gopkg.in/square/go-jose.v2"
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+const (
+	testSecret   = "0123456789abcdef0123456789abcdef"
+	testIssuer   = "https://issuer.example.com"
+	testAudience = "job-scheduler"
+)
+
+// testClaims the claims of a valid token for alice, expiring in an
+// hour
+func testClaims() map[string]interface{} {
+	now := time.Now()
+
+	return map[string]interface{}{
+		"iss":    testIssuer,
+		"aud":    testAudience,
+		"sub":    "alice",
+		"groups": []string{"team-a", "oncall"},
+		"iat":    now.Unix(),
+		"nbf":    now.Unix(),
+		"exp":    now.Add(time.Hour).Unix(),
+	}
+}
+
+// signToken signs the claims with the key. The kid header is left out
+// when keyID is empty
+func signToken(
+	t *testing.T,
+	alg jose.SignatureAlgorithm,
+	key interface{},
+	keyID string,
+	claims map[string]interface{},
+) string {
+	t.Helper()
+	opts := (&jose.SignerOptions{}).WithType("JWT")
+	if keyID != "" {
+		opts = opts.WithHeader("kid", keyID)
+	}
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: alg, Key: key}, opts)
+	if err != nil {
+		t.Fatalf("failed to create a %s signer: %s", alg, err)
+	}
+	token, err := jwt.Signed(signer).Claims(claims).CompactSerialize()
+	if err != nil {
+		t.Fatalf("failed to sign the token: %s", err)
+	}
+
+	return token
+}
+
+// unsignedToken a token with the none algorithm
+func unsignedToken(t *testing.T, claims map[string]interface{}) string {
+	t.Helper()
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	enc := base64.RawURLEncoding
+
+	return enc.EncodeToString([]byte(`{"alg":"none","typ":"JWT"}`)) + "." +
+		enc.EncodeToString(payload) + "."
+}
+
+func with(claims map[string]interface{}, key string, value interface{}) map[string]interface{} {
+	if value == nil {
+		delete(claims, key)
+	} else {
+		claims[key] = value
+	}
+
+	return claims
+}
+
+func newTestHMACAuthenticator(t *testing.T, cfg config.AuthConfig) *HMACAuthenticator {
+	t.Helper()
+	cfg.HMAC.Secret = testSecret
+	cfg.HMAC.Issuer = testIssuer
+	cfg.HMAC.Audience = testAudience
+	a, err := NewHMACAuthenticator(cfg)
+	if err != nil {
+		t.Fatalf("NewHMACAuthenticator: %s", err)
+	}
+
+	return a
+}
+
+func TestHMACAuthenticator(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := newTestHMACAuthenticator(t, config.AuthConfig{})
+	hour := time.Hour
+
+	tests := []struct {
+		name  string
+		token string
+		// wantErr the error has to contain it, the token is accepted
+		// if empty
+		wantErr string
+	}{
+		{
+			name:  "HS256",
+			token: signToken(t, jose.HS256, []byte(testSecret), "", testClaims()),
+		},
+		{
+			name:  "HS512",
+			token: signToken(t, jose.HS512, []byte(testSecret), "", testClaims()),
+		},
+		{
+			name:    "wrong secret",
+			token:   signToken(t, jose.HS256, []byte(strings.Repeat("x", 32)), "", testClaims()),
+			wantErr: "error in cryptographic primitive",
+		},
+		{
+			name:    "RS256 is not allowed",
+			token:   signToken(t, jose.RS256, rsaKey, "", testClaims()),
+			wantErr: "unexpected signing algorithm: RS256",
+		},
+		{
+			name:    "none is not allowed",
+			token:   unsignedToken(t, testClaims()),
+			wantErr: "unexpected signing algorithm: none",
+		},
+		{
+			name:    "expired",
+			token:   signToken(t, jose.HS256, []byte(testSecret), "", with(testClaims(), "exp", time.Now().Add(-hour).Unix())),
+			wantErr: "expired",
+		},
+		{
+			name:    "not valid yet",
+			token:   signToken(t, jose.HS256, []byte(testSecret), "", with(testClaims(), "nbf", time.Now().Add(hour).Unix())),
+			wantErr: "not valid yet",
+		},
+		{
+			name:    "without an expiry",
+			token:   signToken(t, jose.HS256, []byte(testSecret), "", with(testClaims(), "exp", nil)),
+			wantErr: "token has no expiry",
+		},
+		{
+			name:    "other issuer",
+			token:   signToken(t, jose.HS256, []byte(testSecret), "", with(testClaims(), "iss", "https://other.example.com")),
+			wantErr: "invalid issuer",
+		},
+		{
+			name:    "other audience",
+			token:   signToken(t, jose.HS256, []byte(testSecret), "", with(testClaims(), "aud", "other")),
+			wantErr: "invalid audience",
+		},
+		{
+			name:    "without a subject",
+			token:   signToken(t, jose.HS256, []byte(testSecret), "", with(testClaims(), "sub", nil)),
+			wantErr: "token has no sub claim",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			identity, err := a.Authenticate(context.Background(), tt.token)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Authenticate: %s", err)
+			}
+			if identity.Subject != "alice" || identity.Method != "hmac" {
+				t.Errorf("identity = %+v, want alice authenticated by hmac", identity)
+			}
+			if strings.Join(identity.Groups, ",") != "team-a,oncall" {
+				t.Errorf("groups = %v, want [team-a oncall]", identity.Groups)
+			}
+		})
+	}
+}
+
+func TestAlgorithmAllowlists(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		alg        jose.SignatureAlgorithm
+		key        interface{}
+		verifyKey  interface{}
+		algorithms map[jose.SignatureAlgorithm]struct{}
+		allowed    bool
+	}{
+		{name: "HS256 for hmac", alg: jose.HS256, key: []byte(testSecret), verifyKey: []byte(testSecret), algorithms: hmacAlgorithms, allowed: true},
+		{name: "HS384 for hmac", alg: jose.HS384, key: []byte(testSecret), verifyKey: []byte(testSecret), algorithms: hmacAlgorithms, allowed: true},
+		{name: "RS256 for hmac", alg: jose.RS256, key: rsaKey, verifyKey: &rsaKey.PublicKey, algorithms: hmacAlgorithms},
+		{name: "ES256 for hmac", alg: jose.ES256, key: ecKey, verifyKey: &ecKey.PublicKey, algorithms: hmacAlgorithms},
+		{name: "RS256 for oidc", alg: jose.RS256, key: rsaKey, verifyKey: &rsaKey.PublicKey, algorithms: oidcAlgorithms, allowed: true},
+		{name: "PS512 for oidc", alg: jose.PS512, key: rsaKey, verifyKey: &rsaKey.PublicKey, algorithms: oidcAlgorithms, allowed: true},
+		{name: "ES256 for oidc", alg: jose.ES256, key: ecKey, verifyKey: &ecKey.PublicKey, algorithms: oidcAlgorithms, allowed: true},
+		// a shared secret guessed from a public key mustn't be accepted
+		{name: "HS256 for oidc", alg: jose.HS256, key: []byte(testSecret), verifyKey: []byte(testSecret), algorithms: oidcAlgorithms},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token := signToken(t, tt.alg, tt.key, "", testClaims())
+			_, err := verifyJWT(token, tt.verifyKey, tt.algorithms, testIssuer, testAudience)
+			if tt.allowed && err != nil {
+				t.Errorf("rejected %s: %s", tt.alg, err)
+			}
+			if !tt.allowed && (err == nil || !strings.Contains(err.Error(), "unexpected signing algorithm")) {
+				t.Errorf("err = %v, want the algorithm to be rejected", err)
+			}
+		})
+	}
+}
+
+func TestClaimsMapper(t *testing.T) {
+	tests := []struct {
+		name       string
+		cfg        config.AuthConfig
+		claims     map[string]interface{}
+		wantSub    string
+		wantGroups []string
+		wantErr    bool
+	}{
+		{
+			name:       "default claims",
+			claims:     map[string]interface{}{"sub": "alice", "groups": []interface{}{"a", "b"}},
+			wantSub:    "alice",
+			wantGroups: []string{"a", "b"},
+		},
+		{
+			name:       "a single group",
+			claims:     map[string]interface{}{"sub": "alice", "groups": "a"},
+			wantSub:    "alice",
+			wantGroups: []string{"a"},
+		},
+		{
+			name:       "custom claims",
+			cfg:        config.AuthConfig{SubjectClaim: "email", GroupsClaim: "roles"},
+			claims:     map[string]interface{}{"sub": "123", "email": "alice@example.com", "roles": []interface{}{"admin", 1}},
+			wantSub:    "alice@example.com",
+			wantGroups: []string{"admin"},
+		},
+		{
+			name:    "missing subject",
+			cfg:     config.AuthConfig{SubjectClaim: "email"},
+			claims:  map[string]interface{}{"sub": "123"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			identity, err := newClaimsMapper(tt.cfg).identity(tt.claims, "test")
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if identity.Subject != tt.wantSub {
+				t.Errorf("subject = %q, want %q", identity.Subject, tt.wantSub)
+			}
+			if strings.Join(identity.Groups, ",") != strings.Join(tt.wantGroups, ",") {
+				t.Errorf("groups = %v, want %v", identity.Groups, tt.wantGroups)
+			}
+		})
+	}
+}
+
+func TestHMACSecretLength(t *testing.T) {
+	cfg := config.AuthConfig{
+		HMAC: config.HMACAuthConfig{Secret: "too-short"},
+	}
+	if _, err := NewHMACAuthenticator(cfg); err == nil {
+		t.Fatal("accepted a secret shorter than 32 bytes")
+	}
+}
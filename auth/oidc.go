@@ -0,0 +1,203 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/panagiotisptr/job-scheduler/config"
+	"go.uber.org/zap"
+	"gopkg.in/square/go-jose.v2"
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+const (
+	defaultJWKSRefreshInterval = time.Hour
+	// how often the keys can be refetched because of an unknown key id
+	minJWKSRefreshInterval = time.Minute
+	jwksFetchTimeout       = time.Second * 10
+)
+
+var oidcAlgorithms = map[jose.SignatureAlgorithm]struct{}{
+	jose.RS256: {},
+	jose.RS384: {},
+	jose.RS512: {},
+	jose.PS256: {},
+	jose.PS384: {},
+	jose.PS512: {},
+	jose.ES256: {},
+	jose.ES384: {},
+	jose.ES512: {},
+	jose.EdDSA: {},
+}
+
+// OIDCAuthenticator accepts JWTs issued by an OIDC provider and
+// verified against the provider's JSON Web Key Set
+type OIDCAuthenticator struct {
+	logger          *zap.Logger
+	client          *http.Client
+	issuer          string
+	audience        string
+	jwksURL         string
+	refreshInterval time.Duration
+	mapper          claimsMapper
+
+	mu        sync.Mutex
+	keys      *jose.JSONWebKeySet
+	fetchedAt time.Time
+}
+
+func NewOIDCAuthenticator(
+	cfg config.AuthConfig,
+	logger *zap.Logger,
+) (*OIDCAuthenticator, error) {
+	oc := cfg.OIDC
+	if oc.Issuer == "" {
+		return nil, fmt.Errorf("oidc authentication needs an issuer")
+	}
+	if oc.JWKSURL == "" && oc.JWKSFile == "" {
+		return nil, fmt.Errorf("oidc authentication needs a jwksURL or a jwksFile")
+	}
+
+	a := &OIDCAuthenticator{
+		logger:          logger,
+		client:          &http.Client{Timeout: jwksFetchTimeout},
+		issuer:          oc.Issuer,
+		audience:        oc.Audience,
+		jwksURL:         oc.JWKSURL,
+		refreshInterval: oc.RefreshInterval,
+		mapper:          newClaimsMapper(cfg),
+	}
+	if a.refreshInterval <= 0 {
+		a.refreshInterval = defaultJWKSRefreshInterval
+	}
+
+	// keys loaded from a file are never refreshed
+	if oc.JWKSFile != "" {
+		b, err := os.ReadFile(oc.JWKSFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read JWKS file: %w", err)
+		}
+		keys, err := parseJWKS(b)
+		if err != nil {
+			return nil, err
+		}
+		a.keys = keys
+		a.jwksURL = ""
+	}
+
+	return a, nil
+}
+
+func parseJWKS(b []byte) (*jose.JSONWebKeySet, error) {
+	var keys jose.JSONWebKeySet
+	if err := json.Unmarshal(b, &keys); err != nil {
+		return nil, fmt.Errorf("failed to parse JWKS: %w", err)
+	}
+	if len(keys.Keys) == 0 {
+		return nil, fmt.Errorf("JWKS has no keys")
+	}
+
+	return &keys, nil
+}
+
+func (a *OIDCAuthenticator) fetchKeys(ctx context.Context) (*jose.JSONWebKeySet, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, a.jwksURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf(
+			"failed to fetch JWKS. Got status code: %d",
+			resp.StatusCode,
+		)
+	}
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseJWKS(b)
+}
+
+// keySet returns the cached keys, refetching them when they are
+// stale or when the token was signed with a key we don't know about
+func (a *OIDCAuthenticator) keySet(
+	ctx context.Context,
+	keyID string,
+) (*jose.JSONWebKeySet, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.jwksURL == "" {
+		return a.keys, nil
+	}
+
+	age := time.Since(a.fetchedAt)
+	stale := a.keys == nil || age > a.refreshInterval
+	unknownKey := a.keys != nil &&
+		len(a.keys.Key(keyID)) == 0 &&
+		age > minJWKSRefreshInterval
+	if !stale && !unknownKey {
+		return a.keys, nil
+	}
+
+	keys, err := a.fetchKeys(ctx)
+	if err != nil {
+		if a.keys != nil {
+			a.logger.Sugar().Warn("failed to refresh JWKS, using cached keys: ", err)
+			return a.keys, nil
+		}
+		return nil, err
+	}
+	a.keys = keys
+	a.fetchedAt = time.Now()
+
+	return a.keys, nil
+}
+
+func (a *OIDCAuthenticator) Authenticate(
+	ctx context.Context,
+	token string,
+) (*Identity, error) {
+	tok, err := jwt.ParseSigned(token)
+	if err != nil {
+		return nil, err
+	}
+	if len(tok.Headers) != 1 {
+		return nil, fmt.Errorf("expected a single signature")
+	}
+
+	keys, err := a.keySet(ctx, tok.Headers[0].KeyID)
+	if err != nil {
+		return nil, err
+	}
+	// tokens without a key id can only be verified if there's one key
+	var key interface{} = keys
+	if tok.Headers[0].KeyID == "" && len(keys.Keys) == 1 {
+		key = keys.Keys[0].Key
+	}
+	claims, err := verifyJWT(
+		token,
+		key,
+		oidcAlgorithms,
+		a.issuer,
+		a.audience,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return a.mapper.identity(claims, "oidc")
+}
@@ -0,0 +1,268 @@
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/panagiotisptr/job-scheduler/config"
+	"go.uber.org/zap"
+	"gopkg.in/square/go-jose.v2"
+)
+
+// testKeys the signing keys of a fake OIDC provider
+type testKeys struct {
+	rsa *rsa.PrivateKey
+	ec  *ecdsa.PrivateKey
+}
+
+func newTestKeys(t *testing.T) testKeys {
+	t.Helper()
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return testKeys{rsa: rsaKey, ec: ecKey}
+}
+
+// jwks the public keys as a JSON Web Key Set
+func (k testKeys) jwks(t *testing.T) []byte {
+	t.Helper()
+	b, err := json.Marshal(jose.JSONWebKeySet{
+		Keys: []jose.JSONWebKey{
+			{Key: &k.rsa.PublicKey, KeyID: "rsa", Algorithm: string(jose.RS256), Use: "sig"},
+			{Key: &k.ec.PublicKey, KeyID: "ec", Algorithm: string(jose.ES256), Use: "sig"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return b
+}
+
+func writeJWKS(t *testing.T, b []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(path, b, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+func oidcConfig(jwksFile string, jwksURL string) config.AuthConfig {
+	return config.AuthConfig{
+		OIDC: config.OIDCAuthConfig{
+			Issuer:   testIssuer,
+			Audience: testAudience,
+			JWKSFile: jwksFile,
+			JWKSURL:  jwksURL,
+		},
+	}
+}
+
+func TestOIDCAuthenticatorWithJWKSFile(t *testing.T) {
+	keys := newTestKeys(t)
+	a, err := NewOIDCAuthenticator(oidcConfig(writeJWKS(t, keys.jwks(t)), ""), zap.NewNop())
+	if err != nil {
+		t.Fatalf("NewOIDCAuthenticator: %s", err)
+	}
+	other := newTestKeys(t)
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr bool
+	}{
+		{name: "RS256", token: signToken(t, jose.RS256, keys.rsa, "rsa", testClaims())},
+		{name: "ES256", token: signToken(t, jose.ES256, keys.ec, "ec", testClaims())},
+		{name: "unknown key", token: signToken(t, jose.RS256, other.rsa, "rsa", testClaims()), wantErr: true},
+		{name: "unknown key id", token: signToken(t, jose.RS256, keys.rsa, "rotated", testClaims()), wantErr: true},
+		// with more than one key the key id picks the key
+		{name: "no key id", token: signToken(t, jose.RS256, keys.rsa, "", testClaims()), wantErr: true},
+		{name: "HS256", token: signToken(t, jose.HS256, []byte(testSecret), "rsa", testClaims()), wantErr: true},
+		{name: "none", token: unsignedToken(t, testClaims()), wantErr: true},
+		{
+			name:    "other issuer",
+			token:   signToken(t, jose.RS256, keys.rsa, "rsa", with(testClaims(), "iss", "https://other.example.com")),
+			wantErr: true,
+		},
+		{
+			name:    "expired",
+			token:   signToken(t, jose.RS256, keys.rsa, "rsa", with(testClaims(), "exp", time.Now().Add(-time.Hour).Unix())),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			identity, err := a.Authenticate(context.Background(), tt.token)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("accepted the token as %+v", identity)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Authenticate: %s", err)
+			}
+			if identity.Subject != "alice" || identity.Method != "oidc" {
+				t.Errorf("identity = %+v, want alice authenticated by oidc", identity)
+			}
+		})
+	}
+}
+
+func TestOIDCAuthenticatorWithASingleKey(t *testing.T) {
+	keys := newTestKeys(t)
+	b, err := json.Marshal(jose.JSONWebKeySet{
+		Keys: []jose.JSONWebKey{{Key: &keys.rsa.PublicKey, KeyID: "rsa"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, err := NewOIDCAuthenticator(oidcConfig(writeJWKS(t, b), ""), zap.NewNop())
+	if err != nil {
+		t.Fatalf("NewOIDCAuthenticator: %s", err)
+	}
+
+	token := signToken(t, jose.RS256, keys.rsa, "", testClaims())
+	if _, err := a.Authenticate(context.Background(), token); err != nil {
+		t.Errorf("rejected a token without a key id: %s", err)
+	}
+}
+
+func TestOIDCConfig(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  config.AuthConfig
+	}{
+		{name: "no keys", cfg: config.AuthConfig{OIDC: config.OIDCAuthConfig{Issuer: testIssuer}}},
+		{name: "no issuer", cfg: config.AuthConfig{OIDC: config.OIDCAuthConfig{JWKSURL: "https://example.com/jwks"}}},
+		{name: "missing file", cfg: oidcConfig(filepath.Join(t.TempDir(), "missing.json"), "")},
+		{name: "empty key set", cfg: oidcConfig(writeJWKS(t, []byte(`{"keys": []}`)), "")},
+		{name: "not json", cfg: oidcConfig(writeJWKS(t, []byte(`keys`)), "")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewOIDCAuthenticator(tt.cfg, zap.NewNop()); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestOIDCKeysAreRefetchedForUnknownKeyIDs(t *testing.T) {
+	keys := newTestKeys(t)
+	var jwks atomic.Value
+	jwks.Store(keys.jwks(t))
+	var fetches atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		_, _ = w.Write(jwks.Load().([]byte))
+	}))
+	defer srv.Close()
+
+	a, err := NewOIDCAuthenticator(oidcConfig("", srv.URL), zap.NewNop())
+	if err != nil {
+		t.Fatalf("NewOIDCAuthenticator: %s", err)
+	}
+	ctx := context.Background()
+	if _, err := a.Authenticate(ctx, signToken(t, jose.RS256, keys.rsa, "rsa", testClaims())); err != nil {
+		t.Fatalf("Authenticate: %s", err)
+	}
+	if _, err := a.Authenticate(ctx, signToken(t, jose.ES256, keys.ec, "ec", testClaims())); err != nil {
+		t.Fatalf("Authenticate: %s", err)
+	}
+	if got := fetches.Load(); got != 1 {
+		t.Fatalf("fetched the keys %d times, want 1", got)
+	}
+
+	// the provider rotates its keys
+	rotated := newTestKeys(t)
+	b, err := json.Marshal(jose.JSONWebKeySet{
+		Keys: []jose.JSONWebKey{{Key: &rotated.rsa.PublicKey, KeyID: "rsa-2"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	jwks.Store(b)
+	token := signToken(t, jose.RS256, rotated.rsa, "rsa-2", testClaims())
+
+	// unknown key ids are refetched at most once a minute
+	if _, err := a.Authenticate(ctx, token); err == nil {
+		t.Fatal("accepted a token of a key that was not fetched yet")
+	}
+	a.mu.Lock()
+	a.fetchedAt = a.fetchedAt.Add(-2 * minJWKSRefreshInterval)
+	a.mu.Unlock()
+	if _, err := a.Authenticate(ctx, token); err != nil {
+		t.Fatalf("rejected a token of the rotated key: %s", err)
+	}
+	if got := fetches.Load(); got != 2 {
+		t.Errorf("fetched the keys %d times, want 2", got)
+	}
+}
+
+func TestOIDCKeepsTheCachedKeysWhenTheProviderFails(t *testing.T) {
+	keys := newTestKeys(t)
+	var failing atomic.Bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if failing.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write(keys.jwks(t))
+	}))
+	defer srv.Close()
+
+	a, err := NewOIDCAuthenticator(oidcConfig("", srv.URL), zap.NewNop())
+	if err != nil {
+		t.Fatalf("NewOIDCAuthenticator: %s", err)
+	}
+	token := signToken(t, jose.RS256, keys.rsa, "rsa", testClaims())
+	if _, err := a.Authenticate(context.Background(), token); err != nil {
+		t.Fatalf("Authenticate: %s", err)
+	}
+
+	failing.Store(true)
+	a.mu.Lock()
+	a.fetchedAt = a.fetchedAt.Add(-2 * defaultJWKSRefreshInterval)
+	a.mu.Unlock()
+	if _, err := a.Authenticate(context.Background(), token); err != nil {
+		t.Errorf("rejected a token while the provider is down: %s", err)
+	}
+}
+
+func TestOIDCFailsWithoutKeys(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	a, err := NewOIDCAuthenticator(oidcConfig("", srv.URL), zap.NewNop())
+	if err != nil {
+		t.Fatalf("NewOIDCAuthenticator: %s", err)
+	}
+	token := signToken(t, jose.RS256, newTestKeys(t).rsa, "rsa", testClaims())
+	_, err = a.Authenticate(context.Background(), token)
+	if err == nil || !strings.Contains(err.Error(), "500") {
+		t.Errorf("err = %v, want the failed fetch", err)
+	}
+}
@@ -0,0 +1,78 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/panagiotisptr/job-scheduler/config"
+)
+
+type staticToken struct {
+	hash     [sha256.Size]byte
+	identity Identity
+}
+
+// StaticTokenAuthenticator accepts a fixed set of bearer tokens
+type StaticTokenAuthenticator struct {
+	tokens []staticToken
+}
+
+// readSecret returns the value or the trimmed contents of the file
+func readSecret(value string, file string) (string, error) {
+	if file == "" {
+		return value, nil
+	}
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(string(b)), nil
+}
+
+func NewStaticTokenAuthenticator(
+	cfg []config.StaticTokenConfig,
+) (*StaticTokenAuthenticator, error) {
+	a := &StaticTokenAuthenticator{}
+	for i, t := range cfg {
+		token, err := readSecret(t.Token, t.TokenFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read static token %d: %w", i, err)
+		}
+		if token == "" || t.Subject == "" {
+			return nil, fmt.Errorf("static token %d needs a token and a subject", i)
+		}
+		a.tokens = append(a.tokens, staticToken{
+			hash: sha256.Sum256([]byte(token)),
+			identity: Identity{
+				Subject: t.Subject,
+				Groups:  t.Groups,
+				Method:  "static",
+			},
+		})
+	}
+
+	return a, nil
+}
+
+func (a *StaticTokenAuthenticator) Authenticate(
+	ctx context.Context,
+	token string,
+) (*Identity, error) {
+	// compare hashes so that the comparison takes the same
+	// time regardless of the length of the token
+	hash := sha256.Sum256([]byte(token))
+	for _, t := range a.tokens {
+		if subtle.ConstantTimeCompare(hash[:], t.hash[:]) == 1 {
+			identity := t.identity
+
+			return &identity, nil
+		}
+	}
+
+	return nil, fmt.Errorf("unknown static token")
+}
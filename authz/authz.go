@@ -0,0 +1,245 @@
+package authz
+
+import (
+	"fmt"
+
+	"github.com/panagiotisptr/job-scheduler/auth"
+	"github.com/panagiotisptr/job-scheduler/config"
+	"go.uber.org/zap"
+)
+
+// Operation an action on a job that can be authorized
+type Operation string
+
+const (
+	OperationList     Operation = "list"
+	OperationView     Operation = "view"
+	OperationStart    Operation = "start"
+	OperationStop     Operation = "stop"
+	OperationRun      Operation = "run"
+	OperationDelete   Operation = "delete"
+	OperationPromote  Operation = "promote"
+	OperationRollback Operation = "rollback"
+)
+
+var operations = map[Operation]struct{}{
+	OperationList:     {},
+	OperationView:     {},
+	OperationStart:    {},
+	OperationStop:     {},
+	OperationRun:      {},
+	OperationDelete:   {},
+	OperationPromote:  {},
+	OperationRollback: {},
+}
+
+// Request the operation a caller wants to perform on a job.
+// Location is owner/name/path of the job manifest if it is known
+type Request struct {
+	Operation Operation `json:"operation"`
+	Job       string    `json:"job"`
+	Namespace string    `json:"namespace"`
+	Location  string    `json:"location"`
+}
+
+// Decision the outcome of authorizing a request. Role and Rule
+// (1-based) point to the rule that allowed the request
+type Decision struct {
+	Allowed bool   `json:"allowed"`
+	Reason  string `json:"reason"`
+	Role    string `json:"role,omitempty"`
+	Rule    int    `json:"rule,omitempty"`
+}
+
+type rule struct {
+	operations map[Operation]struct{}
+	jobs       []glob
+	namespaces []glob
+	locations  []glob
+}
+
+func (r rule) matches(req Request) bool {
+	if _, ok := r.operations[req.Operation]; !ok {
+		return false
+	}
+
+	return matchAny(r.jobs, req.Job) &&
+		matchAny(r.namespaces, req.Namespace) &&
+		matchAny(r.locations, req.Location)
+}
+
+type binding struct {
+	role     string
+	subjects map[string]struct{}
+	groups   map[string]struct{}
+}
+
+func (b binding) matches(identity *auth.Identity) bool {
+	if _, ok := b.subjects["*"]; ok {
+		return true
+	}
+	if _, ok := b.subjects[identity.Subject]; ok {
+		return true
+	}
+	for _, g := range identity.Groups {
+		if _, ok := b.groups[g]; ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Authorizer grants operations on jobs to callers based on the
+// roles bound to their subject and groups. Everything that is not
+// explicitly allowed is denied
+type Authorizer struct {
+	logger   *zap.Logger
+	enabled  bool
+	roles    map[string][]rule
+	bindings []binding
+}
+
+func ProvideAuthorizer(
+	cfg *config.Config,
+	logger *zap.Logger,
+) (*Authorizer, error) {
+	a := &Authorizer{
+		logger:  logger,
+		enabled: cfg.Authz.Enabled,
+		roles:   make(map[string][]rule),
+	}
+	if !a.enabled {
+		return a, nil
+	}
+
+	for _, rc := range cfg.Authz.Roles {
+		if rc.Name == "" {
+			return nil, fmt.Errorf("authz roles need a name")
+		}
+		if _, ok := a.roles[rc.Name]; ok {
+			return nil, fmt.Errorf("authz role %s is defined twice", rc.Name)
+		}
+		rules := []rule{}
+		for i, ruleCfg := range rc.Rules {
+			r, err := newRule(ruleCfg)
+			if err != nil {
+				return nil, fmt.Errorf("authz role %s rule %d: %w", rc.Name, i, err)
+			}
+			rules = append(rules, r)
+		}
+		a.roles[rc.Name] = rules
+	}
+
+	for _, bc := range cfg.Authz.Bindings {
+		if _, ok := a.roles[bc.Role]; !ok {
+			return nil, fmt.Errorf("authz binding to unknown role: %s", bc.Role)
+		}
+		b := binding{
+			role:     bc.Role,
+			subjects: make(map[string]struct{}),
+			groups:   make(map[string]struct{}),
+		}
+		for _, s := range bc.Subjects {
+			b.subjects[s] = struct{}{}
+		}
+		for _, g := range bc.Groups {
+			b.groups[g] = struct{}{}
+		}
+		a.bindings = append(a.bindings, b)
+	}
+	if len(a.bindings) == 0 {
+		logger.Sugar().Warn("authorization is enabled without bindings. Every request will be denied")
+	}
+
+	return a, nil
+}
+
+func newRule(cfg config.AuthzRuleConfig) (rule, error) {
+	r := rule{
+		operations: make(map[Operation]struct{}),
+	}
+	for _, o := range cfg.Operations {
+		if o == "*" {
+			r.operations = operations
+			break
+		}
+		if _, ok := operations[Operation(o)]; !ok {
+			return rule{}, fmt.Errorf("unknown operation: %s", o)
+		}
+		r.operations[Operation(o)] = struct{}{}
+	}
+	if len(r.operations) == 0 {
+		return rule{}, fmt.Errorf("no operations")
+	}
+
+	var err error
+	if r.jobs, err = compileGlobs(cfg.Jobs); err != nil {
+		return rule{}, err
+	}
+	if r.namespaces, err = compileGlobs(cfg.Namespaces); err != nil {
+		return rule{}, err
+	}
+	if r.locations, err = compileGlobs(cfg.Locations); err != nil {
+		return rule{}, err
+	}
+
+	return r, nil
+}
+
+// ParseOperation validates the name of an operation
+func ParseOperation(name string) (Operation, error) {
+	if _, ok := operations[Operation(name)]; !ok {
+		return "", fmt.Errorf("unknown operation: %s", name)
+	}
+
+	return Operation(name), nil
+}
+
+// Enabled whether requests are authorized
+func (a *Authorizer) Enabled() bool {
+	return a.enabled
+}
+
+// Authorize decides whether the identity can perform the request.
+// A nil identity is treated as the anonymous subject
+func (a *Authorizer) Authorize(
+	identity *auth.Identity,
+	req Request,
+) Decision {
+	if !a.enabled {
+		return Decision{
+			Allowed: true,
+			Reason:  "authorization is disabled",
+		}
+	}
+	if identity == nil {
+		identity = &auth.Identity{Subject: auth.Anonymous}
+	}
+
+	for _, b := range a.bindings {
+		if !b.matches(identity) {
+			continue
+		}
+		for i, r := range a.roles[b.role] {
+			if r.matches(req) {
+				return Decision{
+					Allowed: true,
+					Reason:  fmt.Sprintf("allowed by role %s", b.role),
+					Role:    b.role,
+					Rule:    i + 1,
+				}
+			}
+		}
+	}
+
+	return Decision{
+		Allowed: false,
+		Reason: fmt.Sprintf(
+			"no role bound to %s allows %s on job %q",
+			identity.Subject,
+			req.Operation,
+			req.Job,
+		),
+	}
+}
@@ -0,0 +1,271 @@
+package authz
+
+import (
+	"testing"
+
+	"github.com/panagiotisptr/job-scheduler/auth"
+	"github.com/panagiotisptr/job-scheduler/config"
+	"go.uber.org/zap"
+)
+
+func TestGlob(t *testing.T) {
+	tests := []struct {
+		pattern string
+		value   string
+		want    bool
+	}{
+		{pattern: "backup", value: "backup", want: true},
+		{pattern: "backup", value: "backups", want: false},
+		{pattern: "team-a-*", value: "team-a-backup", want: true},
+		{pattern: "team-a-*", value: "team-a-", want: true},
+		{pattern: "team-a-*", value: "team-b-backup", want: false},
+		{pattern: "*-backup", value: "team-a-backup", want: true},
+		{pattern: "team-?-backup", value: "team-a-backup", want: true},
+		{pattern: "team-?-backup", value: "team-ab-backup", want: false},
+		{pattern: "*", value: "", want: true},
+		// * and ? don't cross path separators, ** does
+		{pattern: "acme/*", value: "acme/cronjobs", want: true},
+		{pattern: "acme/*", value: "acme/cronjobs/prod", want: false},
+		{pattern: "acme/**", value: "acme/cronjobs/prod", want: true},
+		{pattern: "acme/*/prod", value: "acme/cronjobs/prod", want: true},
+		{pattern: "acme/*/prod", value: "acme/cronjobs/staging", want: false},
+		{pattern: "acme/??/prod", value: "acme/a/b/prod", want: false},
+		{pattern: "**/prod", value: "acme/cronjobs/prod", want: true},
+		// everything else is matched literally
+		{pattern: "job.v1", value: "job.v1", want: true},
+		{pattern: "job.v1", value: "jobxv1", want: false},
+		{pattern: "job[1]", value: "job[1]", want: true},
+		{pattern: "job[1]", value: "job1", want: false},
+		{pattern: "a+b", value: "aab", want: false},
+		{pattern: "^backup$", value: "^backup$", want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern+" "+tt.value, func(t *testing.T) {
+			g, err := compileGlob(tt.pattern)
+			if err != nil {
+				t.Fatalf("compileGlob: %s", err)
+			}
+			if got := g.re.MatchString(tt.value); got != tt.want {
+				t.Errorf("%q matches %q = %t, want %t", tt.pattern, tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchAny(t *testing.T) {
+	globs, err := compileGlobs([]string{"team-a-*", "shared-*"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		globs []glob
+		value string
+		want  bool
+	}{
+		{globs: globs, value: "team-a-backup", want: true},
+		{globs: globs, value: "shared-report", want: true},
+		{globs: globs, value: "team-b-backup", want: false},
+		// an empty list matches everything
+		{globs: nil, value: "team-b-backup", want: true},
+	}
+	for _, tt := range tests {
+		if got := matchAny(tt.globs, tt.value); got != tt.want {
+			t.Errorf("matchAny(%d globs, %q) = %t, want %t", len(tt.globs), tt.value, got, tt.want)
+		}
+	}
+}
+
+func testAuthorizer(t *testing.T) *Authorizer {
+	t.Helper()
+	cfg := &config.Config{
+		Authz: config.AuthzConfig{
+			Enabled: true,
+			Roles: []config.AuthzRoleConfig{
+				{
+					Name: "viewer",
+					Rules: []config.AuthzRuleConfig{
+						{Operations: []string{"list", "view"}},
+					},
+				},
+				{
+					Name: "team-a",
+					Rules: []config.AuthzRuleConfig{
+						{Operations: []string{"*"}, Jobs: []string{"team-a-*"}},
+						{
+							Operations: []string{"run"},
+							Namespaces: []string{"shared"},
+							Locations:  []string{"acme/cronjobs/**"},
+						},
+					},
+				},
+			},
+			Bindings: []config.AuthzBindingConfig{
+				{Role: "viewer", Subjects: []string{"*"}},
+				{Role: "team-a", Subjects: []string{"alice"}, Groups: []string{"team-a"}},
+			},
+		},
+	}
+	a, err := ProvideAuthorizer(cfg, zap.NewNop())
+	if err != nil {
+		t.Fatalf("ProvideAuthorizer: %s", err)
+	}
+
+	return a
+}
+
+func TestAuthorize(t *testing.T) {
+	a := testAuthorizer(t)
+	alice := &auth.Identity{Subject: "alice"}
+	bob := &auth.Identity{Subject: "bob"}
+	carol := &auth.Identity{Subject: "carol", Groups: []string{"team-a"}}
+
+	tests := []struct {
+		name     string
+		identity *auth.Identity
+		req      Request
+		allowed  bool
+		role     string
+		rule     int
+	}{
+		{
+			name:     "anyone can view",
+			identity: bob,
+			req:      Request{Operation: OperationView, Job: "team-a-backup"},
+			allowed:  true,
+			role:     "viewer",
+			rule:     1,
+		},
+		{
+			name:    "anonymous callers can list",
+			req:     Request{Operation: OperationList},
+			allowed: true,
+			role:    "viewer",
+			rule:    1,
+		},
+		{
+			name:     "bound by subject",
+			identity: alice,
+			req:      Request{Operation: OperationDelete, Job: "team-a-backup"},
+			allowed:  true,
+			role:     "team-a",
+			rule:     1,
+		},
+		{
+			name:     "bound by group",
+			identity: carol,
+			req:      Request{Operation: OperationRollback, Job: "team-a-backup"},
+			allowed:  true,
+			role:     "team-a",
+			rule:     1,
+		},
+		{
+			name:     "other jobs",
+			identity: alice,
+			req:      Request{Operation: OperationStart, Job: "team-b-backup"},
+		},
+		{
+			name:     "unbound subject",
+			identity: bob,
+			req:      Request{Operation: OperationStart, Job: "team-a-backup"},
+		},
+		{
+			name:     "every list of a rule has to match",
+			identity: alice,
+			req: Request{
+				Operation: OperationRun,
+				Job:       "report",
+				Namespace: "shared",
+				Location:  "acme/cronjobs/prod/report.yaml",
+			},
+			allowed: true,
+			role:    "team-a",
+			rule:    2,
+		},
+		{
+			name:     "other namespace",
+			identity: alice,
+			req: Request{
+				Operation: OperationRun,
+				Job:       "report",
+				Namespace: "default",
+				Location:  "acme/cronjobs/prod/report.yaml",
+			},
+		},
+		{
+			name:     "unknown location",
+			identity: alice,
+			req: Request{
+				Operation: OperationRun,
+				Job:       "report",
+				Namespace: "shared",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := a.Authorize(tt.identity, tt.req)
+			if d.Allowed != tt.allowed {
+				t.Fatalf("allowed = %t, want %t: %s", d.Allowed, tt.allowed, d.Reason)
+			}
+			if d.Role != tt.role || d.Rule != tt.rule {
+				t.Errorf("allowed by %s rule %d, want %s rule %d", d.Role, d.Rule, tt.role, tt.rule)
+			}
+		})
+	}
+}
+
+func TestAuthorizeWhenDisabled(t *testing.T) {
+	a, err := ProvideAuthorizer(&config.Config{}, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := a.Authorize(nil, Request{Operation: OperationDelete, Job: "backup"}); !d.Allowed {
+		t.Errorf("denied a request with authorization disabled: %s", d.Reason)
+	}
+}
+
+func TestAuthorizerConfig(t *testing.T) {
+	role := func(name string, operations ...string) config.AuthzRoleConfig {
+		return config.AuthzRoleConfig{
+			Name:  name,
+			Rules: []config.AuthzRuleConfig{{Operations: operations}},
+		}
+	}
+	tests := []struct {
+		name  string
+		authz config.AuthzConfig
+	}{
+		{name: "role without a name", authz: config.AuthzConfig{Roles: []config.AuthzRoleConfig{role("", "view")}}},
+		{name: "role defined twice", authz: config.AuthzConfig{Roles: []config.AuthzRoleConfig{role("a", "view"), role("a", "run")}}},
+		{name: "unknown operation", authz: config.AuthzConfig{Roles: []config.AuthzRoleConfig{role("a", "deploy")}}},
+		{name: "rule without operations", authz: config.AuthzConfig{Roles: []config.AuthzRoleConfig{role("a")}}},
+		{
+			name: "binding to an unknown role",
+			authz: config.AuthzConfig{
+				Roles:    []config.AuthzRoleConfig{role("a", "view")},
+				Bindings: []config.AuthzBindingConfig{{Role: "b", Subjects: []string{"alice"}}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.authz.Enabled = true
+			if _, err := ProvideAuthorizer(&config.Config{Authz: tt.authz}, zap.NewNop()); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestParseOperation(t *testing.T) {
+	for _, name := range []string{"list", "view", "start", "stop", "run", "delete", "promote", "rollback"} {
+		if op, err := ParseOperation(name); err != nil || string(op) != name {
+			t.Errorf("ParseOperation(%q) = %q, %v", name, op, err)
+		}
+	}
+	for _, name := range []string{"", "*", "Start", "deploy"} {
+		if _, err := ParseOperation(name); err == nil {
+			t.Errorf("ParseOperation(%q) accepted an unknown operation", name)
+		}
+	}
+}
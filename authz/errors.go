@@ -0,0 +1,20 @@
+package authz
+
+import "fmt"
+
+// ForbiddenError returned when the caller is not allowed to perform
+// an operation
+type ForbiddenError struct {
+	Subject string
+	Request Request
+	Reason  string
+}
+
+func (e *ForbiddenError) Error() string {
+	return fmt.Sprintf(
+		"%s is not allowed to %s job %q",
+		e.Subject,
+		e.Request.Operation,
+		e.Request.Job,
+	)
+}
@@ -0,0 +1,69 @@
+package authz
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// glob a compiled pattern where * matches anything but a /,
+// ** matches anything and ? matches a single character
+type glob struct {
+	pattern string
+	re      *regexp.Regexp
+}
+
+func compileGlob(pattern string) (glob, error) {
+	var b strings.Builder
+	b.WriteString("^")
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; c {
+		case '*':
+			if i+1 < len(pattern) && pattern[i+1] == '*' {
+				b.WriteString(".*")
+				i++
+			} else {
+				b.WriteString("[^/]*")
+			}
+		case '?':
+			b.WriteString("[^/]")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	b.WriteString("$")
+
+	re, err := regexp.Compile(b.String())
+	if err != nil {
+		return glob{}, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+	}
+
+	return glob{pattern: pattern, re: re}, nil
+}
+
+func compileGlobs(patterns []string) ([]glob, error) {
+	globs := []glob{}
+	for _, p := range patterns {
+		g, err := compileGlob(p)
+		if err != nil {
+			return nil, err
+		}
+		globs = append(globs, g)
+	}
+
+	return globs, nil
+}
+
+// matchAny an empty list matches every value
+func matchAny(globs []glob, value string) bool {
+	if len(globs) == 0 {
+		return true
+	}
+	for _, g := range globs {
+		if g.re.MatchString(value) {
+			return true
+		}
+	}
+
+	return false
+}
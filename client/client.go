@@ -0,0 +1,406 @@
+// Package client is a Go client for the v1 HTTP API of the job
+// scheduler
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/panagiotisptr/job-scheduler/types"
+	batchv1 "k8s.io/api/batch/v1"
+)
+
+// Error a failed request. Code is one of the apperror codes, e.g.
+// not_found or forbidden
+type Error struct {
+	StatusCode int
+	types.ErrorResponse
+}
+
+func (e *Error) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("request failed with status %d", e.StatusCode)
+	}
+
+	return fmt.Sprintf("%s (%s)", e.Message, e.Code)
+}
+
+// Client calls the API of a job scheduler server
+type Client struct {
+	baseURL    *url.URL
+	token      string
+	httpClient *http.Client
+}
+
+type Option func(c *Client)
+
+// WithToken authenticates the requests with a bearer token
+func WithToken(token string) Option {
+	return func(c *Client) {
+		c.token = token
+	}
+}
+
+// WithHTTPClient replaces http.DefaultClient, e.g. to set up TLS
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// New creates a client for the server at server, e.g.
+// https://job-scheduler.example.com
+func New(server string, opts ...Option) (*Client, error) {
+	u, err := url.Parse(server)
+	if err != nil {
+		return nil, fmt.Errorf("invalid server url: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("invalid server url: %s", server)
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/") + "/api/v1"
+
+	c := &Client{
+		baseURL:    u,
+		httpClient: http.DefaultClient,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c, nil
+}
+
+// ListJobs lists the cronjobs found in the GitHub locations
+func (c *Client) ListJobs(ctx context.Context) ([]string, error) {
+	var res types.JobNamesResponse
+	err := c.do(ctx, http.MethodGet, "/static/jobs", nil, nil, &res)
+
+	return res.JobNames, err
+}
+
+// GetJob returns the manifest of a cronjob, annotated with its source.
+// types.SourceOf reads it back
+func (c *Client) GetJob(
+	ctx context.Context,
+	jobName string,
+) (*batchv1.CronJob, error) {
+	var res batchv1.CronJob
+	err := c.do(ctx, http.MethodGet, "/static/jobs/"+jobName, nil, nil, &res)
+	if err != nil {
+		return nil, err
+	}
+
+	return &res, nil
+}
+
+// GetJobSource returns where the manifest of a cronjob was read from,
+// with the last commit that changed it
+func (c *Client) GetJobSource(
+	ctx context.Context,
+	jobName string,
+) (*types.Source, error) {
+	cj, err := c.GetJob(ctx, jobName)
+	if err != nil {
+		return nil, err
+	}
+	if source := types.SourceOf(cj); source != nil {
+		return source, nil
+	}
+
+	// older schedulers only report it on /source
+	var res types.Source
+	err = c.do(ctx, http.MethodGet, "/static/jobs/"+jobName+"/source", nil, nil, &res)
+	if err != nil {
+		return nil, err
+	}
+
+	return &res, nil
+}
+
+// ListRunningJobs lists the cronjobs running in the cluster
+func (c *Client) ListRunningJobs(ctx context.Context) ([]string, error) {
+	var res types.JobNamesResponse
+	err := c.do(ctx, http.MethodGet, "/cluster/jobs", nil, nil, &res)
+
+	return res.JobNames, err
+}
+
+// StartJob creates or resumes a cronjob
+func (c *Client) StartJob(ctx context.Context, jobName string) error {
+	return c.do(ctx, http.MethodPatch, jobPath(jobName, "start"), nil, nil, nil)
+}
+
+// StopJob suspends a cronjob
+func (c *Client) StopJob(ctx context.Context, jobName string) error {
+	return c.do(ctx, http.MethodPatch, jobPath(jobName, "stop"), nil, nil, nil)
+}
+
+// DeleteJob deletes a cronjob and the jobs it spawned
+func (c *Client) DeleteJob(ctx context.Context, jobName string) error {
+	return c.do(ctx, http.MethodDelete, jobPath(jobName, ""), nil, nil, nil)
+}
+
+// RunJob creates a job from the template of a cronjob and returns
+// its name
+func (c *Client) RunJob(ctx context.Context, jobName string) (string, error) {
+	var res types.RunJobResponse
+	err := c.do(ctx, http.MethodPost, jobPath(jobName, "run"), nil, nil, &res)
+
+	return res.JobName, err
+}
+
+// DiffJob returns the cronjob as defined in GitHub and as it is in
+// the cluster
+func (c *Client) DiffJob(
+	ctx context.Context,
+	jobName string,
+) (*types.JobDiffResponse, error) {
+	var res types.JobDiffResponse
+	err := c.do(ctx, http.MethodGet, jobPath(jobName, "diff"), nil, nil, &res)
+	if err != nil {
+		return nil, err
+	}
+
+	return &res, nil
+}
+
+// ListRevisions lists the specs a cronjob was applied with, newest
+// first
+func (c *Client) ListRevisions(
+	ctx context.Context,
+	jobName string,
+) ([]types.Revision, error) {
+	var res types.RevisionsResponse
+	err := c.do(ctx, http.MethodGet, jobPath(jobName, "revisions"), nil, nil, &res)
+
+	return res.Revisions, err
+}
+
+// Rollback applies an earlier revision of a cronjob and returns the
+// revision it is recorded as
+func (c *Client) Rollback(
+	ctx context.Context,
+	jobName string,
+	revision uint64,
+) (*types.Revision, error) {
+	query := url.Values{}
+	query.Set("revision", strconv.FormatUint(revision, 10))
+
+	var res types.Revision
+	err := c.do(ctx, http.MethodPost, jobPath(jobName, "rollback"), query, nil, &res)
+	if err != nil {
+		return nil, err
+	}
+
+	return &res, nil
+}
+
+// LogOptions selects the job and the part of its logs to return
+type LogOptions struct {
+	// Job a job spawned by the cronjob, the most recent one if empty
+	Job string
+	// TailLines the number of lines from the end, all of them if 0
+	TailLines int64
+	Follow    bool
+}
+
+// JobLogs the logs of the pod of a job. The caller has to close them
+type JobLogs struct {
+	Job string
+	Pod string
+	io.ReadCloser
+}
+
+// GetJobLogs returns the logs of a job spawned by the cronjob. With
+// Follow they are streamed until the pod terminates or ctx is done
+func (c *Client) GetJobLogs(
+	ctx context.Context,
+	jobName string,
+	opts LogOptions,
+) (*JobLogs, error) {
+	query := url.Values{}
+	if opts.Job != "" {
+		query.Set("job", opts.Job)
+	}
+	if opts.TailLines > 0 {
+		query.Set("tail", strconv.FormatInt(opts.TailLines, 10))
+	}
+	if opts.Follow {
+		query.Set("follow", "true")
+	}
+
+	res, err := c.send(ctx, http.MethodGet, jobPath(jobName, "logs"), query, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return &JobLogs{
+		Job:        res.Header.Get("X-Job-Name"),
+		Pod:        res.Header.Get("X-Pod-Name"),
+		ReadCloser: res.Body,
+	}, nil
+}
+
+// ListTasks lists the tasks found in the GitHub locations
+func (c *Client) ListTasks(ctx context.Context) ([]string, error) {
+	var res types.TaskNamesResponse
+	err := c.do(ctx, http.MethodGet, "/static/tasks", nil, nil, &res)
+
+	return res.TaskNames, err
+}
+
+// GetTask returns the manifest of a task
+func (c *Client) GetTask(
+	ctx context.Context,
+	taskName string,
+) (*batchv1.Job, error) {
+	var res batchv1.Job
+	err := c.do(ctx, http.MethodGet, "/static/tasks/"+taskName, nil, nil, &res)
+	if err != nil {
+		return nil, err
+	}
+
+	return &res, nil
+}
+
+// RunTask creates a job from the task and returns its name
+func (c *Client) RunTask(ctx context.Context, taskName string) (string, error) {
+	var res types.RunJobResponse
+	err := c.do(ctx, http.MethodPost, "/cluster/tasks/"+taskName+"/run", nil, nil, &res)
+
+	return res.JobName, err
+}
+
+// ListTaskRuns lists the jobs created from the task, newest first
+func (c *Client) ListTaskRuns(
+	ctx context.Context,
+	taskName string,
+) ([]types.TaskRun, error) {
+	var res types.TaskRunsResponse
+	err := c.do(ctx, http.MethodGet, "/cluster/tasks/"+taskName+"/runs", nil, nil, &res)
+
+	return res.Runs, err
+}
+
+// Promote pins a cronjob to the commit it runs at in another
+// environment, or to req.Commit if it is set
+func (c *Client) Promote(
+	ctx context.Context,
+	req types.PromotionRequest,
+) (*types.Promotion, error) {
+	var res types.Promotion
+	err := c.do(ctx, http.MethodPost, "/promotions", nil, req, &res)
+	if err != nil {
+		return nil, err
+	}
+
+	return &res, nil
+}
+
+// ListPromotions lists the promotions, newest first
+func (c *Client) ListPromotions(ctx context.Context) ([]types.Promotion, error) {
+	var res types.PromotionsResponse
+	err := c.do(ctx, http.MethodGet, "/promotions", nil, nil, &res)
+
+	return res.Promotions, err
+}
+
+// ReleasePromotion makes a promoted cronjob follow the ref of its
+// location again
+func (c *Client) ReleasePromotion(ctx context.Context, jobName string) error {
+	return c.do(ctx, http.MethodDelete, "/promotions/"+jobName, nil, nil, nil)
+}
+
+func jobPath(jobName string, action string) string {
+	p := "/cluster/jobs/" + jobName
+	if action != "" {
+		p += "/" + action
+	}
+
+	return p
+}
+
+// do sends a request and decodes the JSON response into out,
+// unless out is nil
+func (c *Client) do(
+	ctx context.Context,
+	method string,
+	path string,
+	query url.Values,
+	in interface{},
+	out interface{},
+) error {
+	res, err := c.send(ctx, method, path, query, in)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if out == nil {
+		_, err = io.Copy(io.Discard, res.Body)
+		return err
+	}
+	if err := json.NewDecoder(res.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return nil
+}
+
+// send returns the response of a successful request, otherwise an
+// *Error with the body of the response
+func (c *Client) send(
+	ctx context.Context,
+	method string,
+	path string,
+	query url.Values,
+	in interface{},
+) (*http.Response, error) {
+	u := *c.baseURL
+	u.Path += path
+	u.RawQuery = query.Encode()
+
+	var body io.Reader
+	if in != nil {
+		b, err := json.Marshal(in)
+		if err != nil {
+			return nil, err
+		}
+		body = bytes.NewReader(b)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	res, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode >= 200 && res.StatusCode < 300 {
+		return res, nil
+	}
+	defer res.Body.Close()
+
+	apiErr := &Error{StatusCode: res.StatusCode}
+	// the body is best effort, proxies in front of the server may
+	// respond with something else
+	_ = json.NewDecoder(res.Body).Decode(&apiErr.ErrorResponse)
+
+	return nil, apiErr
+}
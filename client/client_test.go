@@ -0,0 +1,375 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/panagiotisptr/job-scheduler/apperror"
+	"github.com/panagiotisptr/job-scheduler/types"
+	batchv1 "k8s.io/api/batch/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// newTestClient a client of a server handling every request with
+// handler
+func newTestClient(t *testing.T, handler http.HandlerFunc, opts ...Option) *Client {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	c, err := New(srv.URL, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return c
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		server  string
+		want    string
+		wantErr bool
+	}{
+		{server: "https://jobs.example.com", want: "https://jobs.example.com/api/v1"},
+		{server: "http://localhost:8080/", want: "http://localhost:8080/api/v1"},
+		{server: "https://example.com/scheduler/", want: "https://example.com/scheduler/api/v1"},
+		{server: "jobs.example.com", wantErr: true},
+		{server: "ftp://jobs.example.com", wantErr: true},
+		{server: "://", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.server, func(t *testing.T) {
+			c, err := New(tt.server)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("New(%q) = %s, want an error", tt.server, c.baseURL)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := c.baseURL.String(); got != tt.want {
+				t.Errorf("base url = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAuthentication(t *testing.T) {
+	var got http.Header
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header
+		writeJSON(w, http.StatusOK, types.JobNamesResponse{JobNames: []string{}})
+	}
+
+	for _, tt := range []struct {
+		name string
+		opts []Option
+		want string
+	}{
+		{name: "with a token", opts: []Option{WithToken("secret")}, want: "Bearer secret"},
+		{name: "without a token"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestClient(t, handler, tt.opts...)
+			if _, err := c.ListJobs(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+			if auth := got.Get("Authorization"); auth != tt.want {
+				t.Errorf("Authorization = %q, want %q", auth, tt.want)
+			}
+			if accept := got.Get("Accept"); accept != "application/json" {
+				t.Errorf("Accept = %q", accept)
+			}
+		})
+	}
+}
+
+func TestWithHTTPClient(t *testing.T) {
+	used := false
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, types.JobNamesResponse{JobNames: []string{"backup"}})
+	}))
+	t.Cleanup(srv.Close)
+	httpClient := &http.Client{Transport: roundTripper(func(r *http.Request) (*http.Response, error) {
+		used = true
+		return http.DefaultTransport.RoundTrip(r)
+	})}
+
+	c, err := New(srv.URL, WithHTTPClient(httpClient))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.ListJobs(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if !used {
+		t.Error("the requests were not sent with the given client")
+	}
+}
+
+type roundTripper func(*http.Request) (*http.Response, error)
+
+func (f roundTripper) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+func TestErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		want    Error
+		message string
+	}{
+		{
+			name:   "api error",
+			status: http.StatusNotFound,
+			body:   `{"code": "not_found", "message": "could not find cronjob backup", "requestId": "req-1"}`,
+			want: Error{
+				StatusCode: http.StatusNotFound,
+				ErrorResponse: types.ErrorResponse{
+					Code:      apperror.CodeNotFound,
+					Message:   "could not find cronjob backup",
+					RequestID: "req-1",
+				},
+			},
+			message: "could not find cronjob backup (not_found)",
+		},
+		{
+			name:    "not json",
+			status:  http.StatusBadGateway,
+			body:    "<html>Bad Gateway</html>",
+			want:    Error{StatusCode: http.StatusBadGateway},
+			message: "request failed with status 502",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				_, _ = io.WriteString(w, tt.body)
+			})
+
+			_, err := c.GetJob(context.Background(), "backup")
+			var apiErr *Error
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("error = %v, want an *Error", err)
+			}
+			if apiErr.StatusCode != tt.want.StatusCode ||
+				apiErr.Code != tt.want.Code ||
+				apiErr.Message != tt.want.Message ||
+				apiErr.RequestID != tt.want.RequestID {
+				t.Errorf("error = %+v, want %+v", *apiErr, tt.want)
+			}
+			if err.Error() != tt.message {
+				t.Errorf("message = %q, want %q", err.Error(), tt.message)
+			}
+		})
+	}
+}
+
+func TestRequests(t *testing.T) {
+	type request struct {
+		method, path, query, contentType, body string
+	}
+	var got request
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		got = request{
+			method:      r.Method,
+			path:        r.URL.Path,
+			query:       r.URL.RawQuery,
+			contentType: r.Header.Get("Content-Type"),
+			body:        string(body),
+		}
+		switch r.URL.Path {
+		case "/api/v1/cluster/jobs/backup/run":
+			writeJSON(w, http.StatusCreated, types.RunJobResponse{JobName: "backup-manual"})
+		case "/api/v1/promotions":
+			writeJSON(w, http.StatusCreated, types.Promotion{JobName: "backup", From: "staging"})
+		default:
+			writeJSON(w, http.StatusOK, struct{}{})
+		}
+	})
+	ctx := context.Background()
+
+	tests := []struct {
+		name string
+		call func() error
+		want request
+	}{
+		{
+			name: "start",
+			call: func() error { return c.StartJob(ctx, "backup") },
+			want: request{method: http.MethodPatch, path: "/api/v1/cluster/jobs/backup/start"},
+		},
+		{
+			name: "delete",
+			call: func() error { return c.DeleteJob(ctx, "backup") },
+			want: request{method: http.MethodDelete, path: "/api/v1/cluster/jobs/backup"},
+		},
+		{
+			name: "run",
+			call: func() error {
+				name, err := c.RunJob(ctx, "backup")
+				if err == nil && name != "backup-manual" {
+					t.Errorf("RunJob = %s, want backup-manual", name)
+				}
+				return err
+			},
+			want: request{method: http.MethodPost, path: "/api/v1/cluster/jobs/backup/run"},
+		},
+		{
+			name: "rollback",
+			call: func() error {
+				_, err := c.Rollback(ctx, "backup", 3)
+				return err
+			},
+			want: request{method: http.MethodPost, path: "/api/v1/cluster/jobs/backup/rollback", query: "revision=3"},
+		},
+		{
+			name: "promote",
+			call: func() error {
+				_, err := c.Promote(ctx, types.PromotionRequest{JobName: "backup", From: "staging"})
+				return err
+			},
+			want: request{
+				method:      http.MethodPost,
+				path:        "/api/v1/promotions",
+				contentType: "application/json",
+				body:        `{"jobName":"backup","from":"staging"}`,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.call(); err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("request = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetJobSource(t *testing.T) {
+	source := &types.Source{
+		Owner:  "acme",
+		Name:   "jobs",
+		Path:   "cronjobs/backup.yaml",
+		Branch: "main",
+		Ref:    "main",
+		Commit: "0123456789abcdef0123456789abcdef01234567",
+		LastCommit: &types.Commit{
+			SHA:     "0123456789abcdef0123456789abcdef01234567",
+			Author:  "alice",
+			Message: "add backup",
+		},
+	}
+	cj := &batchv1.CronJob{ObjectMeta: metav1.ObjectMeta{Name: "backup"}}
+
+	tests := []struct {
+		name      string
+		annotated bool
+		requested []string
+	}{
+		{
+			name:      "annotations",
+			annotated: true,
+			requested: []string{"/api/v1/static/jobs/backup"},
+		},
+		{
+			name:      "older scheduler",
+			requested: []string{"/api/v1/static/jobs/backup", "/api/v1/static/jobs/backup/source"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requested := []string{}
+			c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				requested = append(requested, r.URL.Path)
+				switch r.URL.Path {
+				case "/api/v1/static/jobs/backup":
+					res := cj
+					if tt.annotated {
+						res = types.WithSource(cj, source)
+					}
+					writeJSON(w, http.StatusOK, res)
+				case "/api/v1/static/jobs/backup/source":
+					writeJSON(w, http.StatusOK, source)
+				default:
+					http.NotFound(w, r)
+				}
+			})
+
+			got, err := c.GetJobSource(context.Background(), "backup")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, source) {
+				t.Errorf("source = %+v, want %+v", got, source)
+			}
+			if !reflect.DeepEqual(requested, tt.requested) {
+				t.Errorf("requested %v, want %v", requested, tt.requested)
+			}
+		})
+	}
+}
+
+func TestGetJobLogs(t *testing.T) {
+	var query string
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/cluster/jobs/backup/logs" {
+			http.NotFound(w, r)
+			return
+		}
+		query = r.URL.RawQuery
+		w.Header().Set("X-Job-Name", "backup-28000000")
+		w.Header().Set("X-Pod-Name", "backup-28000000-x7k2p")
+		_, _ = io.WriteString(w, "line 1\nline 2\n")
+	})
+
+	logs, err := c.GetJobLogs(context.Background(), "backup", LogOptions{
+		Job:       "backup-28000000",
+		TailLines: 10,
+		Follow:    true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer logs.Close()
+	if query != "follow=true&job=backup-28000000&tail=10" {
+		t.Errorf("query = %s", query)
+	}
+	if logs.Job != "backup-28000000" || logs.Pod != "backup-28000000-x7k2p" {
+		t.Errorf("logs of job %q pod %q, want the ones of the headers", logs.Job, logs.Pod)
+	}
+	b, err := io.ReadAll(logs)
+	if err != nil || string(b) != "line 1\nline 2\n" {
+		t.Errorf("logs = %q, %v", b, err)
+	}
+
+	// no options, no query
+	if logs, err = c.GetJobLogs(context.Background(), "backup", LogOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	logs.Close()
+	if query != "" {
+		t.Errorf("query = %s, want none", query)
+	}
+}
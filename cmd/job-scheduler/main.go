@@ -5,47 +5,136 @@ import (
 	"fmt"
 	"net/http"
 	"os"
+	"time"
 
 	"github.com/google/go-github/v48/github"
 	"github.com/gorilla/mux"
 	"github.com/panagiotisptr/job-scheduler/app"
+	"github.com/panagiotisptr/job-scheduler/audit"
+	"github.com/panagiotisptr/job-scheduler/auth"
+	"github.com/panagiotisptr/job-scheduler/authz"
 	"github.com/panagiotisptr/job-scheduler/config"
 	"github.com/panagiotisptr/job-scheduler/controller"
+	"github.com/panagiotisptr/job-scheduler/events"
+	"github.com/panagiotisptr/job-scheduler/githubapp"
+	"github.com/panagiotisptr/job-scheduler/health"
+	"github.com/panagiotisptr/job-scheduler/metrics"
+	"github.com/panagiotisptr/job-scheduler/notifier"
 	"github.com/panagiotisptr/job-scheduler/parser"
 	githubRepo "github.com/panagiotisptr/job-scheduler/repository/github"
 	kubeRepo "github.com/panagiotisptr/job-scheduler/repository/kubernetes"
 	"github.com/panagiotisptr/job-scheduler/repository/memory"
+	revisionRepo "github.com/panagiotisptr/job-scheduler/repository/revision"
+	schedulerRepo "github.com/panagiotisptr/job-scheduler/repository/scheduler"
+	"github.com/panagiotisptr/job-scheduler/requestid"
+	"github.com/panagiotisptr/job-scheduler/server"
 	"github.com/panagiotisptr/job-scheduler/service"
+	"github.com/panagiotisptr/job-scheduler/store"
+	boltStore "github.com/panagiotisptr/job-scheduler/store/bolt"
+	memoryStore "github.com/panagiotisptr/job-scheduler/store/memory"
+	"github.com/panagiotisptr/job-scheduler/tracing"
 	"go.uber.org/fx"
 	"go.uber.org/fx/fxevent"
 	"go.uber.org/zap"
 	"golang.org/x/oauth2"
 
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 )
 
-func ProvideKuberentesClientset() (*kubernetes.Clientset, error) {
+func ProvideKubernetesConfig() (*rest.Config, error) {
 	config, err := rest.InClusterConfig()
 	if err != nil {
 		panic(err.Error())
 	}
 
+	return config, nil
+}
+
+func ProvideKuberentesClientset(
+	config *rest.Config,
+) (*kubernetes.Clientset, error) {
 	return kubernetes.NewForConfig(config)
 }
 
+func ProvideDynamicClient(
+	config *rest.Config,
+) (dynamic.Interface, error) {
+	return dynamic.NewForConfig(config)
+}
+
 func ProvideGitHubClient(
 	cfg *config.Config,
+	logger *zap.Logger,
 ) (*github.Client, error) {
-	ctx := context.Background()
-	ts := oauth2.StaticTokenSource(
-		&oauth2.Token{AccessToken: cfg.GitHubConfig.AccessToken},
-	)
-	tc := oauth2.NewClient(ctx, ts)
+	ghCfg := cfg.GitHubConfig
+	var tc *http.Client
+	if ghCfg.App.ID != 0 {
+		key, err := os.ReadFile(ghCfg.App.PrivateKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the private key of the GitHub App: %w", err)
+		}
+		transport, err := githubapp.NewTransport(
+			ghCfg.App.ID,
+			key,
+			ghCfg.BaseURL,
+			ghCfg.GetUploadURL(),
+			nil,
+		)
+		if err != nil {
+			return nil, err
+		}
+		logger.Sugar().Infow(
+			"authenticating as GitHub App",
+			"app", ghCfg.App.ID,
+		)
+		tc = &http.Client{Transport: transport}
+	} else {
+		ts := oauth2.StaticTokenSource(
+			&oauth2.Token{AccessToken: ghCfg.AccessToken},
+		)
+		tc = oauth2.NewClient(context.Background(), ts)
+	}
 
-	client := github.NewClient(tc)
+	if ghCfg.BaseURL != "" {
+		return github.NewEnterpriseClient(ghCfg.BaseURL, ghCfg.GetUploadURL(), tc)
+	}
 
-	return client, nil
+	return github.NewClient(tc), nil
+}
+
+func ProvideStore(
+	lc fx.Lifecycle,
+	cfg *config.Config,
+	logger *zap.Logger,
+) (store.Store, error) {
+	var s store.Store
+	switch cfg.Store.Type {
+	case "", "memory":
+		logger.Sugar().Warn("using the in-memory store. State is lost on restart")
+		s = memoryStore.NewStore()
+	case "bolt":
+		path := cfg.Store.Path
+		if path == "" {
+			path = "/var/lib/job-scheduler/state.db"
+		}
+		bs, err := boltStore.NewStore(path)
+		if err != nil {
+			return nil, err
+		}
+		s = bs
+	default:
+		return nil, fmt.Errorf("unknown store type: %s", cfg.Store.Type)
+	}
+
+	lc.Append(fx.Hook{
+		OnStop: func(ctx context.Context) error {
+			return s.Close()
+		},
+	})
+
+	return s, nil
 }
 
 func ProvideLogger() *zap.Logger {
@@ -54,59 +143,114 @@ func ProvideLogger() *zap.Logger {
 	return logger
 }
 
-func ProvideMuxRouter() *mux.Router {
-	return mux.NewRouter()
+func ProvideMuxRouter(
+	m *metrics.Metrics,
+	t *tracing.Tracing,
+	a *auth.Auth,
+) *mux.Router {
+	r := mux.NewRouter()
+	r.Use(requestid.Middleware, t.Middleware, m.Middleware, a.Middleware)
+	r.Handle("/metrics", m.Handler()).Methods(http.MethodGet)
+	if h, ok := t.SpansHandler(); ok {
+		r.Handle("/debug/traces", h).Methods(http.MethodGet, http.MethodDelete)
+	}
+
+	return r
 }
 
 func Bootstrap(
-	cfg *config.Config,
-	lc fx.Lifecycle,
-	logger *zap.Logger,
-	r *mux.Router,
+	srv *server.HTTPServer,
+	grpcSrv *server.GRPCServer,
 
 	// need these here to invoke them
 	cronJobController *controller.CronJobController,
 	kubeController *controller.KubernetesController,
+	taskController *controller.TaskController,
+	promotionController *controller.PromotionController,
+	eventsController *controller.EventsController,
+	notificationController *controller.NotificationController,
+	healthController *controller.HealthController,
+	authzController *controller.AuthzController,
+	auditController *controller.AuditController,
+	openAPIController *controller.OpenAPIController,
+	grpcService *controller.GRPCService,
 ) {
-	lc.Append(fx.Hook{
-		OnStart: func(ctx context.Context) error {
-			go http.ListenAndServe(fmt.Sprintf(":%d", cfg.Service.Port), r)
-
-			return nil
-		},
-	})
+	srv.RegisterOnShutdown(eventsController.Close)
+	grpcSrv.RegisterOnShutdown(grpcService.Close)
 }
 
 func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "validate":
+			os.Exit(runValidate(os.Args[2:]))
+		case "plan":
+			os.Exit(runPlan(os.Args[2:]))
+		}
+	}
+
 	isDev := os.Getenv("DEV_MODE")
 
 	var kubeRepoProvider interface{}
 	var configProvider interface{}
+	invokes := []interface{}{Bootstrap, metrics.RegisterJobMetrics}
 	if isDev == "true" {
 		kubeRepoProvider = memory.ProvideKubernetesMemoryRepository
 		configProvider = config.ProvideConfig
 	} else {
 		kubeRepoProvider = kubeRepo.ProvideKubernetesRepository
 		configProvider = config.ProvideRemoteConfig
+		invokes = append(invokes, kubeRepo.RegisterJobWatcher)
 	}
+	// has to come last so that readiness flips after every other
+	// OnStart hook and before every OnStop hook
+	invokes = append(invokes, health.RegisterLifecycle)
 
 	app := fx.New(
 		fx.Provide(
 			ProvideLogger,
 			ProvideGitHubClient,
+			ProvideKubernetesConfig,
 			ProvideKuberentesClientset,
+			ProvideDynamicClient,
 			ProvideMuxRouter,
+			server.ProvideHTTPServer,
+			server.ProvideGRPCServer,
 			configProvider,
+			ProvideStore,
+			events.ProvideBus,
+			auth.ProvideAuth,
+			authz.ProvideAuthorizer,
+			audit.ProvideAuditor,
+			metrics.ProvideMetrics,
+			health.ProvideChecker,
+			tracing.ProvideTracing,
+			tracing.ProvideTracerProvider,
 			parser.ProvideCronJobParser,
 			githubRepo.ProvideGitHubCronJobRepository,
 			kubeRepoProvider,
+			schedulerRepo.ProvideEnvironmentRepository,
+			revisionRepo.ProvideRevisionRepository,
 			service.ProvideCronJobService,
 			service.ProvideKubernetesService,
+			service.ProvidePromotionService,
+			notifier.ProvideNotifier,
 			app.ProvideApp,
 			controller.ProvideCronJobController,
 			controller.ProvideKubernetesController,
+			controller.ProvideTaskController,
+			controller.ProvidePromotionController,
+			controller.ProvideEventsController,
+			controller.ProvideNotificationController,
+			controller.ProvideHealthController,
+			controller.ProvideAuthzController,
+			controller.ProvideAuditController,
+			controller.ProvideOpenAPIController,
+			controller.ProvideGRPCService,
 		),
-		fx.Invoke(Bootstrap),
+		fx.Invoke(invokes...),
+		// leave enough time for the http server to drain
+		fx.StopTimeout(time.Second*30),
 		fx.WithLogger(
 			func(logger *zap.Logger) fxevent.Logger {
 				return &fxevent.ZapLogger{Logger: logger}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/panagiotisptr/job-scheduler/plan"
+	"github.com/panagiotisptr/job-scheduler/validation"
+	batchv1 "k8s.io/api/batch/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// liveCronJobs lists the cronjobs of the namespace, the one of the
+// current context if namespace is empty
+func liveCronJobs(kubeconfig string, namespace string) ([]batchv1.CronJob, error) {
+	clientConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
+		&clientcmd.ClientConfigLoadingRules{ExplicitPath: kubeconfig},
+		&clientcmd.ConfigOverrides{},
+	)
+	if namespace == "" {
+		var err error
+		if namespace, _, err = clientConfig.Namespace(); err != nil {
+			return nil, err
+		}
+	}
+	restConfig, err := clientConfig.ClientConfig()
+	if err != nil {
+		return nil, err
+	}
+	client, err := kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*30)
+	defer cancel()
+	list, err := client.BatchV1().CronJobs(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	return list.Items, nil
+}
+
+func snapshotCronJobs(path string) ([]batchv1.CronJob, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return plan.ReadSnapshot(f)
+}
+
+type planOutput struct {
+	Diagnostics []validation.Diagnostic `json:"diagnostics"`
+	Changes     []plan.Change           `json:"changes"`
+}
+
+// runPlan prints what starting the cronjobs of the manifests under
+// the directories would change in the cluster
+func runPlan(args []string) int {
+	flags := flag.NewFlagSet("plan", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: job-scheduler plan [flags] (-kubeconfig <file> | -snapshot <file>) <dir>...")
+		flags.PrintDefaults()
+	}
+	configFile := flags.String("config", "", "config file with the policy to enforce")
+	kubeconfig := flags.String("kubeconfig", "", "kubeconfig of the cluster")
+	namespace := flags.String("namespace", "", "namespace of the cronjobs, defaults to the one of the kubeconfig context")
+	snapshot := flags.String("snapshot", "", "output of kubectl get cronjobs -o json, instead of a cluster")
+	output := flags.String("o", "text", "output format: text or json")
+	src := addSourceFlags(flags)
+	if err := flags.Parse(args); err != nil {
+		return exitFailed
+	}
+	if flags.NArg() == 0 || !src.valid() ||
+		(*kubeconfig == "") == (*snapshot == "") ||
+		(*output != "text" && *output != "json") {
+		flags.Usage()
+		return exitFailed
+	}
+
+	cfg, err := loadConfig(*configFile)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitFailed
+	}
+	validator := validation.NewValidator(cfg.Policy, cfg.Companions)
+	files, err := readManifests(flags.Args(), cfg.Templates.Values, src)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "failed to read manifests:", err)
+		return exitFailed
+	}
+	diagnostics := validator.ValidateFiles(files)
+
+	var live []batchv1.CronJob
+	if *snapshot != "" {
+		live, err = snapshotCronJobs(*snapshot)
+	} else {
+		live, err = liveCronJobs(*kubeconfig, *namespace)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "failed to get the cronjobs of the cluster:", err)
+		return exitFailed
+	}
+	changes := plan.Plan(validCronJobs(files, diagnostics), live)
+
+	if *output == "json" {
+		b, err := json.MarshalIndent(planOutput{
+			Diagnostics: diagnostics,
+			Changes:     changes,
+		}, "", "  ")
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return exitFailed
+		}
+		fmt.Println(string(b))
+	} else {
+		printDiagnostics(os.Stderr, diagnostics)
+		printChanges(changes)
+	}
+
+	if validation.HasErrors(diagnostics) {
+		return exitInvalid
+	}
+
+	return exitOK
+}
+
+var actionSymbols = map[plan.Action]string{
+	plan.ActionCreate: "+",
+	plan.ActionUpdate: "~",
+	plan.ActionOrphan: "-",
+}
+
+func printChanges(changes []plan.Change) {
+	counts := map[plan.Action]int{}
+	for _, c := range changes {
+		counts[c.Action]++
+		if c.Action == plan.ActionNone {
+			continue
+		}
+		line := fmt.Sprintf("%s %s %s", actionSymbols[c.Action], c.Action, c.Job)
+		if c.Reason != "" {
+			line += ": " + c.Reason
+		}
+		fmt.Println(line)
+	}
+	fmt.Printf(
+		"%d to create, %d to update, %d orphaned, %d unchanged\n",
+		counts[plan.ActionCreate],
+		counts[plan.ActionUpdate],
+		counts[plan.ActionOrphan],
+		counts[plan.ActionNone],
+	)
+}
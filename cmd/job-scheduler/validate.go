@@ -0,0 +1,279 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/panagiotisptr/job-scheduler/config"
+	"github.com/panagiotisptr/job-scheduler/helm"
+	"github.com/panagiotisptr/job-scheduler/parser"
+	"github.com/panagiotisptr/job-scheduler/render"
+	"github.com/panagiotisptr/job-scheduler/validation"
+	batchv1 "k8s.io/api/batch/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/kustomize/kyaml/filesys"
+)
+
+// exit codes of the validate and plan subcommands
+const (
+	exitOK      = 0
+	exitInvalid = 1
+	exitFailed  = 2
+)
+
+// source how the directories given to validate and plan are read
+type source struct {
+	// kustomize builds each directory as a kustomization root
+	kustomize bool
+	// helm renders each directory as a chart with valuesFile
+	helm       bool
+	valuesFile string
+}
+
+// addSourceFlags registers the flags of the source
+func addSourceFlags(flags *flag.FlagSet) *source {
+	s := &source{}
+	flags.BoolVar(&s.kustomize, "kustomize", false, "build each directory as a kustomization root")
+	flags.BoolVar(&s.helm, "helm", false, "render each directory as a helm chart")
+	flags.StringVar(&s.valuesFile, "values", "", "values file of the helm charts, the values of the charts if empty")
+
+	return s
+}
+
+// valid whether the flags of the source can be used together
+func (s *source) valid() bool {
+	return !(s.kustomize && s.helm) && (s.helm || s.valuesFile == "")
+}
+
+// readManifests parses every YAML file under the directories.
+// Templated manifests are rendered with the values file of their
+// directory and the overrides. Kustomizations and charts are built
+// as a whole instead
+func readManifests(
+	dirs []string,
+	overrides []config.TemplateValue,
+	src *source,
+) ([]validation.File, error) {
+	files := []validation.File{}
+	for _, dir := range dirs {
+		if src.kustomize {
+			files = append(files, validation.File{
+				Path:   dir,
+				Result: parser.ParseKustomization(filesys.MakeFsOnDisk(), dir),
+			})
+			continue
+		}
+		if src.helm {
+			files = append(files, validation.File{
+				Path: dir,
+				Result: parser.ParseChart(
+					filesys.MakeFsOnDisk(),
+					dir,
+					src.valuesFile,
+					helm.Release{Namespace: metav1.NamespaceDefault},
+				),
+			})
+			continue
+		}
+		err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			ext := filepath.Ext(path)
+			template := render.IsTemplate(path)
+			if d.IsDir() || render.IsValuesFile(path) ||
+				(ext != ".yml" && ext != ".yaml" && !template) {
+				return nil
+			}
+			f, err := os.Open(path)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			if !template {
+				files = append(files, validation.File{
+					Path:   path,
+					Result: parser.Parse(f),
+				})
+				return nil
+			}
+			values, err := readValuesFile(filepath.Dir(path))
+			if err != nil {
+				return err
+			}
+			files = append(files, validation.File{
+				Path:   path,
+				Result: parser.ParseTemplate(path, f, values, overrides),
+			})
+
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return files, nil
+}
+
+// readValuesFile reads the values file of the templates of the
+// directory, nil if there is none
+func readValuesFile(dir string) ([]byte, error) {
+	for _, name := range render.ValuesFiles {
+		b, err := os.ReadFile(filepath.Join(dir, name))
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+
+		return b, err
+	}
+
+	return nil, nil
+}
+
+// loadConfig loads the config file with the policy, the allowed
+// companions and the template values to check the manifests with.
+// Without one the defaults are used
+func loadConfig(configFile string) (*config.Config, error) {
+	if configFile == "" {
+		return &config.Config{}, nil
+	}
+	cfg, err := config.Load(configFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+
+	return cfg, nil
+}
+
+// validCronJobs the cronjobs without errors, the first definition
+// wins when a name is defined more than once
+func validCronJobs(
+	files []validation.File,
+	diagnostics []validation.Diagnostic,
+) []batchv1.CronJob {
+	invalid := map[string]struct{}{}
+	for _, d := range diagnostics {
+		if d.Severity == parser.SeverityError {
+			invalid[d.Path+"/"+d.Job] = struct{}{}
+		}
+	}
+
+	cronJobs := []batchv1.CronJob{}
+	seen := map[string]struct{}{}
+	for _, f := range files {
+		for _, cj := range f.Result.CronJobs {
+			if _, ok := invalid[f.Path+"/"+cj.Name]; ok {
+				continue
+			}
+			if _, ok := seen[cj.Name]; ok {
+				continue
+			}
+			seen[cj.Name] = struct{}{}
+			cronJobs = append(cronJobs, cj)
+		}
+	}
+
+	return cronJobs
+}
+
+func countSeverities(diagnostics []validation.Diagnostic) (int, int) {
+	errors, warnings := 0, 0
+	for _, d := range diagnostics {
+		if d.Severity == parser.SeverityError {
+			errors++
+		} else {
+			warnings++
+		}
+	}
+
+	return errors, warnings
+}
+
+func printDiagnostics(w io.Writer, diagnostics []validation.Diagnostic) {
+	for _, d := range diagnostics {
+		fmt.Fprintln(w, d.String())
+	}
+}
+
+type validateOutput struct {
+	Files       int                     `json:"files"`
+	CronJobs    int                     `json:"cronJobs"`
+	Tasks       int                     `json:"tasks"`
+	Diagnostics []validation.Diagnostic `json:"diagnostics"`
+}
+
+// runValidate checks the manifests under the directories without
+// talking to GitHub or the cluster
+func runValidate(args []string) int {
+	flags := flag.NewFlagSet("validate", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: job-scheduler validate [flags] <dir>...")
+		flags.PrintDefaults()
+	}
+	configFile := flags.String("config", "", "config file with the policy to enforce")
+	output := flags.String("o", "text", "output format: text or json")
+	src := addSourceFlags(flags)
+	if err := flags.Parse(args); err != nil {
+		return exitFailed
+	}
+	if flags.NArg() == 0 || !src.valid() || (*output != "text" && *output != "json") {
+		flags.Usage()
+		return exitFailed
+	}
+
+	cfg, err := loadConfig(*configFile)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitFailed
+	}
+	validator := validation.NewValidator(cfg.Policy, cfg.Companions)
+	files, err := readManifests(flags.Args(), cfg.Templates.Values, src)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "failed to read manifests:", err)
+		return exitFailed
+	}
+	diagnostics := validator.ValidateFiles(files)
+
+	cronJobs, tasks := 0, 0
+	for _, f := range files {
+		cronJobs += len(f.Result.CronJobs)
+		tasks += len(f.Result.Jobs)
+	}
+	if *output == "json" {
+		b, err := json.MarshalIndent(validateOutput{
+			Files:       len(files),
+			CronJobs:    cronJobs,
+			Tasks:       tasks,
+			Diagnostics: diagnostics,
+		}, "", "  ")
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return exitFailed
+		}
+		fmt.Println(string(b))
+	} else {
+		printDiagnostics(os.Stdout, diagnostics)
+		errors, warnings := countSeverities(diagnostics)
+		fmt.Printf(
+			"%d cronjobs and %d tasks in %d files, %d errors, %d warnings\n",
+			cronJobs,
+			tasks,
+			len(files),
+			errors,
+			warnings,
+		)
+	}
+
+	if validation.HasErrors(diagnostics) {
+		return exitInvalid
+	}
+
+	return exitOK
+}
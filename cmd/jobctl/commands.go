@@ -0,0 +1,355 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/panagiotisptr/job-scheduler/client"
+	"github.com/panagiotisptr/job-scheduler/types"
+	"github.com/pmezard/go-difflib/difflib"
+	"github.com/spf13/cobra"
+	batchv1 "k8s.io/api/batch/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// errDifferent makes jobctl exit with 1 when diff finds differences,
+// like diff(1)
+var errDifferent = errors.New("the cronjob differs from the one in the cluster")
+
+func newListCommand(a *app) *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "List the cronjobs found in GitHub",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, cancel := a.context(cmd)
+			defer cancel()
+			names, err := a.client.ListJobs(ctx)
+			if err != nil {
+				return err
+			}
+
+			return a.printer.print(
+				types.JobNamesResponse{JobNames: names},
+				func(w io.Writer) { printNames(w, "NAME", names) },
+			)
+		},
+	}
+}
+
+func newGetCommand(a *app) *cobra.Command {
+	return &cobra.Command{
+		Use:               "get JOB",
+		Short:             "Show the manifest of a cronjob",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: a.completeJobs(availableJobs),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, cancel := a.context(cmd)
+			defer cancel()
+			cj, err := a.client.GetJob(ctx, args[0])
+			if err != nil {
+				return err
+			}
+			// the source is in the annotations, older schedulers
+			// don't report it
+			source := types.Source{}
+			if res := types.SourceOf(cj); res != nil {
+				source = *res
+			}
+			repo, author := "", ""
+			if source.Owner != "" {
+				repo = source.Owner + "/" + source.Name
+			}
+			if source.LastCommit != nil {
+				author = source.LastCommit.Author
+			}
+
+			return a.printer.print(cj, func(w io.Writer) {
+				suspended := cj.Spec.Suspend != nil && *cj.Spec.Suspend
+				fmt.Fprintln(w, "NAME\tSCHEDULE\tSUSPEND\tIMAGES\tREPOSITORY\tPATH\tREF\tCOMMIT\tAUTHOR")
+				fmt.Fprintf(
+					w,
+					"%s\t%s\t%t\t%s\t%s\t%s\t%s\t%s\t%s\n",
+					cj.Name,
+					cj.Spec.Schedule,
+					suspended,
+					strings.Join(images(cj), ","),
+					repo,
+					source.Path,
+					source.Ref,
+					shortSHA(source.Commit),
+					author,
+				)
+			})
+		},
+	}
+}
+
+// shortSHA the abbreviated form of a commit SHA
+func shortSHA(sha string) string {
+	if len(sha) > 7 {
+		return sha[:7]
+	}
+
+	return sha
+}
+
+func images(cj *batchv1.CronJob) []string {
+	images := []string{}
+	for _, c := range cj.Spec.JobTemplate.Spec.Template.Spec.Containers {
+		images = append(images, c.Image)
+	}
+
+	return images
+}
+
+func newRunningCommand(a *app) *cobra.Command {
+	return &cobra.Command{
+		Use:   "running",
+		Short: "List the cronjobs running in the cluster",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, cancel := a.context(cmd)
+			defer cancel()
+			names, err := a.client.ListRunningJobs(ctx)
+			if err != nil {
+				return err
+			}
+
+			return a.printer.print(
+				types.JobNamesResponse{JobNames: names},
+				func(w io.Writer) { printNames(w, "NAME", names) },
+			)
+		},
+	}
+}
+
+func newStartCommand(a *app) *cobra.Command {
+	return &cobra.Command{
+		Use:               "start JOB",
+		Short:             "Create or resume a cronjob",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: a.completeJobs(availableJobs),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, cancel := a.context(cmd)
+			defer cancel()
+			if err := a.client.StartJob(ctx, args[0]); err != nil {
+				return err
+			}
+
+			return a.printer.print(
+				types.SuccessResponse{Success: true},
+				func(w io.Writer) { fmt.Fprintf(w, "cronjob %s started\n", args[0]) },
+			)
+		},
+	}
+}
+
+func newStopCommand(a *app) *cobra.Command {
+	return &cobra.Command{
+		Use:               "stop JOB",
+		Short:             "Suspend a cronjob",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: a.completeJobs(runningJobs),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, cancel := a.context(cmd)
+			defer cancel()
+			if err := a.client.StopJob(ctx, args[0]); err != nil {
+				return err
+			}
+
+			return a.printer.print(
+				types.SuccessResponse{Success: true},
+				func(w io.Writer) { fmt.Fprintf(w, "cronjob %s stopped\n", args[0]) },
+			)
+		},
+	}
+}
+
+func newRunCommand(a *app) *cobra.Command {
+	return &cobra.Command{
+		Use:               "run JOB",
+		Short:             "Create a job from a cronjob now",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: a.completeJobs(availableJobs),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, cancel := a.context(cmd)
+			defer cancel()
+			name, err := a.client.RunJob(ctx, args[0])
+			if err != nil {
+				return err
+			}
+
+			return a.printer.print(
+				types.RunJobResponse{JobName: name},
+				func(w io.Writer) { fmt.Fprintf(w, "job %s created\n", name) },
+			)
+		},
+	}
+}
+
+func newLogsCommand(a *app) *cobra.Command {
+	opts := client.LogOptions{}
+	cmd := &cobra.Command{
+		Use:               "logs JOB",
+		Short:             "Print the logs of the latest job of a cronjob",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: a.completeJobs(availableJobs),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			if !opts.Follow {
+				var cancel context.CancelFunc
+				ctx, cancel = a.context(cmd)
+				defer cancel()
+			}
+			logs, err := a.client.GetJobLogs(ctx, args[0], opts)
+			if err != nil {
+				return err
+			}
+			defer logs.Close()
+			fmt.Fprintf(cmd.ErrOrStderr(), "job %s, pod %s\n", logs.Job, logs.Pod)
+			_, err = io.Copy(cmd.OutOrStdout(), logs)
+
+			return err
+		},
+	}
+	cmd.Flags().StringVar(&opts.Job, "job", "", "a job spawned by the cronjob instead of the latest one")
+	cmd.Flags().Int64Var(&opts.TailLines, "tail", 0, "only print the last lines")
+	cmd.Flags().BoolVarP(&opts.Follow, "follow", "f", false, "keep printing the logs until the pod terminates")
+
+	return cmd
+}
+
+func newDiffCommand(a *app) *cobra.Command {
+	return &cobra.Command{
+		Use:   "diff JOB",
+		Short: "Compare a cronjob in GitHub with the one in the cluster",
+		Long: "Compare a cronjob in GitHub with the one in the cluster. " +
+			"Exits with 1 if they differ. Fields defaulted by the cluster show up as differences",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: a.completeJobs(availableJobs),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, cancel := a.context(cmd)
+			defer cancel()
+			diff, err := a.client.DiffJob(ctx, args[0])
+			if err != nil {
+				return err
+			}
+			text, err := unifiedDiff(diff)
+			if err != nil {
+				return err
+			}
+
+			err = a.printer.print(diff, func(w io.Writer) {
+				io.WriteString(w, text)
+			})
+			if err != nil {
+				return err
+			}
+			if text != "" {
+				return errDifferent
+			}
+
+			return nil
+		},
+	}
+}
+
+func newRevisionsCommand(a *app) *cobra.Command {
+	return &cobra.Command{
+		Use:               "revisions JOB",
+		Short:             "List the specs a cronjob was applied with",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: a.completeJobs(runningJobs),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, cancel := a.context(cmd)
+			defer cancel()
+			revisions, err := a.client.ListRevisions(ctx, args[0])
+			if err != nil {
+				return err
+			}
+
+			return a.printer.print(
+				types.RevisionsResponse{Revisions: revisions},
+				func(w io.Writer) {
+					fmt.Fprintln(w, "REVISION\tAPPLIED\tACTOR\tCOMMIT\tHASH\tROLLBACK OF")
+					for _, r := range revisions {
+						rollbackOf := ""
+						if r.RollbackOf != 0 {
+							rollbackOf = fmt.Sprint(r.RollbackOf)
+						}
+						fmt.Fprintf(
+							w,
+							"%d\t%s\t%s\t%s\t%s\t%s\n",
+							r.Number,
+							r.AppliedAt.Format(time.RFC3339),
+							r.Actor,
+							shortSHA(r.Commit),
+							shortSHA(r.SpecHash),
+							rollbackOf,
+						)
+					}
+				},
+			)
+		},
+	}
+}
+
+func newRollbackCommand(a *app) *cobra.Command {
+	var revision uint64
+	cmd := &cobra.Command{
+		Use:               "rollback JOB --revision N",
+		Short:             "Apply an earlier spec of a cronjob",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: a.completeJobs(runningJobs),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, cancel := a.context(cmd)
+			defer cancel()
+			res, err := a.client.Rollback(ctx, args[0], revision)
+			if err != nil {
+				return err
+			}
+
+			return a.printer.print(res, func(w io.Writer) {
+				fmt.Fprintf(
+					w,
+					"cronjob %s rolled back to revision %d as revision %d\n",
+					args[0],
+					res.RollbackOf,
+					res.Number,
+				)
+			})
+		},
+	}
+	cmd.Flags().Uint64Var(&revision, "revision", 0, "the revision to apply, see revisions")
+	_ = cmd.MarkFlagRequired("revision")
+
+	return cmd
+}
+
+// unifiedDiff the changes starting the job would make to the cluster
+// as a diff of the YAML manifests
+func unifiedDiff(diff *types.JobDiffResponse) (string, error) {
+	desired, err := yaml.Marshal(diff.Desired)
+	if err != nil {
+		return "", err
+	}
+	live := []byte{}
+	if diff.Live != nil {
+		if live, err = yaml.Marshal(diff.Live); err != nil {
+			return "", err
+		}
+	}
+
+	return difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(string(live)),
+		B:        difflib.SplitLines(string(desired)),
+		FromFile: "cluster/" + diff.JobName,
+		ToFile:   "github/" + diff.JobName,
+		Context:  3,
+	})
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/panagiotisptr/job-scheduler/client"
+	"github.com/spf13/viper"
+)
+
+// config the settings of jobctl. They are read from the flags, the
+// JOBCTL_* environment variables and the config file, in that order
+type config struct {
+	Server  string        `mapstructure:"server"`
+	Token   string        `mapstructure:"token"`
+	Output  string        `mapstructure:"output"`
+	Timeout time.Duration `mapstructure:"timeout"`
+}
+
+// defaultConfigFile e.g. ~/.config/jobctl/config.yaml
+func defaultConfigFile() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+
+	return filepath.Join(dir, "jobctl", "config.yaml")
+}
+
+func loadConfig(v *viper.Viper, file string) (*config, error) {
+	v.SetEnvPrefix("jobctl")
+	v.AutomaticEnv()
+
+	if file != "" {
+		v.SetConfigFile(file)
+		err := v.ReadInConfig()
+		// a missing default config file is fine, everything can be
+		// set with flags or environment variables
+		if err != nil && !(os.IsNotExist(err) && file == defaultConfigFile()) {
+			return nil, fmt.Errorf("failed to read config file: %w", err)
+		}
+	}
+
+	cfg := &config{}
+	if err := v.Unmarshal(cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse config: %w", err)
+	}
+	if cfg.Server == "" {
+		return nil, fmt.Errorf("no server configured. Set it with --server, JOBCTL_SERVER or in %s", file)
+	}
+
+	return cfg, nil
+}
+
+func (cfg *config) newClient() (*client.Client, error) {
+	return client.New(
+		cfg.Server,
+		client.WithToken(cfg.Token),
+	)
+}
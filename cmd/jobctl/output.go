@@ -0,0 +1,67 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"sigs.k8s.io/yaml"
+)
+
+var outputFormats = []string{"table", "json", "yaml"}
+
+// printer writes objects as a table, JSON or YAML
+type printer struct {
+	format string
+	out    io.Writer
+}
+
+func newPrinter(format string, out io.Writer) (*printer, error) {
+	for _, f := range outputFormats {
+		if f == format {
+			return &printer{format: format, out: out}, nil
+		}
+	}
+
+	return nil, fmt.Errorf("unknown output format %q, use one of %v", format, outputFormats)
+}
+
+// print writes obj as JSON or YAML, or calls table to write the
+// rows of the table
+func (p *printer) print(
+	obj interface{},
+	table func(w io.Writer),
+) error {
+	switch p.format {
+	case "json":
+		b, err := json.MarshalIndent(obj, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(p.out, string(b))
+
+		return err
+	case "yaml":
+		b, err := yaml.Marshal(obj)
+		if err != nil {
+			return err
+		}
+		_, err = p.out.Write(b)
+
+		return err
+	}
+
+	w := tabwriter.NewWriter(p.out, 0, 4, 3, ' ', 0)
+	table(w)
+
+	return w.Flush()
+}
+
+// printNames a table with a single column
+func printNames(w io.Writer, header string, names []string) {
+	io.WriteString(w, header+"\n")
+	for _, n := range names {
+		io.WriteString(w, n+"\n")
+	}
+}
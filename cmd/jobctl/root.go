@@ -0,0 +1,134 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/panagiotisptr/job-scheduler/client"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// app the state shared by the subcommands, set up before any of
+// them runs
+type app struct {
+	v       *viper.Viper
+	cfg     *config
+	client  *client.Client
+	printer *printer
+}
+
+func newRootCommand() *cobra.Command {
+	a := &app{v: viper.New()}
+	var configFile string
+
+	cmd := &cobra.Command{
+		Use:           "jobctl",
+		Short:         "Manage the cronjobs of a job scheduler",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			if skipSetup(cmd) {
+				return nil
+			}
+
+			return a.setup(cmd, configFile)
+		},
+	}
+
+	flags := cmd.PersistentFlags()
+	flags.StringVar(&configFile, "config", defaultConfigFile(), "config file")
+	flags.String("server", "", "URL of the job scheduler, e.g. https://job-scheduler.example.com")
+	flags.String("token", "", "bearer token to authenticate with")
+	flags.StringP("output", "o", "table", "output format: table, json or yaml")
+	flags.Duration("timeout", 10*time.Second, "timeout of requests, logs --follow is not subject to it")
+	for _, name := range []string{"server", "token", "output", "timeout"} {
+		_ = a.v.BindPFlag(name, flags.Lookup(name))
+	}
+	_ = cmd.RegisterFlagCompletionFunc(
+		"output",
+		cobra.FixedCompletions(outputFormats, cobra.ShellCompDirectiveNoFileComp),
+	)
+
+	cmd.AddCommand(
+		newListCommand(a),
+		newGetCommand(a),
+		newRunningCommand(a),
+		newStartCommand(a),
+		newStopCommand(a),
+		newRunCommand(a),
+		newLogsCommand(a),
+		newDiffCommand(a),
+		newRevisionsCommand(a),
+		newRollbackCommand(a),
+	)
+
+	return cmd
+}
+
+// skipSetup whether the command works without a server, i.e. the
+// completion scripts and completion requests. The latter set up the
+// client themselves once the flags are parsed
+func skipSetup(cmd *cobra.Command) bool {
+	for c := cmd; c != nil; c = c.Parent() {
+		switch c.Name() {
+		case "completion", cobra.ShellCompRequestCmd, cobra.ShellCompNoDescRequestCmd:
+			return true
+		}
+	}
+
+	return false
+}
+
+func (a *app) setup(cmd *cobra.Command, configFile string) error {
+	cfg, err := loadConfig(a.v, configFile)
+	if err != nil {
+		return err
+	}
+	a.cfg = cfg
+	if a.client, err = cfg.newClient(); err != nil {
+		return err
+	}
+	a.printer, err = newPrinter(cfg.Output, cmd.OutOrStdout())
+
+	return err
+}
+
+// context a context with the request timeout
+func (a *app) context(cmd *cobra.Command) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(cmd.Context(), a.cfg.Timeout)
+}
+
+// completeJobs completes the first argument with the names of the
+// jobs returned by list
+func (a *app) completeJobs(
+	list func(c *client.Client, ctx context.Context) ([]string, error),
+) func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+	return func(
+		cmd *cobra.Command,
+		args []string,
+		toComplete string,
+	) ([]string, cobra.ShellCompDirective) {
+		if len(args) > 0 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		// flags are parsed but PersistentPreRunE does not run when
+		// completing
+		if a.setup(cmd, cmd.Flag("config").Value.String()) != nil {
+			return nil, cobra.ShellCompDirectiveError
+		}
+		ctx, cancel := a.context(cmd)
+		defer cancel()
+		names, err := list(a.client, ctx)
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveError
+		}
+
+		return names, cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+var (
+	availableJobs = (*client.Client).ListJobs
+	runningJobs   = (*client.Client).ListRunningJobs
+)
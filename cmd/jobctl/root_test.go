@@ -0,0 +1,192 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/panagiotisptr/job-scheduler/client"
+	"github.com/panagiotisptr/job-scheduler/types"
+	batchv1 "k8s.io/api/batch/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// testServer a job scheduler serving the jobs backup and report, the
+// manifest of backup annotated with its source. It records the
+// Authorization header of the last request
+type testServer struct {
+	*httptest.Server
+	authorization string
+}
+
+func newTestServer(t *testing.T) *testServer {
+	s := &testServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.authorization = r.Header.Get("Authorization")
+		switch r.URL.Path {
+		case "/api/v1/static/jobs":
+			_ = json.NewEncoder(w).Encode(types.JobNamesResponse{JobNames: []string{"backup", "report"}})
+		case "/api/v1/static/jobs/backup":
+			cj := &batchv1.CronJob{ObjectMeta: metav1.ObjectMeta{Name: "backup"}}
+			cj.Spec.Schedule = "0 3 * * *"
+			_ = json.NewEncoder(w).Encode(types.WithSource(cj, &types.Source{
+				Owner:      "acme",
+				Name:       "jobs",
+				Path:       "cronjobs/backup.yaml",
+				Ref:        "main",
+				Commit:     "0123456789abcdef0123456789abcdef01234567",
+				LastCommit: &types.Commit{SHA: "0123456789abcdef0123456789abcdef01234567", Author: "alice"},
+			}))
+		case "/api/v1/cluster/jobs/backup/logs":
+			w.Header().Set("X-Job-Name", "backup-28000000")
+			w.Header().Set("X-Pod-Name", "backup-28000000-x7k2p")
+			_, _ = io.WriteString(w, "line 1\nline 2\n")
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_ = json.NewEncoder(w).Encode(types.ErrorResponse{
+				Code:    "not_found",
+				Message: "could not find " + r.URL.Path,
+			})
+		}
+	}))
+	t.Cleanup(s.Close)
+
+	return s
+}
+
+// runJobctl runs jobctl without the default config file and returns
+// what it printed to stdout and stderr
+func runJobctl(t *testing.T, args ...string) (string, string, error) {
+	t.Helper()
+	var stdout, stderr bytes.Buffer
+	cmd := newRootCommand()
+	cmd.SetArgs(append([]string{"--config", ""}, args...))
+	cmd.SetOut(&stdout)
+	cmd.SetErr(&stderr)
+	err := cmd.Execute()
+
+	return stdout.String(), stderr.String(), err
+}
+
+func TestLogs(t *testing.T) {
+	srv := newTestServer(t)
+	stdout, stderr, err := runJobctl(t, "--server", srv.URL, "--token", "secret", "logs", "backup")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stdout != "line 1\nline 2\n" {
+		t.Errorf("stdout = %q, want the logs", stdout)
+	}
+	if stderr != "job backup-28000000, pod backup-28000000-x7k2p\n" {
+		t.Errorf("stderr = %q, want the job and pod of the headers", stderr)
+	}
+	if srv.authorization != "Bearer secret" {
+		t.Errorf("Authorization = %q, want Bearer secret", srv.authorization)
+	}
+}
+
+func TestList(t *testing.T) {
+	srv := newTestServer(t)
+	tests := []struct {
+		output string
+		want   string
+	}{
+		{output: "table", want: "NAME\nbackup\nreport\n"},
+		{output: "json", want: "{\n  \"jobNames\": [\n    \"backup\",\n    \"report\"\n  ]\n}\n"},
+		{output: "yaml", want: "jobNames:\n- backup\n- report\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.output, func(t *testing.T) {
+			stdout, _, err := runJobctl(t, "--server", srv.URL, "-o", tt.output, "list")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if stdout != tt.want {
+				t.Errorf("stdout = %q, want %q", stdout, tt.want)
+			}
+		})
+	}
+}
+
+func TestGet(t *testing.T) {
+	srv := newTestServer(t)
+	stdout, _, err := runJobctl(t, "--server", srv.URL, "get", "backup")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "NAME     SCHEDULE    SUSPEND   IMAGES   REPOSITORY   PATH                   REF    COMMIT    AUTHOR\n" +
+		"backup   0 3 * * *   false              acme/jobs    cronjobs/backup.yaml   main   0123456   alice\n"
+	if stdout != want {
+		t.Errorf("stdout =\n%s\nwant\n%s", stdout, want)
+	}
+}
+
+func TestServerErrors(t *testing.T) {
+	srv := newTestServer(t)
+	_, _, err := runJobctl(t, "--server", srv.URL, "start", "missing")
+	var apiErr *client.Error
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound {
+		t.Fatalf("error = %v, want a 404 of the server", err)
+	}
+	if !strings.Contains(err.Error(), "could not find /api/v1/cluster/jobs/missing/start") {
+		t.Errorf("error = %q, want the message of the server", err)
+	}
+}
+
+func TestConfig(t *testing.T) {
+	srv := newTestServer(t)
+
+	t.Run("environment", func(t *testing.T) {
+		t.Setenv("JOBCTL_SERVER", srv.URL)
+		t.Setenv("JOBCTL_TOKEN", "from-env")
+		if _, _, err := runJobctl(t, "list"); err != nil {
+			t.Fatal(err)
+		}
+		if srv.authorization != "Bearer from-env" {
+			t.Errorf("Authorization = %q, want the token of JOBCTL_TOKEN", srv.authorization)
+		}
+	})
+
+	t.Run("file", func(t *testing.T) {
+		file := filepath.Join(t.TempDir(), "config.yaml")
+		content := "server: " + srv.URL + "\ntoken: from-file\n"
+		if err := os.WriteFile(file, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if _, _, err := runJobctl(t, "--config", file, "list"); err != nil {
+			t.Fatal(err)
+		}
+		if srv.authorization != "Bearer from-file" {
+			t.Errorf("Authorization = %q, want the token of the file", srv.authorization)
+		}
+
+		// flags take precedence
+		if _, _, err := runJobctl(t, "--config", file, "--token", "from-flag", "list"); err != nil {
+			t.Fatal(err)
+		}
+		if srv.authorization != "Bearer from-flag" {
+			t.Errorf("Authorization = %q, want the token of the flag", srv.authorization)
+		}
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		file := filepath.Join(t.TempDir(), "config.yaml")
+		if _, _, err := runJobctl(t, "--config", file, "--server", srv.URL, "list"); err == nil {
+			t.Error("expected an error for a config file that doesn't exist")
+		}
+	})
+
+	t.Run("no server", func(t *testing.T) {
+		_, _, err := runJobctl(t, "list")
+		if err == nil || !strings.Contains(err.Error(), "no server configured") {
+			t.Errorf("error = %v, want one about the server", err)
+		}
+	})
+}
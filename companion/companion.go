@@ -0,0 +1,29 @@
+// Package companion handles the resources declared in the same file
+// as a cronjob, e.g. the ConfigMap or ServiceAccount it uses
+package companion
+
+import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// Allowed whether the kind is in the list of allowed kinds, given
+// as Kind for the core group or Kind.group
+func Allowed(allowedKinds []string, gvk schema.GroupVersionKind) bool {
+	name := gvk.Kind
+	if gvk.Group != "" {
+		name += "." + gvk.Group
+	}
+	for _, k := range allowedKinds {
+		if k == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Ref identifies a companion in messages, e.g. ConfigMap/settings
+func Ref(obj *unstructured.Unstructured) string {
+	return obj.GetKind() + "/" + obj.GetName()
+}
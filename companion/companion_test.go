@@ -0,0 +1,46 @@
+package companion
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestAllowed(t *testing.T) {
+	allowed := []string{"ConfigMap", "ExternalSecret.external-secrets.io"}
+	tests := []struct {
+		gvk  schema.GroupVersionKind
+		want bool
+	}{
+		{gvk: schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}, want: true},
+		{gvk: schema.GroupVersionKind{Group: "external-secrets.io", Version: "v1beta1", Kind: "ExternalSecret"}, want: true},
+		// the version doesn't matter
+		{gvk: schema.GroupVersionKind{Group: "external-secrets.io", Version: "v1", Kind: "ExternalSecret"}, want: true},
+		{gvk: schema.GroupVersionKind{Version: "v1", Kind: "Secret"}},
+		// same kind in another group
+		{gvk: schema.GroupVersionKind{Group: "example.com", Version: "v1", Kind: "ConfigMap"}},
+		{gvk: schema.GroupVersionKind{Version: "v1", Kind: "ExternalSecret"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.gvk.String(), func(t *testing.T) {
+			if got := Allowed(allowed, tt.gvk); got != tt.want {
+				t.Errorf("Allowed(%s) = %t, want %t", tt.gvk, got, tt.want)
+			}
+		})
+	}
+
+	if Allowed(nil, schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}) {
+		t.Error("a kind is allowed by an empty list")
+	}
+}
+
+func TestRef(t *testing.T) {
+	obj := &unstructured.Unstructured{}
+	obj.SetAPIVersion("v1")
+	obj.SetKind("ConfigMap")
+	obj.SetName("settings")
+	if got := Ref(obj); got != "ConfigMap/settings" {
+		t.Errorf("Ref = %s, want ConfigMap/settings", got)
+	}
+}
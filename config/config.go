@@ -5,14 +5,34 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/spf13/viper"
 	"go.uber.org/zap"
 )
 
+// TLSConfig serves HTTPS when CertFile and KeyFile are set.
+// Setting ClientCAFile enables mutual TLS, ClientAuth is one of
+// require (default) or optional
+type TLSConfig struct {
+	CertFile     string `mapstructure:"certFile"`
+	KeyFile      string `mapstructure:"keyFile"`
+	ClientCAFile string `mapstructure:"clientCAFile"`
+	ClientAuth   string `mapstructure:"clientAuth"`
+}
+
 type ServiceConfig struct {
-	Port int `mapstructure:"port"`
+	Port              int           `mapstructure:"port"`
+	GRPCPort          int           `mapstructure:"grpcPort"`
+	ReadTimeout       time.Duration `mapstructure:"readTimeout"`
+	ReadHeaderTimeout time.Duration `mapstructure:"readHeaderTimeout"`
+	WriteTimeout      time.Duration `mapstructure:"writeTimeout"`
+	IdleTimeout       time.Duration `mapstructure:"idleTimeout"`
+	ShutdownTimeout   time.Duration `mapstructure:"shutdownTimeout"`
+	TLS               TLSConfig     `mapstructure:"tls"`
 }
 
 type GitHubRepositoryArgs struct {
@@ -20,19 +40,260 @@ type GitHubRepositoryArgs struct {
 	Name   string `mapstructure:"name"`
 	Path   string `mapstructure:"path"`
 	Branch string `mapstructure:"branch"`
+	// Ref the branch, tag or commit SHA the manifests are read at,
+	// it takes precedence over Branch
+	Ref string `mapstructure:"ref"`
+	// Kustomize treats Path as a kustomization root, the manifests
+	// are the output of kustomize build
+	Kustomize bool `mapstructure:"kustomize"`
+	// Helm treats Path as a chart directory, the manifests are the
+	// chart rendered with ValuesFile, a path from the root of the
+	// repository. The values of the chart are used if it is empty
+	Helm       bool   `mapstructure:"helm"`
+	ValuesFile string `mapstructure:"valuesFile"`
+}
+
+// GetRef the ref the location is read at, Ref or else Branch
+func (a GitHubRepositoryArgs) GetRef() string {
+	if a.Ref != "" {
+		return a.Ref
+	}
+
+	return a.Branch
+}
+
+// GitHubAppConfig authenticates as the GitHub App with the ID, using
+// the private key generated for it
+type GitHubAppConfig struct {
+	ID             int64  `mapstructure:"id"`
+	PrivateKeyFile string `mapstructure:"privateKeyFile"`
 }
 
+// GitHubConfig BaseURL the API of a GitHub Enterprise Server, e.g.
+// https://github.example.com/api/v3/, github.com if empty. UploadURL
+// its upload API, derived from BaseURL if empty. App is used instead
+// of AccessToken when its ID is set
 type GitHubConfig struct {
 	AccessToken string                 `mapstructure:"accessToken"`
+	BaseURL     string                 `mapstructure:"baseURL"`
+	UploadURL   string                 `mapstructure:"uploadURL"`
+	App         GitHubAppConfig        `mapstructure:"app"`
 	Locations   []GitHubRepositoryArgs `mapstructure:"locations"`
 }
 
+// GetUploadURL UploadURL, or else the upload API of the server of
+// BaseURL, e.g. https://github.example.com/api/uploads/
+func (c GitHubConfig) GetUploadURL() string {
+	if c.UploadURL != "" || c.BaseURL == "" {
+		return c.UploadURL
+	}
+	u, err := url.Parse(c.BaseURL)
+	if err != nil {
+		// reported by the client when it parses BaseURL
+		return c.BaseURL
+	}
+	u.Path = strings.TrimSuffix(strings.TrimSuffix(u.Path, "/"), "/api/v3") + "/api/uploads/"
+
+	return u.String()
+}
+
+// WebhookTarget an HTTP endpoint notified about job events.
+// Format is one of json (default), slack or teams. Template is an
+// optional text/template rendered with the event for the message text
+type WebhookTarget struct {
+	Name     string            `mapstructure:"name"`
+	URL      string            `mapstructure:"url"`
+	Format   string            `mapstructure:"format"`
+	Template string            `mapstructure:"template"`
+	Headers  map[string]string `mapstructure:"headers"`
+	// Events the event types sent to the target.
+	// Defaults to childjob.failed
+	Events []string `mapstructure:"events"`
+	// AllJobs notify the target for every job. Otherwise only jobs
+	// subscribing through the job-scheduler/notify annotation are sent
+	AllJobs bool `mapstructure:"allJobs"`
+}
+
+type NotifierConfig struct {
+	Targets        []WebhookTarget `mapstructure:"targets"`
+	MaxAttempts    int             `mapstructure:"maxAttempts"`
+	InitialBackoff time.Duration   `mapstructure:"initialBackoff"`
+	MaxBackoff     time.Duration   `mapstructure:"maxBackoff"`
+	Timeout        time.Duration   `mapstructure:"timeout"`
+}
+
+// TracingConfig Exporter is one of otlp, stdout or memory.
+// Tracing is disabled when no exporter is set
+type TracingConfig struct {
+	Exporter    string            `mapstructure:"exporter"`
+	Endpoint    string            `mapstructure:"endpoint"`
+	Insecure    bool              `mapstructure:"insecure"`
+	Headers     map[string]string `mapstructure:"headers"`
+	ServiceName string            `mapstructure:"serviceName"`
+	SampleRatio float64           `mapstructure:"sampleRatio"`
+}
+
+// StaticTokenConfig a bearer token mapped to a fixed identity.
+// The token is read from TokenFile (e.g. a mounted secret) if set
+type StaticTokenConfig struct {
+	Token     string   `mapstructure:"token"`
+	TokenFile string   `mapstructure:"tokenFile"`
+	Subject   string   `mapstructure:"subject"`
+	Groups    []string `mapstructure:"groups"`
+}
+
+// HMACAuthConfig accepts JWTs signed with a shared secret (HS256/384/512)
+type HMACAuthConfig struct {
+	Secret     string `mapstructure:"secret"`
+	SecretFile string `mapstructure:"secretFile"`
+	Issuer     string `mapstructure:"issuer"`
+	Audience   string `mapstructure:"audience"`
+}
+
+// OIDCAuthConfig accepts JWTs issued by an OIDC provider. The signing
+// keys are fetched from JWKSURL or loaded from JWKSFile
+type OIDCAuthConfig struct {
+	Issuer          string        `mapstructure:"issuer"`
+	Audience        string        `mapstructure:"audience"`
+	JWKSURL         string        `mapstructure:"jwksURL"`
+	JWKSFile        string        `mapstructure:"jwksFile"`
+	RefreshInterval time.Duration `mapstructure:"refreshInterval"`
+}
+
+type AuthConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// PublicPaths paths served without authentication.
+	// Defaults to /healthz, /readyz, /metrics and /api/v1/openapi.json
+	PublicPaths []string `mapstructure:"publicPaths"`
+	// SubjectClaim and GroupsClaim the JWT claims holding the caller
+	// identity. Default to sub and groups
+	SubjectClaim string              `mapstructure:"subjectClaim"`
+	GroupsClaim  string              `mapstructure:"groupsClaim"`
+	StaticTokens []StaticTokenConfig `mapstructure:"staticTokens"`
+	HMAC         HMACAuthConfig      `mapstructure:"hmac"`
+	OIDC         OIDCAuthConfig      `mapstructure:"oidc"`
+}
+
+// AuthzRuleConfig the operations allowed on the jobs matching every
+// non empty list. Jobs, Namespaces and Locations are glob patterns,
+// locations are matched against owner/name/path of the manifest
+type AuthzRuleConfig struct {
+	Operations []string `mapstructure:"operations"`
+	Jobs       []string `mapstructure:"jobs"`
+	Namespaces []string `mapstructure:"namespaces"`
+	Locations  []string `mapstructure:"locations"`
+}
+
+type AuthzRoleConfig struct {
+	Name  string            `mapstructure:"name"`
+	Rules []AuthzRuleConfig `mapstructure:"rules"`
+}
+
+// AuthzBindingConfig grants a role to subjects and groups.
+// A subject of * matches every caller
+type AuthzBindingConfig struct {
+	Role     string   `mapstructure:"role"`
+	Subjects []string `mapstructure:"subjects"`
+	Groups   []string `mapstructure:"groups"`
+}
+
+type AuthzConfig struct {
+	Enabled  bool                 `mapstructure:"enabled"`
+	Roles    []AuthzRoleConfig    `mapstructure:"roles"`
+	Bindings []AuthzBindingConfig `mapstructure:"bindings"`
+}
+
+// AuditSinkConfig Type is one of file, store, stdout or memory. Path is the
+// JSON lines file of file sinks, Limit the records kept by memory sinks
+type AuditSinkConfig struct {
+	Type  string `mapstructure:"type"`
+	Path  string `mapstructure:"path"`
+	Limit int    `mapstructure:"limit"`
+}
+
+// AuditConfig defaults to a memory sink
+type AuditConfig struct {
+	Sinks []AuditSinkConfig `mapstructure:"sinks"`
+}
+
+// StoreConfig Type is one of memory (default) or bolt. Path is the
+// database file of the bolt store
+type StoreConfig struct {
+	Type string `mapstructure:"type"`
+	Path string `mapstructure:"path"`
+}
+
+// PolicyConfig rules manifests have to follow on top of the ones of
+// Kubernetes, checked by the validate and plan subcommands
+type PolicyConfig struct {
+	// AllowedRegistries image prefixes, e.g. ghcr.io/my-org/. Any
+	// image is allowed if empty
+	AllowedRegistries     []string `mapstructure:"allowedRegistries"`
+	ForbidLatestTag       bool     `mapstructure:"forbidLatestTag"`
+	RequireResourceLimits bool     `mapstructure:"requireResourceLimits"`
+	RequiredLabels        []string `mapstructure:"requiredLabels"`
+}
+
+// CompanionsConfig the resources declared next to a cronjob in its
+// file that are applied when it is started
+type CompanionsConfig struct {
+	// AllowedKinds the kinds that can be applied, as Kind for the
+	// core group or Kind.group e.g. ServiceAccount or
+	// ExternalSecret.external-secrets.io. No companions are applied
+	// if empty
+	AllowedKinds []string `mapstructure:"allowedKinds"`
+	// DeleteWithCronJob makes the cronjob an owner of its companions
+	// so that they are deleted with it
+	DeleteWithCronJob bool `mapstructure:"deleteWithCronJob"`
+}
+
+// TemplateValue overrides a value of the values files of templated
+// manifests. Key is a dot separated path e.g. image.tag
+type TemplateValue struct {
+	Key   string      `mapstructure:"key"`
+	Value interface{} `mapstructure:"value"`
+}
+
+// TemplatesConfig the values of this environment for templated
+// manifests, they take precedence over the values files. They are a
+// list since the keys of maps are lowercased when loading the config
+type TemplatesConfig struct {
+	Values []TemplateValue `mapstructure:"values"`
+}
+
+// PromotionSource an environment cronjobs are promoted from. URL and
+// Token reach its scheduler to look up the commit a cronjob runs at
+// when a promotion doesn't name one
+type PromotionSource struct {
+	Name  string `mapstructure:"name"`
+	URL   string `mapstructure:"url"`
+	Token string `mapstructure:"token"`
+}
+
+// PromotionsConfig Environment the name of the environment of this
+// scheduler, From the environments it accepts promotions from
+type PromotionsConfig struct {
+	Environment string            `mapstructure:"environment"`
+	From        []PromotionSource `mapstructure:"from"`
+}
+
 type Config struct {
-	Service      ServiceConfig `mapstructure:"service"`
-	GitHubConfig GitHubConfig  `mapstructure:"githubConfig"`
+	Service      ServiceConfig    `mapstructure:"service"`
+	GitHubConfig GitHubConfig     `mapstructure:"githubConfig"`
+	Notifier     NotifierConfig   `mapstructure:"notifier"`
+	Tracing      TracingConfig    `mapstructure:"tracing"`
+	Auth         AuthConfig       `mapstructure:"auth"`
+	Authz        AuthzConfig      `mapstructure:"authz"`
+	Audit        AuditConfig      `mapstructure:"audit"`
+	Store        StoreConfig      `mapstructure:"store"`
+	Policy       PolicyConfig     `mapstructure:"policy"`
+	Companions   CompanionsConfig `mapstructure:"companions"`
+	Templates    TemplatesConfig  `mapstructure:"templates"`
+	Promotions   PromotionsConfig `mapstructure:"promotions"`
 }
 
-func loadConfig(filename string) (*Config, error) {
+// Load reads the configuration from a file
+func Load(filename string) (*Config, error) {
 	viper.SetConfigFile(filename)
 	viper.AddConfigPath(".")
 
@@ -80,7 +341,7 @@ func ProvideRemoteConfig(
 		return &config, err
 	}
 
-	return loadConfig("config.prod.yml")
+	return Load("config.prod.yml")
 }
 
 func ProvideConfig(
@@ -93,7 +354,7 @@ func ProvideConfig(
 		configFilename = os.Getenv("CONFIG")
 	}
 
-	return loadConfig(configFilename)
+	return Load(configFilename)
 }
 
 func ProvideTestConfig() (*Config, error) {
@@ -104,5 +365,5 @@ func ProvideTestConfig() (*Config, error) {
 		configFilename = os.Getenv("TEST_CONFIG")
 	}
 
-	return loadConfig(configFilename)
+	return Load(configFilename)
 }
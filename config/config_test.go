@@ -0,0 +1,43 @@
+package config
+
+import "testing"
+
+func TestGetUploadURL(t *testing.T) {
+	tests := []struct {
+		name      string
+		baseURL   string
+		uploadURL string
+		want      string
+	}{
+		{name: "github.com"},
+		{
+			name:    "enterprise",
+			baseURL: "https://github.example.com/api/v3/",
+			want:    "https://github.example.com/api/uploads/",
+		},
+		{
+			name:    "enterprise without a trailing slash",
+			baseURL: "https://github.example.com/api/v3",
+			want:    "https://github.example.com/api/uploads/",
+		},
+		{
+			name:    "enterprise without the api prefix",
+			baseURL: "https://github.example.com/",
+			want:    "https://github.example.com/api/uploads/",
+		},
+		{
+			name:      "set",
+			baseURL:   "https://github.example.com/api/v3/",
+			uploadURL: "https://uploads.example.com/api/uploads/",
+			want:      "https://uploads.example.com/api/uploads/",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := GitHubConfig{BaseURL: tt.baseURL, UploadURL: tt.uploadURL}
+			if got := c.GetUploadURL(); got != tt.want {
+				t.Errorf("GetUploadURL() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
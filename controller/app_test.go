@@ -0,0 +1,413 @@
+package controller
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/panagiotisptr/job-scheduler/app"
+	"github.com/panagiotisptr/job-scheduler/apperror"
+	"github.com/panagiotisptr/job-scheduler/audit"
+	"github.com/panagiotisptr/job-scheduler/auth"
+	"github.com/panagiotisptr/job-scheduler/authz"
+	"github.com/panagiotisptr/job-scheduler/config"
+	"github.com/panagiotisptr/job-scheduler/events"
+	"github.com/panagiotisptr/job-scheduler/health"
+	"github.com/panagiotisptr/job-scheduler/notifier"
+	"github.com/panagiotisptr/job-scheduler/repository"
+	"github.com/panagiotisptr/job-scheduler/repository/memory"
+	revisionRepo "github.com/panagiotisptr/job-scheduler/repository/revision"
+	schedulerRepo "github.com/panagiotisptr/job-scheduler/repository/scheduler"
+	"github.com/panagiotisptr/job-scheduler/server"
+	"github.com/panagiotisptr/job-scheduler/service"
+	"github.com/panagiotisptr/job-scheduler/store"
+	memoryStore "github.com/panagiotisptr/job-scheduler/store/memory"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/fx"
+	"go.uber.org/fx/fxtest"
+	"go.uber.org/zap"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// the static tokens of the tests. team-a can do everything on the
+// team-a-* jobs and nothing else
+const (
+	teamAToken = "team-a-token"
+	teamBToken = "team-b-token"
+)
+
+// testConfig enables authentication with static tokens and
+// authorization, team-a and team-b are bound to their own jobs
+func testConfig() *config.Config {
+	role := func(team string) config.AuthzRoleConfig {
+		return config.AuthzRoleConfig{
+			Name: team,
+			Rules: []config.AuthzRuleConfig{
+				{
+					Operations: []string{"*"},
+					Jobs:       []string{team + "-*"},
+				},
+			},
+		}
+	}
+
+	return &config.Config{
+		Auth: config.AuthConfig{
+			Enabled: true,
+			StaticTokens: []config.StaticTokenConfig{
+				{Token: teamAToken, Subject: "alice"},
+				{Token: teamBToken, Subject: "bob"},
+			},
+		},
+		Authz: config.AuthzConfig{
+			Enabled: true,
+			Roles:   []config.AuthzRoleConfig{role("team-a"), role("team-b")},
+			Bindings: []config.AuthzBindingConfig{
+				{Role: "team-a", Subjects: []string{"alice"}},
+				{Role: "team-b", Subjects: []string{"bob"}},
+			},
+		},
+		Promotions: config.PromotionsConfig{
+			Environment: "production",
+			From:        []config.PromotionSource{{Name: "staging"}},
+		},
+	}
+}
+
+// testCronJob a valid cronjob manifest
+func testCronJob(name string) *batchv1.CronJob {
+	return &batchv1.CronJob{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "batch/v1",
+			Kind:       "CronJob",
+		},
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec: batchv1.CronJobSpec{
+			Schedule: "0 * * * *",
+			JobTemplate: batchv1.JobTemplateSpec{
+				Spec: batchv1.JobSpec{
+					Template: corev1.PodTemplateSpec{
+						Spec: corev1.PodSpec{
+							RestartPolicy: corev1.RestartPolicyOnFailure,
+							Containers: []corev1.Container{
+								{Name: "main", Image: "busybox:1.36"},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// testTask a valid task manifest
+func testTask(name string) *batchv1.Job {
+	cj := testCronJob(name)
+
+	return &batchv1.Job{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "batch/v1",
+			Kind:       "Job",
+		},
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec:       cj.Spec.JobTemplate.Spec,
+	}
+}
+
+// fakeCronJobRepository serves manifests from memory instead of
+// GitHub
+type fakeCronJobRepository struct {
+	mu         sync.Mutex
+	cronJobs   map[string]*batchv1.CronJob
+	tasks      map[string]*batchv1.Job
+	promotions []repository.Promotion
+	pins       map[string]string
+	// brokenCommits the commits the manifests can't be read at
+	brokenCommits map[string]struct{}
+}
+
+func newFakeCronJobRepository(cronJobs ...*batchv1.CronJob) *fakeCronJobRepository {
+	r := &fakeCronJobRepository{
+		cronJobs:      make(map[string]*batchv1.CronJob),
+		tasks:         make(map[string]*batchv1.Job),
+		pins:          make(map[string]string),
+		brokenCommits: make(map[string]struct{}),
+	}
+	for _, cj := range cronJobs {
+		r.cronJobs[cj.Name] = cj
+	}
+
+	return r
+}
+
+func (r *fakeCronJobRepository) GetCronJobNames(ctx context.Context) ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	names := []string{}
+	for name := range r.cronJobs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names, nil
+}
+
+func (r *fakeCronJobRepository) GetCronJob(ctx context.Context, name string) (*batchv1.CronJob, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	cj, ok := r.cronJobs[name]
+	if !ok {
+		return nil, apperror.NotFound("could not find cronjob with name: %s", name)
+	}
+	if _, ok := r.brokenCommits[r.pins[name]]; ok {
+		return nil, apperror.Unavailable("failed to read cronjob %s at %s", name, r.pins[name])
+	}
+
+	return cj.DeepCopy(), nil
+}
+
+func (r *fakeCronJobRepository) GetCronJobAnnotations(ctx context.Context, name string) (map[string]string, error) {
+	cj, err := r.GetCronJob(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	return cj.Annotations, nil
+}
+
+func (r *fakeCronJobRepository) source(name string) *repository.Source {
+	source := &repository.Source{
+		Owner:  "org",
+		Name:   "jobs",
+		Path:   "cronjobs/" + name + ".yml",
+		Branch: "main",
+		Ref:    "main",
+		Commit: "0123456789abcdef0123456789abcdef01234567",
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if pin, ok := r.pins[name]; ok {
+		source.Ref, source.Commit, source.Pinned = pin, pin, true
+	}
+
+	return source
+}
+
+func (r *fakeCronJobRepository) GetCronJobSource(ctx context.Context, name string) (*repository.Source, error) {
+	if _, err := r.GetCronJob(ctx, name); err != nil {
+		return nil, err
+	}
+
+	return r.source(name), nil
+}
+
+func (r *fakeCronJobRepository) GetLastCommit(ctx context.Context, source *repository.Source) (*repository.Commit, error) {
+	return &repository.Commit{SHA: source.Commit, Author: "alice", Message: "add " + source.Path}, nil
+}
+
+func (r *fakeCronJobRepository) GetCompanions(ctx context.Context, name string) ([]unstructured.Unstructured, error) {
+	if _, err := r.GetCronJob(ctx, name); err != nil {
+		return nil, err
+	}
+
+	return nil, nil
+}
+
+func (r *fakeCronJobRepository) GetTaskNames(ctx context.Context) ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	names := []string{}
+	for name := range r.tasks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names, nil
+}
+
+func (r *fakeCronJobRepository) GetTask(ctx context.Context, name string) (*batchv1.Job, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	task, ok := r.tasks[name]
+	if !ok {
+		return nil, apperror.NotFound("could not find task with name: %s", name)
+	}
+
+	return task.DeepCopy(), nil
+}
+
+func (r *fakeCronJobRepository) GetTaskSource(ctx context.Context, name string) (*repository.Source, error) {
+	if _, err := r.GetTask(ctx, name); err != nil {
+		return nil, err
+	}
+
+	return r.source(name), nil
+}
+
+func (r *fakeCronJobRepository) GetSyncHistory(ctx context.Context) ([]repository.SyncRecord, error) {
+	return []repository.SyncRecord{}, nil
+}
+
+func (r *fakeCronJobRepository) PinCronJob(ctx context.Context, name string, commit string) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.cronJobs[name]; !ok {
+		return "", apperror.NotFound("could not find cronjob with name: %s", name)
+	}
+	r.pins[name] = commit
+
+	return commit, nil
+}
+
+func (r *fakeCronJobRepository) UnpinCronJob(ctx context.Context, name string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.pins[name]; !ok {
+		return apperror.NotFound("the cronjob %s is not pinned", name)
+	}
+	delete(r.pins, name)
+
+	return nil
+}
+
+func (r *fakeCronJobRepository) RecordPromotion(ctx context.Context, promotion repository.Promotion) (repository.Promotion, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	promotion.ID = uint64(len(r.promotions) + 1)
+	r.promotions = append(r.promotions, promotion)
+
+	return promotion, nil
+}
+
+func (r *fakeCronJobRepository) DeletePromotion(ctx context.Context, id uint64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, p := range r.promotions {
+		if p.ID == id {
+			r.promotions = append(r.promotions[:i], r.promotions[i+1:]...)
+			break
+		}
+	}
+
+	return nil
+}
+
+func (r *fakeCronJobRepository) GetPromotions(ctx context.Context) ([]repository.Promotion, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	res := []repository.Promotion{}
+	for i := len(r.promotions) - 1; i >= 0; i-- {
+		res = append(res, r.promotions[i])
+	}
+
+	return res, nil
+}
+
+// testEnv the app wired as in main, with the manifests in memory and
+// the in-memory cluster of the dev mode
+type testEnv struct {
+	repo       *fakeCronJobRepository
+	router     *mux.Router
+	app        *app.App
+	auth       *auth.Auth
+	bus        *events.Bus
+	events     *EventsController
+	grpcServer *server.GRPCServer
+	grpc       *GRPCService
+}
+
+// newTestEnv starts the app for the test and stops it when the test
+// ends. The repository starts with the cronjobs team-a-backup and
+// team-b-report and the task team-a-migrate
+func newTestEnv(t *testing.T, cfg *config.Config) *testEnv {
+	t.Helper()
+	env := &testEnv{
+		repo: newFakeCronJobRepository(
+			testCronJob("team-a-backup"),
+			testCronJob("team-b-report"),
+		),
+	}
+	env.repo.tasks["team-a-migrate"] = testTask("team-a-migrate")
+
+	fxApp := fxtest.New(
+		t,
+		fx.NopLogger,
+		fx.Supply(cfg, zap.NewNop()),
+		fx.Provide(
+			func() trace.TracerProvider { return trace.NewNoopTracerProvider() },
+			func() store.Store { return memoryStore.NewStore() },
+			func() repository.CronJobRepository { return env.repo },
+			events.ProvideBus,
+			auth.ProvideAuth,
+			authz.ProvideAuthorizer,
+			audit.ProvideAuditor,
+			memory.ProvideKubernetesMemoryRepository,
+			schedulerRepo.ProvideEnvironmentRepository,
+			revisionRepo.ProvideRevisionRepository,
+			service.ProvideCronJobService,
+			service.ProvideKubernetesService,
+			service.ProvidePromotionService,
+			notifier.ProvideNotifier,
+			app.ProvideApp,
+			server.ProvideGRPCServer,
+			health.ProvideChecker,
+			func(a *auth.Auth) *mux.Router {
+				r := mux.NewRouter()
+				r.Use(a.Middleware)
+
+				return r
+			},
+			ProvideCronJobController,
+			ProvideKubernetesController,
+			ProvideTaskController,
+			ProvidePromotionController,
+			ProvideEventsController,
+			ProvideNotificationController,
+			ProvideHealthController,
+			ProvideAuthzController,
+			ProvideAuditController,
+			ProvideOpenAPIController,
+			ProvideGRPCService,
+		),
+		// registers the routes of every controller
+		fx.Invoke(func(
+			*CronJobController,
+			*KubernetesController,
+			*TaskController,
+			*PromotionController,
+			*EventsController,
+			*NotificationController,
+			*HealthController,
+			*AuthzController,
+			*AuditController,
+			*OpenAPIController,
+		) {
+		}),
+		fx.Populate(
+			&env.router,
+			&env.app,
+			&env.auth,
+			&env.bus,
+			&env.events,
+			&env.grpcServer,
+			&env.grpc,
+		),
+	)
+	fxApp.RequireStart()
+	t.Cleanup(fxApp.RequireStop)
+
+	return env
+}
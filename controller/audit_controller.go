@@ -0,0 +1,121 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/panagiotisptr/job-scheduler/app"
+	"github.com/panagiotisptr/job-scheduler/apperror"
+	"github.com/panagiotisptr/job-scheduler/audit"
+	"github.com/panagiotisptr/job-scheduler/types"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+const defaultAuditLimit = 100
+
+type AuditController struct {
+	logger *zap.Logger
+	app    *app.App
+	tracer trace.Tracer
+}
+
+func ProvideAuditController(
+	logger *zap.Logger,
+	r *mux.Router,
+	app *app.App,
+	tp trace.TracerProvider,
+) (*AuditController, error) {
+	c := &AuditController{
+		logger: logger,
+		app:    app,
+		tracer: tp.Tracer("github.com/panagiotisptr/job-scheduler/controller"),
+	}
+
+	handle(r, "/audit", c.listRecords, http.MethodGet)
+
+	return c, nil
+}
+
+// parseSince accepts a RFC3339 timestamp or a duration before now
+func parseSince(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("since has to be a RFC3339 timestamp or a duration: %s", s)
+	}
+
+	return time.Now().Add(-d), nil
+}
+
+func (c *AuditController) listRecords(
+	w http.ResponseWriter,
+	r *http.Request,
+) {
+	ctx, span := c.tracer.Start(r.Context(), "AuditController.listRecords")
+	defer span.End()
+
+	q := r.URL.Query()
+	since, err := parseSince(q.Get("since"))
+	if err != nil {
+		errorResponse(
+			w,
+			r,
+			apperror.Wrap(apperror.CodeInvalid, err),
+			c.logger,
+		)
+		return
+	}
+	limit := defaultAuditLimit
+	if l := q.Get("limit"); l != "" {
+		limit, err = strconv.Atoi(l)
+		if err != nil || limit <= 0 {
+			errorResponse(
+				w,
+				r,
+				apperror.Invalid("limit has to be a positive number: %s", l),
+				c.logger,
+			)
+			return
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(
+		ctx,
+		time.Second*2,
+	)
+	defer cancel()
+	res, err := c.app.ListAuditRecords(ctx, audit.Filter{
+		Job:   q.Get("job"),
+		Actor: q.Get("actor"),
+		Since: since,
+		Limit: limit,
+	})
+	if err != nil {
+		errorResponse(
+			w,
+			r,
+			err,
+			c.logger,
+		)
+		return
+	}
+
+	writeObject(
+		w,
+		types.AuditRecordsResponse{
+			Records: res,
+		},
+		http.StatusOK,
+		c.logger,
+	)
+}
@@ -0,0 +1,108 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/panagiotisptr/job-scheduler/audit"
+	"github.com/panagiotisptr/job-scheduler/types"
+)
+
+// auditRecords the audit records the caller of the token can view,
+// newest first
+func auditRecords(t *testing.T, env *testEnv, token string) []audit.Record {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, APIPrefix+"/audit", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	rec := httptest.NewRecorder()
+	env.router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /audit: status %d: %s", rec.Code, rec.Body.String())
+	}
+	var res types.AuditRecordsResponse
+	if err := json.NewDecoder(rec.Body).Decode(&res); err != nil {
+		t.Fatal(err)
+	}
+
+	return res.Records
+}
+
+func TestJobOperationsAreAudited(t *testing.T) {
+	env := newTestEnv(t, testConfig())
+	for _, req := range []struct {
+		method string
+		path   string
+		status int
+	}{
+		{http.MethodPatch, "/cluster/jobs/team-a-backup/start", http.StatusOK},
+		// allowed, but there is no manifest
+		{http.MethodPatch, "/cluster/jobs/team-a-missing/start", http.StatusNotFound},
+		{http.MethodPatch, "/cluster/jobs/team-b-report/start", http.StatusForbidden},
+		{http.MethodPatch, "/cluster/jobs/team-a-backup/stop", http.StatusOK},
+	} {
+		r := httptest.NewRequest(req.method, APIPrefix+req.path, nil)
+		r.Header.Set("Authorization", "Bearer "+teamAToken)
+		rec := httptest.NewRecorder()
+		env.router.ServeHTTP(rec, r)
+		if rec.Code != req.status {
+			t.Fatalf("%s %s: status %d, want %d: %s", req.method, req.path, rec.Code, req.status, rec.Body.String())
+		}
+	}
+
+	type summary struct {
+		actor, method, operation, job, commit string
+		outcome                               audit.Outcome
+		failed                                bool
+	}
+	summarize := func(records []audit.Record) []summary {
+		res := []summary{}
+		for _, r := range records {
+			res = append(res, summary{
+				actor:     r.Actor,
+				method:    r.AuthMethod,
+				operation: r.Operation,
+				job:       r.Job,
+				commit:    r.CommitSHA,
+				outcome:   r.Outcome,
+				failed:    r.Error != "",
+			})
+		}
+
+		return res
+	}
+	const commit = "0123456789abcdef0123456789abcdef01234567"
+
+	// the records of team-b jobs are only shown to the ones who can
+	// view them, alice's denied start included
+	for _, tt := range []struct {
+		token string
+		want  []summary
+	}{
+		{
+			token: teamAToken,
+			want: []summary{
+				{"alice", "static", "stop", "team-a-backup", commit, audit.OutcomeSuccess, false},
+				{"alice", "static", "start", "team-a-missing", "", audit.OutcomeFailure, true},
+				{"alice", "static", "start", "team-a-backup", commit, audit.OutcomeSuccess, false},
+			},
+		},
+		{
+			token: teamBToken,
+			want: []summary{
+				{"alice", "static", "start", "team-b-report", commit, audit.OutcomeDenied, true},
+			},
+		},
+	} {
+		got := summarize(auditRecords(t, env, tt.token))
+		if len(got) != len(tt.want) {
+			t.Fatalf("records = %+v, want %+v", got, tt.want)
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("record %d = %+v, want %+v", i, got[i], tt.want[i])
+			}
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/panagiotisptr/job-scheduler/app"
+	"github.com/panagiotisptr/job-scheduler/apperror"
+	"github.com/panagiotisptr/job-scheduler/types"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+type AuthzController struct {
+	logger *zap.Logger
+	app    *app.App
+	tracer trace.Tracer
+}
+
+func ProvideAuthzController(
+	logger *zap.Logger,
+	r *mux.Router,
+	app *app.App,
+	tp trace.TracerProvider,
+) (*AuthzController, error) {
+	c := &AuthzController{
+		logger: logger,
+		app:    app,
+		tracer: tp.Tracer("github.com/panagiotisptr/job-scheduler/controller"),
+	}
+
+	handle(r, "/authz/check", c.check, http.MethodPost)
+
+	return c, nil
+}
+
+func (c *AuthzController) check(
+	w http.ResponseWriter,
+	r *http.Request,
+) {
+	ctx, span := c.tracer.Start(r.Context(), "AuthzController.check")
+	defer span.End()
+
+	var req types.AuthorizationCheckRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		errorResponse(
+			w,
+			r,
+			apperror.Invalid("invalid request body: %s", err),
+			c.logger,
+		)
+		return
+	}
+	res, err := c.app.CheckAuthorization(ctx, app.AuthorizationCheck{
+		Subject:   req.Subject,
+		Groups:    req.Groups,
+		Operation: req.Operation,
+		Job:       req.Job,
+		Namespace: req.Namespace,
+		Location:  req.Location,
+	})
+	if err != nil {
+		errorResponse(
+			w,
+			r,
+			err,
+			c.logger,
+		)
+		return
+	}
+
+	writeObject(
+		w,
+		types.AuthorizationCheckResponse{
+			Subject:  res.Subject,
+			Groups:   res.Groups,
+			Request:  res.Request,
+			Decision: res.Decision,
+		},
+		http.StatusOK,
+		c.logger,
+	)
+}
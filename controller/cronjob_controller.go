@@ -2,32 +2,39 @@ package controller
 
 import (
 	"context"
-	"fmt"
 	"net/http"
 	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/panagiotisptr/job-scheduler/app"
+	"github.com/panagiotisptr/job-scheduler/apperror"
+	"github.com/panagiotisptr/job-scheduler/types"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 )
 
 type CronJobController struct {
 	logger *zap.Logger
 	app    *app.App
+	tracer trace.Tracer
 }
 
 func ProvideCronJobController(
 	logger *zap.Logger,
 	r *mux.Router,
 	app *app.App,
+	tp trace.TracerProvider,
 ) (*CronJobController, error) {
 	c := &CronJobController{
 		logger: logger,
 		app:    app,
+		tracer: tp.Tracer("github.com/panagiotisptr/job-scheduler/controller"),
 	}
 
-	r.HandleFunc("/static/jobs", c.listJobs).Methods(http.MethodGet)
-	r.HandleFunc("/static/jobs/{jobName}", c.getJob).Methods(http.MethodGet)
+	handle(r, "/static/jobs", c.listJobs, http.MethodGet)
+	handle(r, "/static/jobs/{jobName}", c.getJob, http.MethodGet)
+	handle(r, "/static/jobs/{jobName}/source", c.getJobSource, http.MethodGet)
+	handle(r, "/static/syncs", c.listSyncs, http.MethodGet)
 
 	return c, nil
 }
@@ -36,8 +43,10 @@ func (c *CronJobController) listJobs(
 	w http.ResponseWriter,
 	r *http.Request,
 ) {
+	ctx, span := c.tracer.Start(r.Context(), "CronJobController.listJobs")
+	defer span.End()
 	ctx, cancel := context.WithTimeout(
-		context.Background(),
+		ctx,
 		time.Second*2,
 	)
 	defer cancel()
@@ -45,8 +54,8 @@ func (c *CronJobController) listJobs(
 	if err != nil {
 		errorResponse(
 			w,
+			r,
 			err,
-			http.StatusInternalServerError,
 			c.logger,
 		)
 		return
@@ -54,9 +63,7 @@ func (c *CronJobController) listJobs(
 
 	writeObject(
 		w,
-		struct {
-			JobNames []string `json:"jobNames"`
-		}{
+		types.JobNamesResponse{
 			JobNames: res,
 		},
 		http.StatusOK,
@@ -72,22 +79,77 @@ func (c *CronJobController) getJob(
 	if !ok {
 		errorResponse(
 			w,
-			fmt.Errorf("could not find job"),
-			http.StatusNotFound,
+			r,
+			apperror.NotFound("could not find job"),
 			c.logger,
 		)
+		return
 	}
+	ctx, span := c.tracer.Start(r.Context(), "CronJobController.getJob")
+	defer span.End()
+	// looking up the last commit of the source is a call to GitHub
 	ctx, cancel := context.WithTimeout(
-		context.Background(),
-		time.Second*2,
+		ctx,
+		time.Second*10,
 	)
 	defer cancel()
-	res, err := c.app.GetCronJobConfig(ctx, jobName)
+	cj, err := c.app.GetCronJobConfig(ctx, jobName)
 	if err != nil {
 		errorResponse(
 			w,
+			r,
+			err,
+			c.logger,
+		)
+		return
+	}
+	source, err := c.app.GetCronJobSource(ctx, jobName)
+	if err != nil {
+		c.logger.Sugar().Warnw(
+			"failed to get the source of the job",
+			"job", jobName,
+			"error", err,
+		)
+	} else {
+		cj = types.WithSource(cj, source)
+	}
+
+	writeObject(
+		w,
+		cj,
+		http.StatusOK,
+		c.logger,
+	)
+}
+
+func (c *CronJobController) getJobSource(
+	w http.ResponseWriter,
+	r *http.Request,
+) {
+	jobName, ok := mux.Vars(r)["jobName"]
+	if !ok {
+		errorResponse(
+			w,
+			r,
+			apperror.NotFound("could not find job"),
+			c.logger,
+		)
+		return
+	}
+	ctx, span := c.tracer.Start(r.Context(), "CronJobController.getJobSource")
+	defer span.End()
+	// looking up the last commit is a call to GitHub
+	ctx, cancel := context.WithTimeout(
+		ctx,
+		time.Second*10,
+	)
+	defer cancel()
+	res, err := c.app.GetCronJobSource(ctx, jobName)
+	if err != nil {
+		errorResponse(
+			w,
+			r,
 			err,
-			http.StatusInternalServerError,
 			c.logger,
 		)
 		return
@@ -100,3 +162,35 @@ func (c *CronJobController) getJob(
 		c.logger,
 	)
 }
+
+func (c *CronJobController) listSyncs(
+	w http.ResponseWriter,
+	r *http.Request,
+) {
+	ctx, span := c.tracer.Start(r.Context(), "CronJobController.listSyncs")
+	defer span.End()
+	ctx, cancel := context.WithTimeout(
+		ctx,
+		time.Second*2,
+	)
+	defer cancel()
+	res, err := c.app.ListSyncHistory(ctx)
+	if err != nil {
+		errorResponse(
+			w,
+			r,
+			err,
+			c.logger,
+		)
+		return
+	}
+
+	writeObject(
+		w,
+		types.SyncsResponse{
+			Syncs: res,
+		},
+		http.StatusOK,
+		c.logger,
+	)
+}
@@ -0,0 +1,57 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/panagiotisptr/job-scheduler/types"
+	batchv1 "k8s.io/api/batch/v1"
+)
+
+func TestGetJobSource(t *testing.T) {
+	env := newTestEnv(t, testConfig())
+	get := func(path string, v interface{}) {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodGet, APIPrefix+path, nil)
+		req.Header.Set("Authorization", "Bearer "+teamAToken)
+		rec := httptest.NewRecorder()
+		env.router.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("GET %s: status %d: %s", path, rec.Code, rec.Body.String())
+		}
+		if err := json.NewDecoder(rec.Body).Decode(v); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var cj batchv1.CronJob
+	get("/static/jobs/team-a-backup", &cj)
+	want := map[string]string{
+		types.SourceRepositoryAnnotation:  "org/jobs",
+		types.SourcePathAnnotation:        "cronjobs/team-a-backup.yml",
+		types.SourceBranchAnnotation:      "main",
+		types.SourceRefAnnotation:         "main",
+		types.SourceCommitAnnotation:      "0123456789abcdef0123456789abcdef01234567",
+		types.LastCommitSHAAnnotation:     "0123456789abcdef0123456789abcdef01234567",
+		types.LastCommitAuthorAnnotation:  "alice",
+		types.LastCommitMessageAnnotation: "add cronjobs/team-a-backup.yml",
+	}
+	for key, value := range want {
+		if got := cj.Annotations[key]; got != value {
+			t.Errorf("annotation %s = %q, want %q", key, got, value)
+		}
+	}
+	if _, ok := cj.Annotations[types.SourcePinnedAnnotation]; ok {
+		t.Errorf("annotated as pinned, the job follows its branch")
+	}
+
+	// the source of the annotations, and the one of the alias
+	var source types.Source
+	get("/static/jobs/team-a-backup/source", &source)
+	if got := types.SourceOf(&cj); !reflect.DeepEqual(got, &source) {
+		t.Errorf("source of the annotations = %+v, want %+v", got, source)
+	}
+}
@@ -0,0 +1,164 @@
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/panagiotisptr/job-scheduler/app"
+	"github.com/panagiotisptr/job-scheduler/events"
+	"go.uber.org/zap"
+)
+
+const keepAliveInterval = time.Second * 15
+
+type EventsController struct {
+	logger   *zap.Logger
+	app      *app.App
+	shutdown chan struct{}
+	once     sync.Once
+}
+
+func ProvideEventsController(
+	logger *zap.Logger,
+	r *mux.Router,
+	app *app.App,
+) (*EventsController, error) {
+	c := &EventsController{
+		logger:   logger,
+		app:      app,
+		shutdown: make(chan struct{}),
+	}
+
+	handle(r, "/events", c.streamEvents, http.MethodGet)
+
+	return c, nil
+}
+
+// Close ends every open event stream
+func (c *EventsController) Close() {
+	c.once.Do(func() {
+		close(c.shutdown)
+	})
+}
+
+// eventFilter optional filters passed as query parameters
+// e.g. /events?type=childjob.failed,childjob.succeeded&job=backup
+type eventFilter struct {
+	types map[events.Type]struct{}
+	job   string
+}
+
+func parseEventFilter(r *http.Request) eventFilter {
+	f := eventFilter{
+		types: make(map[events.Type]struct{}),
+		job:   r.URL.Query().Get("job"),
+	}
+	for _, t := range strings.Split(r.URL.Query().Get("type"), ",") {
+		if t != "" {
+			f.types[events.Type(t)] = struct{}{}
+		}
+	}
+
+	return f
+}
+
+func (f eventFilter) matches(e events.Event) bool {
+	if f.job != "" && f.job != e.JobName {
+		return false
+	}
+	if len(f.types) == 0 {
+		return true
+	}
+	_, ok := f.types[e.Type]
+
+	return ok
+}
+
+// streamEvents streams job events to the client as Server-Sent Events
+func (c *EventsController) streamEvents(
+	w http.ResponseWriter,
+	r *http.Request,
+) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		errorResponse(
+			w,
+			r,
+			fmt.Errorf("streaming is not supported"),
+			c.logger,
+		)
+		return
+	}
+	filter := parseEventFilter(r)
+
+	// the stream is long lived so it can't be subject to the
+	// write timeout of the server
+	err := http.NewResponseController(w).SetWriteDeadline(time.Time{})
+	if err != nil {
+		c.logger.Sugar().Warn(
+			"failed to clear write deadline for event stream: ",
+			err,
+		)
+	}
+
+	ch, unsubscribe := c.app.SubscribeEvents()
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(keepAliveInterval)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-c.shutdown:
+			return
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		case e, ok := <-ch:
+			if !ok {
+				return
+			}
+			if !filter.matches(e) || !c.app.CanViewEvent(r.Context(), e) {
+				continue
+			}
+			b, err := json.Marshal(e)
+			if err != nil {
+				c.logger.Sugar().Error(
+					"failed to marshal event: ",
+					err,
+				)
+				continue
+			}
+			_, err = fmt.Fprintf(
+				w,
+				"id: %d\nevent: %s\ndata: %s\n\n",
+				e.ID,
+				e.Type,
+				b,
+			)
+			if err != nil {
+				c.logger.Sugar().Error(
+					"failed to write event to response: ",
+					err,
+				)
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
@@ -0,0 +1,217 @@
+package controller
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/panagiotisptr/job-scheduler/events"
+)
+
+// eventStream an open GET /events response
+type eventStream struct {
+	res     *http.Response
+	scanner *bufio.Scanner
+	cancel  context.CancelFunc
+}
+
+// openEventStream opens the event stream of the server as the caller
+// of the token
+func openEventStream(t *testing.T, srv *httptest.Server, token string, query string) *eventStream {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+APIPrefix+"/events"+query, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	res, err := srv.Client().Do(req)
+	if err != nil {
+		t.Fatalf("GET /events: %s", err)
+	}
+	t.Cleanup(func() {
+		cancel()
+		res.Body.Close()
+	})
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want %d", res.StatusCode, http.StatusOK)
+	}
+	for header, want := range map[string]string{
+		"Content-Type":  "text/event-stream",
+		"Cache-Control": "no-cache",
+	} {
+		if got := res.Header.Get(header); got != want {
+			t.Errorf("%s = %q, want %q", header, got, want)
+		}
+	}
+
+	return &eventStream{res: res, scanner: bufio.NewScanner(res.Body), cancel: cancel}
+}
+
+// next reads the next event of the stream and checks that its id and
+// event fields match the data
+func (s *eventStream) next(t *testing.T) events.Event {
+	t.Helper()
+	fields := map[string]string{}
+	for s.scanner.Scan() {
+		line := s.scanner.Text()
+		if line == "" && len(fields) > 0 {
+			break
+		}
+		if key, value, ok := strings.Cut(line, ": "); ok {
+			fields[key] = value
+		}
+	}
+	if err := s.scanner.Err(); err != nil {
+		t.Fatalf("failed to read the stream: %s", err)
+	}
+	var e events.Event
+	if err := json.Unmarshal([]byte(fields["data"]), &e); err != nil {
+		t.Fatalf("invalid event %q: %s", fields["data"], err)
+	}
+	if fields["id"] != strconv.FormatUint(e.ID, 10) || fields["event"] != string(e.Type) {
+		t.Errorf("id %q and event %q don't match the data %+v", fields["id"], fields["event"], e)
+	}
+
+	return e
+}
+
+// publishUntilDone publishes the events every 10ms until the test
+// ends, the stream may subscribe after the first ones
+func publishUntilDone(t *testing.T, bus *events.Bus, es ...events.Event) {
+	done := make(chan struct{})
+	t.Cleanup(func() { close(done) })
+	go func() {
+		ticker := time.NewTicker(10 * time.Millisecond)
+		defer ticker.Stop()
+		for {
+			for _, e := range es {
+				bus.Publish(e)
+			}
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+func TestEventStreamRequiresAuthentication(t *testing.T) {
+	env := newTestEnv(t, testConfig())
+	rec := httptest.NewRecorder()
+	env.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, APIPrefix+"/events", nil))
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+}
+
+func TestEventStreamFiltersByView(t *testing.T) {
+	env := newTestEnv(t, testConfig())
+	srv := httptest.NewServer(env.router)
+	t.Cleanup(srv.Close)
+
+	alice := openEventStream(t, srv, teamAToken, "")
+	bob := openEventStream(t, srv, teamBToken, "")
+	publishUntilDone(
+		t,
+		env.bus,
+		events.Event{Type: events.JobStarted, JobName: "team-b-report"},
+		events.Event{Type: events.JobStarted, JobName: "team-a-backup"},
+		events.Event{Type: events.TaskRun, JobName: "team-a-migrate"},
+	)
+
+	seen := map[string]bool{}
+	for i := 0; i < 6; i++ {
+		e := alice.next(t)
+		if !strings.HasPrefix(e.JobName, "team-a-") {
+			t.Fatalf("alice received an event of %s", e.JobName)
+		}
+		seen[e.JobName] = true
+		if e := bob.next(t); e.JobName != "team-b-report" {
+			t.Fatalf("bob received an event of %s", e.JobName)
+		}
+	}
+	if !seen["team-a-backup"] || !seen["team-a-migrate"] {
+		t.Errorf("alice received the events of %v, want team-a-backup and team-a-migrate", seen)
+	}
+}
+
+func TestEventStreamFiltersByRequest(t *testing.T) {
+	env := newTestEnv(t, testConfig())
+	env.repo.cronJobs["team-a-cleanup"] = testCronJob("team-a-cleanup")
+	srv := httptest.NewServer(env.router)
+	t.Cleanup(srv.Close)
+
+	stream := openEventStream(t, srv, teamAToken, "?type=job.stopped,job.deleted&job=team-a-backup")
+	publishUntilDone(
+		t,
+		env.bus,
+		events.Event{Type: events.JobStarted, JobName: "team-a-backup"},
+		events.Event{Type: events.JobStopped, JobName: "team-a-cleanup"},
+		events.Event{Type: events.JobStopped, JobName: "team-a-backup"},
+		events.Event{Type: events.JobDeleted, JobName: "team-a-backup"},
+	)
+
+	types := map[events.Type]bool{}
+	for i := 0; i < 4; i++ {
+		e := stream.next(t)
+		if e.JobName != "team-a-backup" {
+			t.Fatalf("received an event of %s", e.JobName)
+		}
+		types[e.Type] = true
+	}
+	if len(types) != 2 || !types[events.JobStopped] || !types[events.JobDeleted] {
+		t.Errorf("received the types %v, want job.stopped and job.deleted", types)
+	}
+}
+
+func TestEventStreamEndsWhenTheClientDisconnects(t *testing.T) {
+	env := newTestEnv(t, testConfig())
+	returned := make(chan struct{}, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		env.router.ServeHTTP(w, r)
+		returned <- struct{}{}
+	}))
+	t.Cleanup(srv.Close)
+
+	stream := openEventStream(t, srv, teamAToken, "")
+	publishUntilDone(t, env.bus, events.Event{Type: events.JobStarted, JobName: "team-a-backup"})
+	stream.next(t)
+
+	stream.cancel()
+	select {
+	case <-returned:
+	case <-time.After(2 * time.Second):
+		t.Fatal("the handler kept streaming after the client disconnected")
+	}
+}
+
+func TestEventStreamEndsOnClose(t *testing.T) {
+	env := newTestEnv(t, testConfig())
+	srv := httptest.NewServer(env.router)
+	t.Cleanup(srv.Close)
+
+	stream := openEventStream(t, srv, teamAToken, "")
+	publishUntilDone(t, env.bus, events.Event{Type: events.JobStarted, JobName: "team-a-backup"})
+	stream.next(t)
+
+	env.events.Close()
+	ended := make(chan struct{})
+	go func() {
+		for stream.scanner.Scan() {
+		}
+		close(ended)
+	}()
+	select {
+	case <-ended:
+	case <-time.After(2 * time.Second):
+		t.Fatal("the stream is still open after closing the controller")
+	}
+}
@@ -0,0 +1,197 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+
+	"github.com/panagiotisptr/job-scheduler/app"
+	"github.com/panagiotisptr/job-scheduler/apperror"
+	"github.com/panagiotisptr/job-scheduler/events"
+	jobschedulerv1 "github.com/panagiotisptr/job-scheduler/gen/jobscheduler/v1"
+	"github.com/panagiotisptr/job-scheduler/server"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// GRPCService serves the operations of the App over gRPC
+type GRPCService struct {
+	jobschedulerv1.UnimplementedJobSchedulerServiceServer
+
+	logger   *zap.Logger
+	app      *app.App
+	shutdown chan struct{}
+	once     sync.Once
+}
+
+func ProvideGRPCService(
+	logger *zap.Logger,
+	srv *server.GRPCServer,
+	app *app.App,
+) (*GRPCService, error) {
+	s := &GRPCService{
+		logger:   logger,
+		app:      app,
+		shutdown: make(chan struct{}),
+	}
+
+	srv.RegisterService(&jobschedulerv1.JobSchedulerService_ServiceDesc, s)
+
+	return s, nil
+}
+
+// Close ends every open event stream
+func (s *GRPCService) Close() {
+	s.once.Do(func() {
+		close(s.shutdown)
+	})
+}
+
+func (s *GRPCService) ListAvailableJobs(
+	ctx context.Context,
+	req *jobschedulerv1.ListAvailableJobsRequest,
+) (*jobschedulerv1.ListAvailableJobsResponse, error) {
+	names, err := s.app.ListAvailableCronJobNames(ctx)
+	if err != nil {
+		return nil, apperror.GRPCStatus(err)
+	}
+
+	return &jobschedulerv1.ListAvailableJobsResponse{JobNames: names}, nil
+}
+
+func (s *GRPCService) GetJobConfig(
+	ctx context.Context,
+	req *jobschedulerv1.GetJobConfigRequest,
+) (*jobschedulerv1.GetJobConfigResponse, error) {
+	cj, err := s.app.GetCronJobConfig(ctx, req.GetJobName())
+	if err != nil {
+		return nil, apperror.GRPCStatus(err)
+	}
+
+	// the manifest goes through JSON so that it keeps the field names
+	// of the Kubernetes API
+	b, err := json.Marshal(cj)
+	if err != nil {
+		return nil, apperror.GRPCStatus(err)
+	}
+	m := map[string]interface{}{}
+	if err := json.Unmarshal(b, &m); err != nil {
+		return nil, apperror.GRPCStatus(err)
+	}
+	cronJob, err := structpb.NewStruct(m)
+	if err != nil {
+		return nil, apperror.GRPCStatus(err)
+	}
+
+	return &jobschedulerv1.GetJobConfigResponse{CronJob: cronJob}, nil
+}
+
+func (s *GRPCService) ListRunningJobs(
+	ctx context.Context,
+	req *jobschedulerv1.ListRunningJobsRequest,
+) (*jobschedulerv1.ListRunningJobsResponse, error) {
+	names, err := s.app.ListRunningJobs(ctx)
+	if err != nil {
+		return nil, apperror.GRPCStatus(err)
+	}
+
+	return &jobschedulerv1.ListRunningJobsResponse{JobNames: names}, nil
+}
+
+func (s *GRPCService) StartJob(
+	ctx context.Context,
+	req *jobschedulerv1.StartJobRequest,
+) (*jobschedulerv1.StartJobResponse, error) {
+	if err := s.app.StartJob(ctx, req.GetJobName()); err != nil {
+		return nil, apperror.GRPCStatus(err)
+	}
+
+	return &jobschedulerv1.StartJobResponse{}, nil
+}
+
+func (s *GRPCService) StopJob(
+	ctx context.Context,
+	req *jobschedulerv1.StopJobRequest,
+) (*jobschedulerv1.StopJobResponse, error) {
+	if err := s.app.StopJob(ctx, req.GetJobName()); err != nil {
+		return nil, apperror.GRPCStatus(err)
+	}
+
+	return &jobschedulerv1.StopJobResponse{}, nil
+}
+
+func (s *GRPCService) DeleteJob(
+	ctx context.Context,
+	req *jobschedulerv1.DeleteJobRequest,
+) (*jobschedulerv1.DeleteJobResponse, error) {
+	if err := s.app.DeleteJob(ctx, req.GetJobName()); err != nil {
+		return nil, apperror.GRPCStatus(err)
+	}
+
+	return &jobschedulerv1.DeleteJobResponse{}, nil
+}
+
+// StreamEvents streams the job events matching the request that the
+// caller can view until the client goes away or the server shuts down
+func (s *GRPCService) StreamEvents(
+	req *jobschedulerv1.StreamEventsRequest,
+	stream jobschedulerv1.JobSchedulerService_StreamEventsServer,
+) error {
+	filter := eventFilter{
+		types: make(map[events.Type]struct{}),
+		job:   req.GetJobName(),
+	}
+	for _, t := range req.GetTypes() {
+		if t != "" {
+			filter.types[events.Type(t)] = struct{}{}
+		}
+	}
+
+	ch, unsubscribe := s.app.SubscribeEvents()
+	defer unsubscribe()
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case <-s.shutdown:
+			return nil
+		case e, ok := <-ch:
+			if !ok {
+				return nil
+			}
+			if !filter.matches(e) || !s.app.CanViewEvent(stream.Context(), e) {
+				continue
+			}
+			if err := stream.Send(eventToProto(e)); err != nil {
+				s.logger.Sugar().Error(
+					"failed to send event to stream: ",
+					err,
+				)
+				return err
+			}
+		}
+	}
+}
+
+func eventToProto(e events.Event) *jobschedulerv1.Event {
+	pe := &jobschedulerv1.Event{
+		Id:           e.ID,
+		Type:         string(e.Type),
+		JobName:      e.JobName,
+		Namespace:    e.Namespace,
+		Timestamp:    timestamppb.New(e.Timestamp),
+		ChildJobName: e.ChildJobName,
+		Actor:        e.Actor,
+		Message:      e.Message,
+	}
+	if e.StartTime != nil {
+		pe.StartTime = timestamppb.New(*e.StartTime)
+	}
+	if e.CompletionTime != nil {
+		pe.CompletionTime = timestamppb.New(*e.CompletionTime)
+	}
+
+	return pe
+}
@@ -0,0 +1,366 @@
+package controller
+
+import (
+	"context"
+	"io"
+	"net"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/panagiotisptr/job-scheduler/events"
+	jobschedulerv1 "github.com/panagiotisptr/job-scheduler/gen/jobscheduler/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	reflectionpb "google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// dialGRPC serves the gRPC server of the environment on an in-memory
+// listener and connects to it
+func dialGRPC(t *testing.T, env *testEnv) *grpc.ClientConn {
+	t.Helper()
+	ln := bufconn.Listen(1 << 20)
+	go func() {
+		_ = env.grpcServer.Serve(ln)
+	}()
+	t.Cleanup(func() { ln.Close() })
+
+	conn, err := grpc.Dial(
+		"bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return ln.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("failed to dial: %s", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return conn
+}
+
+// withToken authenticates the calls made with the context
+func withToken(ctx context.Context, token string) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+token)
+}
+
+func testContext(t *testing.T) context.Context {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	t.Cleanup(cancel)
+
+	return ctx
+}
+
+func requireCode(t *testing.T, err error, want codes.Code) {
+	t.Helper()
+	if got := status.Code(err); got != want {
+		t.Fatalf("code = %s, want %s (err: %v)", got, want, err)
+	}
+}
+
+func TestGRPCAuthentication(t *testing.T) {
+	env := newTestEnv(t, testConfig())
+	client := jobschedulerv1.NewJobSchedulerServiceClient(dialGRPC(t, env))
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		want codes.Code
+	}{
+		{
+			name: "missing token",
+			ctx:  testContext(t),
+			want: codes.Unauthenticated,
+		},
+		{
+			name: "unknown token",
+			ctx:  withToken(testContext(t), "unknown"),
+			want: codes.Unauthenticated,
+		},
+		{
+			name: "static token",
+			ctx:  withToken(testContext(t), teamAToken),
+			want: codes.OK,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := client.ListAvailableJobs(tt.ctx, &jobschedulerv1.ListAvailableJobsRequest{})
+			requireCode(t, err, tt.want)
+
+			stream, err := client.StreamEvents(tt.ctx, &jobschedulerv1.StreamEventsRequest{})
+			if err != nil {
+				t.Fatalf("StreamEvents: %s", err)
+			}
+			if tt.want != codes.OK {
+				_, err = stream.Recv()
+				requireCode(t, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestGRPCReflectionIsPublic(t *testing.T) {
+	env := newTestEnv(t, testConfig())
+	client := reflectionpb.NewServerReflectionClient(dialGRPC(t, env))
+
+	stream, err := client.ServerReflectionInfo(testContext(t))
+	if err != nil {
+		t.Fatalf("ServerReflectionInfo: %s", err)
+	}
+	err = stream.Send(&reflectionpb.ServerReflectionRequest{
+		MessageRequest: &reflectionpb.ServerReflectionRequest_ListServices{},
+	})
+	if err != nil {
+		t.Fatalf("Send: %s", err)
+	}
+	res, err := stream.Recv()
+	if err != nil {
+		t.Fatalf("Recv: %s", err)
+	}
+
+	services := []string{}
+	for _, s := range res.GetListServicesResponse().GetService() {
+		services = append(services, s.GetName())
+	}
+	found := false
+	for _, s := range services {
+		found = found || s == jobschedulerv1.JobSchedulerService_ServiceDesc.ServiceName
+	}
+	if !found {
+		t.Errorf("services %v, want %s", services, jobschedulerv1.JobSchedulerService_ServiceDesc.ServiceName)
+	}
+}
+
+func TestGRPCListAvailableJobs(t *testing.T) {
+	env := newTestEnv(t, testConfig())
+	client := jobschedulerv1.NewJobSchedulerServiceClient(dialGRPC(t, env))
+
+	res, err := client.ListAvailableJobs(
+		withToken(testContext(t), teamAToken),
+		&jobschedulerv1.ListAvailableJobsRequest{},
+	)
+	if err != nil {
+		t.Fatalf("ListAvailableJobs: %s", err)
+	}
+	if got := res.GetJobNames(); len(got) != 1 || got[0] != "team-a-backup" {
+		t.Errorf("job names = %v, want only the ones of team-a", got)
+	}
+}
+
+func TestGRPCGetJobConfig(t *testing.T) {
+	env := newTestEnv(t, testConfig())
+	client := jobschedulerv1.NewJobSchedulerServiceClient(dialGRPC(t, env))
+	ctx := withToken(testContext(t), teamAToken)
+
+	res, err := client.GetJobConfig(ctx, &jobschedulerv1.GetJobConfigRequest{JobName: "team-a-backup"})
+	if err != nil {
+		t.Fatalf("GetJobConfig: %s", err)
+	}
+	cronJob := res.GetCronJob().AsMap()
+	metadata, _ := cronJob["metadata"].(map[string]interface{})
+	if metadata["name"] != "team-a-backup" {
+		t.Errorf("metadata = %v, want the name of the cronjob", metadata)
+	}
+	spec, _ := cronJob["spec"].(map[string]interface{})
+	if spec["schedule"] != "0 * * * *" {
+		t.Errorf("spec = %v, want the fields of the Kubernetes API", spec)
+	}
+
+	_, err = client.GetJobConfig(ctx, &jobschedulerv1.GetJobConfigRequest{JobName: "team-b-report"})
+	requireCode(t, err, codes.PermissionDenied)
+}
+
+func TestGRPCJobLifecycle(t *testing.T) {
+	env := newTestEnv(t, testConfig())
+	client := jobschedulerv1.NewJobSchedulerServiceClient(dialGRPC(t, env))
+	ctx := withToken(testContext(t), teamAToken)
+
+	running := func() []string {
+		t.Helper()
+		res, err := client.ListRunningJobs(ctx, &jobschedulerv1.ListRunningJobsRequest{})
+		if err != nil {
+			t.Fatalf("ListRunningJobs: %s", err)
+		}
+		names := res.GetJobNames()
+		sort.Strings(names)
+
+		return names
+	}
+
+	if _, err := client.StartJob(ctx, &jobschedulerv1.StartJobRequest{JobName: "team-a-backup"}); err != nil {
+		t.Fatalf("StartJob: %s", err)
+	}
+	if got := running(); len(got) != 1 || got[0] != "team-a-backup" {
+		t.Fatalf("running jobs = %v, want team-a-backup", got)
+	}
+	if _, err := client.StopJob(ctx, &jobschedulerv1.StopJobRequest{JobName: "team-a-backup"}); err != nil {
+		t.Fatalf("StopJob: %s", err)
+	}
+	if _, err := client.DeleteJob(ctx, &jobschedulerv1.DeleteJobRequest{JobName: "team-a-backup"}); err != nil {
+		t.Fatalf("DeleteJob: %s", err)
+	}
+	if got := running(); len(got) != 0 {
+		t.Errorf("running jobs = %v, want none", got)
+	}
+}
+
+func TestGRPCErrorCodes(t *testing.T) {
+	env := newTestEnv(t, testConfig())
+	client := jobschedulerv1.NewJobSchedulerServiceClient(dialGRPC(t, env))
+	ctx := withToken(testContext(t), teamAToken)
+
+	tests := []struct {
+		name string
+		call func() error
+		want codes.Code
+	}{
+		{
+			name: "start a job of another team",
+			call: func() error {
+				_, err := client.StartJob(ctx, &jobschedulerv1.StartJobRequest{JobName: "team-b-report"})
+				return err
+			},
+			want: codes.PermissionDenied,
+		},
+		{
+			name: "stop a job of another team",
+			call: func() error {
+				_, err := client.StopJob(ctx, &jobschedulerv1.StopJobRequest{JobName: "team-b-report"})
+				return err
+			},
+			want: codes.PermissionDenied,
+		},
+		{
+			name: "delete a job of another team",
+			call: func() error {
+				_, err := client.DeleteJob(ctx, &jobschedulerv1.DeleteJobRequest{JobName: "team-b-report"})
+				return err
+			},
+			want: codes.PermissionDenied,
+		},
+		{
+			name: "start a job without a manifest",
+			call: func() error {
+				_, err := client.StartJob(ctx, &jobschedulerv1.StartJobRequest{JobName: "team-a-missing"})
+				return err
+			},
+			want: codes.NotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requireCode(t, tt.call(), tt.want)
+		})
+	}
+}
+
+// recvEvent receives the next event of the stream, publishing the
+// events with publish until the stream has subscribed
+func recvEvent(
+	t *testing.T,
+	stream jobschedulerv1.JobSchedulerService_StreamEventsClient,
+	publish func(),
+) *jobschedulerv1.Event {
+	t.Helper()
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		ticker := time.NewTicker(10 * time.Millisecond)
+		defer ticker.Stop()
+		for {
+			publish()
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	e, err := stream.Recv()
+	if err != nil {
+		t.Fatalf("Recv: %s", err)
+	}
+
+	return e
+}
+
+func TestGRPCStreamEventsFiltersByView(t *testing.T) {
+	env := newTestEnv(t, testConfig())
+	client := jobschedulerv1.NewJobSchedulerServiceClient(dialGRPC(t, env))
+
+	stream, err := client.StreamEvents(
+		withToken(testContext(t), teamAToken),
+		&jobschedulerv1.StreamEventsRequest{},
+	)
+	if err != nil {
+		t.Fatalf("StreamEvents: %s", err)
+	}
+	for i := 0; i < 3; i++ {
+		e := recvEvent(t, stream, func() {
+			env.bus.Publish(events.Event{Type: events.JobStarted, JobName: "team-b-report"})
+			env.bus.Publish(events.Event{Type: events.JobStarted, JobName: "team-a-backup"})
+		})
+		if e.GetJobName() != "team-a-backup" {
+			t.Fatalf("received an event of %s, which the caller can't view", e.GetJobName())
+		}
+	}
+}
+
+func TestGRPCStreamEventsFiltersByRequest(t *testing.T) {
+	env := newTestEnv(t, testConfig())
+	client := jobschedulerv1.NewJobSchedulerServiceClient(dialGRPC(t, env))
+
+	stream, err := client.StreamEvents(
+		withToken(testContext(t), teamAToken),
+		&jobschedulerv1.StreamEventsRequest{
+			Types:   []string{string(events.JobStopped)},
+			JobName: "team-a-backup",
+		},
+	)
+	if err != nil {
+		t.Fatalf("StreamEvents: %s", err)
+	}
+	e := recvEvent(t, stream, func() {
+		env.bus.Publish(events.Event{Type: events.JobStarted, JobName: "team-a-backup"})
+		env.bus.Publish(events.Event{Type: events.JobStopped, JobName: "team-a-other"})
+		env.bus.Publish(events.Event{Type: events.JobStopped, JobName: "team-a-backup"})
+	})
+	if e.GetType() != string(events.JobStopped) || e.GetJobName() != "team-a-backup" {
+		t.Errorf("received %s of %s, want job.stopped of team-a-backup", e.GetType(), e.GetJobName())
+	}
+}
+
+func TestGRPCStreamEventsEndOnShutdown(t *testing.T) {
+	env := newTestEnv(t, testConfig())
+	client := jobschedulerv1.NewJobSchedulerServiceClient(dialGRPC(t, env))
+
+	stream, err := client.StreamEvents(
+		withToken(testContext(t), teamAToken),
+		&jobschedulerv1.StreamEventsRequest{},
+	)
+	if err != nil {
+		t.Fatalf("StreamEvents: %s", err)
+	}
+	// the stream is open once it delivers an event
+	recvEvent(t, stream, func() {
+		env.bus.Publish(events.Event{Type: events.JobStarted, JobName: "team-a-backup"})
+	})
+
+	env.grpc.Close()
+	for {
+		_, err := stream.Recv()
+		if err == io.EOF {
+			return
+		}
+		if err != nil {
+			t.Fatalf("Recv: %s, want the stream to end", err)
+		}
+	}
+}
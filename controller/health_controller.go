@@ -0,0 +1,66 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/panagiotisptr/job-scheduler/health"
+	"go.uber.org/zap"
+)
+
+type HealthController struct {
+	logger  *zap.Logger
+	checker *health.Checker
+}
+
+func ProvideHealthController(
+	logger *zap.Logger,
+	r *mux.Router,
+	checker *health.Checker,
+) (*HealthController, error) {
+	c := &HealthController{
+		logger:  logger,
+		checker: checker,
+	}
+
+	r.HandleFunc("/healthz", c.liveness).Methods(http.MethodGet)
+	r.HandleFunc("/readyz", c.readiness).Methods(http.MethodGet)
+
+	return c, nil
+}
+
+// liveness the process is up and serving requests
+func (c *HealthController) liveness(
+	w http.ResponseWriter,
+	r *http.Request,
+) {
+	writeObject(
+		w,
+		struct {
+			Status string `json:"status"`
+		}{
+			Status: health.StatusOK,
+		},
+		http.StatusOK,
+		c.logger,
+	)
+}
+
+// readiness every dependency of the service is usable
+func (c *HealthController) readiness(
+	w http.ResponseWriter,
+	r *http.Request,
+) {
+	report := c.checker.Readiness(r.Context())
+	code := http.StatusOK
+	if report.Status != health.StatusOK {
+		code = http.StatusServiceUnavailable
+	}
+
+	writeObject(
+		w,
+		report,
+		code,
+		c.logger,
+	)
+}
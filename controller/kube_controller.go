@@ -2,33 +2,47 @@ package controller
 
 import (
 	"context"
-	"fmt"
+	"io"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/panagiotisptr/job-scheduler/app"
+	"github.com/panagiotisptr/job-scheduler/apperror"
+	"github.com/panagiotisptr/job-scheduler/repository"
+	"github.com/panagiotisptr/job-scheduler/types"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 )
 
 type KubernetesController struct {
 	logger *zap.Logger
 	app    *app.App
+	tracer trace.Tracer
 }
 
 func ProvideKubernetesController(
 	logger *zap.Logger,
 	r *mux.Router,
 	app *app.App,
+	tp trace.TracerProvider,
 ) (*KubernetesController, error) {
 	c := &KubernetesController{
 		logger: logger,
 		app:    app,
+		tracer: tp.Tracer("github.com/panagiotisptr/job-scheduler/controller"),
 	}
 
-	r.HandleFunc("/cluster/jobs", c.listRunningJobs).Methods(http.MethodGet)
-	r.HandleFunc("/cluster/jobs/{jobName}/start", c.startJob).Methods(http.MethodPatch)
-	r.HandleFunc("/cluster/jobs/{jobName}/stop", c.stopJob).Methods(http.MethodPatch)
+	handle(r, "/cluster/jobs", c.listRunningJobs, http.MethodGet)
+	handle(r, "/cluster/jobs/{jobName}/start", c.startJob, http.MethodPatch)
+	handle(r, "/cluster/jobs/{jobName}/stop", c.stopJob, http.MethodPatch)
+	handle(r, "/cluster/jobs/{jobName}", c.deleteJob, http.MethodDelete)
+	handle(r, "/cluster/jobs/{jobName}/run", c.runJob, http.MethodPost)
+	handle(r, "/cluster/jobs/{jobName}/logs", c.jobLogs, http.MethodGet)
+	handle(r, "/cluster/jobs/{jobName}/diff", c.diffJob, http.MethodGet)
+	handle(r, "/cluster/jobs/{jobName}/revisions", c.listRevisions, http.MethodGet)
+	handle(r, "/cluster/jobs/{jobName}/rollback", c.rollbackJob, http.MethodPost)
 
 	return c, nil
 }
@@ -37,8 +51,10 @@ func (c *KubernetesController) listRunningJobs(
 	w http.ResponseWriter,
 	r *http.Request,
 ) {
+	ctx, span := c.tracer.Start(r.Context(), "KubernetesController.listRunningJobs")
+	defer span.End()
 	ctx, cancel := context.WithTimeout(
-		context.Background(),
+		ctx,
 		time.Second*2,
 	)
 	defer cancel()
@@ -46,8 +62,8 @@ func (c *KubernetesController) listRunningJobs(
 	if err != nil {
 		errorResponse(
 			w,
+			r,
 			err,
-			http.StatusInternalServerError,
 			c.logger,
 		)
 		return
@@ -55,9 +71,7 @@ func (c *KubernetesController) listRunningJobs(
 
 	writeObject(
 		w,
-		struct {
-			JobNames []string `json:"jobNames"`
-		}{
+		types.JobNamesResponse{
 			JobNames: res,
 		},
 		http.StatusOK,
@@ -73,13 +87,16 @@ func (c *KubernetesController) startJob(
 	if !ok {
 		errorResponse(
 			w,
-			fmt.Errorf("could not find job"),
-			http.StatusNotFound,
+			r,
+			apperror.NotFound("could not find job"),
 			c.logger,
 		)
+		return
 	}
+	ctx, span := c.tracer.Start(r.Context(), "KubernetesController.startJob")
+	defer span.End()
 	ctx, cancel := context.WithTimeout(
-		context.Background(),
+		ctx,
 		time.Second*2,
 	)
 	defer cancel()
@@ -90,8 +107,8 @@ func (c *KubernetesController) startJob(
 	if err != nil {
 		errorResponse(
 			w,
+			r,
 			err,
-			http.StatusInternalServerError,
 			c.logger,
 		)
 		return
@@ -99,9 +116,7 @@ func (c *KubernetesController) startJob(
 
 	writeObject(
 		w,
-		struct {
-			Success bool `json:"success"`
-		}{
+		types.SuccessResponse{
 			Success: true,
 		},
 		http.StatusOK,
@@ -117,13 +132,16 @@ func (c *KubernetesController) stopJob(
 	if !ok {
 		errorResponse(
 			w,
-			fmt.Errorf("could not find job"),
-			http.StatusNotFound,
+			r,
+			apperror.NotFound("could not find job"),
 			c.logger,
 		)
+		return
 	}
+	ctx, span := c.tracer.Start(r.Context(), "KubernetesController.stopJob")
+	defer span.End()
 	ctx, cancel := context.WithTimeout(
-		context.Background(),
+		ctx,
 		time.Second*2,
 	)
 	defer cancel()
@@ -134,8 +152,8 @@ func (c *KubernetesController) stopJob(
 	if err != nil {
 		errorResponse(
 			w,
+			r,
 			err,
-			http.StatusInternalServerError,
 			c.logger,
 		)
 		return
@@ -143,12 +161,349 @@ func (c *KubernetesController) stopJob(
 
 	writeObject(
 		w,
-		struct {
-			Success bool `json:"success"`
-		}{
+		types.SuccessResponse{
 			Success: true,
 		},
 		http.StatusOK,
 		c.logger,
 	)
 }
+
+func (c *KubernetesController) deleteJob(
+	w http.ResponseWriter,
+	r *http.Request,
+) {
+	jobName, ok := mux.Vars(r)["jobName"]
+	if !ok {
+		errorResponse(
+			w,
+			r,
+			apperror.NotFound("could not find job"),
+			c.logger,
+		)
+		return
+	}
+	ctx, span := c.tracer.Start(r.Context(), "KubernetesController.deleteJob")
+	defer span.End()
+	ctx, cancel := context.WithTimeout(
+		ctx,
+		time.Second*2,
+	)
+	defer cancel()
+	err := c.app.DeleteJob(
+		ctx,
+		jobName,
+	)
+	if err != nil {
+		errorResponse(
+			w,
+			r,
+			err,
+			c.logger,
+		)
+		return
+	}
+
+	writeObject(
+		w,
+		types.SuccessResponse{
+			Success: true,
+		},
+		http.StatusOK,
+		c.logger,
+	)
+}
+
+func (c *KubernetesController) runJob(
+	w http.ResponseWriter,
+	r *http.Request,
+) {
+	jobName, ok := mux.Vars(r)["jobName"]
+	if !ok {
+		errorResponse(
+			w,
+			r,
+			apperror.NotFound("could not find job"),
+			c.logger,
+		)
+		return
+	}
+	ctx, span := c.tracer.Start(r.Context(), "KubernetesController.runJob")
+	defer span.End()
+	ctx, cancel := context.WithTimeout(
+		ctx,
+		time.Second*2,
+	)
+	defer cancel()
+	runName, err := c.app.RunJob(
+		ctx,
+		jobName,
+	)
+	if err != nil {
+		errorResponse(
+			w,
+			r,
+			err,
+			c.logger,
+		)
+		return
+	}
+
+	writeObject(
+		w,
+		types.RunJobResponse{
+			JobName: runName,
+		},
+		http.StatusCreated,
+		c.logger,
+	)
+}
+
+// jobLogs streams the logs of the latest job of the cronjob, or of
+// the one given in the job query parameter, as plain text.
+// e.g. /cluster/jobs/backup/logs?tail=100&follow=true
+func (c *KubernetesController) jobLogs(
+	w http.ResponseWriter,
+	r *http.Request,
+) {
+	jobName, ok := mux.Vars(r)["jobName"]
+	if !ok {
+		errorResponse(
+			w,
+			r,
+			apperror.NotFound("could not find job"),
+			c.logger,
+		)
+		return
+	}
+	opts := repository.LogOptions{
+		Job: r.URL.Query().Get("job"),
+	}
+	if tail := r.URL.Query().Get("tail"); tail != "" {
+		var err error
+		opts.TailLines, err = strconv.ParseInt(tail, 10, 64)
+		if err != nil || opts.TailLines < 0 {
+			errorResponse(
+				w,
+				r,
+				apperror.Invalid("invalid tail: %s", tail),
+				c.logger,
+			)
+			return
+		}
+	}
+	if follow := r.URL.Query().Get("follow"); follow != "" {
+		var err error
+		opts.Follow, err = strconv.ParseBool(follow)
+		if err != nil {
+			errorResponse(
+				w,
+				r,
+				apperror.Invalid("invalid follow: %s", follow),
+				c.logger,
+			)
+			return
+		}
+	}
+
+	// no timeout, the logs are streamed until the client goes away
+	// or the pod terminates
+	ctx, span := c.tracer.Start(r.Context(), "KubernetesController.jobLogs")
+	defer span.End()
+	logs, err := c.app.GetJobLogs(
+		ctx,
+		jobName,
+		opts,
+	)
+	if err != nil {
+		errorResponse(
+			w,
+			r,
+			err,
+			c.logger,
+		)
+		return
+	}
+	defer logs.Close()
+
+	if opts.Follow {
+		err := http.NewResponseController(w).SetWriteDeadline(time.Time{})
+		if err != nil {
+			c.logger.Sugar().Warn(
+				"failed to clear write deadline for log stream: ",
+				err,
+			)
+		}
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("X-Job-Name", logs.Job)
+	w.Header().Set("X-Pod-Name", logs.Pod)
+	w.WriteHeader(http.StatusOK)
+
+	flusher, _ := w.(http.Flusher)
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := logs.Read(buf)
+		if n > 0 {
+			if _, err := w.Write(buf[:n]); err != nil {
+				return
+			}
+			if flusher != nil {
+				flusher.Flush()
+			}
+		}
+		if err != nil {
+			if err != io.EOF && ctx.Err() == nil {
+				c.logger.Sugar().Error(
+					"failed to read job logs: ",
+					err,
+				)
+			}
+			return
+		}
+	}
+}
+
+func (c *KubernetesController) diffJob(
+	w http.ResponseWriter,
+	r *http.Request,
+) {
+	jobName, ok := mux.Vars(r)["jobName"]
+	if !ok {
+		errorResponse(
+			w,
+			r,
+			apperror.NotFound("could not find job"),
+			c.logger,
+		)
+		return
+	}
+	ctx, span := c.tracer.Start(r.Context(), "KubernetesController.diffJob")
+	defer span.End()
+	ctx, cancel := context.WithTimeout(
+		ctx,
+		time.Second*2,
+	)
+	defer cancel()
+	diff, err := c.app.DiffJob(
+		ctx,
+		jobName,
+	)
+	if err != nil {
+		errorResponse(
+			w,
+			r,
+			err,
+			c.logger,
+		)
+		return
+	}
+
+	writeObject(
+		w,
+		types.JobDiffResponse{
+			JobName: diff.JobName,
+			Desired: diff.Desired,
+			Live:    diff.Live,
+		},
+		http.StatusOK,
+		c.logger,
+	)
+}
+
+func (c *KubernetesController) listRevisions(
+	w http.ResponseWriter,
+	r *http.Request,
+) {
+	jobName, ok := mux.Vars(r)["jobName"]
+	if !ok {
+		errorResponse(
+			w,
+			r,
+			apperror.NotFound("could not find job"),
+			c.logger,
+		)
+		return
+	}
+	ctx, span := c.tracer.Start(r.Context(), "KubernetesController.listRevisions")
+	defer span.End()
+	ctx, cancel := context.WithTimeout(
+		ctx,
+		time.Second*2,
+	)
+	defer cancel()
+	res, err := c.app.ListJobRevisions(ctx, jobName)
+	if err != nil {
+		errorResponse(
+			w,
+			r,
+			err,
+			c.logger,
+		)
+		return
+	}
+
+	writeObject(
+		w,
+		types.RevisionsResponse{
+			Revisions: res,
+		},
+		http.StatusOK,
+		c.logger,
+	)
+}
+
+func (c *KubernetesController) rollbackJob(
+	w http.ResponseWriter,
+	r *http.Request,
+) {
+	jobName, ok := mux.Vars(r)["jobName"]
+	if !ok {
+		errorResponse(
+			w,
+			r,
+			apperror.NotFound("could not find job"),
+			c.logger,
+		)
+		return
+	}
+	revision := r.URL.Query().Get("revision")
+	number, err := strconv.ParseUint(revision, 10, 64)
+	if err != nil || number == 0 {
+		errorResponse(
+			w,
+			r,
+			apperror.Invalid("invalid revision: %s", revision),
+			c.logger,
+		)
+		return
+	}
+	ctx, span := c.tracer.Start(r.Context(), "KubernetesController.rollbackJob")
+	defer span.End()
+	ctx, cancel := context.WithTimeout(
+		ctx,
+		time.Second*2,
+	)
+	defer cancel()
+	res, err := c.app.RollbackJob(
+		ctx,
+		jobName,
+		number,
+	)
+	if err != nil {
+		errorResponse(
+			w,
+			r,
+			err,
+			c.logger,
+		)
+		return
+	}
+
+	writeObject(
+		w,
+		res,
+		http.StatusOK,
+		c.logger,
+	)
+}
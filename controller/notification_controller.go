@@ -0,0 +1,68 @@
+package controller
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/panagiotisptr/job-scheduler/app"
+	"github.com/panagiotisptr/job-scheduler/types"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+type NotificationController struct {
+	logger *zap.Logger
+	app    *app.App
+	tracer trace.Tracer
+}
+
+func ProvideNotificationController(
+	logger *zap.Logger,
+	r *mux.Router,
+	app *app.App,
+	tp trace.TracerProvider,
+) (*NotificationController, error) {
+	c := &NotificationController{
+		logger: logger,
+		app:    app,
+		tracer: tp.Tracer("github.com/panagiotisptr/job-scheduler/controller"),
+	}
+
+	handle(r, "/notifications/deliveries", c.listDeliveries, http.MethodGet)
+
+	return c, nil
+}
+
+func (c *NotificationController) listDeliveries(
+	w http.ResponseWriter,
+	r *http.Request,
+) {
+	ctx, span := c.tracer.Start(r.Context(), "NotificationController.listDeliveries")
+	defer span.End()
+	ctx, cancel := context.WithTimeout(
+		ctx,
+		time.Second*2,
+	)
+	defer cancel()
+	res, err := c.app.ListNotificationDeliveries(ctx)
+	if err != nil {
+		errorResponse(
+			w,
+			r,
+			err,
+			c.logger,
+		)
+		return
+	}
+
+	writeObject(
+		w,
+		types.DeliveriesResponse{
+			Deliveries: res,
+		},
+		http.StatusOK,
+		c.logger,
+	)
+}
@@ -0,0 +1,37 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/panagiotisptr/job-scheduler/types"
+	"go.uber.org/zap"
+)
+
+type OpenAPIController struct {
+	logger *zap.Logger
+}
+
+func ProvideOpenAPIController(
+	logger *zap.Logger,
+	r *mux.Router,
+) (*OpenAPIController, error) {
+	c := &OpenAPIController{
+		logger: logger,
+	}
+
+	r.HandleFunc(APIPrefix+"/openapi.json", c.getDocument).Methods(http.MethodGet)
+
+	return c, nil
+}
+
+func (c *OpenAPIController) getDocument(
+	w http.ResponseWriter,
+	r *http.Request,
+) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(types.OpenAPI); err != nil {
+		c.logger.Sugar().Error("failed to write to response: ", err)
+	}
+}
@@ -0,0 +1,423 @@
+package controller
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/panagiotisptr/job-scheduler/events"
+	"github.com/panagiotisptr/job-scheduler/types"
+)
+
+// openAPIDocument the parts of types/openapi.json the contract tests
+// check the handlers against
+type openAPIDocument map[string]interface{}
+
+func loadOpenAPI(t *testing.T) openAPIDocument {
+	t.Helper()
+	var doc openAPIDocument
+	if err := json.Unmarshal(types.OpenAPI, &doc); err != nil {
+		t.Fatalf("invalid openapi.json: %s", err)
+	}
+
+	return doc
+}
+
+func object(v interface{}) map[string]interface{} {
+	m, _ := v.(map[string]interface{})
+	return m
+}
+
+// operations the documented operations as "METHOD /path"
+func (d openAPIDocument) operations() map[string]struct{} {
+	res := map[string]struct{}{}
+	for path, item := range object(d["paths"]) {
+		for method := range object(item) {
+			if method == "parameters" {
+				continue
+			}
+			res[strings.ToUpper(method)+" "+path] = struct{}{}
+		}
+	}
+
+	return res
+}
+
+// operation the documented operation of the route
+func (d openAPIDocument) operation(method string, path string) map[string]interface{} {
+	return object(object(object(d["paths"])[path])[strings.ToLower(method)])
+}
+
+// resolve follows a local $ref, e.g. #/components/schemas/Job
+func (d openAPIDocument) resolve(v map[string]interface{}) map[string]interface{} {
+	ref, ok := v["$ref"].(string)
+	if !ok {
+		return v
+	}
+	var cur interface{} = map[string]interface{}(d)
+	for _, part := range strings.Split(strings.TrimPrefix(ref, "#/"), "/") {
+		cur = object(cur)[part]
+	}
+
+	return d.resolve(object(cur))
+}
+
+// validate returns how the value differs from the schema. It covers
+// the keywords openapi.json uses, properties that are not documented
+// are reported unless additionalProperties allows them
+func (d openAPIDocument) validate(
+	schema map[string]interface{},
+	v interface{},
+	at string,
+) []string {
+	schema = d.resolve(schema)
+	if v == nil {
+		if schema["nullable"] == true || schema["type"] == nil {
+			return nil
+		}
+		return []string{at + ": null is not allowed"}
+	}
+
+	errs := []string{}
+	switch schema["type"] {
+	case "object":
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return []string{fmt.Sprintf("%s: %T is not an object", at, v)}
+		}
+		required, _ := schema["required"].([]interface{})
+		for _, name := range required {
+			if _, ok := m[name.(string)]; !ok {
+				errs = append(errs, fmt.Sprintf("%s: %s is required", at, name))
+			}
+		}
+		properties := object(schema["properties"])
+		for name, value := range m {
+			if p, ok := properties[name]; ok {
+				errs = append(errs, d.validate(object(p), value, at+"."+name)...)
+				continue
+			}
+			switch additional := schema["additionalProperties"].(type) {
+			case map[string]interface{}:
+				errs = append(errs, d.validate(additional, value, at+"."+name)...)
+			case bool:
+				if !additional {
+					errs = append(errs, fmt.Sprintf("%s: %s is not documented", at, name))
+				}
+			default:
+				if properties != nil {
+					errs = append(errs, fmt.Sprintf("%s: %s is not documented", at, name))
+				}
+			}
+		}
+	case "array":
+		items, ok := v.([]interface{})
+		if !ok {
+			return []string{fmt.Sprintf("%s: %T is not an array", at, v)}
+		}
+		for i, item := range items {
+			errs = append(errs, d.validate(object(schema["items"]), item, fmt.Sprintf("%s[%d]", at, i))...)
+		}
+	case "string":
+		s, ok := v.(string)
+		if !ok {
+			return []string{fmt.Sprintf("%s: %T is not a string", at, v)}
+		}
+		if schema["format"] == "date-time" {
+			if _, err := time.Parse(time.RFC3339Nano, s); err != nil {
+				errs = append(errs, fmt.Sprintf("%s: %q is not a date-time", at, s))
+			}
+		}
+		if enum, ok := schema["enum"].([]interface{}); ok {
+			found := false
+			for _, e := range enum {
+				found = found || e == s
+			}
+			if !found {
+				errs = append(errs, fmt.Sprintf("%s: %q is not one of %v", at, s, enum))
+			}
+		}
+	case "integer":
+		n, ok := v.(float64)
+		if !ok || n != math.Trunc(n) {
+			return []string{fmt.Sprintf("%s: %v is not an integer", at, v)}
+		}
+	case "number":
+		if _, ok := v.(float64); !ok {
+			return []string{fmt.Sprintf("%s: %v is not a number", at, v)}
+		}
+	case "boolean":
+		if _, ok := v.(bool); !ok {
+			return []string{fmt.Sprintf("%s: %v is not a boolean", at, v)}
+		}
+	}
+
+	return errs
+}
+
+// routeOf the method and OpenAPI path of the route serving the request
+func routeOf(t *testing.T, r *mux.Router, req *http.Request) string {
+	t.Helper()
+	var match mux.RouteMatch
+	if !r.Match(req, &match) {
+		t.Fatalf("no route for %s %s", req.Method, req.URL.Path)
+	}
+	template, err := match.Route.GetPathTemplate()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return req.Method + " " + strings.TrimPrefix(template, APIPrefix)
+}
+
+func TestRoutesMatchOpenAPI(t *testing.T) {
+	env := newTestEnv(t, testConfig())
+	doc := loadOpenAPI(t)
+
+	routes := map[string]struct{}{}
+	err := env.router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		template, err := route.GetPathTemplate()
+		if err != nil {
+			return err
+		}
+		// the legacy aliases and the probes are not part of the API
+		if !strings.HasPrefix(template, APIPrefix+"/") {
+			return nil
+		}
+		methods, err := route.GetMethods()
+		if err != nil {
+			return fmt.Errorf("%s: %w", template, err)
+		}
+		for _, m := range methods {
+			routes[m+" "+strings.TrimPrefix(template, APIPrefix)] = struct{}{}
+		}
+
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("failed to walk the routes: %s", err)
+	}
+
+	documented := doc.operations()
+	for route := range routes {
+		if _, ok := documented[route]; !ok {
+			t.Errorf("%s is served but not in openapi.json", route)
+		}
+	}
+	for op := range documented {
+		if _, ok := routes[op]; !ok {
+			t.Errorf("%s is in openapi.json but not served", op)
+		}
+	}
+}
+
+func TestResponsesMatchOpenAPI(t *testing.T) {
+	env := newTestEnv(t, testConfig())
+	doc := loadOpenAPI(t)
+
+	// in order, later requests depend on the cluster state of the
+	// earlier ones
+	tests := []struct {
+		method string
+		path   string
+		body   string
+		token  string
+		status int
+	}{
+		{method: http.MethodGet, path: "/static/jobs", status: http.StatusUnauthorized},
+		{method: http.MethodGet, path: "/static/jobs", token: teamAToken, status: http.StatusOK},
+		{method: http.MethodGet, path: "/static/jobs/team-a-backup", token: teamAToken, status: http.StatusOK},
+		{method: http.MethodGet, path: "/static/jobs/team-b-report", token: teamAToken, status: http.StatusForbidden},
+		{method: http.MethodGet, path: "/static/jobs/team-a-missing", token: teamAToken, status: http.StatusNotFound},
+		{method: http.MethodGet, path: "/static/jobs/team-a-backup/source", token: teamAToken, status: http.StatusOK},
+		{method: http.MethodGet, path: "/static/tasks", token: teamAToken, status: http.StatusOK},
+		{method: http.MethodGet, path: "/static/tasks/team-a-migrate", token: teamAToken, status: http.StatusOK},
+		{method: http.MethodGet, path: "/static/syncs", token: teamAToken, status: http.StatusOK},
+		{method: http.MethodGet, path: "/cluster/jobs/team-a-backup/diff", token: teamAToken, status: http.StatusOK},
+		{method: http.MethodPatch, path: "/cluster/jobs/team-a-backup/start", token: teamAToken, status: http.StatusOK},
+		{method: http.MethodPatch, path: "/cluster/jobs/team-b-report/start", token: teamAToken, status: http.StatusForbidden},
+		{method: http.MethodGet, path: "/cluster/jobs", token: teamAToken, status: http.StatusOK},
+		{method: http.MethodGet, path: "/cluster/jobs/team-a-backup/diff", token: teamAToken, status: http.StatusOK},
+		{method: http.MethodPost, path: "/cluster/jobs/team-a-backup/run", token: teamAToken, status: http.StatusCreated},
+		{method: http.MethodGet, path: "/cluster/jobs/team-a-backup/logs", token: teamAToken, status: http.StatusOK},
+		{method: http.MethodGet, path: "/cluster/jobs/team-a-backup/logs?job=team-b-report-1", token: teamAToken, status: http.StatusNotFound},
+		{method: http.MethodGet, path: "/cluster/jobs/team-a-backup/revisions", token: teamAToken, status: http.StatusOK},
+		{method: http.MethodPost, path: "/cluster/jobs/team-a-backup/rollback?revision=1", token: teamAToken, status: http.StatusOK},
+		{method: http.MethodPost, path: "/cluster/jobs/team-a-backup/rollback?revision=x", token: teamAToken, status: http.StatusBadRequest},
+		{method: http.MethodPost, path: "/cluster/jobs/team-a-backup/rollback?revision=99", token: teamAToken, status: http.StatusNotFound},
+		{method: http.MethodPost, path: "/cluster/tasks/team-a-migrate/run", token: teamAToken, status: http.StatusCreated},
+		{method: http.MethodGet, path: "/cluster/tasks/team-a-migrate/runs", token: teamAToken, status: http.StatusOK},
+		{
+			method: http.MethodPost,
+			path:   "/promotions",
+			body:   `{"jobName": "team-a-backup", "from": "staging", "commit": "0123456789abcdef0123456789abcdef01234567"}`,
+			token:  teamAToken,
+			status: http.StatusCreated,
+		},
+		{
+			method: http.MethodPost,
+			path:   "/promotions",
+			body:   `{"jobName": "team-a-backup", "from": "qa"}`,
+			token:  teamAToken,
+			status: http.StatusBadRequest,
+		},
+		{method: http.MethodGet, path: "/promotions", token: teamAToken, status: http.StatusOK},
+		{method: http.MethodDelete, path: "/promotions/team-a-backup", token: teamAToken, status: http.StatusOK},
+		{method: http.MethodPatch, path: "/cluster/jobs/team-a-backup/stop", token: teamAToken, status: http.StatusOK},
+		{method: http.MethodDelete, path: "/cluster/jobs/team-a-backup", token: teamAToken, status: http.StatusOK},
+		{method: http.MethodGet, path: "/notifications/deliveries", token: teamAToken, status: http.StatusOK},
+		{method: http.MethodGet, path: "/audit", token: teamAToken, status: http.StatusOK},
+		{
+			method: http.MethodPost,
+			path:   "/authz/check",
+			body:   `{"operation": "start", "job": "team-a-backup"}`,
+			token:  teamAToken,
+			status: http.StatusOK,
+		},
+		{
+			method: http.MethodPost,
+			path:   "/authz/check",
+			body:   `{"subject": "bob", "operation": "start", "job": "team-b-report"}`,
+			token:  teamAToken,
+			status: http.StatusForbidden,
+		},
+		{method: http.MethodGet, path: "/openapi.json", status: http.StatusOK},
+	}
+
+	covered := map[string]struct{}{}
+	for i, tt := range tests {
+		t.Run(strconv.Itoa(i)+" "+tt.method+" "+tt.path, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, APIPrefix+tt.path, bytes.NewBufferString(tt.body))
+			if tt.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+			route := routeOf(t, env.router, req)
+			covered[route] = struct{}{}
+
+			rec := httptest.NewRecorder()
+			env.router.ServeHTTP(rec, req)
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.status, rec.Body.String())
+			}
+
+			method, path, _ := strings.Cut(route, " ")
+			responses := object(doc.operation(method, path)["responses"])
+			documented, ok := responses[strconv.Itoa(rec.Code)]
+			if !ok {
+				t.Fatalf("%s doesn't document the status %d", route, rec.Code)
+			}
+			content := object(doc.resolve(object(documented))["content"])
+			if len(content) == 0 {
+				if rec.Body.Len() > 0 {
+					t.Errorf("%s documents no content for %d, got %s", route, rec.Code, rec.Body.String())
+				}
+				return
+			}
+			contentType, _, _ := strings.Cut(rec.Header().Get("Content-Type"), ";")
+			media, ok := content[contentType]
+			if !ok {
+				t.Fatalf("%s doesn't document %s responses for %d", route, contentType, rec.Code)
+			}
+			if contentType != "application/json" {
+				return
+			}
+
+			var body interface{}
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatalf("invalid JSON: %s", err)
+			}
+			errs := doc.validate(object(object(media)["schema"]), body, "body")
+			for _, err := range errs {
+				t.Error(err)
+			}
+		})
+	}
+
+	// the event stream doesn't end, see
+	// TestEventStreamMatchesOpenAPI
+	covered["GET /events"] = struct{}{}
+	missing := []string{}
+	for op := range doc.operations() {
+		if _, ok := covered[op]; !ok {
+			missing = append(missing, op)
+		}
+	}
+	sort.Strings(missing)
+	if len(missing) > 0 {
+		t.Errorf("no request covers %v", missing)
+	}
+}
+
+func TestEventStreamMatchesOpenAPI(t *testing.T) {
+	env := newTestEnv(t, testConfig())
+	doc := loadOpenAPI(t)
+	srv := httptest.NewServer(env.router)
+	t.Cleanup(srv.Close)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+APIPrefix+"/events", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", "Bearer "+teamAToken)
+	res, err := srv.Client().Do(req)
+	if err != nil {
+		t.Fatalf("GET /events: %s", err)
+	}
+	defer res.Body.Close()
+	if contentType := res.Header.Get("Content-Type"); contentType != "text/event-stream" {
+		t.Fatalf("Content-Type = %q", contentType)
+	}
+
+	// published until the stream has subscribed
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		ticker := time.NewTicker(10 * time.Millisecond)
+		defer ticker.Stop()
+		for {
+			env.bus.Publish(events.Event{Type: events.JobStarted, JobName: "team-b-report"})
+			env.bus.Publish(events.Event{Type: events.JobStarted, JobName: "team-a-backup", Actor: "alice"})
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	scanner := bufio.NewScanner(res.Body)
+	for received := 0; received < 3 && scanner.Scan(); {
+		data, ok := strings.CutPrefix(scanner.Text(), "data: ")
+		if !ok {
+			continue
+		}
+		received++
+		var body interface{}
+		if err := json.Unmarshal([]byte(data), &body); err != nil {
+			t.Fatalf("invalid JSON: %s", err)
+		}
+		schema := map[string]interface{}{"$ref": "#/components/schemas/Event"}
+		for _, err := range doc.validate(schema, body, "data") {
+			t.Error(err)
+		}
+		if job := object(body)["jobName"]; job != "team-a-backup" {
+			t.Fatalf("received an event of %v, which the caller can't view", job)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatalf("failed to read the stream: %s", err)
+	}
+}
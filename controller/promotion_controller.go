@@ -0,0 +1,170 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/panagiotisptr/job-scheduler/app"
+	"github.com/panagiotisptr/job-scheduler/apperror"
+	"github.com/panagiotisptr/job-scheduler/types"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+type PromotionController struct {
+	logger *zap.Logger
+	app    *app.App
+	tracer trace.Tracer
+}
+
+func ProvidePromotionController(
+	logger *zap.Logger,
+	r *mux.Router,
+	app *app.App,
+	tp trace.TracerProvider,
+) (*PromotionController, error) {
+	c := &PromotionController{
+		logger: logger,
+		app:    app,
+		tracer: tp.Tracer("github.com/panagiotisptr/job-scheduler/controller"),
+	}
+
+	handle(r, "/promotions", c.listPromotions, http.MethodGet)
+	handle(r, "/promotions", c.promote, http.MethodPost)
+	handle(r, "/promotions/{jobName}", c.release, http.MethodDelete)
+
+	return c, nil
+}
+
+func (c *PromotionController) listPromotions(
+	w http.ResponseWriter,
+	r *http.Request,
+) {
+	ctx, span := c.tracer.Start(r.Context(), "PromotionController.listPromotions")
+	defer span.End()
+	ctx, cancel := context.WithTimeout(
+		ctx,
+		time.Second*2,
+	)
+	defer cancel()
+	res, err := c.app.ListPromotions(ctx)
+	if err != nil {
+		errorResponse(
+			w,
+			r,
+			err,
+			c.logger,
+		)
+		return
+	}
+
+	writeObject(
+		w,
+		types.PromotionsResponse{
+			Promotions: res,
+		},
+		http.StatusOK,
+		c.logger,
+	)
+}
+
+func (c *PromotionController) promote(
+	w http.ResponseWriter,
+	r *http.Request,
+) {
+	ctx, span := c.tracer.Start(r.Context(), "PromotionController.promote")
+	defer span.End()
+
+	var req types.PromotionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		errorResponse(
+			w,
+			r,
+			apperror.Invalid("invalid request body: %s", err),
+			c.logger,
+		)
+		return
+	}
+	if req.JobName == "" || req.From == "" {
+		errorResponse(
+			w,
+			r,
+			apperror.Invalid("jobName and from are required"),
+			c.logger,
+		)
+		return
+	}
+	// asks the scheduler of the other environment and GitHub before
+	// updating the cluster
+	ctx, cancel := context.WithTimeout(
+		ctx,
+		time.Second*10,
+	)
+	defer cancel()
+	res, err := c.app.PromoteJob(
+		ctx,
+		req.JobName,
+		req.From,
+		req.Commit,
+	)
+	if err != nil {
+		errorResponse(
+			w,
+			r,
+			err,
+			c.logger,
+		)
+		return
+	}
+
+	writeObject(
+		w,
+		res,
+		http.StatusCreated,
+		c.logger,
+	)
+}
+
+func (c *PromotionController) release(
+	w http.ResponseWriter,
+	r *http.Request,
+) {
+	jobName, ok := mux.Vars(r)["jobName"]
+	if !ok {
+		errorResponse(
+			w,
+			r,
+			apperror.NotFound("could not find job"),
+			c.logger,
+		)
+		return
+	}
+	ctx, span := c.tracer.Start(r.Context(), "PromotionController.release")
+	defer span.End()
+	ctx, cancel := context.WithTimeout(
+		ctx,
+		time.Second*2,
+	)
+	defer cancel()
+	if err := c.app.ReleaseJob(ctx, jobName); err != nil {
+		errorResponse(
+			w,
+			r,
+			err,
+			c.logger,
+		)
+		return
+	}
+
+	writeObject(
+		w,
+		types.SuccessResponse{
+			Success: true,
+		},
+		http.StatusOK,
+		c.logger,
+	)
+}
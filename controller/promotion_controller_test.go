@@ -0,0 +1,114 @@
+package controller
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/panagiotisptr/job-scheduler/managed"
+	"github.com/panagiotisptr/job-scheduler/types"
+)
+
+// serve sends the request of alice and decodes the response into res
+// if it is set
+func serve(
+	t *testing.T,
+	env *testEnv,
+	method string,
+	path string,
+	body string,
+	status int,
+	res interface{},
+) {
+	t.Helper()
+	req := httptest.NewRequest(method, APIPrefix+path, bytes.NewBufferString(body))
+	req.Header.Set("Authorization", "Bearer "+teamAToken)
+	rec := httptest.NewRecorder()
+	env.router.ServeHTTP(rec, req)
+	if rec.Code != status {
+		t.Fatalf("%s %s: status %d, want %d: %s", method, path, rec.Code, status, rec.Body.String())
+	}
+	if res != nil {
+		if err := json.NewDecoder(rec.Body).Decode(res); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// appliedCommit the commit annotation of the cronjob in the cluster
+func appliedCommit(t *testing.T, env *testEnv, jobName string) string {
+	t.Helper()
+	var diff types.JobDiffResponse
+	serve(t, env, http.MethodGet, "/cluster/jobs/"+jobName+"/diff", "", http.StatusOK, &diff)
+	if diff.Live == nil {
+		t.Fatalf("%s is not in the cluster", jobName)
+	}
+
+	return diff.Live.Annotations[managed.CommitAnnotation]
+}
+
+func TestPromoteJob(t *testing.T) {
+	const (
+		head     = "0123456789abcdef0123456789abcdef01234567"
+		promoted = "1111111111111111111111111111111111111111"
+		broken   = "2222222222222222222222222222222222222222"
+	)
+	env := newTestEnv(t, testConfig())
+	promote := func(commit string, status int) {
+		t.Helper()
+		body := `{"jobName": "team-a-backup", "from": "staging", "commit": "` + commit + `"}`
+		serve(t, env, http.MethodPost, "/promotions", body, status, nil)
+	}
+	promotions := func() []types.Promotion {
+		t.Helper()
+		var res types.PromotionsResponse
+		serve(t, env, http.MethodGet, "/promotions", "", http.StatusOK, &res)
+		return res.Promotions
+	}
+
+	serve(t, env, http.MethodPatch, "/cluster/jobs/team-a-backup/start", "", http.StatusOK, nil)
+	if got := appliedCommit(t, env, "team-a-backup"); got != head {
+		t.Fatalf("applied at %q, want the head of the branch", got)
+	}
+
+	// the running cronjob is updated right away
+	promote(promoted, http.StatusCreated)
+	if got := appliedCommit(t, env, "team-a-backup"); got != promoted {
+		t.Errorf("applied at %q after the promotion, want %s", got, promoted)
+	}
+	list := promotions()
+	if len(list) != 1 || list[0].Commit != promoted || list[0].PreviousCommit != head || list[0].Actor != "alice" {
+		t.Fatalf("promotions = %+v, want the one to %s", list, promoted)
+	}
+
+	// the manifest can't be read at the commit, the promotion is
+	// reverted to the previous pin
+	env.repo.brokenCommits[broken] = struct{}{}
+	promote(broken, http.StatusServiceUnavailable)
+	if pin := env.repo.pins["team-a-backup"]; pin != promoted {
+		t.Errorf("pinned to %q after the failed promotion, want %s", pin, promoted)
+	}
+	if got := appliedCommit(t, env, "team-a-backup"); got != promoted {
+		t.Errorf("applied at %q after the failed promotion, want %s", got, promoted)
+	}
+	if list := promotions(); len(list) != 1 || list[0].Commit != promoted {
+		t.Errorf("promotions = %+v, want only the one to %s", list, promoted)
+	}
+
+	// released, the cluster keeps the promoted commit until the
+	// cronjob is started again
+	serve(t, env, http.MethodDelete, "/promotions/team-a-backup", "", http.StatusOK, nil)
+	if _, ok := env.repo.pins["team-a-backup"]; ok {
+		t.Error("still pinned after the release")
+	}
+	if got := appliedCommit(t, env, "team-a-backup"); got != promoted {
+		t.Errorf("applied at %q after the release, want %s", got, promoted)
+	}
+	serve(t, env, http.MethodPatch, "/cluster/jobs/team-a-backup/start", "", http.StatusOK, nil)
+	if got := appliedCommit(t, env, "team-a-backup"); got != head {
+		t.Errorf("applied at %q after the start, want the head of the branch", got)
+	}
+	serve(t, env, http.MethodDelete, "/promotions/team-a-backup", "", http.StatusNotFound, nil)
+}
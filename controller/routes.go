@@ -0,0 +1,31 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// APIPrefix the prefix of the routes of the v1 API
+const APIPrefix = "/api/v1"
+
+// handle registers f under the v1 API and under the unversioned path
+// it had before, which is kept as a deprecated alias
+func handle(
+	r *mux.Router,
+	path string,
+	f http.HandlerFunc,
+	methods ...string,
+) {
+	r.HandleFunc(APIPrefix+path, f).Methods(methods...)
+	r.Handle(path, deprecated(f)).Methods(methods...)
+}
+
+// deprecated points the callers of a legacy route to its v1 successor
+func deprecated(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Deprecation", "true")
+		w.Header().Set("Link", "<"+APIPrefix+r.URL.Path+`>; rel="successor-version"`)
+		next.ServeHTTP(w, r)
+	})
+}
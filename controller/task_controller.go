@@ -0,0 +1,199 @@
+package controller
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/panagiotisptr/job-scheduler/app"
+	"github.com/panagiotisptr/job-scheduler/apperror"
+	"github.com/panagiotisptr/job-scheduler/types"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+type TaskController struct {
+	logger *zap.Logger
+	app    *app.App
+	tracer trace.Tracer
+}
+
+func ProvideTaskController(
+	logger *zap.Logger,
+	r *mux.Router,
+	app *app.App,
+	tp trace.TracerProvider,
+) (*TaskController, error) {
+	c := &TaskController{
+		logger: logger,
+		app:    app,
+		tracer: tp.Tracer("github.com/panagiotisptr/job-scheduler/controller"),
+	}
+
+	handle(r, "/static/tasks", c.listTasks, http.MethodGet)
+	handle(r, "/static/tasks/{taskName}", c.getTask, http.MethodGet)
+	handle(r, "/cluster/tasks/{taskName}/run", c.runTask, http.MethodPost)
+	handle(r, "/cluster/tasks/{taskName}/runs", c.listRuns, http.MethodGet)
+
+	return c, nil
+}
+
+func (c *TaskController) listTasks(
+	w http.ResponseWriter,
+	r *http.Request,
+) {
+	ctx, span := c.tracer.Start(r.Context(), "TaskController.listTasks")
+	defer span.End()
+	ctx, cancel := context.WithTimeout(
+		ctx,
+		time.Second*2,
+	)
+	defer cancel()
+	res, err := c.app.ListAvailableTaskNames(ctx)
+	if err != nil {
+		errorResponse(
+			w,
+			r,
+			err,
+			c.logger,
+		)
+		return
+	}
+
+	writeObject(
+		w,
+		types.TaskNamesResponse{
+			TaskNames: res,
+		},
+		http.StatusOK,
+		c.logger,
+	)
+}
+
+func (c *TaskController) getTask(
+	w http.ResponseWriter,
+	r *http.Request,
+) {
+	taskName, ok := mux.Vars(r)["taskName"]
+	if !ok {
+		errorResponse(
+			w,
+			r,
+			apperror.NotFound("could not find task"),
+			c.logger,
+		)
+		return
+	}
+	ctx, span := c.tracer.Start(r.Context(), "TaskController.getTask")
+	defer span.End()
+	ctx, cancel := context.WithTimeout(
+		ctx,
+		time.Second*2,
+	)
+	defer cancel()
+	res, err := c.app.GetTaskConfig(ctx, taskName)
+	if err != nil {
+		errorResponse(
+			w,
+			r,
+			err,
+			c.logger,
+		)
+		return
+	}
+
+	writeObject(
+		w,
+		res,
+		http.StatusOK,
+		c.logger,
+	)
+}
+
+func (c *TaskController) runTask(
+	w http.ResponseWriter,
+	r *http.Request,
+) {
+	taskName, ok := mux.Vars(r)["taskName"]
+	if !ok {
+		errorResponse(
+			w,
+			r,
+			apperror.NotFound("could not find task"),
+			c.logger,
+		)
+		return
+	}
+	ctx, span := c.tracer.Start(r.Context(), "TaskController.runTask")
+	defer span.End()
+	ctx, cancel := context.WithTimeout(
+		ctx,
+		time.Second*2,
+	)
+	defer cancel()
+	runName, err := c.app.RunTask(
+		ctx,
+		taskName,
+	)
+	if err != nil {
+		errorResponse(
+			w,
+			r,
+			err,
+			c.logger,
+		)
+		return
+	}
+
+	writeObject(
+		w,
+		types.RunJobResponse{
+			JobName: runName,
+		},
+		http.StatusCreated,
+		c.logger,
+	)
+}
+
+func (c *TaskController) listRuns(
+	w http.ResponseWriter,
+	r *http.Request,
+) {
+	taskName, ok := mux.Vars(r)["taskName"]
+	if !ok {
+		errorResponse(
+			w,
+			r,
+			apperror.NotFound("could not find task"),
+			c.logger,
+		)
+		return
+	}
+	ctx, span := c.tracer.Start(r.Context(), "TaskController.listRuns")
+	defer span.End()
+	ctx, cancel := context.WithTimeout(
+		ctx,
+		time.Second*2,
+	)
+	defer cancel()
+	res, err := c.app.ListTaskRuns(ctx, taskName)
+	if err != nil {
+		errorResponse(
+			w,
+			r,
+			err,
+			c.logger,
+		)
+		return
+	}
+
+	writeObject(
+		w,
+		types.TaskRunsResponse{
+			Runs: res,
+		},
+		http.StatusOK,
+		c.logger,
+	)
+}
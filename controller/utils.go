@@ -4,37 +4,19 @@ import (
 	"encoding/json"
 	"net/http"
 
+	"github.com/panagiotisptr/job-scheduler/apperror"
 	"go.uber.org/zap"
 )
 
+// errorResponse writes err with the status code matching its
+// apperror code
 func errorResponse(
 	w http.ResponseWriter,
+	r *http.Request,
 	err error,
-	code int,
 	logger *zap.Logger,
 ) {
-	w.WriteHeader(code)
-	b, err := json.Marshal(struct {
-		Error string `json:"error"`
-	}{
-		Error: err.Error(),
-	})
-
-	if err != nil {
-		logger.Sugar().Error(
-			"failed to marshal error: ",
-			err,
-		)
-		return
-	}
-
-	_, err = w.Write(b)
-	if err != nil {
-		logger.Sugar().Error(
-			"failed to write to response: ",
-			err,
-		)
-	}
+	apperror.WriteHTTP(w, r, err, logger)
 }
 
 func writeObject(
@@ -43,18 +25,18 @@ func writeObject(
 	code int,
 	logger *zap.Logger,
 ) {
-	w.WriteHeader(code)
 	b, err := json.Marshal(obj)
 	if err != nil {
-		errorResponse(
-			w,
+		logger.Sugar().Error(
+			"failed to marshal response: ",
 			err,
-			http.StatusInternalServerError,
-			logger,
 		)
+		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
 
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
 	_, err = w.Write(b)
 	if err != nil {
 		logger.Sugar().Error(
@@ -0,0 +1,76 @@
+package events
+
+import (
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const subscriberBufferSize = 64
+
+// Bus fans out published events to every subscriber. Publishing
+// never blocks - slow subscribers miss events instead
+type Bus struct {
+	logger      *zap.Logger
+	mu          sync.Mutex
+	lastEventID uint64
+	lastSubID   uint64
+	subscribers map[uint64]chan Event
+}
+
+func ProvideBus(
+	logger *zap.Logger,
+) *Bus {
+	return &Bus{
+		logger:      logger,
+		subscribers: make(map[uint64]chan Event),
+	}
+}
+
+// Publish assigns an ID (and a timestamp if missing) to the event
+// and delivers it to all subscribers
+func (b *Bus) Publish(e Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.lastEventID++
+	e.ID = b.lastEventID
+	if e.Timestamp.IsZero() {
+		e.Timestamp = time.Now().UTC()
+	}
+
+	for id, ch := range b.subscribers {
+		select {
+		case ch <- e:
+		default:
+			b.logger.With(
+				zap.Uint64("subscriber", id),
+				zap.String("type", string(e.Type)),
+				zap.String("job", e.JobName),
+			).Sugar().Warn("dropping event for slow subscriber")
+		}
+	}
+}
+
+// Subscribe returns a channel receiving every event published from
+// now on and a function that cancels the subscription
+func (b *Bus) Subscribe() (<-chan Event, func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.lastSubID++
+	id := b.lastSubID
+	ch := make(chan Event, subscriberBufferSize)
+	b.subscribers[id] = ch
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			b.mu.Lock()
+			defer b.mu.Unlock()
+			delete(b.subscribers, id)
+			close(ch)
+		})
+	}
+}
@@ -0,0 +1,125 @@
+package events
+
+import (
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// receive the next event of the subscription or fails after a second
+func receive(t *testing.T, ch <-chan Event) Event {
+	t.Helper()
+	select {
+	case e, ok := <-ch:
+		if !ok {
+			t.Fatal("the subscription was closed")
+		}
+		return e
+	case <-time.After(time.Second):
+		t.Fatal("no event received")
+	}
+
+	return Event{}
+}
+
+func TestPublishAssignsIDsAndTimestamps(t *testing.T) {
+	bus := ProvideBus(zap.NewNop())
+	ch, unsubscribe := bus.Subscribe()
+	defer unsubscribe()
+
+	at := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	bus.Publish(Event{Type: JobStarted, JobName: "backup"})
+	bus.Publish(Event{Type: JobStopped, JobName: "backup", Timestamp: at})
+
+	first, second := receive(t, ch), receive(t, ch)
+	if first.ID != 1 || second.ID != 2 {
+		t.Errorf("ids = %d, %d, want 1, 2", first.ID, second.ID)
+	}
+	if first.Timestamp.IsZero() || first.Timestamp.Location() != time.UTC {
+		t.Errorf("timestamp = %s, want the current UTC time", first.Timestamp)
+	}
+	if !second.Timestamp.Equal(at) {
+		t.Errorf("timestamp = %s, want the one it was published with", second.Timestamp)
+	}
+	if first.Type != JobStarted || first.JobName != "backup" {
+		t.Errorf("event = %+v", first)
+	}
+}
+
+func TestEverySubscriberReceivesEvents(t *testing.T) {
+	bus := ProvideBus(zap.NewNop())
+	a, unsubscribeA := bus.Subscribe()
+	defer unsubscribeA()
+	b, unsubscribeB := bus.Subscribe()
+	defer unsubscribeB()
+
+	bus.Publish(Event{Type: JobRun, JobName: "backup"})
+
+	if e := receive(t, a); e.ID != 1 {
+		t.Errorf("first subscriber got %+v", e)
+	}
+	if e := receive(t, b); e.ID != 1 {
+		t.Errorf("second subscriber got %+v", e)
+	}
+}
+
+func TestSubscribersOnlyReceiveLaterEvents(t *testing.T) {
+	bus := ProvideBus(zap.NewNop())
+	bus.Publish(Event{Type: JobStarted, JobName: "before"})
+	ch, unsubscribe := bus.Subscribe()
+	defer unsubscribe()
+	bus.Publish(Event{Type: JobStarted, JobName: "after"})
+
+	if e := receive(t, ch); e.JobName != "after" {
+		t.Errorf("received %s, want the event published after subscribing", e.JobName)
+	}
+}
+
+func TestUnsubscribe(t *testing.T) {
+	bus := ProvideBus(zap.NewNop())
+	ch, unsubscribe := bus.Subscribe()
+	other, unsubscribeOther := bus.Subscribe()
+	defer unsubscribeOther()
+
+	unsubscribe()
+	// cancelling twice is safe
+	unsubscribe()
+	if _, ok := <-ch; ok {
+		t.Fatal("the channel is open after unsubscribing")
+	}
+
+	// publishing to the remaining subscribers still works
+	bus.Publish(Event{Type: JobStarted, JobName: "backup"})
+	receive(t, other)
+	if n := len(bus.subscribers); n != 1 {
+		t.Errorf("%d subscribers, want 1", n)
+	}
+}
+
+func TestSlowSubscribersMissEvents(t *testing.T) {
+	bus := ProvideBus(zap.NewNop())
+	slow, unsubscribeSlow := bus.Subscribe()
+	defer unsubscribeSlow()
+
+	published := make(chan struct{})
+	go func() {
+		for i := 0; i < subscriberBufferSize*2; i++ {
+			bus.Publish(Event{Type: ChildJobCreated, JobName: "backup"})
+		}
+		close(published)
+	}()
+	select {
+	case <-published:
+	case <-time.After(time.Second):
+		t.Fatal("publishing blocked on a subscriber that doesn't read")
+	}
+
+	if n := len(slow); n != subscriberBufferSize {
+		t.Errorf("%d events buffered, want %d", n, subscriberBufferSize)
+	}
+	// the buffered events are the oldest ones
+	if e := receive(t, slow); e.ID != 1 {
+		t.Errorf("first buffered event = %d, want 1", e.ID)
+	}
+}
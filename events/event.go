@@ -0,0 +1,62 @@
+package events
+
+import "time"
+
+// Type the kind of change described by an event
+type Type string
+
+const (
+	// ManifestAdded a cronjob manifest appeared in a GitHub location
+	ManifestAdded Type = "manifest.added"
+	// ManifestChanged a cronjob manifest was modified in a GitHub location
+	ManifestChanged Type = "manifest.changed"
+	// ManifestRemoved a cronjob manifest disappeared from a GitHub location
+	ManifestRemoved Type = "manifest.removed"
+
+	// JobStarted a cronjob was started through the API
+	JobStarted Type = "job.started"
+	// JobStopped a cronjob was stopped through the API
+	JobStopped Type = "job.stopped"
+	// JobDeleted a cronjob was deleted through the API
+	JobDeleted Type = "job.deleted"
+	// JobRun a job was created from a cronjob through the API
+	JobRun Type = "job.run"
+	// JobRolledBack an earlier spec of a cronjob was applied through
+	// the API
+	JobRolledBack Type = "job.rolledback"
+
+	// ChildJobCreated a cronjob spawned a job in the cluster
+	ChildJobCreated Type = "childjob.created"
+	// ChildJobSucceeded a job spawned by a cronjob completed successfully
+	ChildJobSucceeded Type = "childjob.succeeded"
+	// ChildJobFailed a job spawned by a cronjob failed
+	ChildJobFailed Type = "childjob.failed"
+
+	// TaskRun a job was created from a task through the API
+	TaskRun Type = "task.run"
+	// TaskSucceeded a job created from a task completed successfully
+	TaskSucceeded Type = "task.succeeded"
+	// TaskFailed a job created from a task failed
+	TaskFailed Type = "task.failed"
+)
+
+// Event a change in the state of a job
+type Event struct {
+	ID        uint64    `json:"id"`
+	Type      Type      `json:"type"`
+	JobName   string    `json:"jobName"`
+	Namespace string    `json:"namespace"`
+	Timestamp time.Time `json:"timestamp"`
+
+	// ChildJobName is only set for childjob.*, task.* and job.run
+	// events. JobName is the name of the task for task.* events
+	ChildJobName   string     `json:"childJobName,omitempty"`
+	StartTime      *time.Time `json:"startTime,omitempty"`
+	CompletionTime *time.Time `json:"completionTime,omitempty"`
+
+	// Actor the authenticated caller, only set for job.* and
+	// task.run events
+	Actor string `json:"actor,omitempty"`
+
+	Message string `json:"message,omitempty"`
+}
@@ -0,0 +1,363 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.2.0
+// - protoc             (unknown)
+// source: jobscheduler/v1/job_scheduler.proto
+
+package jobschedulerv1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+// JobSchedulerServiceClient is the client API for JobSchedulerService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type JobSchedulerServiceClient interface {
+	// ListAvailableJobs lists the cronjobs found in the GitHub locations
+	ListAvailableJobs(ctx context.Context, in *ListAvailableJobsRequest, opts ...grpc.CallOption) (*ListAvailableJobsResponse, error)
+	// GetJobConfig returns the manifest of a cronjob
+	GetJobConfig(ctx context.Context, in *GetJobConfigRequest, opts ...grpc.CallOption) (*GetJobConfigResponse, error)
+	// ListRunningJobs lists the cronjobs running in the cluster
+	ListRunningJobs(ctx context.Context, in *ListRunningJobsRequest, opts ...grpc.CallOption) (*ListRunningJobsResponse, error)
+	// StartJob creates or resumes a cronjob
+	StartJob(ctx context.Context, in *StartJobRequest, opts ...grpc.CallOption) (*StartJobResponse, error)
+	// StopJob suspends a cronjob
+	StopJob(ctx context.Context, in *StopJobRequest, opts ...grpc.CallOption) (*StopJobResponse, error)
+	// DeleteJob deletes a cronjob and the jobs it spawned
+	DeleteJob(ctx context.Context, in *DeleteJobRequest, opts ...grpc.CallOption) (*DeleteJobResponse, error)
+	// StreamEvents streams job events until the client cancels
+	StreamEvents(ctx context.Context, in *StreamEventsRequest, opts ...grpc.CallOption) (JobSchedulerService_StreamEventsClient, error)
+}
+
+type jobSchedulerServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewJobSchedulerServiceClient(cc grpc.ClientConnInterface) JobSchedulerServiceClient {
+	return &jobSchedulerServiceClient{cc}
+}
+
+func (c *jobSchedulerServiceClient) ListAvailableJobs(ctx context.Context, in *ListAvailableJobsRequest, opts ...grpc.CallOption) (*ListAvailableJobsResponse, error) {
+	out := new(ListAvailableJobsResponse)
+	err := c.cc.Invoke(ctx, "/jobscheduler.v1.JobSchedulerService/ListAvailableJobs", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *jobSchedulerServiceClient) GetJobConfig(ctx context.Context, in *GetJobConfigRequest, opts ...grpc.CallOption) (*GetJobConfigResponse, error) {
+	out := new(GetJobConfigResponse)
+	err := c.cc.Invoke(ctx, "/jobscheduler.v1.JobSchedulerService/GetJobConfig", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *jobSchedulerServiceClient) ListRunningJobs(ctx context.Context, in *ListRunningJobsRequest, opts ...grpc.CallOption) (*ListRunningJobsResponse, error) {
+	out := new(ListRunningJobsResponse)
+	err := c.cc.Invoke(ctx, "/jobscheduler.v1.JobSchedulerService/ListRunningJobs", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *jobSchedulerServiceClient) StartJob(ctx context.Context, in *StartJobRequest, opts ...grpc.CallOption) (*StartJobResponse, error) {
+	out := new(StartJobResponse)
+	err := c.cc.Invoke(ctx, "/jobscheduler.v1.JobSchedulerService/StartJob", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *jobSchedulerServiceClient) StopJob(ctx context.Context, in *StopJobRequest, opts ...grpc.CallOption) (*StopJobResponse, error) {
+	out := new(StopJobResponse)
+	err := c.cc.Invoke(ctx, "/jobscheduler.v1.JobSchedulerService/StopJob", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *jobSchedulerServiceClient) DeleteJob(ctx context.Context, in *DeleteJobRequest, opts ...grpc.CallOption) (*DeleteJobResponse, error) {
+	out := new(DeleteJobResponse)
+	err := c.cc.Invoke(ctx, "/jobscheduler.v1.JobSchedulerService/DeleteJob", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *jobSchedulerServiceClient) StreamEvents(ctx context.Context, in *StreamEventsRequest, opts ...grpc.CallOption) (JobSchedulerService_StreamEventsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &JobSchedulerService_ServiceDesc.Streams[0], "/jobscheduler.v1.JobSchedulerService/StreamEvents", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &jobSchedulerServiceStreamEventsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type JobSchedulerService_StreamEventsClient interface {
+	Recv() (*Event, error)
+	grpc.ClientStream
+}
+
+type jobSchedulerServiceStreamEventsClient struct {
+	grpc.ClientStream
+}
+
+func (x *jobSchedulerServiceStreamEventsClient) Recv() (*Event, error) {
+	m := new(Event)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// JobSchedulerServiceServer is the server API for JobSchedulerService service.
+// All implementations must embed UnimplementedJobSchedulerServiceServer
+// for forward compatibility
+type JobSchedulerServiceServer interface {
+	// ListAvailableJobs lists the cronjobs found in the GitHub locations
+	ListAvailableJobs(context.Context, *ListAvailableJobsRequest) (*ListAvailableJobsResponse, error)
+	// GetJobConfig returns the manifest of a cronjob
+	GetJobConfig(context.Context, *GetJobConfigRequest) (*GetJobConfigResponse, error)
+	// ListRunningJobs lists the cronjobs running in the cluster
+	ListRunningJobs(context.Context, *ListRunningJobsRequest) (*ListRunningJobsResponse, error)
+	// StartJob creates or resumes a cronjob
+	StartJob(context.Context, *StartJobRequest) (*StartJobResponse, error)
+	// StopJob suspends a cronjob
+	StopJob(context.Context, *StopJobRequest) (*StopJobResponse, error)
+	// DeleteJob deletes a cronjob and the jobs it spawned
+	DeleteJob(context.Context, *DeleteJobRequest) (*DeleteJobResponse, error)
+	// StreamEvents streams job events until the client cancels
+	StreamEvents(*StreamEventsRequest, JobSchedulerService_StreamEventsServer) error
+	mustEmbedUnimplementedJobSchedulerServiceServer()
+}
+
+// UnimplementedJobSchedulerServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedJobSchedulerServiceServer struct {
+}
+
+func (UnimplementedJobSchedulerServiceServer) ListAvailableJobs(context.Context, *ListAvailableJobsRequest) (*ListAvailableJobsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListAvailableJobs not implemented")
+}
+func (UnimplementedJobSchedulerServiceServer) GetJobConfig(context.Context, *GetJobConfigRequest) (*GetJobConfigResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetJobConfig not implemented")
+}
+func (UnimplementedJobSchedulerServiceServer) ListRunningJobs(context.Context, *ListRunningJobsRequest) (*ListRunningJobsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListRunningJobs not implemented")
+}
+func (UnimplementedJobSchedulerServiceServer) StartJob(context.Context, *StartJobRequest) (*StartJobResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method StartJob not implemented")
+}
+func (UnimplementedJobSchedulerServiceServer) StopJob(context.Context, *StopJobRequest) (*StopJobResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method StopJob not implemented")
+}
+func (UnimplementedJobSchedulerServiceServer) DeleteJob(context.Context, *DeleteJobRequest) (*DeleteJobResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteJob not implemented")
+}
+func (UnimplementedJobSchedulerServiceServer) StreamEvents(*StreamEventsRequest, JobSchedulerService_StreamEventsServer) error {
+	return status.Errorf(codes.Unimplemented, "method StreamEvents not implemented")
+}
+func (UnimplementedJobSchedulerServiceServer) mustEmbedUnimplementedJobSchedulerServiceServer() {}
+
+// UnsafeJobSchedulerServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to JobSchedulerServiceServer will
+// result in compilation errors.
+type UnsafeJobSchedulerServiceServer interface {
+	mustEmbedUnimplementedJobSchedulerServiceServer()
+}
+
+func RegisterJobSchedulerServiceServer(s grpc.ServiceRegistrar, srv JobSchedulerServiceServer) {
+	s.RegisterService(&JobSchedulerService_ServiceDesc, srv)
+}
+
+func _JobSchedulerService_ListAvailableJobs_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListAvailableJobsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(JobSchedulerServiceServer).ListAvailableJobs(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/jobscheduler.v1.JobSchedulerService/ListAvailableJobs",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(JobSchedulerServiceServer).ListAvailableJobs(ctx, req.(*ListAvailableJobsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _JobSchedulerService_GetJobConfig_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetJobConfigRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(JobSchedulerServiceServer).GetJobConfig(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/jobscheduler.v1.JobSchedulerService/GetJobConfig",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(JobSchedulerServiceServer).GetJobConfig(ctx, req.(*GetJobConfigRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _JobSchedulerService_ListRunningJobs_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListRunningJobsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(JobSchedulerServiceServer).ListRunningJobs(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/jobscheduler.v1.JobSchedulerService/ListRunningJobs",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(JobSchedulerServiceServer).ListRunningJobs(ctx, req.(*ListRunningJobsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _JobSchedulerService_StartJob_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(StartJobRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(JobSchedulerServiceServer).StartJob(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/jobscheduler.v1.JobSchedulerService/StartJob",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(JobSchedulerServiceServer).StartJob(ctx, req.(*StartJobRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _JobSchedulerService_StopJob_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(StopJobRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(JobSchedulerServiceServer).StopJob(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/jobscheduler.v1.JobSchedulerService/StopJob",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(JobSchedulerServiceServer).StopJob(ctx, req.(*StopJobRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _JobSchedulerService_DeleteJob_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteJobRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(JobSchedulerServiceServer).DeleteJob(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/jobscheduler.v1.JobSchedulerService/DeleteJob",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(JobSchedulerServiceServer).DeleteJob(ctx, req.(*DeleteJobRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _JobSchedulerService_StreamEvents_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StreamEventsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(JobSchedulerServiceServer).StreamEvents(m, &jobSchedulerServiceStreamEventsServer{stream})
+}
+
+type JobSchedulerService_StreamEventsServer interface {
+	Send(*Event) error
+	grpc.ServerStream
+}
+
+type jobSchedulerServiceStreamEventsServer struct {
+	grpc.ServerStream
+}
+
+func (x *jobSchedulerServiceStreamEventsServer) Send(m *Event) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+// JobSchedulerService_ServiceDesc is the grpc.ServiceDesc for JobSchedulerService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var JobSchedulerService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "jobscheduler.v1.JobSchedulerService",
+	HandlerType: (*JobSchedulerServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListAvailableJobs",
+			Handler:    _JobSchedulerService_ListAvailableJobs_Handler,
+		},
+		{
+			MethodName: "GetJobConfig",
+			Handler:    _JobSchedulerService_GetJobConfig_Handler,
+		},
+		{
+			MethodName: "ListRunningJobs",
+			Handler:    _JobSchedulerService_ListRunningJobs_Handler,
+		},
+		{
+			MethodName: "StartJob",
+			Handler:    _JobSchedulerService_StartJob_Handler,
+		},
+		{
+			MethodName: "StopJob",
+			Handler:    _JobSchedulerService_StopJob_Handler,
+		},
+		{
+			MethodName: "DeleteJob",
+			Handler:    _JobSchedulerService_DeleteJob_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamEvents",
+			Handler:       _JobSchedulerService_StreamEvents_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "jobscheduler/v1/job_scheduler.proto",
+}
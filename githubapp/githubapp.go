@@ -0,0 +1,301 @@
+// Package githubapp authenticates requests to the GitHub API as a
+// GitHub App, with an installation token for the owner of the
+// repository each request is about
+package githubapp
+
+import (
+	"context"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/go-github/v48/github"
+	"golang.org/x/sync/singleflight"
+	"gopkg.in/square/go-jose.v2"
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+const (
+	// the app JWT may be valid for at most 10 minutes
+	jwtLifetime = 9 * time.Minute
+	// backdated against clock skew, as GitHub recommends
+	jwtSkew = time.Minute
+	// installation tokens expire after an hour and are replaced this
+	// long before they do
+	refreshBefore = 5 * time.Minute
+)
+
+type installationToken struct {
+	token     string
+	expiresAt time.Time
+}
+
+// Transport adds the installation token of the owner to the requests
+// for /repos/{owner}/... on the API. Other requests, e.g. for the
+// signed archive links, are sent as they are
+type Transport struct {
+	base    http.RoundTripper
+	baseURL *url.URL
+	// apps the client authenticated as the app itself
+	apps *github.Client
+
+	mu sync.Mutex
+	// installations the installation ids by lowercase owner
+	installations map[string]int64
+	tokens        map[string]installationToken
+	// refreshes creates one token at a time for each owner
+	refreshes singleflight.Group
+}
+
+// NewTransport authenticates as the app with the PEM encoded private
+// key. baseURL is the API of GitHub Enterprise Server, e.g.
+// https://github.example.com/api/v3/, or that of github.com if it is
+// empty, and uploadURL its upload API. Requests are sent with base,
+// http.DefaultTransport if nil
+func NewTransport(
+	appID int64,
+	privateKey []byte,
+	baseURL string,
+	uploadURL string,
+	base http.RoundTripper,
+) (*Transport, error) {
+	key, err := parsePrivateKey(privateKey)
+	if err != nil {
+		return nil, err
+	}
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	signer, err := jose.NewSigner(
+		jose.SigningKey{Algorithm: jose.RS256, Key: key},
+		(&jose.SignerOptions{}).WithType("JWT"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	apps := github.NewClient(&http.Client{
+		Transport: &appTransport{
+			appID:  appID,
+			signer: signer,
+			base:   base,
+		},
+	})
+	if baseURL != "" {
+		apps, err = github.NewEnterpriseClient(baseURL, uploadURL, apps.Client())
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &Transport{
+		base:          base,
+		baseURL:       apps.BaseURL,
+		apps:          apps,
+		installations: make(map[string]int64),
+		tokens:        make(map[string]installationToken),
+	}, nil
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	owner, ok := t.owner(req.URL)
+	if !ok {
+		return t.base.RoundTrip(req)
+	}
+	token, err := t.token(req.Context(), owner)
+	if err != nil {
+		return nil, err
+	}
+
+	// a RoundTripper must not modify the request
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "token "+token)
+	res, err := t.base.RoundTrip(req)
+	if err == nil && res.StatusCode == http.StatusUnauthorized {
+		// revoked, the next request gets a new one unless another
+		// request replaced it already
+		t.mu.Lock()
+		if t.tokens[owner].token == token {
+			delete(t.tokens, owner)
+		}
+		t.mu.Unlock()
+	}
+
+	return res, err
+}
+
+// owner the lowercase owner of the repository the request is about
+func (t *Transport) owner(u *url.URL) (string, bool) {
+	if u.Host != t.baseURL.Host {
+		return "", false
+	}
+	rest := strings.TrimPrefix(u.Path, t.baseURL.Path+"repos/")
+	if rest == u.Path {
+		return "", false
+	}
+	owner, _, _ := strings.Cut(rest, "/")
+	if owner == "" {
+		return "", false
+	}
+
+	return strings.ToLower(owner), true
+}
+
+// token the installation token of the owner, a new one if the cached
+// one is about to expire. Concurrent requests for the same owner wait
+// for a single new token
+func (t *Transport) token(ctx context.Context, owner string) (string, error) {
+	if token, ok := t.cachedToken(owner); ok {
+		return token, nil
+	}
+
+	token, err, _ := t.refreshes.Do(owner, func() (interface{}, error) {
+		// created by the previous refresh while this one waited
+		if token, ok := t.cachedToken(owner); ok {
+			return token, nil
+		}
+
+		return t.createToken(ctx, owner)
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return token.(string), nil
+}
+
+// cachedToken the token of the owner unless it is about to expire
+func (t *Transport) cachedToken(owner string) (string, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	cached, ok := t.tokens[owner]
+	if !ok || time.Until(cached.expiresAt) <= refreshBefore {
+		return "", false
+	}
+
+	return cached.token, true
+}
+
+func (t *Transport) createToken(ctx context.Context, owner string) (string, error) {
+	id, err := t.installation(ctx, owner)
+	if err != nil {
+		return "", err
+	}
+	res, _, err := t.apps.Apps.CreateInstallationToken(ctx, id, nil)
+	var errRes *github.ErrorResponse
+	if errors.As(err, &errRes) && errRes.Response != nil &&
+		errRes.Response.StatusCode == http.StatusNotFound {
+		// uninstalled, or installed again with a new id which is
+		// looked up by the next request
+		t.mu.Lock()
+		delete(t.installations, owner)
+		t.mu.Unlock()
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to create installation token for %s: %w", owner, err)
+	}
+
+	token := installationToken{
+		token:     res.GetToken(),
+		expiresAt: res.GetExpiresAt(),
+	}
+	t.mu.Lock()
+	t.tokens[owner] = token
+	t.mu.Unlock()
+
+	return token.token, nil
+}
+
+// installation the id of the installation of the app for the owner.
+// The installations are listed again when the owner is not known, in
+// case the app was installed since
+func (t *Transport) installation(ctx context.Context, owner string) (int64, error) {
+	t.mu.Lock()
+	id, ok := t.installations[owner]
+	t.mu.Unlock()
+	if ok {
+		return id, nil
+	}
+
+	installations := make(map[string]int64)
+	opts := &github.ListOptions{PerPage: 100}
+	for {
+		page, res, err := t.apps.Apps.ListInstallations(ctx, opts)
+		if err != nil {
+			return 0, fmt.Errorf("failed to list installations: %w", err)
+		}
+		for _, i := range page {
+			installations[strings.ToLower(i.GetAccount().GetLogin())] = i.GetID()
+		}
+		if res.NextPage == 0 {
+			break
+		}
+		opts.Page = res.NextPage
+	}
+
+	t.mu.Lock()
+	t.installations = installations
+	t.mu.Unlock()
+	id, ok = installations[owner]
+	if !ok {
+		return 0, fmt.Errorf("the GitHub App is not installed for %s", owner)
+	}
+
+	return id, nil
+}
+
+// appTransport authenticates as the app with a short-lived JWT, as
+// required by the /app endpoints
+type appTransport struct {
+	appID  int64
+	signer jose.Signer
+	base   http.RoundTripper
+}
+
+func (t *appTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	now := time.Now()
+	token, err := jwt.Signed(t.signer).Claims(jwt.Claims{
+		Issuer:   strconv.FormatInt(t.appID, 10),
+		IssuedAt: jwt.NewNumericDate(now.Add(-jwtSkew)),
+		Expiry:   jwt.NewNumericDate(now.Add(jwtLifetime)),
+	}).CompactSerialize()
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign app token: %w", err)
+	}
+
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	return t.base.RoundTrip(req)
+}
+
+// parsePrivateKey reads the PKCS #1 key GitHub generates, or a
+// PKCS #8 one
+func parsePrivateKey(b []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(b)
+	if block == nil {
+		return nil, fmt.Errorf("the private key is not PEM encoded")
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the private key: %w", err)
+	}
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("the private key is not an RSA key")
+	}
+
+	return rsaKey, nil
+}
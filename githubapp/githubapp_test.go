@@ -0,0 +1,403 @@
+package githubapp
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+const appID = 42
+
+// fakeGitHub emulates the endpoints of the GitHub Enterprise API the
+// transport calls
+type fakeGitHub struct {
+	t   *testing.T
+	key *rsa.PrivateKey
+	// pages the installations by owner login, one map per page
+	pages []map[string]int64
+	// tokenTTL how long the created tokens are valid for
+	tokenTTL time.Duration
+	// tokenStatus if set, the status of creating tokens
+	tokenStatus int
+	// repoStatus if set, the status of the next /repos request
+	repoStatus int
+
+	mu            sync.Mutex
+	listed        []int
+	created       map[int64]int
+	authorization []string
+	claims        []jwt.Claims
+}
+
+func (f *fakeGitHub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, "/api/v3")
+	switch {
+	case path == "/app/installations":
+		f.checkJWT(r)
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		if page == 0 {
+			page = 1
+		}
+		f.mu.Lock()
+		f.listed = append(f.listed, page)
+		f.mu.Unlock()
+		if page < len(f.pages) {
+			next := *r.URL
+			q := next.Query()
+			q.Set("page", strconv.Itoa(page+1))
+			next.RawQuery = q.Encode()
+			next.Scheme = "http"
+			next.Host = r.Host
+			w.Header().Set("Link", fmt.Sprintf(`<%s>; rel="next"`, next.String()))
+		}
+		installations := []map[string]interface{}{}
+		for login, id := range f.pages[page-1] {
+			installations = append(installations, map[string]interface{}{
+				"id":      id,
+				"account": map[string]string{"login": login},
+			})
+		}
+		writeJSON(w, http.StatusOK, installations)
+	case strings.HasPrefix(path, "/app/installations/") && strings.HasSuffix(path, "/access_tokens"):
+		f.checkJWT(r)
+		id, _ := strconv.ParseInt(strings.Split(path, "/")[3], 10, 64)
+		f.mu.Lock()
+		f.created[id]++
+		n := f.created[id]
+		status := f.tokenStatus
+		f.mu.Unlock()
+		// slow enough for concurrent requests to overlap
+		time.Sleep(20 * time.Millisecond)
+		if status != 0 {
+			writeJSON(w, status, map[string]string{"message": http.StatusText(status)})
+			return
+		}
+		writeJSON(w, http.StatusCreated, map[string]interface{}{
+			"token":      fmt.Sprintf("token-%d-%d", id, n),
+			"expires_at": time.Now().Add(f.tokenTTL).UTC().Format(time.RFC3339),
+		})
+	case strings.HasPrefix(path, "/repos/"):
+		f.mu.Lock()
+		f.authorization = append(f.authorization, r.Header.Get("Authorization"))
+		status := f.repoStatus
+		f.repoStatus = 0
+		f.mu.Unlock()
+		if status != 0 {
+			w.WriteHeader(status)
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]string{})
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// checkJWT verifies the app JWT of the request and records its claims
+func (f *fakeGitHub) checkJWT(r *http.Request) {
+	raw := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	token, err := jwt.ParseSigned(raw)
+	if err != nil {
+		f.t.Errorf("invalid app JWT: %s", err)
+		return
+	}
+	if alg := token.Headers[0].Algorithm; alg != "RS256" {
+		f.t.Errorf("app JWT signed with %s, want RS256", alg)
+	}
+	var claims jwt.Claims
+	if err := token.Claims(&f.key.PublicKey, &claims); err != nil {
+		f.t.Errorf("app JWT not signed by the app key: %s", err)
+		return
+	}
+	f.mu.Lock()
+	f.claims = append(f.claims, claims)
+	f.mu.Unlock()
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+var (
+	keyOnce sync.Once
+	testKey *rsa.PrivateKey
+)
+
+func privateKey(t *testing.T) *rsa.PrivateKey {
+	keyOnce.Do(func() {
+		key, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			t.Fatalf("failed to generate key: %s", err)
+		}
+		testKey = key
+	})
+
+	return testKey
+}
+
+// newFake starts the fake API and a transport for it
+func newFake(t *testing.T, pages ...map[string]int64) (*fakeGitHub, *Transport, string) {
+	t.Helper()
+	f := &fakeGitHub{
+		t:        t,
+		key:      privateKey(t),
+		pages:    pages,
+		tokenTTL: time.Hour,
+		created:  make(map[int64]int),
+	}
+	srv := httptest.NewServer(f)
+	t.Cleanup(srv.Close)
+
+	pemKey := pem.EncodeToMemory(&pem.Block{
+		Type:  "RSA PRIVATE KEY",
+		Bytes: x509.MarshalPKCS1PrivateKey(f.key),
+	})
+	tr, err := NewTransport(appID, pemKey, srv.URL+"/api/v3/", srv.URL+"/api/uploads/", nil)
+	if err != nil {
+		t.Fatalf("NewTransport: %s", err)
+	}
+
+	return f, tr, srv.URL + "/api/v3/"
+}
+
+// get requests the repository with the transport
+func get(t *testing.T, tr *Transport, apiURL string, repo string) int {
+	t.Helper()
+	res, err := (&http.Client{Transport: tr}).Get(apiURL + "repos/" + repo)
+	if err != nil {
+		t.Fatalf("GET %s: %s", repo, err)
+	}
+	res.Body.Close()
+
+	return res.StatusCode
+}
+
+func (f *fakeGitHub) lastAuthorization() string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.authorization[len(f.authorization)-1]
+}
+
+func TestAppJWTClaims(t *testing.T) {
+	f, tr, apiURL := newFake(t, map[string]int64{"org": 1})
+
+	before := time.Now()
+	get(t, tr, apiURL, "org/repo")
+
+	if len(f.claims) == 0 {
+		t.Fatal("the app endpoints were not called")
+	}
+	for _, c := range f.claims {
+		if c.Issuer != strconv.Itoa(appID) {
+			t.Errorf("iss = %q, want %d", c.Issuer, appID)
+		}
+		iat := c.IssuedAt.Time()
+		if iat.After(before.Add(-jwtSkew + time.Second)) {
+			t.Errorf("iat %s is not backdated", iat)
+		}
+		exp := c.Expiry.Time()
+		if lifetime := exp.Sub(iat); lifetime > 10*time.Minute {
+			t.Errorf("the JWT is valid for %s, GitHub allows 10m", lifetime)
+		}
+		if exp.Before(time.Now()) {
+			t.Errorf("the JWT expired at %s", exp)
+		}
+	}
+}
+
+func TestInstallationsArePaged(t *testing.T) {
+	f, tr, apiURL := newFake(
+		t,
+		map[string]int64{"first": 1},
+		map[string]int64{"Second": 2},
+	)
+
+	if status := get(t, tr, apiURL, "second/repo"); status != http.StatusOK {
+		t.Fatalf("status = %d", status)
+	}
+	if got := f.lastAuthorization(); got != "token token-2-1" {
+		t.Errorf("Authorization = %q, want the token of the second page", got)
+	}
+	if len(f.listed) != 2 {
+		t.Errorf("listed pages %v, want both", f.listed)
+	}
+}
+
+func TestUnknownOwner(t *testing.T) {
+	_, tr, apiURL := newFake(t, map[string]int64{"org": 1})
+
+	_, err := (&http.Client{Transport: tr}).Get(apiURL + "repos/other/repo")
+	if err == nil || !strings.Contains(err.Error(), "not installed for other") {
+		t.Errorf("err = %v, want the app not being installed", err)
+	}
+}
+
+func TestTokensAreCachedPerOwner(t *testing.T) {
+	f, tr, apiURL := newFake(t, map[string]int64{"a": 1, "b": 2})
+
+	get(t, tr, apiURL, "a/one")
+	get(t, tr, apiURL, "A/two")
+	get(t, tr, apiURL, "b/one")
+	if got := f.lastAuthorization(); got != "token token-2-1" {
+		t.Errorf("Authorization = %q, want the token of b", got)
+	}
+	if f.created[1] != 1 || f.created[2] != 1 {
+		t.Errorf("created tokens %v, want one per owner", f.created)
+	}
+}
+
+func TestTokensAreRefreshedBeforeTheyExpire(t *testing.T) {
+	f, tr, apiURL := newFake(t, map[string]int64{"org": 1})
+	f.tokenTTL = refreshBefore - time.Minute
+
+	get(t, tr, apiURL, "org/repo")
+	get(t, tr, apiURL, "org/repo")
+	if f.created[1] != 2 {
+		t.Errorf("created %d tokens, want a new one for every request", f.created[1])
+	}
+	if got := f.lastAuthorization(); got != "token token-1-2" {
+		t.Errorf("Authorization = %q, want the refreshed token", got)
+	}
+}
+
+func TestConcurrentRequestsShareARefresh(t *testing.T) {
+	f, tr, apiURL := newFake(t, map[string]int64{"org": 1})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			get(t, tr, apiURL, "org/repo")
+		}()
+	}
+	wg.Wait()
+
+	if f.created[1] != 1 {
+		t.Errorf("created %d tokens, want 1", f.created[1])
+	}
+}
+
+func TestTokenIsDroppedOnUnauthorized(t *testing.T) {
+	f, tr, apiURL := newFake(t, map[string]int64{"org": 1})
+
+	get(t, tr, apiURL, "org/repo")
+	f.repoStatus = http.StatusUnauthorized
+	if status := get(t, tr, apiURL, "org/repo"); status != http.StatusUnauthorized {
+		t.Fatalf("status = %d, want 401", status)
+	}
+	get(t, tr, apiURL, "org/repo")
+
+	if f.created[1] != 2 {
+		t.Errorf("created %d tokens, want a new one after the 401", f.created[1])
+	}
+	if got := f.lastAuthorization(); got != "token token-1-2" {
+		t.Errorf("Authorization = %q, want the new token", got)
+	}
+}
+
+func TestInstallationIsDroppedOnNotFound(t *testing.T) {
+	f, tr, apiURL := newFake(t, map[string]int64{"org": 1})
+
+	f.tokenStatus = http.StatusNotFound
+	if _, err := (&http.Client{Transport: tr}).Get(apiURL + "repos/org/repo"); err == nil {
+		t.Fatal("want an error when the token can't be created")
+	}
+	// installed again with a new id
+	f.tokenStatus = 0
+	f.pages = []map[string]int64{{"org": 7}}
+	if status := get(t, tr, apiURL, "org/repo"); status != http.StatusOK {
+		t.Fatalf("status = %d", status)
+	}
+
+	if got := f.lastAuthorization(); got != "token token-7-1" {
+		t.Errorf("Authorization = %q, want the token of the new installation", got)
+	}
+	if len(f.listed) != 2 {
+		t.Errorf("listed installations %d times, want 2", len(f.listed))
+	}
+}
+
+func TestOwner(t *testing.T) {
+	tests := []struct {
+		name    string
+		baseURL string
+		url     string
+		owner   string
+		ok      bool
+	}{
+		{
+			name:  "github.com",
+			url:   "https://api.github.com/repos/Org/repo/contents/x",
+			owner: "org",
+			ok:    true,
+		},
+		{
+			name: "github.com other endpoint",
+			url:  "https://api.github.com/app/installations",
+		},
+		{
+			name: "archive link",
+			url:  "https://codeload.github.com/org/repo/legacy.tar.gz/main",
+		},
+		{
+			name:    "enterprise",
+			baseURL: "https://github.example.com/api/v3/",
+			url:     "https://github.example.com/api/v3/repos/org/repo",
+			owner:   "org",
+			ok:      true,
+		},
+		{
+			name:    "enterprise without the api prefix",
+			baseURL: "https://github.example.com/api/v3/",
+			url:     "https://github.example.com/repos/org/repo",
+		},
+		{
+			name:    "github.com with an enterprise base URL",
+			baseURL: "https://github.example.com/api/v3/",
+			url:     "https://api.github.com/repos/org/repo",
+		},
+		{
+			name:    "enterprise without an owner",
+			baseURL: "https://github.example.com/api/v3/",
+			url:     "https://github.example.com/api/v3/repos/",
+		},
+	}
+
+	pemKey := pem.EncodeToMemory(&pem.Block{
+		Type:  "RSA PRIVATE KEY",
+		Bytes: x509.MarshalPKCS1PrivateKey(privateKey(t)),
+	})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tr, err := NewTransport(appID, pemKey, tt.baseURL, "", nil)
+			if err != nil {
+				t.Fatalf("NewTransport: %s", err)
+			}
+			u, err := url.Parse(tt.url)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			owner, ok := tr.owner(u)
+			if owner != tt.owner || ok != tt.ok {
+				t.Errorf("owner(%s) = %q, %t, want %q, %t", tt.url, owner, ok, tt.owner, tt.ok)
+			}
+		})
+	}
+}
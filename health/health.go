@@ -0,0 +1,150 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/fx"
+)
+
+const checkTimeout = time.Second * 2
+
+const (
+	StatusOK          = "ok"
+	StatusUnavailable = "unavailable"
+)
+
+// CheckFunc reports whether a dependency of the service is usable
+type CheckFunc func(ctx context.Context) error
+
+type CheckResult struct {
+	Status   string `json:"status"`
+	Error    string `json:"error,omitempty"`
+	Duration string `json:"duration"`
+}
+
+// Report the readiness of the service with a breakdown per dependency
+type Report struct {
+	Status string                 `json:"status"`
+	Checks map[string]CheckResult `json:"checks"`
+}
+
+type namedCheck struct {
+	name  string
+	check CheckFunc
+}
+
+// Checker runs the readiness checks registered by the other
+// components of the service
+type Checker struct {
+	mu           sync.RWMutex
+	checks       []namedCheck
+	started      bool
+	shuttingDown bool
+}
+
+func ProvideChecker() *Checker {
+	return &Checker{}
+}
+
+// Register adds a check that has to pass for the service to be ready
+func (c *Checker) Register(
+	name string,
+	check CheckFunc,
+) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.checks = append(c.checks, namedCheck{
+		name:  name,
+		check: check,
+	})
+}
+
+// RegisterLifecycle marks the service as started once every other
+// OnStart hook has run and as shutting down before any OnStop hook
+// runs. It has to be invoked after everything else is constructed
+func RegisterLifecycle(
+	lc fx.Lifecycle,
+	c *Checker,
+) {
+	lc.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			c.mu.Lock()
+			defer c.mu.Unlock()
+			c.started = true
+
+			return nil
+		},
+
+		OnStop: func(ctx context.Context) error {
+			c.mu.Lock()
+			defer c.mu.Unlock()
+			c.shuttingDown = true
+
+			return nil
+		},
+	})
+}
+
+func (c *Checker) lifecycleCheck(ctx context.Context) error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if c.shuttingDown {
+		return fmt.Errorf("shutting down")
+	}
+	if !c.started {
+		return fmt.Errorf("starting up")
+	}
+
+	return nil
+}
+
+// Readiness runs every check concurrently
+func (c *Checker) Readiness(ctx context.Context) Report {
+	c.mu.RLock()
+	checks := append([]namedCheck{{
+		name:  "lifecycle",
+		check: c.lifecycleCheck,
+	}}, c.checks...)
+	c.mu.RUnlock()
+
+	report := Report{
+		Status: StatusOK,
+		Checks: make(map[string]CheckResult, len(checks)),
+	}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, nc := range checks {
+		wg.Add(1)
+		go func(nc namedCheck) {
+			defer wg.Done()
+			checkCtx, cancel := context.WithTimeout(ctx, checkTimeout)
+			defer cancel()
+
+			start := time.Now()
+			err := nc.check(checkCtx)
+			res := CheckResult{
+				Status:   StatusOK,
+				Duration: time.Since(start).String(),
+			}
+			if err != nil {
+				res.Status = StatusUnavailable
+				res.Error = err.Error()
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			report.Checks[nc.name] = res
+			if err != nil {
+				report.Status = StatusUnavailable
+			}
+		}(nc)
+	}
+	wg.Wait()
+
+	return report
+}
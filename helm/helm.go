@@ -0,0 +1,134 @@
+// Package helm renders charts in-process with the template engine of
+// Helm, without a cluster or a release
+package helm
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/engine"
+	"sigs.k8s.io/kustomize/kyaml/filesys"
+)
+
+// Release the name and namespace the chart is rendered for, under
+// .Release
+type Release struct {
+	Name      string
+	Namespace string
+}
+
+// Render renders the chart at dir with the values file, the values of
+// the chart if valuesFile is empty, and returns the manifests as a
+// YAML stream. The release is named after the chart if release.Name
+// is empty. Dependencies have to be vendored under charts/ since
+// nothing is downloaded
+func Render(
+	fSys filesys.FileSystem,
+	dir string,
+	valuesFile string,
+	release Release,
+) ([]byte, error) {
+	c, err := load(fSys, dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load chart: %w", err)
+	}
+
+	values := chartutil.Values{}
+	if valuesFile != "" {
+		b, err := fSys.ReadFile(valuesFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read values file: %w", err)
+		}
+		if values, err = chartutil.ReadValues(b); err != nil {
+			return nil, fmt.Errorf("failed to parse values file: %w", err)
+		}
+	}
+	if err := chartutil.ProcessDependencies(c, values); err != nil {
+		return nil, fmt.Errorf("failed to process dependencies: %w", err)
+	}
+
+	if release.Name == "" {
+		release.Name = c.Name()
+	}
+	renderValues, err := chartutil.ToRenderValues(
+		c,
+		values,
+		chartutil.ReleaseOptions{
+			Name:      release.Name,
+			Namespace: release.Namespace,
+			Revision:  1,
+			IsInstall: true,
+		},
+		chartutil.DefaultCapabilities,
+	)
+	if err != nil {
+		return nil, err
+	}
+	rendered, err := engine.Render(c, renderValues)
+	if err != nil {
+		return nil, err
+	}
+
+	return join(rendered), nil
+}
+
+// load reads the files of the chart at dir
+func load(fSys filesys.FileSystem, dir string) (*chart.Chart, error) {
+	root := path.Clean(dir)
+	files := []*loader.BufferedFile{}
+	err := fSys.Walk(dir, func(p string, info fs.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		b, err := fSys.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		name := p
+		if root != "." {
+			name = strings.TrimPrefix(p, root+"/")
+		}
+		files = append(files, &loader.BufferedFile{
+			Name: name,
+			Data: b,
+		})
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return loader.LoadFiles(files)
+}
+
+// join the rendered templates ordered by name, leaving out the notes
+// and the templates that render to nothing
+func join(rendered map[string]string) []byte {
+	names := make([]string, 0, len(rendered))
+	for name := range rendered {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b bytes.Buffer
+	for _, name := range names {
+		content := strings.TrimSpace(rendered[name])
+		if content == "" || path.Base(name) == "NOTES.txt" {
+			continue
+		}
+		fmt.Fprintf(&b, "---\n# Source: %s\n%s\n", name, content)
+	}
+
+	return b.Bytes()
+}
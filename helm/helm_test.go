@@ -0,0 +1,141 @@
+package helm
+
+import (
+	"strings"
+	"testing"
+
+	"sigs.k8s.io/kustomize/kyaml/filesys"
+	"sigs.k8s.io/yaml"
+)
+
+// rendered a document of the output of Render
+type rendered struct {
+	source string
+	object map[string]interface{}
+}
+
+// documents splits the output of Render into its documents
+func documents(t *testing.T, out []byte) []rendered {
+	t.Helper()
+	docs := []rendered{}
+	for _, doc := range strings.Split(string(out), "---\n")[1:] {
+		source, body, ok := strings.Cut(doc, "\n")
+		if !ok || !strings.HasPrefix(source, "# Source: ") {
+			t.Fatalf("document without its source:\n%s", doc)
+		}
+		object := map[string]interface{}{}
+		if err := yaml.Unmarshal([]byte(body), &object); err != nil {
+			t.Fatal(err)
+		}
+		docs = append(docs, rendered{
+			source: strings.TrimPrefix(source, "# Source: "),
+			object: object,
+		})
+	}
+
+	return docs
+}
+
+func field(object map[string]interface{}, fields ...string) interface{} {
+	var v interface{} = object
+	for _, f := range fields {
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		v = m[f]
+	}
+
+	return v
+}
+
+func TestRender(t *testing.T) {
+	fSys := filesys.MakeFsOnDisk()
+
+	t.Run("values of the chart", func(t *testing.T) {
+		out, err := Render(fSys, "testdata/backup", "", Release{Namespace: "jobs"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		docs := documents(t, out)
+		// no settings, the metrics subchart is disabled and the notes
+		// are left out
+		if len(docs) != 1 || docs[0].source != "backup/templates/cronjob.yaml" {
+			t.Fatalf("rendered %v, want only the cronjob:\n%s", docs, out)
+		}
+		cj := docs[0].object
+		for _, tt := range []struct {
+			fields []string
+			want   interface{}
+		}{
+			{[]string{"metadata", "name"}, "backup-backup"},
+			{[]string{"metadata", "namespace"}, "jobs"},
+			{[]string{"metadata", "labels", "chart"}, "backup-0.1.0"},
+			{[]string{"spec", "schedule"}, "0 3 * * *"},
+		} {
+			if got := field(cj, tt.fields...); got != tt.want {
+				t.Errorf("%s = %v, want %v", strings.Join(tt.fields, "."), got, tt.want)
+			}
+		}
+	})
+
+	t.Run("values file", func(t *testing.T) {
+		out, err := Render(
+			fSys,
+			"testdata/backup",
+			"testdata/prod-values.yaml",
+			Release{Name: "nightly", Namespace: "jobs"},
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+		docs := documents(t, out)
+		sources := []string{}
+		for _, d := range docs {
+			sources = append(sources, d.source)
+		}
+		want := []string{
+			"backup/charts/metrics/templates/service.yaml",
+			"backup/templates/configmap.yaml",
+			"backup/templates/cronjob.yaml",
+		}
+		if strings.Join(sources, ",") != strings.Join(want, ",") {
+			t.Fatalf("rendered %v, want %v", sources, want)
+		}
+		if got := field(docs[0].object, "metadata", "name"); got != "nightly-metrics" {
+			t.Errorf("service = %v, want the one of the release", got)
+		}
+		if got := field(docs[1].object, "data", "mode"); got != "nightly" {
+			t.Errorf("configmap data.mode = %v, want the one of the values file", got)
+		}
+		cj := docs[2].object
+		if got := field(cj, "metadata", "name"); got != "nightly-backup" {
+			t.Errorf("name = %v, want the one of the release", got)
+		}
+		if got := field(cj, "spec", "schedule"); got != "0 1 * * *" {
+			t.Errorf("schedule = %v, want the one of the values file", got)
+		}
+		containers := field(cj, "spec", "jobTemplate", "spec", "template", "spec", "containers").([]interface{})
+		if image := field(containers[0].(map[string]interface{}), "image"); image != "ghcr.io/acme/backup:2.0" {
+			t.Errorf("image = %v, want the tag of the values file", image)
+		}
+	})
+
+	for _, tt := range []struct {
+		name       string
+		dir        string
+		valuesFile string
+		wantErr    string
+	}{
+		{name: "missing value", dir: "testdata/broken", wantErr: "name is required"},
+		{name: "missing values file", dir: "testdata/backup", valuesFile: "testdata/missing.yaml", wantErr: "failed to read values file"},
+		{name: "not a chart", dir: "testdata", wantErr: "failed to load chart"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Render(fSys, tt.dir, tt.valuesFile, Release{Namespace: "jobs"})
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %v, want one containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
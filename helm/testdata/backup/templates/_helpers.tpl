@@ -0,0 +1,3 @@
+{{- define "backup.fullname" -}}
+{{ .Release.Name }}-backup
+{{- end -}}
@@ -0,0 +1,20 @@
+// Package kustomize builds kustomizations in-process, for locations
+// holding kustomize bases and overlays instead of plain manifests
+package kustomize
+
+import (
+	"sigs.k8s.io/kustomize/api/krusty"
+	"sigs.k8s.io/kustomize/kyaml/filesys"
+)
+
+// Build runs kustomize build on the kustomization root at dir and
+// returns the resources as a YAML stream
+func Build(fSys filesys.FileSystem, dir string) ([]byte, error) {
+	k := krusty.MakeKustomizer(krusty.MakeDefaultOptions())
+	resources, err := k.Run(fSys, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	return resources.AsYaml()
+}
@@ -0,0 +1,95 @@
+package kustomize
+
+import (
+	"strings"
+	"testing"
+
+	batchv1 "k8s.io/api/batch/v1"
+	"sigs.k8s.io/kustomize/kyaml/filesys"
+	"sigs.k8s.io/yaml"
+)
+
+// documents splits the output of a build into its documents
+func documents(t *testing.T, out []byte) []map[string]interface{} {
+	t.Helper()
+	docs := []map[string]interface{}{}
+	for _, doc := range strings.Split(string(out), "\n---\n") {
+		m := map[string]interface{}{}
+		if err := yaml.Unmarshal([]byte(doc), &m); err != nil {
+			t.Fatal(err)
+		}
+		docs = append(docs, m)
+	}
+
+	return docs
+}
+
+func TestBuild(t *testing.T) {
+	fSys := filesys.MakeFsOnDisk()
+
+	t.Run("base", func(t *testing.T) {
+		out, err := Build(fSys, "testdata/base")
+		if err != nil {
+			t.Fatal(err)
+		}
+		var cj batchv1.CronJob
+		if err := yaml.Unmarshal(out, &cj); err != nil {
+			t.Fatal(err)
+		}
+		if cj.Name != "backup" || cj.Labels["team"] != "a" || cj.Spec.Schedule != "0 3 * * *" {
+			t.Errorf("cronjob = %s %v %s, want the base", cj.Name, cj.Labels, cj.Spec.Schedule)
+		}
+	})
+
+	t.Run("overlay", func(t *testing.T) {
+		out, err := Build(fSys, "testdata/overlays/prod")
+		if err != nil {
+			t.Fatal(err)
+		}
+		byKind := map[string]map[string]interface{}{}
+		for _, doc := range documents(t, out) {
+			byKind[doc["kind"].(string)] = doc
+		}
+		if len(byKind) != 2 || byKind["CronJob"] == nil || byKind["ConfigMap"] == nil {
+			t.Fatalf("built %v, want the cronjob and the configmap:\n%s", byKind, out)
+		}
+		metadata := byKind["ConfigMap"]["metadata"].(map[string]interface{})
+		if metadata["name"] != "prod-settings" {
+			t.Errorf("configmap = %v, want prod-settings", metadata["name"])
+		}
+
+		b, err := yaml.Marshal(byKind["CronJob"])
+		if err != nil {
+			t.Fatal(err)
+		}
+		var cj batchv1.CronJob
+		if err := yaml.Unmarshal(b, &cj); err != nil {
+			t.Fatal(err)
+		}
+		if cj.Name != "prod-backup" {
+			t.Errorf("name = %s, want the prefix of the overlay", cj.Name)
+		}
+		if cj.Labels["team"] != "a" {
+			t.Errorf("labels = %v, want the ones of the base", cj.Labels)
+		}
+		if cj.Spec.Schedule != "0 1 * * *" {
+			t.Errorf("schedule = %s, want the patched one", cj.Spec.Schedule)
+		}
+		if image := cj.Spec.JobTemplate.Spec.Template.Spec.Containers[0].Image; image != "ghcr.io/acme/backup:2.0" {
+			t.Errorf("image = %s, want the tag of the overlay", image)
+		}
+	})
+
+	t.Run("missing resource", func(t *testing.T) {
+		if _, err := Build(fSys, "testdata/overlays/broken"); err == nil ||
+			!strings.Contains(err.Error(), "missing.yaml") {
+			t.Errorf("error = %v, want one about missing.yaml", err)
+		}
+	})
+
+	t.Run("not a kustomization", func(t *testing.T) {
+		if _, err := Build(fSys, "testdata"); err == nil {
+			t.Error("expected an error for a directory without kustomization.yaml")
+		}
+	})
+}
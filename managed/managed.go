@@ -0,0 +1,68 @@
+// Package managed marks the cronjobs created by the scheduler so that
+// they can be told apart from the rest of the cluster
+package managed
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+
+	batchv1 "k8s.io/api/batch/v1"
+)
+
+const (
+	// Label set to ManagedBy on every cronjob the scheduler creates
+	Label     = "app.kubernetes.io/managed-by"
+	ManagedBy = "job-scheduler"
+	// SpecHashAnnotation the hash of the spec the cronjob was last
+	// started with, see SpecHash
+	SpecHashAnnotation = "job-scheduler/spec-hash"
+	// CommitAnnotation the SHA the manifest of the cronjob was read at
+	// when it was last started, read by the schedulers it is promoted
+	// to
+	CommitAnnotation = "job-scheduler/commit"
+)
+
+// SpecHash the hash of the spec of the cronjob. Suspend is left out
+// since it changes when the cronjob is started and stopped
+func SpecHash(cj *batchv1.CronJob) string {
+	spec := cj.Spec.DeepCopy()
+	spec.Suspend = nil
+	b, err := json.Marshal(spec)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(b)
+
+	return hex.EncodeToString(sum[:])
+}
+
+// Mark returns a copy of the cronjob with the managed-by label, the
+// spec hash annotation and the commit annotation if commit is set
+func Mark(cj *batchv1.CronJob, commit string) *batchv1.CronJob {
+	marked := cj.DeepCopy()
+	if marked.Labels == nil {
+		marked.Labels = map[string]string{}
+	}
+	if marked.Annotations == nil {
+		marked.Annotations = map[string]string{}
+	}
+	marked.Labels[Label] = ManagedBy
+	marked.Annotations[SpecHashAnnotation] = SpecHash(cj)
+	if commit != "" {
+		marked.Annotations[CommitAnnotation] = commit
+	}
+
+	return marked
+}
+
+// IsManaged whether the cronjob was created by the scheduler
+func IsManaged(cj *batchv1.CronJob) bool {
+	return cj.Labels[Label] == ManagedBy
+}
+
+// UpToDate whether the cronjob in the cluster was started with the
+// spec of desired
+func UpToDate(live *batchv1.CronJob, desired *batchv1.CronJob) bool {
+	return live.Annotations[SpecHashAnnotation] == SpecHash(desired)
+}
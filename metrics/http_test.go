@@ -0,0 +1,94 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+// requestCounts the number of requests recorded by route, method and
+// status code
+func requestCounts(t *testing.T, m *Metrics) map[string]uint64 {
+	t.Helper()
+	families, err := m.Registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	res := map[string]uint64{}
+	for _, f := range families {
+		if f.GetName() != "job_scheduler_http_request_duration_seconds" {
+			continue
+		}
+		for _, metric := range f.GetMetric() {
+			labels := map[string]string{}
+			for _, l := range metric.GetLabel() {
+				labels[l.GetName()] = l.GetValue()
+			}
+			key := labels["method"] + " " + labels["route"] + " " + labels["code"]
+			res[key] = metric.GetHistogram().GetSampleCount()
+		}
+	}
+
+	return res
+}
+
+func TestMiddlewareLabelsRequestsWithTheirRoute(t *testing.T) {
+	m, err := ProvideMetrics()
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := mux.NewRouter()
+	r.Use(m.Middleware)
+	r.HandleFunc("/api/v1/jobs/{jobName}", func(w http.ResponseWriter, r *http.Request) {
+		if mux.Vars(r)["jobName"] == "missing" {
+			http.Error(w, "not found", http.StatusNotFound)
+		}
+	}).Methods(http.MethodGet, http.MethodDelete)
+	r.HandleFunc("/api/v1/jobs", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	}).Methods(http.MethodPost)
+
+	for _, req := range []struct {
+		method string
+		path   string
+	}{
+		{http.MethodGet, "/api/v1/jobs/backup"},
+		{http.MethodGet, "/api/v1/jobs/report"},
+		{http.MethodGet, "/api/v1/jobs/missing"},
+		{http.MethodDelete, "/api/v1/jobs/backup"},
+		{http.MethodPost, "/api/v1/jobs"},
+	} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(req.method, req.path, nil))
+	}
+
+	// the job names are not labels, the requests of every job share
+	// a series
+	want := map[string]uint64{
+		"GET /api/v1/jobs/{jobName} 200":    2,
+		"GET /api/v1/jobs/{jobName} 404":    1,
+		"DELETE /api/v1/jobs/{jobName} 200": 1,
+		"POST /api/v1/jobs 201":             1,
+	}
+	if got := requestCounts(t, m); !reflect.DeepEqual(got, want) {
+		t.Errorf("requests = %v, want %v", got, want)
+	}
+}
+
+func TestMiddlewareWithoutARoute(t *testing.T) {
+	m, err := ProvideMetrics()
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler := m.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/anything", nil))
+
+	want := map[string]uint64{"GET unknown 418": 1}
+	if got := requestCounts(t, m); !reflect.DeepEqual(got, want) {
+		t.Errorf("requests = %v, want %v", got, want)
+	}
+}
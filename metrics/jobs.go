@@ -0,0 +1,72 @@
+package metrics
+
+import (
+	"context"
+
+	"github.com/panagiotisptr/job-scheduler/events"
+	"go.uber.org/fx"
+)
+
+// RegisterJobMetrics keeps the per job metrics up to date from the
+// events of the jobs spawned by cronjobs
+func RegisterJobMetrics(
+	lc fx.Lifecycle,
+	m *Metrics,
+	bus *events.Bus,
+) {
+	stop := make(chan struct{})
+	lc.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			ch, unsubscribe := bus.Subscribe()
+			go func() {
+				defer unsubscribe()
+				for {
+					select {
+					case e := <-ch:
+						m.observeJobEvent(e)
+					case <-stop:
+						return
+					}
+				}
+			}()
+
+			return nil
+		},
+
+		OnStop: func(ctx context.Context) error {
+			close(stop)
+
+			return nil
+		},
+	})
+}
+
+func (m *Metrics) observeJobEvent(e events.Event) {
+	var outcome string
+	switch e.Type {
+	case events.ChildJobSucceeded:
+		outcome = "succeeded"
+	case events.ChildJobFailed:
+		outcome = "failed"
+	default:
+		return
+	}
+
+	// failed jobs don't have a completion time
+	finishedAt := e.Timestamp
+	if e.CompletionTime != nil {
+		finishedAt = *e.CompletionTime
+	}
+
+	if outcome == "succeeded" {
+		m.JobLastSuccess.WithLabelValues(e.JobName).Set(float64(finishedAt.Unix()))
+	} else {
+		m.JobLastFailure.WithLabelValues(e.JobName).Set(float64(finishedAt.Unix()))
+	}
+
+	if e.StartTime != nil {
+		m.JobRunDuration.WithLabelValues(e.JobName, outcome).Observe(
+			finishedAt.Sub(*e.StartTime).Seconds(),
+		)
+	}
+}
@@ -0,0 +1,166 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"k8s.io/apimachinery/pkg/api/errors"
+)
+
+const namespace = "job_scheduler"
+
+// Metrics the prometheus collectors exposed by the service
+type Metrics struct {
+	Registry *prometheus.Registry
+
+	SyncDuration     *prometheus.HistogramVec
+	SyncErrors       *prometheus.CounterVec
+	IndexedManifests prometheus.Gauge
+	ParseFailures    prometheus.Gauge
+
+	HTTPRequestDuration *prometheus.HistogramVec
+
+	KubernetesRequestDuration *prometheus.HistogramVec
+	KubernetesRequestErrors   *prometheus.CounterVec
+
+	JobLastSuccess *prometheus.GaugeVec
+	JobLastFailure *prometheus.GaugeVec
+	JobRunDuration *prometheus.HistogramVec
+}
+
+func ProvideMetrics() (*Metrics, error) {
+	m := &Metrics{
+		Registry: prometheus.NewRegistry(),
+		SyncDuration: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Namespace: namespace,
+				Name:      "sync_duration_seconds",
+				Help:      "Time taken to sync the manifests of a GitHub location.",
+				Buckets:   []float64{0.1, 0.5, 1, 2.5, 5, 10, 30, 60, 120, 300},
+			},
+			[]string{"location"},
+		),
+		SyncErrors: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "sync_errors_total",
+				Help:      "Number of syncs of a GitHub location that failed.",
+			},
+			[]string{"location"},
+		),
+		IndexedManifests: prometheus.NewGauge(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Name:      "indexed_manifests",
+				Help:      "Number of cronjob manifests currently indexed.",
+			},
+		),
+		ParseFailures: prometheus.NewGauge(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Name:      "parse_failures",
+				Help:      "Number of manifest documents, kustomizations and charts that failed to parse in the last sync.",
+			},
+		),
+		HTTPRequestDuration: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Namespace: namespace,
+				Name:      "http_request_duration_seconds",
+				Help:      "Latency of HTTP requests by route, method and status code.",
+				Buckets:   prometheus.DefBuckets,
+			},
+			[]string{"route", "method", "code"},
+		),
+		KubernetesRequestDuration: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Namespace: namespace,
+				Name:      "kubernetes_request_duration_seconds",
+				Help:      "Latency of Kubernetes API calls by verb.",
+				Buckets:   prometheus.DefBuckets,
+			},
+			[]string{"verb"},
+		),
+		KubernetesRequestErrors: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "kubernetes_request_errors_total",
+				Help:      "Number of failed Kubernetes API calls by verb.",
+			},
+			[]string{"verb"},
+		),
+		JobLastSuccess: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Name:      "job_last_success_timestamp_seconds",
+				Help:      "Unix time of the last successful run of a job.",
+			},
+			[]string{"job"},
+		),
+		JobLastFailure: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Name:      "job_last_failure_timestamp_seconds",
+				Help:      "Unix time of the last failed run of a job.",
+			},
+			[]string{"job"},
+		),
+		JobRunDuration: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Namespace: namespace,
+				Name:      "job_run_duration_seconds",
+				Help:      "Duration of job runs by outcome.",
+				Buckets:   prometheus.ExponentialBuckets(1, 2, 16),
+			},
+			[]string{"job", "outcome"},
+		),
+	}
+
+	collectorsToRegister := []prometheus.Collector{
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.SyncDuration,
+		m.SyncErrors,
+		m.IndexedManifests,
+		m.ParseFailures,
+		m.HTTPRequestDuration,
+		m.KubernetesRequestDuration,
+		m.KubernetesRequestErrors,
+		m.JobLastSuccess,
+		m.JobLastFailure,
+		m.JobRunDuration,
+	}
+	for _, c := range collectorsToRegister {
+		if err := m.Registry.Register(c); err != nil {
+			return nil, err
+		}
+	}
+
+	return m, nil
+}
+
+// ObserveSync records the duration and outcome of syncing a location
+func (m *Metrics) ObserveSync(
+	location string,
+	start time.Time,
+	failed bool,
+) {
+	m.SyncDuration.WithLabelValues(location).Observe(time.Since(start).Seconds())
+	if failed {
+		m.SyncErrors.WithLabelValues(location).Inc()
+	}
+}
+
+// ObserveKubernetesRequest records the duration and outcome of a
+// Kubernetes API call. Not found errors are expected when looking
+// up cronjobs so they are not counted as failures
+func (m *Metrics) ObserveKubernetesRequest(
+	verb string,
+	start time.Time,
+	err error,
+) {
+	m.KubernetesRequestDuration.WithLabelValues(verb).Observe(time.Since(start).Seconds())
+	if err != nil && !errors.IsNotFound(err) {
+		m.KubernetesRequestErrors.WithLabelValues(verb).Inc()
+	}
+}
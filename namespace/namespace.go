@@ -0,0 +1,19 @@
+// Package namespace tells the namespace the scheduler manages
+package namespace
+
+import (
+	"os"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Current the namespace of the pod of the scheduler, set through the
+// downward API as POD_NAMESPACE, or default
+func Current() string {
+	envNamespace := os.Getenv("POD_NAMESPACE")
+	if envNamespace != "" {
+		return envNamespace
+	}
+
+	return metav1.NamespaceDefault
+}
@@ -0,0 +1,124 @@
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/panagiotisptr/job-scheduler/events"
+	"github.com/panagiotisptr/job-scheduler/store"
+	"go.uber.org/zap"
+)
+
+const (
+	deliveryBucket = "deliveries"
+	// how many deliveries are kept around for inspection
+	maxTrackedDeliveries = 500
+)
+
+type DeliveryStatus string
+
+const (
+	DeliveryPending   DeliveryStatus = "pending"
+	DeliveryDelivered DeliveryStatus = "delivered"
+	DeliveryFailed    DeliveryStatus = "failed"
+)
+
+// Delivery the state of sending one event to one webhook target
+type Delivery struct {
+	ID        uint64         `json:"id"`
+	Target    string         `json:"target"`
+	EventID   uint64         `json:"eventId"`
+	EventType events.Type    `json:"eventType"`
+	JobName   string         `json:"jobName"`
+	Status    DeliveryStatus `json:"status"`
+	Attempts  int            `json:"attempts"`
+	LastError string         `json:"lastError,omitempty"`
+	CreatedAt time.Time      `json:"createdAt"`
+	UpdatedAt time.Time      `json:"updatedAt"`
+}
+
+// deliveryTracker keeps the most recent deliveries in the state
+// store. Failing to track a delivery is logged and doesn't stop it
+type deliveryTracker struct {
+	logger *zap.Logger
+	store  store.Store
+}
+
+func (t *deliveryTracker) create(
+	ctx context.Context,
+	target string,
+	e events.Event,
+) uint64 {
+	id, err := t.store.NextSequence(ctx, deliveryBucket)
+	if err != nil {
+		t.logger.Sugar().Warn("failed to track webhook delivery: ", err)
+		return 0
+	}
+	now := time.Now().UTC()
+	err = store.PutJSON(ctx, t.store, deliveryBucket, store.SequenceKey(id), Delivery{
+		ID:        id,
+		Target:    target,
+		EventID:   e.ID,
+		EventType: e.Type,
+		JobName:   e.JobName,
+		Status:    DeliveryPending,
+		CreatedAt: now,
+		UpdatedAt: now,
+	})
+	if err == nil && id > maxTrackedDeliveries {
+		err = t.store.Delete(ctx, deliveryBucket, store.SequenceKey(id-maxTrackedDeliveries))
+	}
+	if err != nil {
+		t.logger.Sugar().Warn("failed to track webhook delivery: ", err)
+	}
+
+	return id
+}
+
+// update records the outcome of an attempt. It uses its own context
+// so that deliveries cancelled on shutdown are still recorded
+func (t *deliveryTracker) update(
+	id uint64,
+	status DeliveryStatus,
+	attempts int,
+	err error,
+) {
+	ctx := context.Background()
+	key := store.SequenceKey(id)
+	var d Delivery
+	if getErr := store.GetJSON(ctx, t.store, deliveryBucket, key, &d); getErr != nil {
+		// not tracked or already dropped
+		return
+	}
+	d.Status = status
+	d.Attempts = attempts
+	d.UpdatedAt = time.Now().UTC()
+	if err != nil {
+		d.LastError = err.Error()
+	}
+	if putErr := store.PutJSON(ctx, t.store, deliveryBucket, key, d); putErr != nil {
+		t.logger.Sugar().Warn("failed to track webhook delivery: ", putErr)
+	}
+}
+
+// list returns the tracked deliveries, newest first
+func (t *deliveryTracker) list(
+	ctx context.Context,
+) ([]Delivery, error) {
+	entries, err := t.store.List(ctx, deliveryBucket)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]Delivery, 0, len(entries))
+	for i := len(entries) - 1; i >= 0; i-- {
+		var d Delivery
+		if err := json.Unmarshal(entries[i].Value, &d); err != nil {
+			continue
+		}
+		res = append(res, d)
+	}
+
+	return res, nil
+}
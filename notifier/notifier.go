@@ -0,0 +1,326 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/panagiotisptr/job-scheduler/config"
+	"github.com/panagiotisptr/job-scheduler/events"
+	"github.com/panagiotisptr/job-scheduler/service"
+	"github.com/panagiotisptr/job-scheduler/store"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+)
+
+const (
+	// NotifyAnnotation comma separated webhook target names a job
+	// subscribes to e.g. job-scheduler/notify: "oncall-slack,team-teams"
+	NotifyAnnotation = "job-scheduler/notify"
+	// NotifyOnAnnotation comma separated event types the job subscribes
+	// to. Defaults to the events configured on each target
+	NotifyOnAnnotation = "job-scheduler/notify-on"
+
+	defaultMaxAttempts    = 5
+	defaultInitialBackoff = time.Second
+	defaultMaxBackoff     = time.Minute
+	defaultTimeout        = time.Second * 10
+)
+
+func splitList(s string) []string {
+	res := []string{}
+	for _, v := range strings.Split(s, ",") {
+		v = strings.TrimSpace(v)
+		if v != "" {
+			res = append(res, v)
+		}
+	}
+
+	return res
+}
+
+func contains(list []string, v string) bool {
+	for _, l := range list {
+		if l == v {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Notifier sends job events to the configured webhook targets
+type Notifier struct {
+	logger         *zap.Logger
+	cfg            config.NotifierConfig
+	cronJobService *service.CronJobService
+	client         *http.Client
+	deliveries     *deliveryTracker
+
+	// mu guards closed so that no goroutine is added to wg once
+	// OnStop waits for it
+	mu     sync.Mutex
+	closed bool
+	wg     sync.WaitGroup
+}
+
+func ProvideNotifier(
+	lc fx.Lifecycle,
+	cfg *config.Config,
+	logger *zap.Logger,
+	bus *events.Bus,
+	cronJobService *service.CronJobService,
+	st store.Store,
+) (*Notifier, error) {
+	nc := cfg.Notifier
+	nc.Targets = append([]config.WebhookTarget{}, cfg.Notifier.Targets...)
+	if nc.MaxAttempts <= 0 {
+		nc.MaxAttempts = defaultMaxAttempts
+	}
+	if nc.InitialBackoff <= 0 {
+		nc.InitialBackoff = defaultInitialBackoff
+	}
+	if nc.MaxBackoff <= 0 {
+		nc.MaxBackoff = defaultMaxBackoff
+	}
+	if nc.Timeout <= 0 {
+		nc.Timeout = defaultTimeout
+	}
+	for i, t := range nc.Targets {
+		if t.Name == "" || t.URL == "" {
+			return nil, fmt.Errorf("webhook target %d needs a name and a url", i)
+		}
+		switch t.Format {
+		case "", formatJSON, formatSlack, formatTeams:
+		default:
+			return nil, fmt.Errorf("unknown format %s for webhook target %s", t.Format, t.Name)
+		}
+		if len(t.Events) == 0 {
+			nc.Targets[i].Events = []string{string(events.ChildJobFailed)}
+		}
+	}
+
+	n := &Notifier{
+		logger:         logger,
+		cfg:            nc,
+		cronJobService: cronJobService,
+		client:         &http.Client{Timeout: nc.Timeout},
+		deliveries: &deliveryTracker{
+			logger: logger,
+			store:  st,
+		},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			if len(n.cfg.Targets) == 0 {
+				return nil
+			}
+			ch, unsubscribe := bus.Subscribe()
+			go func() {
+				defer unsubscribe()
+				for {
+					select {
+					case e := <-ch:
+						// off the loop so that a slow lookup can't
+						// fill the buffer of the subscription
+						n.goTracked(func() {
+							n.notify(ctx, e)
+						})
+					case <-ctx.Done():
+						return
+					}
+				}
+			}()
+
+			return nil
+		},
+
+		OnStop: func(context.Context) error {
+			n.mu.Lock()
+			n.closed = true
+			n.mu.Unlock()
+			cancel()
+			n.wg.Wait()
+
+			return nil
+		},
+	})
+
+	return n, nil
+}
+
+// ListDeliveries the most recent deliveries, newest first
+func (n *Notifier) ListDeliveries(
+	ctx context.Context,
+) ([]Delivery, error) {
+	return n.deliveries.list(ctx)
+}
+
+// targetsFor finds the targets subscribed to the event either
+// globally or through the annotations of the job's manifest
+func (n *Notifier) targetsFor(
+	ctx context.Context,
+	e events.Event,
+) []config.WebhookTarget {
+	res := []config.WebhookTarget{}
+	needsAnnotations := false
+	for _, t := range n.cfg.Targets {
+		if !t.AllJobs {
+			needsAnnotations = true
+			continue
+		}
+		if contains(t.Events, string(e.Type)) {
+			res = append(res, t)
+		}
+	}
+	if !needsAnnotations {
+		return res
+	}
+
+	annotations, err := n.cronJobService.GetCronJobAnnotations(ctx, e.JobName)
+	if err != nil {
+		n.logger.With(
+			zap.String("job", e.JobName),
+		).Sugar().Warn(
+			"failed to look up job subscriptions: ",
+			err,
+		)
+		return res
+	}
+
+	subscribed := splitList(annotations[NotifyAnnotation])
+	eventTypes := splitList(annotations[NotifyOnAnnotation])
+	for _, t := range n.cfg.Targets {
+		if t.AllJobs || !contains(subscribed, t.Name) {
+			continue
+		}
+		types := eventTypes
+		if len(types) == 0 {
+			types = t.Events
+		}
+		if contains(types, string(e.Type)) {
+			res = append(res, t)
+		}
+	}
+
+	return res
+}
+
+func (n *Notifier) notify(
+	ctx context.Context,
+	e events.Event,
+) {
+	for _, t := range n.targetsFor(ctx, e) {
+		id := n.deliveries.create(ctx, t.Name, e)
+		t := t
+		started := n.goTracked(func() {
+			n.deliver(ctx, id, t, e)
+		})
+		if !started {
+			n.deliveries.update(id, DeliveryFailed, 0, context.Canceled)
+		}
+	}
+}
+
+// goTracked runs f in a goroutine OnStop waits for, unless the
+// notifier is stopping
+func (n *Notifier) goTracked(f func()) bool {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.closed {
+		return false
+	}
+	n.wg.Add(1)
+	go func() {
+		defer n.wg.Done()
+		f()
+	}()
+
+	return true
+}
+
+// deliver sends the event to the target retrying with an
+// exponential backoff until it succeeds or runs out of attempts
+func (n *Notifier) deliver(
+	ctx context.Context,
+	id uint64,
+	target config.WebhookTarget,
+	e events.Event,
+) {
+	logger := n.logger.With(
+		zap.String("target", target.Name),
+		zap.String("job", e.JobName),
+		zap.String("type", string(e.Type)),
+	)
+	body, err := buildPayload(target, e)
+	if err != nil {
+		logger.Sugar().Error("failed to build webhook payload: ", err)
+		n.deliveries.update(id, DeliveryFailed, 0, err)
+		return
+	}
+
+	backoff := n.cfg.InitialBackoff
+	for attempt := 1; ; attempt++ {
+		err = n.send(ctx, target, body)
+		if err == nil {
+			n.deliveries.update(id, DeliveryDelivered, attempt, nil)
+			return
+		}
+		if attempt >= n.cfg.MaxAttempts {
+			logger.Sugar().Error("giving up on webhook delivery: ", err)
+			n.deliveries.update(id, DeliveryFailed, attempt, err)
+			return
+		}
+		logger.Sugar().Warn("webhook delivery failed, retrying: ", err)
+		n.deliveries.update(id, DeliveryPending, attempt, err)
+
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			n.deliveries.update(id, DeliveryFailed, attempt, ctx.Err())
+			return
+		}
+		backoff *= 2
+		if backoff > n.cfg.MaxBackoff {
+			backoff = n.cfg.MaxBackoff
+		}
+	}
+}
+
+func (n *Notifier) send(
+	ctx context.Context,
+	target config.WebhookTarget,
+	body []byte,
+) error {
+	req, err := http.NewRequestWithContext(
+		ctx,
+		http.MethodPost,
+		target.URL,
+		bytes.NewReader(body),
+	)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range target.Headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded with status code: %d", resp.StatusCode)
+	}
+
+	return nil
+}
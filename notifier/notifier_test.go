@@ -0,0 +1,452 @@
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/panagiotisptr/job-scheduler/apperror"
+	"github.com/panagiotisptr/job-scheduler/config"
+	"github.com/panagiotisptr/job-scheduler/events"
+	"github.com/panagiotisptr/job-scheduler/repository"
+	"github.com/panagiotisptr/job-scheduler/service"
+	memoryStore "github.com/panagiotisptr/job-scheduler/store/memory"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/fx/fxtest"
+	"go.uber.org/zap"
+)
+
+// annotationRepository serves the annotations the notifier looks
+// subscriptions up in, the rest of the repository is not used
+type annotationRepository struct {
+	repository.CronJobRepository
+	annotations map[string]map[string]string
+}
+
+func (r *annotationRepository) GetCronJobAnnotations(
+	ctx context.Context,
+	name string,
+) (map[string]string, error) {
+	annotations, ok := r.annotations[name]
+	if !ok {
+		return nil, apperror.NotFound("could not find cronjob %s", name)
+	}
+
+	return annotations, nil
+}
+
+// request a webhook request received by a target
+type request struct {
+	at     time.Time
+	target string
+	header http.Header
+	body   []byte
+}
+
+// webhookServer records the requests of every target, a target is
+// the path it is served on. fail is how many requests of a target
+// are answered with a 500 before it succeeds
+type webhookServer struct {
+	*httptest.Server
+	mu       sync.Mutex
+	requests []request
+	fail     map[string]int
+}
+
+func newWebhookServer(t *testing.T) *webhookServer {
+	s := &webhookServer{fail: map[string]int{}}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		target := strings.TrimPrefix(r.URL.Path, "/")
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.requests = append(s.requests, request{
+			at:     time.Now(),
+			target: target,
+			header: r.Header,
+			body:   body,
+		})
+		if s.fail[target] > 0 {
+			s.fail[target]--
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	t.Cleanup(s.Close)
+
+	return s
+}
+
+// target a target of the given format served by the server
+func (s *webhookServer) target(name string, format string) config.WebhookTarget {
+	return config.WebhookTarget{
+		Name:    name,
+		URL:     s.URL + "/" + name,
+		Format:  format,
+		AllJobs: true,
+	}
+}
+
+func (s *webhookServer) received(target string) []request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	res := []request{}
+	for _, r := range s.requests {
+		if r.target == target {
+			res = append(res, r)
+		}
+	}
+
+	return res
+}
+
+// startNotifier starts a notifier for the targets and returns the bus
+// it listens to. Backoffs are short so that retries are quick
+func startNotifier(
+	t *testing.T,
+	nc config.NotifierConfig,
+	annotations map[string]map[string]string,
+) (*Notifier, *events.Bus) {
+	t.Helper()
+	if nc.InitialBackoff == 0 {
+		nc.InitialBackoff = 10 * time.Millisecond
+	}
+	lc := fxtest.NewLifecycle(t)
+	bus := events.ProvideBus(zap.NewNop())
+	cronJobService, err := service.ProvideCronJobService(
+		&annotationRepository{annotations: annotations},
+		zap.NewNop(),
+		trace.NewNoopTracerProvider(),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n, err := ProvideNotifier(
+		lc,
+		&config.Config{Notifier: nc},
+		zap.NewNop(),
+		bus,
+		cronJobService,
+		memoryStore.NewStore(),
+	)
+	if err != nil {
+		t.Fatalf("ProvideNotifier: %s", err)
+	}
+	lc.RequireStart()
+	t.Cleanup(lc.RequireStop)
+
+	return n, bus
+}
+
+// waitForDeliveries waits until n deliveries are no longer pending
+func waitForDeliveries(t *testing.T, notifier *Notifier, n int) []Delivery {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		deliveries, err := notifier.ListDeliveries(context.Background())
+		if err != nil {
+			t.Fatalf("ListDeliveries: %s", err)
+		}
+		done := 0
+		for _, d := range deliveries {
+			if d.Status != DeliveryPending {
+				done++
+			}
+		}
+		if done >= n {
+			return deliveries
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%d of %d deliveries finished: %+v", done, n, deliveries)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestProvideNotifierValidatesTargets(t *testing.T) {
+	tests := []struct {
+		name   string
+		target config.WebhookTarget
+	}{
+		{name: "no name", target: config.WebhookTarget{URL: "http://example.com"}},
+		{name: "no url", target: config.WebhookTarget{Name: "ops"}},
+		{name: "unknown format", target: config.WebhookTarget{Name: "ops", URL: "http://example.com", Format: "xml"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ProvideNotifier(
+				fxtest.NewLifecycle(t),
+				&config.Config{Notifier: config.NotifierConfig{Targets: []config.WebhookTarget{tt.target}}},
+				zap.NewNop(),
+				events.ProvideBus(zap.NewNop()),
+				nil,
+				memoryStore.NewStore(),
+			)
+			if err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestPayloads(t *testing.T) {
+	srv := newWebhookServer(t)
+	templated := srv.target("templated", "slack")
+	templated.Template = "{{ .JobName }} failed on {{ .Namespace }}"
+	withHeaders := srv.target("headers", "")
+	withHeaders.Headers = map[string]string{"Authorization": "Bearer secret"}
+	targets := []config.WebhookTarget{
+		srv.target("json", ""),
+		srv.target("slack", "slack"),
+		srv.target("teams", "teams"),
+		templated,
+		withHeaders,
+	}
+	notifier, bus := startNotifier(t, config.NotifierConfig{Targets: targets}, nil)
+
+	bus.Publish(events.Event{
+		Type:         events.ChildJobFailed,
+		JobName:      "backup",
+		Namespace:    "jobs",
+		ChildJobName: "backup-28000000",
+		Message:      "BackoffLimitExceeded",
+	})
+	waitForDeliveries(t, notifier, len(targets))
+
+	const text = "Job backup run backup-28000000 failed (namespace jobs): BackoffLimitExceeded"
+	tests := []struct {
+		target string
+		want   map[string]interface{}
+	}{
+		{
+			target: "json",
+			want: map[string]interface{}{
+				"type":         "childjob.failed",
+				"jobName":      "backup",
+				"namespace":    "jobs",
+				"childJobName": "backup-28000000",
+				"message":      "BackoffLimitExceeded",
+				"text":         text,
+			},
+		},
+		{
+			target: "slack",
+			want:   map[string]interface{}{"text": text},
+		},
+		{
+			target: "teams",
+			want: map[string]interface{}{
+				"@type":      "MessageCard",
+				"@context":   "https://schema.org/extensions",
+				"summary":    text,
+				"themeColor": "D70000",
+				"title":      "backup: childjob.failed",
+				"text":       text,
+			},
+		},
+		{
+			target: "templated",
+			want:   map[string]interface{}{"text": "backup failed on jobs"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.target, func(t *testing.T) {
+			requests := srv.received(tt.target)
+			if len(requests) != 1 {
+				t.Fatalf("received %d requests, want 1", len(requests))
+			}
+			if ct := requests[0].header.Get("Content-Type"); ct != "application/json" {
+				t.Errorf("Content-Type = %q", ct)
+			}
+			var got map[string]interface{}
+			if err := json.Unmarshal(requests[0].body, &got); err != nil {
+				t.Fatalf("invalid payload %s: %s", requests[0].body, err)
+			}
+			for k, want := range tt.want {
+				if got[k] != want {
+					t.Errorf("%s = %v, want %v", k, got[k], want)
+				}
+			}
+			if tt.target != "json" && len(got) != len(tt.want) {
+				t.Errorf("payload = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	requests := srv.received("headers")
+	if len(requests) != 1 || requests[0].header.Get("Authorization") != "Bearer secret" {
+		t.Errorf("the headers of the target were not sent: %+v", requests)
+	}
+}
+
+func TestRetries(t *testing.T) {
+	srv := newWebhookServer(t)
+	srv.fail["flaky"] = 2
+	srv.fail["down"] = 100
+	notifier, bus := startNotifier(
+		t,
+		config.NotifierConfig{
+			Targets:        []config.WebhookTarget{srv.target("flaky", ""), srv.target("down", "")},
+			MaxAttempts:    4,
+			InitialBackoff: 20 * time.Millisecond,
+			MaxBackoff:     50 * time.Millisecond,
+		},
+		nil,
+	)
+
+	bus.Publish(events.Event{Type: events.ChildJobFailed, JobName: "backup"})
+	deliveries := waitForDeliveries(t, notifier, 2)
+
+	byTarget := map[string]Delivery{}
+	for _, d := range deliveries {
+		byTarget[d.Target] = d
+	}
+	if d := byTarget["flaky"]; d.Status != DeliveryDelivered || d.Attempts != 3 {
+		t.Errorf("flaky delivery = %+v, want delivered after 3 attempts", d)
+	}
+	if d := byTarget["down"]; d.Status != DeliveryFailed ||
+		d.Attempts != 4 ||
+		!strings.Contains(d.LastError, "status code: 500") {
+		t.Errorf("down delivery = %+v, want failed after 4 attempts", d)
+	}
+
+	// the backoff doubles from 20ms and is capped at 50ms
+	requests := srv.received("down")
+	if len(requests) != 4 {
+		t.Fatalf("down received %d requests, want 4", len(requests))
+	}
+	for i, want := range []time.Duration{20, 40, 50} {
+		want *= time.Millisecond
+		gap := requests[i+1].at.Sub(requests[i].at)
+		if gap < want || gap > want+time.Second {
+			t.Errorf("attempt %d was sent after %s, want %s", i+2, gap, want)
+		}
+	}
+}
+
+func TestSubscriptions(t *testing.T) {
+	srv := newWebhookServer(t)
+	everything := srv.target("everything", "")
+	everything.Events = []string{string(events.ChildJobSucceeded), string(events.ChildJobFailed)}
+	team := srv.target("team", "")
+	team.AllJobs = false
+	other := srv.target("other", "")
+	other.AllJobs = false
+	notifier, bus := startNotifier(
+		t,
+		config.NotifierConfig{
+			Targets: []config.WebhookTarget{
+				everything,
+				team,
+				other,
+				// defaults to childjob.failed
+				srv.target("failures", ""),
+			},
+		},
+		map[string]map[string]string{
+			// the events of the target
+			"backup": {NotifyAnnotation: "team"},
+			// only the listed events
+			"report": {NotifyAnnotation: " team , unknown", NotifyOnAnnotation: "childjob.succeeded"},
+			"quiet":  {},
+		},
+	)
+
+	published := []events.Event{
+		{Type: events.ChildJobFailed, JobName: "backup"},
+		{Type: events.ChildJobSucceeded, JobName: "backup"},
+		{Type: events.ChildJobFailed, JobName: "report"},
+		{Type: events.ChildJobSucceeded, JobName: "report"},
+		{Type: events.ChildJobCreated, JobName: "report"},
+		{Type: events.ChildJobFailed, JobName: "quiet"},
+		// the annotations can't be looked up, only the targets of
+		// every job are notified
+		{Type: events.ChildJobFailed, JobName: "deleted"},
+	}
+	for _, e := range published {
+		bus.Publish(e)
+	}
+
+	want := map[string][]string{
+		"everything": {
+			"backup childjob.failed",
+			"backup childjob.succeeded",
+			"deleted childjob.failed",
+			"quiet childjob.failed",
+			"report childjob.failed",
+			"report childjob.succeeded",
+		},
+		"failures": {
+			"backup childjob.failed",
+			"deleted childjob.failed",
+			"quiet childjob.failed",
+			"report childjob.failed",
+		},
+		"team": {
+			"backup childjob.failed",
+			"report childjob.succeeded",
+		},
+	}
+	total := 0
+	for _, w := range want {
+		total += len(w)
+	}
+	deliveries := waitForDeliveries(t, notifier, total)
+	if len(deliveries) != total {
+		t.Errorf("%d deliveries, want %d", len(deliveries), total)
+	}
+
+	for _, target := range []string{"everything", "failures", "team", "other"} {
+		got := []string{}
+		for _, r := range srv.received(target) {
+			var e events.Event
+			if err := json.Unmarshal(r.body, &e); err != nil {
+				t.Fatal(err)
+			}
+			got = append(got, fmt.Sprintf("%s %s", e.JobName, e.Type))
+		}
+		sort.Strings(got)
+		if strings.Join(got, ",") != strings.Join(want[target], ",") {
+			t.Errorf("%s received %v, want %v", target, got, want[target])
+		}
+	}
+}
+
+func TestDeliveriesAreKeptInTheStore(t *testing.T) {
+	st := memoryStore.NewStore()
+	tracker := &deliveryTracker{logger: zap.NewNop(), store: st}
+	ctx := context.Background()
+
+	for i := 1; i <= maxTrackedDeliveries+2; i++ {
+		tracker.create(ctx, "ops", events.Event{ID: uint64(i), Type: events.ChildJobFailed})
+	}
+	tracker.update(maxTrackedDeliveries+2, DeliveryFailed, 3, fmt.Errorf("timeout"))
+
+	// a notifier on the same store lists the deliveries
+	restarted := &deliveryTracker{logger: zap.NewNop(), store: st}
+	deliveries, err := restarted.list(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(deliveries) != maxTrackedDeliveries {
+		t.Fatalf("%d deliveries, want %d", len(deliveries), maxTrackedDeliveries)
+	}
+	newest, oldest := deliveries[0], deliveries[len(deliveries)-1]
+	if newest.ID != maxTrackedDeliveries+2 ||
+		newest.Status != DeliveryFailed ||
+		newest.Attempts != 3 ||
+		newest.LastError != "timeout" {
+		t.Errorf("newest delivery = %+v", newest)
+	}
+	if oldest.ID != 3 || oldest.Status != DeliveryPending {
+		t.Errorf("oldest delivery = %+v, want the pending delivery 3", oldest)
+	}
+}
@@ -0,0 +1,116 @@
+package notifier
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"text/template"
+
+	"github.com/panagiotisptr/job-scheduler/config"
+	"github.com/panagiotisptr/job-scheduler/events"
+)
+
+const (
+	formatJSON  = "json"
+	formatSlack = "slack"
+	formatTeams = "teams"
+)
+
+func defaultText(e events.Event) string {
+	var text string
+	switch e.Type {
+	case events.ChildJobCreated:
+		text = fmt.Sprintf("Job %s started run %s", e.JobName, e.ChildJobName)
+	case events.ChildJobSucceeded:
+		text = fmt.Sprintf("Job %s run %s succeeded", e.JobName, e.ChildJobName)
+	case events.ChildJobFailed:
+		text = fmt.Sprintf("Job %s run %s failed", e.JobName, e.ChildJobName)
+	default:
+		text = fmt.Sprintf("Job %s: %s", e.JobName, e.Type)
+	}
+	text = fmt.Sprintf("%s (namespace %s)", text, e.Namespace)
+	if e.Message != "" {
+		text = fmt.Sprintf("%s: %s", text, e.Message)
+	}
+
+	return text
+}
+
+func renderText(
+	target config.WebhookTarget,
+	e events.Event,
+) (string, error) {
+	if target.Template == "" {
+		return defaultText(e), nil
+	}
+
+	t, err := template.New(target.Name).Parse(target.Template)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err = t.Execute(&buf, e); err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
+
+// themeColor used by Teams to highlight the card
+func themeColor(t events.Type) string {
+	switch t {
+	case events.ChildJobFailed:
+		return "D70000"
+	case events.ChildJobSucceeded:
+		return "2EB886"
+	default:
+		return "0078D7"
+	}
+}
+
+// buildPayload builds the request body for the target's format
+func buildPayload(
+	target config.WebhookTarget,
+	e events.Event,
+) ([]byte, error) {
+	text, err := renderText(target, e)
+	if err != nil {
+		return nil, err
+	}
+
+	switch target.Format {
+	case "", formatJSON:
+		return json.Marshal(struct {
+			events.Event
+			Text string `json:"text"`
+		}{
+			Event: e,
+			Text:  text,
+		})
+	case formatSlack:
+		return json.Marshal(struct {
+			Text string `json:"text"`
+		}{
+			Text: text,
+		})
+	case formatTeams:
+		// legacy actionable message card accepted by incoming webhooks
+		return json.Marshal(struct {
+			Type       string `json:"@type"`
+			Context    string `json:"@context"`
+			Summary    string `json:"summary"`
+			ThemeColor string `json:"themeColor"`
+			Title      string `json:"title"`
+			Text       string `json:"text"`
+		}{
+			Type:       "MessageCard",
+			Context:    "https://schema.org/extensions",
+			Summary:    text,
+			ThemeColor: themeColor(e.Type),
+			Title:      fmt.Sprintf("%s: %s", e.JobName, e.Type),
+			Text:       text,
+		})
+	}
+
+	return nil, fmt.Errorf("unknown webhook format: %s", target.Format)
+}
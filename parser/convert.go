@@ -0,0 +1,116 @@
+package parser
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	batchv1 "k8s.io/api/batch/v1"
+	batchv1beta1 "k8s.io/api/batch/v1beta1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// cronJobVersion decodes the CronJobs of an apiVersion into
+// batch/v1 ones
+type cronJobVersion struct {
+	decode func(obj map[string]interface{}) (batchv1.CronJob, error)
+	// deprecated why the version should no longer be used, empty
+	// for the current version
+	deprecated string
+}
+
+// cronJobVersions the apiVersions CronJob manifests can be written
+// in. Documents of any other version are rejected
+var cronJobVersions = map[string]cronJobVersion{
+	batchv1.SchemeGroupVersion.String(): {
+		decode: decodeV1CronJob,
+	},
+	batchv1beta1.SchemeGroupVersion.String(): {
+		decode:     decodeV1beta1CronJob,
+		deprecated: "batch/v1beta1 CronJobs are not served since Kubernetes 1.25",
+	},
+}
+
+// supportedCronJobVersions lists the keys of cronJobVersions
+func supportedCronJobVersions() string {
+	versions := []string{}
+	for v := range cronJobVersions {
+		versions = append(versions, v)
+	}
+	sort.Strings(versions)
+
+	return strings.Join(versions, ", ")
+}
+
+func decodeV1CronJob(obj map[string]interface{}) (batchv1.CronJob, error) {
+	var cj batchv1.CronJob
+	err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj, &cj)
+
+	return cj, err
+}
+
+func decodeV1beta1CronJob(obj map[string]interface{}) (batchv1.CronJob, error) {
+	var in batchv1beta1.CronJob
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj, &in); err != nil {
+		return batchv1.CronJob{}, err
+	}
+
+	return convertV1beta1CronJob(&in), nil
+}
+
+// convertV1beta1CronJob the fields of the two versions are the same,
+// only the job template moved to the batch/v1 types
+func convertV1beta1CronJob(in *batchv1beta1.CronJob) batchv1.CronJob {
+	spec := in.Spec
+
+	return batchv1.CronJob{
+		ObjectMeta: *in.ObjectMeta.DeepCopy(),
+		Spec: batchv1.CronJobSpec{
+			Schedule:                spec.Schedule,
+			TimeZone:                spec.TimeZone,
+			StartingDeadlineSeconds: spec.StartingDeadlineSeconds,
+			ConcurrencyPolicy:       batchv1.ConcurrencyPolicy(spec.ConcurrencyPolicy),
+			Suspend:                 spec.Suspend,
+			JobTemplate: batchv1.JobTemplateSpec{
+				ObjectMeta: *spec.JobTemplate.ObjectMeta.DeepCopy(),
+				Spec:       *spec.JobTemplate.Spec.DeepCopy(),
+			},
+			SuccessfulJobsHistoryLimit: spec.SuccessfulJobsHistoryLimit,
+			FailedJobsHistoryLimit:     spec.FailedJobsHistoryLimit,
+		},
+	}
+}
+
+// decodeCronJob converts a CronJob of any supported apiVersion to
+// batch/v1. The returned message is set when the version is
+// deprecated
+func decodeCronJob(
+	apiVersion string,
+	obj map[string]interface{},
+) (batchv1.CronJob, string, error) {
+	version, ok := cronJobVersions[apiVersion]
+	if !ok {
+		return batchv1.CronJob{}, "", fmt.Errorf(
+			"unsupported apiVersion %q for a CronJob, expected one of: %s",
+			apiVersion,
+			supportedCronJobVersions(),
+		)
+	}
+	cj, err := version.decode(obj)
+	if err != nil {
+		return batchv1.CronJob{}, "", err
+	}
+	cj.APIVersion = batchv1.SchemeGroupVersion.String()
+	cj.Kind = "CronJob"
+
+	deprecated := ""
+	if version.deprecated != "" {
+		deprecated = fmt.Sprintf(
+			"%s, it was converted to %s. Update the manifest",
+			version.deprecated,
+			batchv1.SchemeGroupVersion,
+		)
+	}
+
+	return cj, deprecated, nil
+}
@@ -1,72 +1,282 @@
 package parser
 
 import (
+	"bytes"
+	"fmt"
 	"io"
+	"path"
 
+	"github.com/panagiotisptr/job-scheduler/config"
+	"github.com/panagiotisptr/job-scheduler/helm"
+	"github.com/panagiotisptr/job-scheduler/kustomize"
+	"github.com/panagiotisptr/job-scheduler/render"
 	"go.uber.org/zap"
 	batchv1 "k8s.io/api/batch/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/serializer/yaml"
 	yamlutil "k8s.io/apimachinery/pkg/util/yaml"
+	"sigs.k8s.io/kustomize/kyaml/filesys"
 )
 
-type CronJobParser struct {
-	logger *zap.Logger
+// Severity whether a diagnostic makes a manifest unusable
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+)
+
+// Diagnostic a problem found in a manifest. Document is the 1-based
+// index of the YAML document in the file, 0 if it is about the
+// whole file
+type Diagnostic struct {
+	Document int      `json:"document,omitempty"`
+	Job      string   `json:"job,omitempty"`
+	Severity Severity `json:"severity"`
+	Message  string   `json:"message"`
 }
 
-func ProvideCronJobParser(
-	logger *zap.Logger,
-) (*CronJobParser, error) {
-	return &CronJobParser{
-		logger: logger,
-	}, nil
+func (d Diagnostic) String() string {
+	s := string(d.Severity)
+	if d.Document > 0 {
+		s = fmt.Sprintf("document %d: %s", d.Document, s)
+	}
+	if d.Job != "" {
+		s += ": " + d.Job
+	}
+
+	return s + ": " + d.Message
 }
 
-func (p *CronJobParser) ParseCronJobConfigs(
-	r io.ReadCloser,
-) []batchv1.CronJob {
-	cronJobs := []batchv1.CronJob{}
+// Result the cronjobs and jobs of a file and the problems found
+// while parsing it. Companions are the documents of any other kind,
+// they belong to the cronjobs of the file
+type Result struct {
+	CronJobs    []batchv1.CronJob
+	Jobs        []batchv1.Job
+	Companions  []unstructured.Unstructured
+	Diagnostics []Diagnostic
+}
+
+// HasErrors whether any of the diagnostics is an error
+func HasErrors(diagnostics []Diagnostic) bool {
+	for _, d := range diagnostics {
+		if d.Severity == SeverityError {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Parse decodes every CronJob and batch/v1 Job of a YAML or JSON
+// stream. CronJobs of older apiVersions are converted to batch/v1
+// with a warning. Documents of other kinds are returned as
+// companions, documents that fail to decode or have an unsupported
+// apiVersion are reported as errors
+func Parse(r io.Reader) Result {
+	res := Result{
+		CronJobs:    []batchv1.CronJob{},
+		Jobs:        []batchv1.Job{},
+		Companions:  []unstructured.Unstructured{},
+		Diagnostics: []Diagnostic{},
+	}
+	addError := func(document int, format string, args ...interface{}) {
+		res.Diagnostics = append(res.Diagnostics, Diagnostic{
+			Document: document,
+			Severity: SeverityError,
+			Message:  fmt.Sprintf(format, args...),
+		})
+	}
+
 	var err error
+	document := 0
 	decoder := yamlutil.NewYAMLOrJSONDecoder(r, 100)
 	for {
 		var rawObj runtime.RawExtension
 		if err = decoder.Decode(&rawObj); err != nil {
 			break
 		}
+		document++
+		// empty documents e.g. a leading ---
+		if len(rawObj.Raw) == 0 || string(rawObj.Raw) == "null" {
+			continue
+		}
 
 		obj, _, err := yaml.NewDecodingSerializer(unstructured.UnstructuredJSONScheme).Decode(rawObj.Raw, nil, nil)
+		if err != nil {
+			addError(document, "failed to decode yaml config: %s", err)
+			continue
+		}
 		unstructuredMap, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
 		if err != nil {
-			p.logger.Sugar().Error(
-				"failed to parse yaml config: ",
-				err,
-			)
+			addError(document, "failed to parse yaml config: %s", err)
+			continue
 		}
 
 		unstructuredObj := &unstructured.Unstructured{Object: unstructuredMap}
-		if unstructuredObj.GetKind() == "CronJob" {
-			var cronJob batchv1.CronJob
+		apiVersion := unstructuredObj.GetAPIVersion()
+		switch unstructuredObj.GetKind() {
+		case "CronJob":
+			cronJob, deprecated, err := decodeCronJob(apiVersion, unstructuredMap)
+			if err != nil {
+				addError(document, "failed to parse cronjob: %s", err)
+				continue
+			}
+			if deprecated != "" {
+				res.Diagnostics = append(res.Diagnostics, Diagnostic{
+					Document: document,
+					Job:      cronJob.Name,
+					Severity: SeverityWarning,
+					Message:  deprecated,
+				})
+			}
+			res.CronJobs = append(res.CronJobs, cronJob)
+		case "Job":
+			if apiVersion != batchv1.SchemeGroupVersion.String() {
+				addError(
+					document,
+					"failed to parse job: unsupported apiVersion %q for a Job, expected %s",
+					apiVersion,
+					batchv1.SchemeGroupVersion,
+				)
+				continue
+			}
+			var job batchv1.Job
 			err = runtime.DefaultUnstructuredConverter.FromUnstructured(
 				unstructuredMap,
-				&cronJob,
+				&job,
 			)
 			if err != nil {
-				p.logger.Sugar().Error(
-					"failed to parse cronjob: ",
-					err,
-				)
+				addError(document, "failed to parse job: %s", err)
 				continue
 			}
-			cronJobs = append(cronJobs, cronJob)
+			res.Jobs = append(res.Jobs, job)
+		default:
+			res.Companions = append(res.Companions, *unstructuredObj)
 		}
 	}
 	if err != io.EOF {
-		p.logger.Sugar().Error(
-			"expected EOF got: ",
-			err,
-		)
+		addError(document+1, "expected EOF got: %s", err)
+	}
+
+	return res
+}
+
+// ParseTemplate renders a templated manifest with the values file,
+// which may be empty, and the overrides and parses the result. A
+// template that fails to render is reported as an error
+func ParseTemplate(
+	name string,
+	r io.Reader,
+	valuesFile []byte,
+	overrides []config.TemplateValue,
+) Result {
+	text, err := io.ReadAll(r)
+	if err != nil {
+		return failedResult("failed to read template: %s", err)
+	}
+	values, err := render.LoadValues(valuesFile, overrides)
+	if err != nil {
+		return failedResult("invalid template values: %s", err)
+	}
+	rendered, err := render.Render(path.Base(name), text, values)
+	if err != nil {
+		return failedResult("failed to render template: %s", err)
+	}
+
+	return Parse(bytes.NewReader(rendered))
+}
+
+// ParseKustomization builds the kustomization root at dir and parses
+// the result. A kustomization that fails to build is reported as an
+// error
+func ParseKustomization(fSys filesys.FileSystem, dir string) Result {
+	out, err := kustomize.Build(fSys, dir)
+	if err != nil {
+		return failedResult("failed to build kustomization: %s", err)
+	}
+
+	return Parse(bytes.NewReader(out))
+}
+
+// ParseChart renders the chart at dir with the values file and parses
+// the result. A chart that fails to render is reported as an error
+func ParseChart(
+	fSys filesys.FileSystem,
+	dir string,
+	valuesFile string,
+	release helm.Release,
+) Result {
+	out, err := helm.Render(fSys, dir, valuesFile, release)
+	if err != nil {
+		return failedResult("failed to render chart: %s", err)
+	}
+
+	return Parse(bytes.NewReader(out))
+}
+
+// failedResult a result with a single error
+func failedResult(format string, args ...interface{}) Result {
+	return Result{
+		CronJobs:   []batchv1.CronJob{},
+		Jobs:       []batchv1.Job{},
+		Companions: []unstructured.Unstructured{},
+		Diagnostics: []Diagnostic{{
+			Severity: SeverityError,
+			Message:  fmt.Sprintf(format, args...),
+		}},
+	}
+}
+
+type CronJobParser struct {
+	logger *zap.Logger
+}
+
+func ProvideCronJobParser(
+	logger *zap.Logger,
+) (*CronJobParser, error) {
+	return &CronJobParser{
+		logger: logger,
+	}, nil
+}
+
+// ParseManifests returns the cronjobs and jobs that could be parsed
+// and logs the rest
+func (p *CronJobParser) ParseManifests(
+	r io.ReadCloser,
+) Result {
+	return p.report(Parse(r))
+}
+
+// ParseTemplateManifests is ParseManifests for a templated manifest
+func (p *CronJobParser) ParseTemplateManifests(
+	name string,
+	r io.ReadCloser,
+	valuesFile []byte,
+	overrides []config.TemplateValue,
+) Result {
+	return p.report(ParseTemplate(name, r, valuesFile, overrides))
+}
+
+// report logs the diagnostics of the result
+func (p *CronJobParser) report(res Result) Result {
+	for _, d := range res.Diagnostics {
+		if d.Severity == SeverityWarning {
+			p.logger.Sugar().Warn(d.String())
+			continue
+		}
+		p.logger.Sugar().Error(d.String())
 	}
 
-	return cronJobs
+	return res
+}
+
+// ParseCronJobConfigs returns the cronjobs that could be parsed and
+// logs the rest
+func (p *CronJobParser) ParseCronJobConfigs(
+	r io.ReadCloser,
+) []batchv1.CronJob {
+	return p.ParseManifests(r).CronJobs
 }
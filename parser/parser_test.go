@@ -0,0 +1,219 @@
+package parser
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	batchv1 "k8s.io/api/batch/v1"
+)
+
+const v1CronJob = `apiVersion: batch/v1
+kind: CronJob
+metadata:
+  name: backup
+  namespace: jobs
+  labels:
+    team: a
+spec:
+  schedule: "0 3 * * *"
+  timeZone: Europe/London
+  concurrencyPolicy: Forbid
+  successfulJobsHistoryLimit: 2
+  jobTemplate:
+    metadata:
+      labels:
+        app: backup
+    spec:
+      backoffLimit: 1
+      template:
+        spec:
+          restartPolicy: Never
+          containers:
+            - name: backup
+              image: ghcr.io/acme/backup:1.0.0
+`
+
+func TestParse(t *testing.T) {
+	v1beta1CronJob := strings.Replace(v1CronJob, "batch/v1", "batch/v1beta1", 1)
+
+	tests := []struct {
+		name     string
+		manifest string
+		// cronJobs, jobs and companions the names of the parsed
+		// documents
+		cronJobs   []string
+		jobs       []string
+		companions []string
+		// diagnostics the severity and a part of the message of each
+		// diagnostic, as document:severity:message
+		diagnostics []string
+	}{
+		{
+			name:     "batch/v1 CronJob",
+			manifest: v1CronJob,
+			cronJobs: []string{"backup"},
+		},
+		{
+			name:        "batch/v1beta1 CronJob",
+			manifest:    v1beta1CronJob,
+			cronJobs:    []string{"backup"},
+			diagnostics: []string{"1:warning:batch/v1beta1 CronJobs are not served since Kubernetes 1.25, it was converted to batch/v1"},
+		},
+		{
+			name:        "unknown CronJob apiVersion",
+			manifest:    strings.Replace(v1CronJob, "batch/v1", "batch/v2alpha1", 1),
+			diagnostics: []string{`1:error:unsupported apiVersion "batch/v2alpha1" for a CronJob, expected one of: batch/v1, batch/v1beta1`},
+		},
+		{
+			name: "batch/v1 Job",
+			manifest: `apiVersion: batch/v1
+kind: Job
+metadata:
+  name: migrate
+spec:
+  template:
+    spec:
+      restartPolicy: Never
+      containers:
+        - name: migrate
+          image: ghcr.io/acme/migrate:1.0.0
+`,
+			jobs: []string{"migrate"},
+		},
+		{
+			name: "unknown Job apiVersion",
+			manifest: `apiVersion: batch/v1beta1
+kind: Job
+metadata:
+  name: migrate
+`,
+			diagnostics: []string{`1:error:unsupported apiVersion "batch/v1beta1" for a Job, expected batch/v1`},
+		},
+		{
+			name: "companions",
+			manifest: `apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: backup
+---
+` + v1CronJob + `---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: backup-config
+`,
+			cronJobs:   []string{"backup"},
+			companions: []string{"backup", "backup-config"},
+		},
+		{
+			name:     "empty documents",
+			manifest: "---\n" + v1CronJob + "---\n---\n",
+			cronJobs: []string{"backup"},
+		},
+		{
+			name:     "JSON",
+			manifest: `{"apiVersion": "batch/v1", "kind": "CronJob", "metadata": {"name": "backup"}, "spec": {"schedule": "@daily"}}`,
+			cronJobs: []string{"backup"},
+		},
+		{
+			name: "a document without a kind",
+			manifest: `apiVersion: v1
+metadata:
+  name: nameless
+---
+` + v1CronJob,
+			cronJobs:    []string{"backup"},
+			diagnostics: []string{"1:error:failed to decode yaml config"},
+		},
+		{
+			name: "fields of the wrong type",
+			manifest: `apiVersion: batch/v1
+kind: CronJob
+metadata:
+  name: backup
+spec:
+  schedule: [1, 2]
+`,
+			diagnostics: []string{"1:error:failed to parse cronjob"},
+		},
+		{
+			name:        "invalid YAML",
+			manifest:    v1CronJob + "---\nkind: [CronJob\n",
+			cronJobs:    []string{"backup"},
+			diagnostics: []string{"2:error:expected EOF"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := Parse(strings.NewReader(tt.manifest))
+
+			cronJobs := []string{}
+			for _, cj := range res.CronJobs {
+				cronJobs = append(cronJobs, cj.Name)
+			}
+			jobs := []string{}
+			for _, j := range res.Jobs {
+				jobs = append(jobs, j.Name)
+			}
+			companions := []string{}
+			for _, c := range res.Companions {
+				companions = append(companions, c.GetName())
+			}
+			for _, got := range []struct {
+				what      string
+				got, want []string
+			}{
+				{what: "cronjobs", got: cronJobs, want: tt.cronJobs},
+				{what: "jobs", got: jobs, want: tt.jobs},
+				{what: "companions", got: companions, want: tt.companions},
+			} {
+				if strings.Join(got.got, ",") != strings.Join(got.want, ",") {
+					t.Errorf("%s = %v, want %v", got.what, got.got, got.want)
+				}
+			}
+
+			if len(res.Diagnostics) != len(tt.diagnostics) {
+				t.Fatalf("diagnostics = %v, want %v", res.Diagnostics, tt.diagnostics)
+			}
+			for i, want := range tt.diagnostics {
+				parts := strings.SplitN(want, ":", 3)
+				d := res.Diagnostics[i]
+				if strconv.Itoa(d.Document) != parts[0] ||
+					string(d.Severity) != parts[1] ||
+					!strings.Contains(d.Message, parts[2]) {
+					t.Errorf("diagnostic %d = %s, want %s", i, d, want)
+				}
+			}
+			if HasErrors(res.Diagnostics) != strings.Contains(strings.Join(tt.diagnostics, "\n"), ":error:") {
+				t.Errorf("HasErrors = %t for %v", HasErrors(res.Diagnostics), res.Diagnostics)
+			}
+		})
+	}
+}
+
+// TestV1beta1Conversion every field of a batch/v1beta1 CronJob is kept
+// when it is converted to batch/v1
+func TestV1beta1Conversion(t *testing.T) {
+	v1 := Parse(strings.NewReader(v1CronJob))
+	v1beta1 := Parse(strings.NewReader(strings.Replace(v1CronJob, "batch/v1", "batch/v1beta1", 1)))
+	if len(v1.CronJobs) != 1 || len(v1beta1.CronJobs) != 1 {
+		t.Fatalf("parsed %d and %d cronjobs, want 1 each", len(v1.CronJobs), len(v1beta1.CronJobs))
+	}
+
+	got := v1beta1.CronJobs[0]
+	if got.APIVersion != batchv1.SchemeGroupVersion.String() || got.Kind != "CronJob" {
+		t.Errorf("converted to %s %s, want batch/v1 CronJob", got.APIVersion, got.Kind)
+	}
+	if !reflect.DeepEqual(got, v1.CronJobs[0]) {
+		t.Errorf("converted cronjob\n%+v\ndiffers from the batch/v1 one\n%+v", got, v1.CronJobs[0])
+	}
+	if *got.Spec.TimeZone != "Europe/London" ||
+		got.Spec.ConcurrencyPolicy != batchv1.ForbidConcurrent ||
+		*got.Spec.SuccessfulJobsHistoryLimit != 2 ||
+		*got.Spec.JobTemplate.Spec.BackoffLimit != 1 ||
+		got.Spec.JobTemplate.Labels["app"] != "backup" {
+		t.Errorf("fields were lost in the conversion: %+v", got.Spec)
+	}
+}
@@ -0,0 +1,110 @@
+// Package plan works out what starting the cronjobs of a set of
+// manifests would change in the cluster
+package plan
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/panagiotisptr/job-scheduler/managed"
+	batchv1 "k8s.io/api/batch/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// Action what happens to a cronjob in the cluster
+type Action string
+
+const (
+	// ActionCreate the cronjob is not in the cluster
+	ActionCreate Action = "create"
+	// ActionUpdate the cronjob in the cluster has a different spec
+	ActionUpdate Action = "update"
+	// ActionOrphan the cronjob was created by the scheduler but has
+	// no manifest anymore
+	ActionOrphan Action = "orphan"
+	// ActionNone the cronjob in the cluster is up to date
+	ActionNone Action = "none"
+)
+
+type Change struct {
+	Job    string `json:"job"`
+	Action Action `json:"action"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// Plan compares the cronjobs of the manifests with the ones in the
+// cluster. Cronjobs in the cluster that were not created by the
+// scheduler are only considered if there is a manifest for them
+func Plan(
+	desired []batchv1.CronJob,
+	live []batchv1.CronJob,
+) []Change {
+	liveByName := map[string]*batchv1.CronJob{}
+	for i := range live {
+		liveByName[live[i].Name] = &live[i]
+	}
+
+	changes := []Change{}
+	desiredNames := map[string]struct{}{}
+	for i := range desired {
+		cj := &desired[i]
+		desiredNames[cj.Name] = struct{}{}
+		l, ok := liveByName[cj.Name]
+		switch {
+		case !ok:
+			changes = append(changes, Change{
+				Job:    cj.Name,
+				Action: ActionCreate,
+			})
+		case !managed.IsManaged(l):
+			changes = append(changes, Change{
+				Job:    cj.Name,
+				Action: ActionUpdate,
+				Reason: fmt.Sprintf("the cronjob was not created by the scheduler, it has no %s label", managed.Label),
+			})
+		case !managed.UpToDate(l, cj):
+			changes = append(changes, Change{
+				Job:    cj.Name,
+				Action: ActionUpdate,
+				Reason: "the spec changed",
+			})
+		default:
+			changes = append(changes, Change{
+				Job:    cj.Name,
+				Action: ActionNone,
+			})
+		}
+	}
+	for _, l := range live {
+		if _, ok := desiredNames[l.Name]; ok || !managed.IsManaged(&l) {
+			continue
+		}
+		changes = append(changes, Change{
+			Job:    l.Name,
+			Action: ActionOrphan,
+			Reason: "there is no manifest for the cronjob",
+		})
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Job < changes[j].Job
+	})
+
+	return changes
+}
+
+// ReadSnapshot reads the cronjobs of a cluster from the output of
+// `kubectl get cronjobs -o json` (or yaml)
+func ReadSnapshot(r io.Reader) ([]batchv1.CronJob, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	var list batchv1.CronJobList
+	if err := yaml.Unmarshal(b, &list); err != nil {
+		return nil, fmt.Errorf("failed to parse snapshot: %w", err)
+	}
+
+	return list.Items, nil
+}
@@ -0,0 +1,153 @@
+package plan
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/panagiotisptr/job-scheduler/managed"
+	batchv1 "k8s.io/api/batch/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func cronJob(name string, schedule string) batchv1.CronJob {
+	return batchv1.CronJob{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec:       batchv1.CronJobSpec{Schedule: schedule},
+	}
+}
+
+// started the cronjob as the scheduler leaves it in the cluster
+func started(cj batchv1.CronJob) batchv1.CronJob {
+	return *managed.Mark(&cj, "")
+}
+
+func suspended(cj batchv1.CronJob) batchv1.CronJob {
+	suspend := true
+	cj.Spec.Suspend = &suspend
+
+	return cj
+}
+
+func TestPlan(t *testing.T) {
+	backup := cronJob("backup", "0 3 * * *")
+	report := cronJob("report", "@daily")
+
+	tests := []struct {
+		name    string
+		desired []batchv1.CronJob
+		live    []batchv1.CronJob
+		want    []Change
+	}{
+		{
+			name:    "empty cluster",
+			desired: []batchv1.CronJob{report, backup},
+			want: []Change{
+				{Job: "backup", Action: ActionCreate},
+				{Job: "report", Action: ActionCreate},
+			},
+		},
+		{
+			name:    "up to date",
+			desired: []batchv1.CronJob{backup},
+			live:    []batchv1.CronJob{started(backup)},
+			want:    []Change{{Job: "backup", Action: ActionNone}},
+		},
+		{
+			name:    "stopping a cronjob doesn't change its spec",
+			desired: []batchv1.CronJob{backup},
+			live:    []batchv1.CronJob{suspended(started(backup))},
+			want:    []Change{{Job: "backup", Action: ActionNone}},
+		},
+		{
+			name:    "changed spec",
+			desired: []batchv1.CronJob{cronJob("backup", "0 4 * * *")},
+			live:    []batchv1.CronJob{started(backup)},
+			want:    []Change{{Job: "backup", Action: ActionUpdate, Reason: "the spec changed"}},
+		},
+		{
+			name:    "cronjob created by someone else",
+			desired: []batchv1.CronJob{backup},
+			live:    []batchv1.CronJob{backup},
+			want: []Change{{
+				Job:    "backup",
+				Action: ActionUpdate,
+				Reason: "the cronjob was not created by the scheduler, it has no app.kubernetes.io/managed-by label",
+			}},
+		},
+		{
+			name:    "orphaned",
+			desired: []batchv1.CronJob{backup},
+			live:    []batchv1.CronJob{started(backup), started(report)},
+			want: []Change{
+				{Job: "backup", Action: ActionNone},
+				{Job: "report", Action: ActionOrphan, Reason: "there is no manifest for the cronjob"},
+			},
+		},
+		{
+			name: "cronjobs not created by the scheduler are ignored",
+			live: []batchv1.CronJob{report},
+			want: []Change{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Plan(tt.desired, tt.live); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Plan() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadSnapshot(t *testing.T) {
+	tests := []struct {
+		name     string
+		snapshot string
+		want     []string
+		wantErr  bool
+	}{
+		{
+			name: "json",
+			snapshot: `{"apiVersion": "v1", "kind": "List", "items": [
+				{"apiVersion": "batch/v1", "kind": "CronJob", "metadata": {"name": "backup"}, "spec": {"schedule": "@daily"}},
+				{"apiVersion": "batch/v1", "kind": "CronJob", "metadata": {"name": "report"}, "spec": {"schedule": "@hourly"}}
+			]}`,
+			want: []string{"backup", "report"},
+		},
+		{
+			name: "yaml",
+			snapshot: `apiVersion: v1
+kind: List
+items:
+  - apiVersion: batch/v1
+    kind: CronJob
+    metadata:
+      name: backup
+`,
+			want: []string{"backup"},
+		},
+		{name: "empty list", snapshot: `{"items": []}`, want: []string{}},
+		{name: "not a list", snapshot: `{"items": {}}`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			live, err := ReadSnapshot(strings.NewReader(tt.snapshot))
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ReadSnapshot: %s", err)
+			}
+			names := []string{}
+			for _, cj := range live {
+				names = append(names, cj.Name)
+			}
+			if !reflect.DeepEqual(names, tt.want) {
+				t.Errorf("cronjobs = %v, want %v", names, tt.want)
+			}
+		})
+	}
+}
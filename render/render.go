@@ -0,0 +1,139 @@
+// Package render renders templated manifests, Go templates executed
+// with the values file next to them and the values of the
+// environment
+package render
+
+import (
+	"bytes"
+	"fmt"
+	"path"
+	"strings"
+	"text/template"
+
+	"github.com/panagiotisptr/job-scheduler/config"
+	"sigs.k8s.io/yaml"
+)
+
+// Extension added to the name of a YAML manifest to render it as a
+// template e.g. backup.yaml.tmpl
+const Extension = ".tmpl"
+
+// ValuesFiles the names of the values file of the templates of a
+// directory
+var ValuesFiles = []string{"values.yaml", "values.yml"}
+
+// Values the data templates are executed with, as .Values
+type Values map[string]interface{}
+
+// IsTemplate whether the file is a templated manifest
+func IsTemplate(p string) bool {
+	return strings.HasSuffix(p, ".yaml"+Extension) ||
+		strings.HasSuffix(p, ".yml"+Extension)
+}
+
+// IsValuesFile whether the file holds the values of the templates of
+// its directory
+func IsValuesFile(p string) bool {
+	base := path.Base(p)
+	for _, name := range ValuesFiles {
+		if base == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// LoadValues parses the values file, which may be empty, and applies
+// the overrides to it
+func LoadValues(
+	valuesFile []byte,
+	overrides []config.TemplateValue,
+) (Values, error) {
+	values := Values{}
+	if err := yaml.Unmarshal(valuesFile, &values); err != nil {
+		return nil, err
+	}
+	if values == nil {
+		values = Values{}
+	}
+	for _, o := range overrides {
+		if err := values.Set(o.Key, o.Value); err != nil {
+			return nil, err
+		}
+	}
+
+	return values, nil
+}
+
+// Set sets the value at a dot separated path, creating the maps
+// along it
+func (v Values) Set(key string, value interface{}) error {
+	parts := strings.Split(key, ".")
+	m := map[string]interface{}(v)
+	for i, p := range parts[:len(parts)-1] {
+		next, ok := m[p]
+		if !ok {
+			child := map[string]interface{}{}
+			m[p] = child
+			m = child
+			continue
+		}
+		child, ok := next.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf(
+				"cannot set %s, %s is not a map",
+				key,
+				strings.Join(parts[:i+1], "."),
+			)
+		}
+		m = child
+	}
+	m[parts[len(parts)-1]] = value
+
+	return nil
+}
+
+var funcs = template.FuncMap{
+	"default": func(def interface{}, v interface{}) interface{} {
+		if v == nil || v == "" {
+			return def
+		}
+		return v
+	},
+	"required": func(msg string, v interface{}) (interface{}, error) {
+		if v == nil || v == "" {
+			return nil, fmt.Errorf("%s", msg)
+		}
+		return v, nil
+	},
+	"quote": func(v interface{}) string {
+		return fmt.Sprintf("%q", fmt.Sprint(v))
+	},
+	"lower": strings.ToLower,
+	"upper": strings.ToUpper,
+	"trim":  strings.TrimSpace,
+}
+
+// Render executes the template. Referencing a value that is not set
+// is an error, optional ones can be read with index and default e.g.
+// {{ index .Values "tag" | default "latest" }}
+func Render(name string, text []byte, values Values) ([]byte, error) {
+	tmpl, err := template.New(name).
+		Funcs(funcs).
+		Option("missingkey=error").
+		Parse(string(text))
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	err = tmpl.Execute(&buf, map[string]interface{}{
+		"Values": map[string]interface{}(values),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
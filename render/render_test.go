@@ -0,0 +1,181 @@
+package render
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/panagiotisptr/job-scheduler/config"
+)
+
+func TestIsTemplate(t *testing.T) {
+	for p, want := range map[string]bool{
+		"jobs/backup.yaml.tmpl": true,
+		"jobs/backup.yml.tmpl":  true,
+		"jobs/backup.yaml":      false,
+		"jobs/notes.txt.tmpl":   false,
+		"jobs/values.yaml":      false,
+	} {
+		if got := IsTemplate(p); got != want {
+			t.Errorf("IsTemplate(%s) = %t, want %t", p, got, want)
+		}
+	}
+}
+
+func TestIsValuesFile(t *testing.T) {
+	for p, want := range map[string]bool{
+		"jobs/values.yaml":      true,
+		"values.yml":            true,
+		"jobs/prod-values.yaml": false,
+		"jobs/values.json":      false,
+	} {
+		if got := IsValuesFile(p); got != want {
+			t.Errorf("IsValuesFile(%s) = %t, want %t", p, got, want)
+		}
+	}
+}
+
+func TestLoadValues(t *testing.T) {
+	valuesFile := []byte(`
+image:
+  repository: ghcr.io/acme/backup
+  tag: "1.0"
+schedule: "0 3 * * *"
+env:
+  LOG_LEVEL: info
+`)
+	tests := []struct {
+		name       string
+		valuesFile []byte
+		overrides  []config.TemplateValue
+		want       Values
+		wantErr    string
+	}{
+		{
+			name:       "values file",
+			valuesFile: valuesFile,
+			want: Values{
+				"image":    map[string]interface{}{"repository": "ghcr.io/acme/backup", "tag": "1.0"},
+				"schedule": "0 3 * * *",
+				"env":      map[string]interface{}{"LOG_LEVEL": "info"},
+			},
+		},
+		{
+			name:       "overrides take precedence",
+			valuesFile: valuesFile,
+			overrides: []config.TemplateValue{
+				{Key: "image.tag", Value: "2.0"},
+				{Key: "env.DRY_RUN", Value: true},
+				{Key: "replicas", Value: 2},
+			},
+			want: Values{
+				"image":    map[string]interface{}{"repository": "ghcr.io/acme/backup", "tag": "2.0"},
+				"schedule": "0 3 * * *",
+				"env":      map[string]interface{}{"LOG_LEVEL": "info", "DRY_RUN": true},
+				"replicas": 2,
+			},
+		},
+		{
+			name: "no values file",
+			overrides: []config.TemplateValue{
+				{Key: "image.tag", Value: "2.0"},
+			},
+			want: Values{"image": map[string]interface{}{"tag": "2.0"}},
+		},
+		{
+			name:       "empty values file",
+			valuesFile: []byte("# nothing yet\n"),
+			want:       Values{},
+		},
+		{
+			name:       "override through a value",
+			valuesFile: valuesFile,
+			overrides:  []config.TemplateValue{{Key: "schedule.hour", Value: 3}},
+			wantErr:    "cannot set schedule.hour, schedule is not a map",
+		},
+		{
+			name:       "invalid values file",
+			valuesFile: []byte("image: [\n"),
+			wantErr:    "yaml",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := LoadValues(tt.valuesFile, tt.overrides)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("error = %v, want one containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("values = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRender(t *testing.T) {
+	values := Values{
+		"image":    map[string]interface{}{"tag": "2.0"},
+		"schedule": "0 3 * * *",
+		"name":     " Backup ",
+	}
+	tests := []struct {
+		name     string
+		template string
+		want     string
+		wantErr  string
+	}{
+		{
+			name:     "values",
+			template: `image: ghcr.io/acme/backup:{{ .Values.image.tag }}`,
+			want:     `image: ghcr.io/acme/backup:2.0`,
+		},
+		{
+			name:     "functions",
+			template: `schedule: {{ .Values.schedule | quote }}, name: {{ .Values.name | trim | lower }}`,
+			want:     `schedule: "0 3 * * *", name: backup`,
+		},
+		{
+			name:     "default of an optional value",
+			template: `tag: {{ index .Values.image "digest" | default "none" }}`,
+			want:     `tag: none`,
+		},
+		{
+			name:     "missing value",
+			template: `suspend: {{ .Values.suspend }}`,
+			wantErr:  `map has no entry for key "suspend"`,
+		},
+		{
+			name:     "required value",
+			template: `{{ required "set the team" (index .Values "team") }}`,
+			wantErr:  "set the team",
+		},
+		{
+			name:     "invalid template",
+			template: `{{ .Values.image.tag `,
+			wantErr:  "backup.yaml.tmpl",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Render("backup.yaml.tmpl", []byte(tt.template), values)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("error = %v, want one containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("rendered %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
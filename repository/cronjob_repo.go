@@ -2,15 +2,116 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	batchv1 "k8s.io/api/batch/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 )
 
+// Source the location of the manifest a cronjob or task was read from
+type Source struct {
+	Owner  string `json:"owner"`
+	Name   string `json:"name"`
+	Path   string `json:"path"`
+	Branch string `json:"branch"`
+	// Ref the branch, tag or commit SHA of the location
+	Ref string `json:"ref"`
+	// Commit the SHA the manifest was read at, empty if unknown
+	Commit string `json:"commit,omitempty"`
+	// Pinned whether Commit was promoted, the manifest doesn't follow
+	// Ref until the pin is released
+	Pinned bool `json:"pinned,omitempty"`
+	// LastCommit the last commit that changed the manifest, only set
+	// when showing a single cronjob
+	LastCommit *Commit `json:"lastCommit,omitempty"`
+}
+
+// Commit a commit of the repository of a manifest
+type Commit struct {
+	SHA         string    `json:"sha"`
+	Author      string    `json:"author"`
+	AuthorEmail string    `json:"authorEmail,omitempty"`
+	Message     string    `json:"message"`
+	Date        time.Time `json:"date"`
+}
+
+// SyncRecord the outcome of indexing the manifests of every location
+type SyncRecord struct {
+	ID          uint64    `json:"id"`
+	StartedAt   time.Time `json:"startedAt"`
+	FinishedAt  time.Time `json:"finishedAt"`
+	Manifests   int       `json:"manifests"`
+	FailedPaths []string  `json:"failedPaths"`
+}
+
+// Promotion a cronjob of this environment pinned to the commit it
+// runs at in another environment
+type Promotion struct {
+	ID      uint64 `json:"id"`
+	JobName string `json:"jobName"`
+	From    string `json:"from"`
+	To      string `json:"to"`
+	Commit  string `json:"commit"`
+	// PreviousCommit the commit the manifest was read at before
+	PreviousCommit string `json:"previousCommit,omitempty"`
+	// PreviousPinned whether PreviousCommit was a promoted one
+	PreviousPinned bool      `json:"previousPinned,omitempty"`
+	Actor          string    `json:"actor"`
+	CreatedAt      time.Time `json:"createdAt"`
+}
+
 // CronJobRepository interfaces with the GitHub API to get available cronjobs
 type CronJobRepository interface {
 	// GetCronJobNames get list of available cronjob names
 	GetCronJobNames(ctx context.Context) ([]string, error)
 
-	// GetCronJob get cronjob configuration
+	// GetCronJob get cronjob configuration, read at the commit it
+	// was indexed at rather than the head of its ref so that it
+	// matches the commit recorded for it
 	GetCronJob(ctx context.Context, name string) (*batchv1.CronJob, error)
+
+	// GetCronJobAnnotations get the annotations of the cronjob as
+	// indexed, without reading its manifest
+	GetCronJobAnnotations(ctx context.Context, name string) (map[string]string, error)
+
+	// GetCronJobSource get the location of the cronjob manifest
+	GetCronJobSource(ctx context.Context, name string) (*Source, error)
+
+	// GetLastCommit get the last commit that changed the manifest of
+	// the source, as of the commit it was read at
+	GetLastCommit(ctx context.Context, source *Source) (*Commit, error)
+
+	// GetCompanions get the other resources declared in the file of
+	// the cronjob
+	GetCompanions(ctx context.Context, name string) ([]unstructured.Unstructured, error)
+
+	// GetTaskNames get list of available task names. Tasks are
+	// one-off Job manifests
+	GetTaskNames(ctx context.Context) ([]string, error)
+
+	// GetTask get task configuration
+	GetTask(ctx context.Context, name string) (*batchv1.Job, error)
+
+	// GetTaskSource get the location of the task manifest
+	GetTaskSource(ctx context.Context, name string) (*Source, error)
+
+	// GetSyncHistory get the most recent syncs, newest first
+	GetSyncHistory(ctx context.Context) ([]SyncRecord, error)
+
+	// PinCronJob read the manifest of the cronjob at the commit
+	// instead of the head of its ref. The commit is resolved to a
+	// full SHA, which is returned
+	PinCronJob(ctx context.Context, name string, commit string) (string, error)
+
+	// UnpinCronJob follow the ref of the cronjob again
+	UnpinCronJob(ctx context.Context, name string) error
+
+	// RecordPromotion store the promotion, assigning it an ID
+	RecordPromotion(ctx context.Context, promotion Promotion) (Promotion, error)
+
+	// DeletePromotion remove the promotion from the history
+	DeletePromotion(ctx context.Context, id uint64) error
+
+	// GetPromotions get the most recent promotions, newest first
+	GetPromotions(ctx context.Context) ([]Promotion, error)
 }
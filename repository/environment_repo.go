@@ -0,0 +1,11 @@
+package repository
+
+import "context"
+
+// EnvironmentRepository reads from the schedulers of the environments
+// cronjobs are promoted from
+type EnvironmentRepository interface {
+	// GetAppliedCommit get the commit the cronjob was last applied
+	// at in the cluster of the environment
+	GetAppliedCommit(ctx context.Context, environment string, name string) (string, error)
+}
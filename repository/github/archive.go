@@ -0,0 +1,77 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/google/go-github/v48/github"
+	"github.com/panagiotisptr/job-scheduler/apperror"
+	"github.com/panagiotisptr/job-scheduler/archive"
+	"github.com/panagiotisptr/job-scheduler/config"
+	"github.com/panagiotisptr/job-scheduler/tracing"
+	"go.opentelemetry.io/otel/trace"
+	"sigs.k8s.io/kustomize/kyaml/filesys"
+)
+
+// downloadRepository extracts the archive of the repository at the
+// ref to an in-memory file system
+func (r *GitHubCronJobRepository) downloadRepository(
+	ctx context.Context,
+	location config.GitHubRepositoryArgs,
+	ref string,
+) (filesys.FileSystem, error) {
+	ctx, span := r.tracer.Start(
+		ctx,
+		"github.GetArchive",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(locationAttributes(location)...),
+	)
+	defer span.End()
+
+	link, _, err := r.client.Repositories.GetArchiveLink(
+		ctx,
+		location.Owner,
+		location.Name,
+		github.Tarball,
+		&github.RepositoryContentGetOptions{
+			Ref: ref,
+		},
+		true,
+	)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return nil, apperror.Wrap(apperror.CodeUnavailable, err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, link.String(), nil)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return nil, err
+	}
+	res, err := r.client.Client().Do(req)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return nil, apperror.Wrap(apperror.CodeUnavailable, err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		err := apperror.Unavailable(
+			"failed to download the archive of %s/%s: %s",
+			location.Owner,
+			location.Name,
+			res.Status,
+		)
+		tracing.RecordError(span, err)
+		return nil, err
+	}
+
+	fSys, err := archive.FromTarball(res.Body)
+	if err != nil {
+		err = fmt.Errorf("failed to extract the archive of %s/%s: %w", location.Owner, location.Name, err)
+		tracing.RecordError(span, err)
+		return nil, apperror.Wrap(apperror.CodeUnavailable, err)
+	}
+
+	return fSys, nil
+}
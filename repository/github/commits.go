@@ -0,0 +1,91 @@
+package github
+
+import (
+	"context"
+
+	"github.com/google/go-github/v48/github"
+	"github.com/panagiotisptr/job-scheduler/apperror"
+	"github.com/panagiotisptr/job-scheduler/repository"
+	"github.com/panagiotisptr/job-scheduler/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// lastCommit the last commit of a path as of a SHA
+type lastCommit struct {
+	at     string
+	commit *repository.Commit
+}
+
+func (r *GitHubCronJobRepository) GetLastCommit(
+	ctx context.Context,
+	source *repository.Source,
+) (*repository.Commit, error) {
+	ref := source.Ref
+	if source.Commit != "" {
+		ref = source.Commit
+	}
+	key := source.Owner + "/" + source.Name + "/" + source.Path
+
+	// the history of a commit never changes, only refs move
+	r.commitsMu.Lock()
+	cached, ok := r.lastCommits[key]
+	r.commitsMu.Unlock()
+	if ok && source.Commit != "" && cached.at == source.Commit {
+		return cached.commit, nil
+	}
+
+	ctx, span := r.tracer.Start(
+		ctx,
+		"github.ListCommits",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("github.owner", source.Owner),
+			attribute.String("github.repo", source.Name),
+			attribute.String("github.path", source.Path),
+			attribute.String("github.ref", ref),
+		),
+	)
+	defer span.End()
+
+	commits, _, err := r.client.Repositories.ListCommits(
+		ctx,
+		source.Owner,
+		source.Name,
+		&github.CommitsListOptions{
+			SHA:  ref,
+			Path: source.Path,
+			ListOptions: github.ListOptions{
+				PerPage: 1,
+			},
+		},
+	)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return nil, apperror.Wrap(apperror.CodeUnavailable, err)
+	}
+	if len(commits) == 0 {
+		err := apperror.NotFound("could not find a commit of %s", source.Path)
+		tracing.RecordError(span, err)
+		return nil, err
+	}
+
+	c := commits[0]
+	commit := &repository.Commit{
+		SHA:         c.GetSHA(),
+		Author:      c.GetCommit().GetAuthor().GetName(),
+		AuthorEmail: c.GetCommit().GetAuthor().GetEmail(),
+		Message:     c.GetCommit().GetMessage(),
+		Date:        c.GetCommit().GetAuthor().GetDate(),
+	}
+	if source.Commit != "" {
+		r.commitsMu.Lock()
+		r.lastCommits[key] = lastCommit{
+			at:     source.Commit,
+			commit: commit,
+		}
+		r.commitsMu.Unlock()
+	}
+
+	return commit, nil
+}
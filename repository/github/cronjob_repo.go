@@ -2,18 +2,35 @@ package github
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"io"
+	"path"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/google/go-github/v48/github"
+	"github.com/panagiotisptr/job-scheduler/apperror"
 	"github.com/panagiotisptr/job-scheduler/config"
+	"github.com/panagiotisptr/job-scheduler/events"
+	"github.com/panagiotisptr/job-scheduler/health"
+	"github.com/panagiotisptr/job-scheduler/metrics"
 	"github.com/panagiotisptr/job-scheduler/parser"
+	"github.com/panagiotisptr/job-scheduler/render"
 	"github.com/panagiotisptr/job-scheduler/repository"
+	"github.com/panagiotisptr/job-scheduler/store"
+	"github.com/panagiotisptr/job-scheduler/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/fx"
 	"go.uber.org/zap"
+	"golang.org/x/sync/singleflight"
 	batchv1 "k8s.io/api/batch/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 )
 
 const (
@@ -26,11 +43,95 @@ func isYaml(path string) bool {
 		strings.Contains(path, ".yaml")
 }
 
+// hashManifest used to detect changes to a manifest between syncs
+func hashManifest(obj interface{}) string {
+	b, err := json.Marshal(obj)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(b)
+
+	return hex.EncodeToString(sum[:])
+}
+
+// sameLocation whether a is b or a directory under it
+func sameLocation(a, b config.GitHubRepositoryArgs) bool {
+	return a.Owner == b.Owner &&
+		a.Name == b.Name &&
+		a.GetRef() == b.GetRef() &&
+		underPath(a.Path, b.Path)
+}
+
+// underPath whether p is dir or inside it. A plain prefix check would
+// put jobs-old/ under jobs
+func underPath(p string, dir string) bool {
+	dir = strings.TrimSuffix(dir, "/")
+	if dir == "" || dir == "." {
+		return true
+	}
+
+	return p == dir || strings.HasPrefix(p, dir+"/")
+}
+
+func locationLabel(location config.GitHubRepositoryArgs) string {
+	return fmt.Sprintf(
+		"%s/%s/%s@%s",
+		location.Owner,
+		location.Name,
+		location.Path,
+		location.GetRef(),
+	)
+}
+
+func locationAttributes(location config.GitHubRepositoryArgs) []attribute.KeyValue {
+	return []attribute.KeyValue{
+		attribute.String("github.owner", location.Owner),
+		attribute.String("github.repo", location.Name),
+		attribute.String("github.path", location.Path),
+		attribute.String("github.ref", location.GetRef()),
+	}
+}
+
+type manifestEntry struct {
+	location config.GitHubRepositoryArgs
+	// commit the SHA the manifest was read at
+	commit    string
+	namespace string
+	hash      string
+	// annotations of the cronjob, looked up for every job event
+	annotations map[string]string
+	// pinned whether commit is a promoted one
+	pinned bool
+}
+
 type GitHubCronJobRepository struct {
 	logger        *zap.Logger
 	client        *github.Client
-	cronJobs      map[string]config.GitHubRepositoryArgs
+	bus           *events.Bus
+	metrics       *metrics.Metrics
+	tracer        trace.Tracer
+	mu            sync.RWMutex
+	lastSync      time.Time
+	cronJobs      map[string]manifestEntry
+	tasks         map[string]manifestEntry
 	cronJobParser *parser.CronJobParser
+	store         store.Store
+	// templateValues the values of this environment for templated
+	// manifests
+	templateValues []config.TemplateValue
+	// lastCommits the last commit of each manifest path, at the SHA
+	// it was looked up at
+	commitsMu   sync.Mutex
+	lastCommits map[string]lastCommit
+	// pins the promoted revisions of cronjobs, read instead of the
+	// head of their ref
+	pins map[string]pin
+	// rendered the kustomize builds and chart renders by location
+	// and commit, see
+	// cachedRender
+	renderMu sync.Mutex
+	rendered map[string]parser.Result
+	renders  singleflight.Group
 }
 
 func ProvideGitHubCronJobRepository(
@@ -39,13 +140,34 @@ func ProvideGitHubCronJobRepository(
 	logger *zap.Logger,
 	client *github.Client,
 	p *parser.CronJobParser,
+	bus *events.Bus,
+	m *metrics.Metrics,
+	tp trace.TracerProvider,
+	checker *health.Checker,
+	st store.Store,
 ) (repository.CronJobRepository, error) {
 	repo := &GitHubCronJobRepository{
 		logger:        logger,
-		cronJobs:      make(map[string]config.GitHubRepositoryArgs),
+		cronJobs:      make(map[string]manifestEntry),
+		tasks:         make(map[string]manifestEntry),
 		client:        client,
+		bus:           bus,
+		metrics:       m,
+		tracer:        tp.Tracer("github.com/panagiotisptr/job-scheduler/repository/github"),
 		cronJobParser: p,
+		store:         st,
+
+		templateValues: cfg.Templates.Values,
+		lastCommits:    make(map[string]lastCommit),
+		pins:           make(map[string]pin),
+		rendered:       make(map[string]parser.Result),
 	}
+	// serve the index of the previous run until the first sync
+	if err := repo.loadState(context.Background()); err != nil {
+		logger.Sugar().Error("failed to load the persisted index: ", err)
+	}
+
+	checker.Register("github-sync", repo.checkSynced)
 
 	ticker := time.NewTicker(syncTime)
 	stop := make(chan struct{})
@@ -58,20 +180,23 @@ func ProvideGitHubCronJobRepository(
 				repo.logger.Sugar().Error("failed to sync cronjobs with GitHub: ", err)
 			}
 			go func() {
-				select {
-				case <-ticker.C:
-					repo.logger.Sugar().Info("syncing cronjobs with github")
-					// has to be different context here
-					tctx, cl := context.WithTimeout(context.Background(), time.Duration(timeoutThreshold))
-					defer cl()
-					err := repo.sync(tctx, cfg.GitHubConfig.Locations)
-					if err != nil {
-						repo.logger.Sugar().Error("failed to sync cronjobs with GitHub: ", err)
+				for {
+					select {
+					case <-ticker.C:
+						repo.logger.Sugar().Info("syncing cronjobs with github")
+						// has to be different context here
+						tctx, cl := context.WithTimeout(context.Background(), time.Duration(timeoutThreshold))
+						err := repo.sync(tctx, cfg.GitHubConfig.Locations)
+						cl()
+						if err != nil {
+							repo.logger.Sugar().Error("failed to sync cronjobs with GitHub: ", err)
+							continue
+						}
+						repo.logger.Sugar().Info("cronjobs synced")
+					case <-stop:
+						ticker.Stop()
+						return
 					}
-					repo.logger.Sugar().Info("cronjobs synced")
-				case <-stop:
-					ticker.Stop()
-					return
 				}
 			}()
 
@@ -92,36 +217,96 @@ func (r *GitHubCronJobRepository) sync(
 	ctx context.Context,
 	locations []config.GitHubRepositoryArgs,
 ) error {
-	completed := make(chan struct{})
-	var err error
+	ctx, span := r.tracer.Start(ctx, "GitHubCronJobRepository.sync")
+	defer span.End()
+
+	// buffered so that the sync can complete after a timeout
+	completed := make(chan error, 1)
 
 	go func() {
+		start := time.Now()
+		index := make(map[string]manifestEntry)
+		taskIndex := make(map[string]manifestEntry)
+		failed := []config.GitHubRepositoryArgs{}
+		parseFailures := 0
+		syncedLocations := 0
 		for _, location := range locations {
+			ctx, locationSpan := r.tracer.Start(
+				ctx,
+				"GitHubCronJobRepository.syncLocation",
+				trace.WithAttributes(locationAttributes(location)...),
+			)
+			start := time.Now()
+			failedBefore := len(failed)
 			paths := []string{location.Path}
+			// read every file of the location at the same commit
+			commit := r.resolveCommit(ctx, location)
+			ref := location.GetRef()
+			if commit != "" {
+				ref = commit
+			}
+			if location.Kustomize || location.Helm {
+				// the location is built or rendered as a whole
+				// instead of walking its files
+				paths = nil
+				manifests, err := r.parseFile(ctx, location, ref)
+				if err != nil {
+					r.logger.With(
+						zap.String("owner", location.Owner),
+						zap.String("name", location.Name),
+						zap.String("path", location.Path),
+						zap.String("ref", location.GetRef()),
+					).Sugar().Error(
+						"failed to sync location: ",
+						err,
+					)
+					failed = append(failed, location)
+					if apperror.CodeOf(err) == apperror.CodeInvalid {
+						parseFailures++
+					}
+				} else {
+					parseFailures += r.indexManifests(index, taskIndex, manifests, location, commit)
+				}
+			}
 
 			for len(paths) > 0 {
 				p := paths[len(paths)-1]
 				paths = paths[:len(paths)-1]
 
-				_, content, _, err := r.client.Repositories.GetContents(
+				contentsCtx, contentsSpan := r.tracer.Start(
 					ctx,
+					"github.GetContents",
+					trace.WithSpanKind(trace.SpanKindClient),
+					trace.WithAttributes(attribute.String("github.path", p)),
+				)
+				_, content, _, err := r.client.Repositories.GetContents(
+					contentsCtx,
 					location.Owner,
 					location.Name,
 					p,
 					&github.RepositoryContentGetOptions{
-						Ref: location.Branch,
+						Ref: ref,
 					},
 				)
+				tracing.RecordError(contentsSpan, err)
+				contentsSpan.End()
 				if err != nil {
 					r.logger.With(
 						zap.String("owner", location.Owner),
 						zap.String("name", location.Name),
 						zap.String("path", p),
-						zap.String("branch", location.Branch),
+						zap.String("ref", location.GetRef()),
 					).Sugar().Error(
 						"failed to get repository contents: ",
 						err,
 					)
+					failed = append(failed, config.GitHubRepositoryArgs{
+						Owner:  location.Owner,
+						Name:   location.Name,
+						Path:   p,
+						Branch: location.Branch,
+						Ref:    location.Ref,
+					})
 					continue
 				}
 
@@ -132,74 +317,292 @@ func (r *GitHubCronJobRepository) sync(
 							paths = append(paths, c.GetPath())
 						}
 					case "file":
-						if !isYaml(c.GetPath()) {
+						if !isYaml(c.GetPath()) || render.IsValuesFile(c.GetPath()) {
 							continue
 						}
-						reader, err := r.getFileReader(
-							ctx,
-							config.GitHubRepositoryArgs{
-								Owner:  location.Owner,
-								Name:   location.Name,
-								Path:   c.GetPath(),
-								Branch: location.Branch,
-							},
-						)
+						entryLocation := config.GitHubRepositoryArgs{
+							Owner:  location.Owner,
+							Name:   location.Name,
+							Path:   c.GetPath(),
+							Branch: location.Branch,
+							Ref:    location.Ref,
+						}
+						manifests, err := r.parseFile(ctx, entryLocation, ref)
 						if err != nil {
 							r.logger.With(
 								zap.String("owner", location.Owner),
 								zap.String("name", location.Name),
 								zap.String("path", p),
-								zap.String("branch", location.Branch),
+								zap.String("ref", location.GetRef()),
 							).Sugar().Error(
 								"failed to get reader for file: ",
 								err,
 							)
+							failed = append(failed, entryLocation)
 							continue
 						}
-						defer reader.Close()
-						cronJobs := r.cronJobParser.ParseCronJobConfigs(
-							reader,
-						)
-						for _, cj := range cronJobs {
-							// one yaml file could have multiple cron jobs
-							r.cronJobs[cj.Name] = config.GitHubRepositoryArgs{
-								Owner:  location.Owner,
-								Name:   location.Name,
-								Path:   c.GetPath(),
-								Branch: location.Branch,
-							}
-						}
+						parseFailures += r.indexManifests(index, taskIndex, manifests, entryLocation, commit)
 					}
 				}
 			}
+			r.metrics.ObserveSync(
+				locationLabel(location),
+				start,
+				len(failed) > failedBefore,
+			)
+			if len(failed) > failedBefore {
+				locationSpan.SetStatus(codes.Error, "failed to sync some paths")
+			} else {
+				syncedLocations++
+			}
+			locationSpan.End()
 		}
 
-		completed <- struct{}{}
+		r.updateIndex(index, taskIndex, failed)
+		r.metrics.ParseFailures.Set(float64(parseFailures))
+		manifests := len(index) + len(taskIndex)
+		record := syncRecord(start, manifests, failed)
+		if err := r.recordSync(context.Background(), record); err != nil {
+			r.logger.Sugar().Error("failed to record sync: ", err)
+		}
+		// a sync where every location failed is no sync at all
+		if len(failed) == 0 || syncedLocations > 0 {
+			r.mu.Lock()
+			r.lastSync = time.Now()
+			r.mu.Unlock()
+		}
+		if len(failed) > 0 {
+			completed <- fmt.Errorf(
+				"failed to sync %s",
+				strings.Join(record.FailedPaths, ", "),
+			)
+			return
+		}
+		completed <- nil
 	}()
 
 	select {
-	case <-completed:
+	case err := <-completed:
+		tracing.RecordError(span, err)
 		return err
 	case <-ctx.Done():
-		return fmt.Errorf("failed to sync files. Context timeout")
+		err := fmt.Errorf("failed to sync files. Context timeout")
+		tracing.RecordError(span, err)
+		return err
+	}
+}
+
+// indexManifests adds the cronjobs and tasks of a file, or of
+// a kustomization or chart, to the indexes and returns how many of
+// its documents failed to parse
+func (r *GitHubCronJobRepository) indexManifests(
+	index map[string]manifestEntry,
+	taskIndex map[string]manifestEntry,
+	manifests parser.Result,
+	location config.GitHubRepositoryArgs,
+	commit string,
+) int {
+	for _, cj := range manifests.CronJobs {
+		// one yaml file could have multiple cron jobs
+		index[cj.Name] = manifestEntry{
+			location:    location,
+			commit:      commit,
+			namespace:   cj.Namespace,
+			hash:        hashManifest(cj),
+			annotations: cj.Annotations,
+		}
+	}
+	for _, task := range manifests.Jobs {
+		taskIndex[task.Name] = manifestEntry{
+			location:  location,
+			commit:    commit,
+			namespace: task.Namespace,
+			hash:      hashManifest(task),
+		}
+	}
+
+	parseFailures := 0
+	for _, d := range manifests.Diagnostics {
+		if d.Severity == parser.SeverityError {
+			parseFailures++
+		}
+	}
+
+	return parseFailures
+}
+
+// checkSynced fails until a sync with GitHub reads at least one
+// location
+func (r *GitHubCronJobRepository) checkSynced(ctx context.Context) error {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if r.lastSync.IsZero() {
+		return fmt.Errorf("initial sync with GitHub has not completed")
+	}
+
+	return nil
+}
+
+// carryOver copies the entries under paths that failed to sync
+// from the current index
+func carryOver(
+	current map[string]manifestEntry,
+	index map[string]manifestEntry,
+	failed []config.GitHubRepositoryArgs,
+) {
+	for name, old := range current {
+		if _, ok := index[name]; ok {
+			continue
+		}
+		for _, f := range failed {
+			if sameLocation(old.location, f) {
+				index[name] = old
+				break
+			}
+		}
+	}
+}
+
+// updateIndex replaces the current index with the one built during
+// a sync and publishes an event for every cronjob manifest that
+// changed. Entries under paths that failed to sync are carried over
+// so that a GitHub outage is not reported as the manifests being
+// removed, and so are pinned cronjobs, which are read at their
+// promoted commit whether the head of their ref has them or not
+func (r *GitHubCronJobRepository) updateIndex(
+	index map[string]manifestEntry,
+	taskIndex map[string]manifestEntry,
+	failed []config.GitHubRepositoryArgs,
+) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	carryOver(r.cronJobs, index, failed)
+	carryOver(r.tasks, taskIndex, failed)
+	for name := range r.pins {
+		if _, ok := index[name]; ok {
+			continue
+		}
+		if old, ok := r.cronJobs[name]; ok {
+			index[name] = old
+		}
+	}
+
+	for name, entry := range index {
+		old, ok := r.cronJobs[name]
+		if !ok {
+			r.bus.Publish(events.Event{
+				Type:      events.ManifestAdded,
+				JobName:   name,
+				Namespace: entry.namespace,
+			})
+		} else if old.hash != entry.hash {
+			r.bus.Publish(events.Event{
+				Type:      events.ManifestChanged,
+				JobName:   name,
+				Namespace: entry.namespace,
+			})
+		}
+	}
+	for name, old := range r.cronJobs {
+		if _, ok := index[name]; !ok {
+			r.bus.Publish(events.Event{
+				Type:      events.ManifestRemoved,
+				JobName:   name,
+				Namespace: old.namespace,
+			})
+		}
+	}
+
+	r.cronJobs = index
+	r.tasks = taskIndex
+	r.pruneRendered()
+	r.metrics.IndexedManifests.Set(float64(len(index) + len(taskIndex)))
+	if err := r.saveIndex(context.Background(), index, taskIndex); err != nil {
+		r.logger.Sugar().Error("failed to persist the index: ", err)
+	}
+}
+
+// resolveCommit returns the SHA the ref of the location points to
+// or an empty string if it can't be resolved
+func (r *GitHubCronJobRepository) resolveCommit(
+	ctx context.Context,
+	location config.GitHubRepositoryArgs,
+) string {
+	sha, err := r.commitSHA(ctx, location, location.GetRef())
+	if err != nil {
+		r.logger.With(
+			zap.String("owner", location.Owner),
+			zap.String("name", location.Name),
+			zap.String("ref", location.GetRef()),
+		).Sugar().Warn(
+			"failed to resolve commit, reading the ref instead: ",
+			err,
+		)
+		return ""
+	}
+
+	return sha
+}
+
+// commitSHA returns the full SHA of a ref of the repository of the
+// location
+func (r *GitHubCronJobRepository) commitSHA(
+	ctx context.Context,
+	location config.GitHubRepositoryArgs,
+	ref string,
+) (string, error) {
+	ctx, span := r.tracer.Start(
+		ctx,
+		"github.GetCommitSHA1",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(locationAttributes(location)...),
+	)
+	defer span.End()
+
+	sha, _, err := r.client.Repositories.GetCommitSHA1(
+		ctx,
+		location.Owner,
+		location.Name,
+		ref,
+		"",
+	)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return "", err
 	}
+
+	return sha, nil
 }
 
+// getFileReader downloads the file at the location. ref is the
+// commit or branch to read the file at
 func (r *GitHubCronJobRepository) getFileReader(
 	ctx context.Context,
 	location config.GitHubRepositoryArgs,
+	ref string,
 ) (io.ReadCloser, error) {
+	ctx, span := r.tracer.Start(
+		ctx,
+		"github.DownloadContents",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(locationAttributes(location)...),
+	)
+	defer span.End()
+
 	reader, _, err := r.client.Repositories.DownloadContents(
 		ctx,
 		location.Owner,
 		location.Name,
 		location.Path,
 		&github.RepositoryContentGetOptions{
-			Ref: location.Branch,
+			Ref: ref,
 		},
 	)
 	if err != nil {
-		return nil, err
+		tracing.RecordError(span, err)
+		return nil, apperror.Wrap(apperror.CodeUnavailable, err)
 	}
 
 	return reader, nil
@@ -208,6 +611,9 @@ func (r *GitHubCronJobRepository) getFileReader(
 func (r *GitHubCronJobRepository) GetCronJobNames(
 	ctx context.Context,
 ) ([]string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
 	names := []string{}
 	for name := range r.cronJobs {
 		names = append(names, name)
@@ -216,44 +622,221 @@ func (r *GitHubCronJobRepository) GetCronJobNames(
 	return names, nil
 }
 
+func (r *GitHubCronJobRepository) GetCronJobSource(
+	ctx context.Context,
+	name string,
+) (*repository.Source, error) {
+	entry, ok := r.cronJobEntry(name)
+	if !ok {
+		return nil, apperror.NotFound("could not find cronjob with name: %s", name)
+	}
+
+	return entrySource(entry), nil
+}
+
+func (r *GitHubCronJobRepository) GetCronJobAnnotations(
+	ctx context.Context,
+	name string,
+) (map[string]string, error) {
+	entry, ok := r.cronJobEntry(name)
+	if !ok {
+		return nil, apperror.NotFound("could not find cronjob with name: %s", name)
+	}
+
+	return entry.annotations, nil
+}
+
+// entrySource the location an entry was indexed from
+func entrySource(entry manifestEntry) *repository.Source {
+	return &repository.Source{
+		Owner:  entry.location.Owner,
+		Name:   entry.location.Name,
+		Path:   entry.location.Path,
+		Branch: entry.location.Branch,
+		Ref:    entry.location.GetRef(),
+		Commit: entry.commit,
+		Pinned: entry.pinned,
+	}
+}
+
 func (r *GitHubCronJobRepository) GetCronJob(
 	ctx context.Context,
 	name string,
 ) (*batchv1.CronJob, error) {
-	location, ok := r.cronJobs[name]
+	ctx, span := r.tracer.Start(
+		ctx,
+		"GitHubCronJobRepository.GetCronJob",
+		trace.WithAttributes(attribute.String("job.name", name)),
+	)
+	defer span.End()
+
+	entry, ok := r.cronJobEntry(name)
 	if !ok {
-		return nil, fmt.Errorf("could not find cronjob with name: %s", name)
+		err := apperror.NotFound("could not find cronjob with name: %s", name)
+		tracing.RecordError(span, err)
+		return nil, err
 	}
 
-	reader, err := r.getFileReader(
+	manifests, err := r.readManifests(ctx, entry)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return nil, err
+	}
+	for _, cj := range manifests.CronJobs {
+		if cj.Name == name {
+			return &cj, nil
+		}
+	}
+
+	err = apperror.NotFound(
+		"failed to find cronjob with name: %s",
+		name,
+	)
+	tracing.RecordError(span, err)
+
+	return nil, err
+}
+
+func (r *GitHubCronJobRepository) GetCompanions(
+	ctx context.Context,
+	name string,
+) ([]unstructured.Unstructured, error) {
+	ctx, span := r.tracer.Start(
 		ctx,
-		location,
+		"GitHubCronJobRepository.GetCompanions",
+		trace.WithAttributes(attribute.String("job.name", name)),
 	)
+	defer span.End()
+
+	entry, ok := r.cronJobEntry(name)
+	if !ok {
+		err := apperror.NotFound("could not find cronjob with name: %s", name)
+		tracing.RecordError(span, err)
+		return nil, err
+	}
+
+	manifests, err := r.readManifests(ctx, entry)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return nil, err
+	}
+
+	return manifests.Companions, nil
+}
+
+// readManifests downloads and parses the file of an entry at the
+// commit it was indexed at
+func (r *GitHubCronJobRepository) readManifests(
+	ctx context.Context,
+	entry manifestEntry,
+) (parser.Result, error) {
+	location := entry.location
+	ref := location.GetRef()
+	if entry.commit != "" {
+		ref = entry.commit
+	}
+
+	manifests, err := r.parseFile(ctx, location, ref)
 	if err != nil {
 		r.logger.With(
 			zap.String("owner", location.Owner),
 			zap.String("name", location.Name),
 			zap.String("path", location.Path),
-			zap.String("branch", location.Branch),
+			zap.String("ref", location.GetRef()),
 		).Sugar().Error(
 			"failed to get reader for file: ",
 			err,
 		)
-		return nil, err
+		return parser.Result{}, err
+	}
+
+	return manifests, nil
+}
+
+// parseFile downloads and parses a manifest at the ref. Templated
+// manifests are rendered with the values file of their directory,
+// kustomize locations are built and charts rendered
+func (r *GitHubCronJobRepository) parseFile(
+	ctx context.Context,
+	location config.GitHubRepositoryArgs,
+	ref string,
+) (parser.Result, error) {
+	if location.Kustomize {
+		return r.cachedRender(ctx, location, ref, r.parseKustomization)
+	}
+	if location.Helm {
+		return r.cachedRender(ctx, location, ref, r.parseChart)
+	}
+
+	reader, err := r.getFileReader(
+		ctx,
+		location,
+		ref,
+	)
+	if err != nil {
+		return parser.Result{}, err
 	}
+	defer reader.Close()
 
-	cronJobs := r.cronJobParser.ParseCronJobConfigs(
+	if !render.IsTemplate(location.Path) {
+		return r.cronJobParser.ParseManifests(reader), nil
+	}
+	values, err := r.readValuesFile(ctx, location, ref)
+	if err != nil {
+		return parser.Result{}, err
+	}
+
+	return r.cronJobParser.ParseTemplateManifests(
+		location.Path,
 		reader,
+		values,
+		r.templateValues,
+	), nil
+}
+
+// readValuesFile downloads the values file next to a templated
+// manifest, nil if there is none
+func (r *GitHubCronJobRepository) readValuesFile(
+	ctx context.Context,
+	location config.GitHubRepositoryArgs,
+	ref string,
+) ([]byte, error) {
+	dir := path.Dir(location.Path)
+	contentsCtx, contentsSpan := r.tracer.Start(
+		ctx,
+		"github.GetContents",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("github.path", dir)),
+	)
+	_, content, _, err := r.client.Repositories.GetContents(
+		contentsCtx,
+		location.Owner,
+		location.Name,
+		dir,
+		&github.RepositoryContentGetOptions{
+			Ref: ref,
+		},
 	)
+	tracing.RecordError(contentsSpan, err)
+	contentsSpan.End()
+	if err != nil {
+		return nil, apperror.Wrap(apperror.CodeUnavailable, err)
+	}
 
-	for _, cj := range cronJobs {
-		if cj.Name == name {
-			return &cj, nil
+	for _, c := range content {
+		if c.GetType() != "file" || !render.IsValuesFile(c.GetPath()) {
+			continue
+		}
+		valuesLocation := location
+		valuesLocation.Path = c.GetPath()
+		reader, err := r.getFileReader(ctx, valuesLocation, ref)
+		if err != nil {
+			return nil, err
 		}
+		defer reader.Close()
+
+		return io.ReadAll(reader)
 	}
 
-	return nil, fmt.Errorf(
-		"failed to find cronjob with name: %s",
-		name,
-	)
+	return nil, nil
 }
@@ -0,0 +1,133 @@
+package github
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/panagiotisptr/job-scheduler/config"
+)
+
+func syncConfig(locations ...config.GitHubRepositoryArgs) *config.Config {
+	return &config.Config{
+		GitHubConfig: config.GitHubConfig{Locations: locations},
+	}
+}
+
+func cronJobNames(t *testing.T, r *GitHubCronJobRepository) []string {
+	t.Helper()
+	names, err := r.GetCronJobNames(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+func TestSync(t *testing.T) {
+	gh := newFakeGitHub(t, map[string]string{
+		"team-a/backup.yml":         cronJobManifest("backup"),
+		"team-a/nested/report.yaml": cronJobManifest("report"),
+		"team-a/README.md":          "not a manifest",
+		"team-b/cleanup.yml":        cronJobManifest("cleanup"),
+	})
+	cfg := syncConfig(testLocation("team-a"), testLocation("team-b"))
+	r := newTestRepository(t, gh, cfg, nil)
+
+	if err := r.checkSynced(context.Background()); err == nil {
+		t.Error("synced before the first sync")
+	}
+	if err := r.sync(context.Background(), cfg.GitHubConfig.Locations); err != nil {
+		t.Fatalf("sync: %s", err)
+	}
+	if err := r.checkSynced(context.Background()); err != nil {
+		t.Errorf("checkSynced: %s", err)
+	}
+	if got, want := cronJobNames(t, r), []string{"backup", "cleanup", "report"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("cronjobs = %v, want %v", got, want)
+	}
+	source, err := r.GetCronJobSource(context.Background(), "report")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if source.Path != "team-a/nested/report.yaml" || source.Commit != testSHA {
+		t.Errorf("source = %+v, want team-a/nested/report.yaml at %s", source, testSHA)
+	}
+}
+
+func TestSyncWithFailedLocations(t *testing.T) {
+	files := map[string]string{
+		"team-a/backup.yml":  cronJobManifest("backup"),
+		"team-b/cleanup.yml": cronJobManifest("cleanup"),
+	}
+	locations := []config.GitHubRepositoryArgs{testLocation("team-a"), testLocation("team-b")}
+
+	t.Run("some locations", func(t *testing.T) {
+		gh := newFakeGitHub(t, files)
+		gh.fail("team-b")
+		r := newTestRepository(t, gh, syncConfig(locations...), nil)
+
+		err := r.sync(context.Background(), locations)
+		if err == nil || !strings.Contains(err.Error(), "acme/jobs/team-b@main") {
+			t.Errorf("sync error = %v, want one listing acme/jobs/team-b@main", err)
+		}
+		if err != nil && strings.Contains(err.Error(), "team-a") {
+			t.Errorf("sync error %s lists a location that synced", err)
+		}
+		if err := r.checkSynced(context.Background()); err != nil {
+			t.Errorf("checkSynced: %s", err)
+		}
+		if got := cronJobNames(t, r); !reflect.DeepEqual(got, []string{"backup"}) {
+			t.Errorf("cronjobs = %v, want [backup]", got)
+		}
+	})
+
+	t.Run("every location", func(t *testing.T) {
+		gh := newFakeGitHub(t, files)
+		gh.fail("")
+		r := newTestRepository(t, gh, syncConfig(locations...), nil)
+
+		err := r.sync(context.Background(), locations)
+		if err == nil ||
+			!strings.Contains(err.Error(), "acme/jobs/team-a@main") ||
+			!strings.Contains(err.Error(), "acme/jobs/team-b@main") {
+			t.Errorf("sync error = %v, want one listing both locations", err)
+		}
+		if err := r.checkSynced(context.Background()); err == nil {
+			t.Error("a sync where every location failed counts as synced")
+		}
+
+		history, err := r.GetSyncHistory(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(history) != 1 || len(history[0].FailedPaths) != 2 {
+			t.Errorf("history = %+v, want one sync with 2 failed paths", history)
+		}
+	})
+
+	t.Run("keeps the manifests of failed locations", func(t *testing.T) {
+		gh := newFakeGitHub(t, files)
+		r := newTestRepository(t, gh, syncConfig(locations...), nil)
+		if err := r.sync(context.Background(), locations); err != nil {
+			t.Fatal(err)
+		}
+		synced := r.lastSync
+
+		time.Sleep(time.Millisecond)
+		gh.fail("")
+		if err := r.sync(context.Background(), locations); err == nil {
+			t.Error("expected an error")
+		}
+		if got := cronJobNames(t, r); !reflect.DeepEqual(got, []string{"backup", "cleanup"}) {
+			t.Errorf("cronjobs = %v, want the ones of the last sync", got)
+		}
+		if !r.lastSync.Equal(synced) {
+			t.Errorf("last sync moved to %s by a failed sync", r.lastSync)
+		}
+	})
+}
@@ -0,0 +1,304 @@
+package github
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/google/go-github/v48/github"
+	"github.com/panagiotisptr/job-scheduler/config"
+	"github.com/panagiotisptr/job-scheduler/events"
+	"github.com/panagiotisptr/job-scheduler/health"
+	"github.com/panagiotisptr/job-scheduler/metrics"
+	"github.com/panagiotisptr/job-scheduler/parser"
+	"github.com/panagiotisptr/job-scheduler/store"
+	memoryStore "github.com/panagiotisptr/job-scheduler/store/memory"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/fx/fxtest"
+	"go.uber.org/zap"
+)
+
+const (
+	testOwner = "acme"
+	testName  = "jobs"
+	testSHA   = "0123456789abcdef0123456789abcdef01234567"
+)
+
+// fakeGitHub serves the files of the repository acme/jobs through
+// the parts of the GitHub API the repository uses, and as a tarball
+// under /archive/. The files are read at every ref
+type fakeGitHub struct {
+	*httptest.Server
+
+	mu    sync.Mutex
+	files map[string]string
+	sha   string
+	// failing paths, or every path if it contains "", whose contents
+	// requests fail
+	failing map[string]bool
+	// requests the paths of the API requests served
+	requests []string
+}
+
+func newFakeGitHub(t *testing.T, files map[string]string) *fakeGitHub {
+	gh := &fakeGitHub{
+		files:   files,
+		sha:     testSHA,
+		failing: map[string]bool{},
+	}
+	gh.Server = httptest.NewServer(http.HandlerFunc(gh.serve))
+	t.Cleanup(gh.Close)
+
+	return gh
+}
+
+// client a go-github client of the server
+func (gh *fakeGitHub) client(t *testing.T) *github.Client {
+	client, err := github.NewEnterpriseClient(gh.URL, gh.URL, gh.Server.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return client
+}
+
+func (gh *fakeGitHub) setFile(p string, content string) {
+	gh.mu.Lock()
+	defer gh.mu.Unlock()
+	gh.files[p] = content
+}
+
+// setSHA moves every ref to the commit
+func (gh *fakeGitHub) setSHA(sha string) {
+	gh.mu.Lock()
+	defer gh.mu.Unlock()
+	gh.sha = sha
+}
+
+func (gh *fakeGitHub) fail(p string) {
+	gh.mu.Lock()
+	defer gh.mu.Unlock()
+	gh.failing[p] = true
+}
+
+// requested how many requests were served for paths starting with
+// prefix
+func (gh *fakeGitHub) requested(prefix string) int {
+	gh.mu.Lock()
+	defer gh.mu.Unlock()
+	n := 0
+	for _, r := range gh.requests {
+		if strings.HasPrefix(r, prefix) {
+			n++
+		}
+	}
+
+	return n
+}
+
+func (gh *fakeGitHub) serve(w http.ResponseWriter, r *http.Request) {
+	gh.mu.Lock()
+	defer gh.mu.Unlock()
+	gh.requests = append(gh.requests, r.URL.Path)
+
+	if raw, ok := strings.CutPrefix(r.URL.Path, "/raw/"); ok {
+		content, ok := gh.files[raw]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(content))
+		return
+	}
+	if _, ok := strings.CutPrefix(r.URL.Path, "/archive/"); ok {
+		gh.serveArchive(w)
+		return
+	}
+
+	rest, ok := strings.CutPrefix(r.URL.Path, "/api/v3/repos/"+testOwner+"/"+testName+"/")
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	switch {
+	case strings.HasPrefix(rest, "commits/"):
+		if gh.failing[""] {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte(gh.sha))
+	case strings.HasPrefix(rest, "tarball/"):
+		if gh.failing[""] {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		http.Redirect(w, r, gh.URL+"/archive/"+strings.TrimPrefix(rest, "tarball/"), http.StatusFound)
+	case strings.HasPrefix(rest, "contents"):
+		p := strings.Trim(strings.TrimPrefix(rest, "contents"), "/")
+		gh.serveContents(w, r, p)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// serveContents serves a file or the listing of a directory
+func (gh *fakeGitHub) serveContents(w http.ResponseWriter, r *http.Request, p string) {
+	if p == "." {
+		p = ""
+	}
+	if gh.failing[""] || gh.failing[p] {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	if _, ok := gh.files[p]; ok {
+		writeJSON(w, gh.entry("file", p))
+		return
+	}
+
+	entries := map[string]*github.RepositoryContent{}
+	for f := range gh.files {
+		rel := f
+		if p != "" {
+			var ok bool
+			if rel, ok = strings.CutPrefix(f, p+"/"); !ok {
+				continue
+			}
+		}
+		if dir, _, ok := strings.Cut(rel, "/"); ok {
+			entries[dir] = gh.entry("dir", path.Join(p, dir))
+		} else {
+			entries[rel] = gh.entry("file", f)
+		}
+	}
+	if len(entries) == 0 {
+		http.NotFound(w, r)
+		return
+	}
+	names := []string{}
+	for name := range entries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	res := []*github.RepositoryContent{}
+	for _, name := range names {
+		res = append(res, entries[name])
+	}
+	writeJSON(w, res)
+}
+
+// serveArchive serves the files as a gzipped tarball with a top-level
+// directory, like GitHub
+func (gh *fakeGitHub) serveArchive(w http.ResponseWriter) {
+	var b bytes.Buffer
+	gz := gzip.NewWriter(&b)
+	tw := tar.NewWriter(gz)
+	top := testOwner + "-" + testName + "-" + gh.sha[:7] + "/"
+	_ = tw.WriteHeader(&tar.Header{Name: top, Typeflag: tar.TypeDir, Mode: 0o755})
+	for p, content := range gh.files {
+		_ = tw.WriteHeader(&tar.Header{
+			Name:     top + p,
+			Typeflag: tar.TypeReg,
+			Mode:     0o644,
+			Size:     int64(len(content)),
+		})
+		_, _ = tw.Write([]byte(content))
+	}
+	_ = tw.Close()
+	_ = gz.Close()
+	_, _ = w.Write(b.Bytes())
+}
+
+func (gh *fakeGitHub) entry(t string, p string) *github.RepositoryContent {
+	entry := &github.RepositoryContent{
+		Type: github.String(t),
+		Name: github.String(path.Base(p)),
+		Path: github.String(p),
+	}
+	if t == "file" {
+		entry.DownloadURL = github.String(gh.URL + "/raw/" + p)
+	}
+
+	return entry
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// testLocation a location of the fake repository
+func testLocation(p string) config.GitHubRepositoryArgs {
+	return config.GitHubRepositoryArgs{
+		Owner:  testOwner,
+		Name:   testName,
+		Path:   p,
+		Branch: "main",
+	}
+}
+
+// newTestRepository a repository reading the fake GitHub into the
+// store. It isn't started, the tests sync it
+func newTestRepository(
+	t *testing.T,
+	gh *fakeGitHub,
+	cfg *config.Config,
+	st store.Store,
+) *GitHubCronJobRepository {
+	t.Helper()
+	if st == nil {
+		st = memoryStore.NewStore()
+	}
+	logger := zap.NewNop()
+	m, err := metrics.ProvideMetrics()
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := parser.ProvideCronJobParser(logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo, err := ProvideGitHubCronJobRepository(
+		fxtest.NewLifecycle(t),
+		cfg,
+		logger,
+		gh.client(t),
+		p,
+		events.ProvideBus(logger),
+		m,
+		trace.NewNoopTracerProvider(),
+		health.ProvideChecker(),
+		st,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return repo.(*GitHubCronJobRepository)
+}
+
+// cronJobManifest a minimal valid cronjob
+func cronJobManifest(name string) string {
+	return `apiVersion: batch/v1
+kind: CronJob
+metadata:
+  name: ` + name + `
+spec:
+  schedule: "0 3 * * *"
+  jobTemplate:
+    spec:
+      template:
+        spec:
+          restartPolicy: Never
+          containers:
+            - name: main
+              image: busybox:1.36
+`
+}
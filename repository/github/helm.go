@@ -0,0 +1,49 @@
+package github
+
+import (
+	"bytes"
+	"context"
+	"io"
+
+	"github.com/panagiotisptr/job-scheduler/apperror"
+	"github.com/panagiotisptr/job-scheduler/archive"
+	"github.com/panagiotisptr/job-scheduler/config"
+	"github.com/panagiotisptr/job-scheduler/helm"
+	"github.com/panagiotisptr/job-scheduler/namespace"
+	"github.com/panagiotisptr/job-scheduler/parser"
+)
+
+// parseChart renders the chart of the location at the ref with its
+// values file. A chart that fails to render is an error so that the
+// location keeps its last indexed manifests
+func (r *GitHubCronJobRepository) parseChart(
+	ctx context.Context,
+	location config.GitHubRepositoryArgs,
+	ref string,
+) (parser.Result, error) {
+	fSys, err := r.downloadRepository(ctx, location, ref)
+	if err != nil {
+		return parser.Result{}, err
+	}
+	valuesFile := ""
+	if location.ValuesFile != "" {
+		valuesFile = archive.Root(location.ValuesFile)
+	}
+	out, err := helm.Render(
+		fSys,
+		archive.Root(location.Path),
+		valuesFile,
+		helm.Release{Namespace: namespace.Current()},
+	)
+	if err != nil {
+		return parser.Result{}, apperror.Invalid(
+			"failed to render chart %s: %s",
+			location.Path,
+			err,
+		)
+	}
+
+	return r.cronJobParser.ParseManifests(
+		io.NopCloser(bytes.NewReader(out)),
+	), nil
+}
@@ -0,0 +1,142 @@
+package github
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/panagiotisptr/job-scheduler/config"
+)
+
+func chartFiles() map[string]string {
+	return map[string]string{
+		"charts/backup/Chart.yaml":  "apiVersion: v2\nname: backup\nversion: 0.1.0\n",
+		"charts/backup/values.yaml": "schedule: \"0 3 * * *\"\ntag: \"1.36\"\nsettings: {}\n",
+		"charts/backup/templates/cronjob.yaml": `apiVersion: batch/v1
+kind: CronJob
+metadata:
+  name: {{ .Release.Name }}-backup
+  namespace: {{ .Release.Namespace }}
+spec:
+  schedule: {{ .Values.schedule | quote }}
+  jobTemplate:
+    spec:
+      template:
+        spec:
+          restartPolicy: Never
+          containers:
+            - name: main
+              image: busybox:{{ .Values.tag }}
+`,
+		"charts/backup/templates/configmap.yaml": `{{- if .Values.settings }}
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: {{ .Release.Name }}-settings
+data:
+  {{- toYaml .Values.settings | nindent 2 }}
+{{- end }}
+`,
+		"envs/prod.yaml": "schedule: \"0 1 * * *\"\ntag: \"1.37\"\nsettings:\n  mode: nightly\n",
+	}
+}
+
+func chartLocation(valuesFile string) config.GitHubRepositoryArgs {
+	location := testLocation("charts/backup")
+	location.Helm = true
+	location.ValuesFile = valuesFile
+
+	return location
+}
+
+func TestSyncChart(t *testing.T) {
+	t.Setenv("POD_NAMESPACE", "jobs")
+	ctx := context.Background()
+
+	for _, tt := range []struct {
+		name       string
+		valuesFile string
+		schedule   string
+		image      string
+		companions []string
+	}{
+		{
+			name:       "values of the chart",
+			schedule:   "0 3 * * *",
+			image:      "busybox:1.36",
+			companions: []string{},
+		},
+		{
+			name:       "values file",
+			valuesFile: "envs/prod.yaml",
+			schedule:   "0 1 * * *",
+			image:      "busybox:1.37",
+			companions: []string{"backup-settings"},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			gh := newFakeGitHub(t, chartFiles())
+			cfg := syncConfig(chartLocation(tt.valuesFile))
+			r := newTestRepository(t, gh, cfg, nil)
+			if err := r.sync(ctx, cfg.GitHubConfig.Locations); err != nil {
+				t.Fatalf("sync: %s", err)
+			}
+			if got := cronJobNames(t, r); !reflect.DeepEqual(got, []string{"backup-backup"}) {
+				t.Fatalf("cronjobs = %v, want the one of the release named after the chart", got)
+			}
+
+			cj, err := r.GetCronJob(ctx, "backup-backup")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cj.Namespace != "jobs" {
+				t.Errorf("namespace = %s, want the one of the scheduler", cj.Namespace)
+			}
+			if cj.Spec.Schedule != tt.schedule {
+				t.Errorf("schedule = %s, want %s", cj.Spec.Schedule, tt.schedule)
+			}
+			if image := cj.Spec.JobTemplate.Spec.Template.Spec.Containers[0].Image; image != tt.image {
+				t.Errorf("image = %s, want %s", image, tt.image)
+			}
+			companions, err := r.GetCompanions(ctx, "backup-backup")
+			if err != nil {
+				t.Fatal(err)
+			}
+			names := []string{}
+			for _, c := range companions {
+				names = append(names, c.GetName())
+			}
+			if !reflect.DeepEqual(names, tt.companions) {
+				t.Errorf("companions = %v, want %v", names, tt.companions)
+			}
+
+			// rendered once for the sync and the requests
+			if n := gh.requested("/archive/"); n != 1 {
+				t.Errorf("downloaded the repository %d times, want once", n)
+			}
+		})
+	}
+}
+
+func TestSyncBrokenChart(t *testing.T) {
+	gh := newFakeGitHub(t, chartFiles())
+	cfg := syncConfig(chartLocation(""))
+	r := newTestRepository(t, gh, cfg, nil)
+	if err := r.sync(context.Background(), cfg.GitHubConfig.Locations); err != nil {
+		t.Fatalf("sync: %s", err)
+	}
+
+	gh.setFile("charts/backup/templates/cronjob.yaml", "name: {{ .Values.name.first }}\n")
+	gh.setSHA(strings.Repeat("1", 40))
+	err := r.sync(context.Background(), cfg.GitHubConfig.Locations)
+	if err == nil || !strings.Contains(err.Error(), "charts/backup") {
+		t.Errorf("sync error = %v, want one listing charts/backup", err)
+	}
+	if got := cronJobNames(t, r); !reflect.DeepEqual(got, []string{"backup-backup"}) {
+		t.Errorf("cronjobs = %v, want the ones of the last sync", got)
+	}
+	if got := parseFailures(t, r); got != 1 {
+		t.Errorf("parse failures = %v, want 1", got)
+	}
+}
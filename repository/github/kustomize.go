@@ -0,0 +1,40 @@
+package github
+
+import (
+	"bytes"
+	"context"
+	"io"
+
+	"github.com/panagiotisptr/job-scheduler/apperror"
+	"github.com/panagiotisptr/job-scheduler/archive"
+	"github.com/panagiotisptr/job-scheduler/config"
+	"github.com/panagiotisptr/job-scheduler/kustomize"
+	"github.com/panagiotisptr/job-scheduler/parser"
+)
+
+// parseKustomization builds the kustomization root of the location
+// at the ref. The whole repository is downloaded since overlays can
+// refer to bases anywhere in it. A kustomization that fails to build
+// is an error so that the location keeps its last indexed manifests
+func (r *GitHubCronJobRepository) parseKustomization(
+	ctx context.Context,
+	location config.GitHubRepositoryArgs,
+	ref string,
+) (parser.Result, error) {
+	fSys, err := r.downloadRepository(ctx, location, ref)
+	if err != nil {
+		return parser.Result{}, err
+	}
+	out, err := kustomize.Build(fSys, archive.Root(location.Path))
+	if err != nil {
+		return parser.Result{}, apperror.Invalid(
+			"failed to build kustomization %s: %s",
+			location.Path,
+			err,
+		)
+	}
+
+	return r.cronJobParser.ParseManifests(
+		io.NopCloser(bytes.NewReader(out)),
+	), nil
+}
@@ -0,0 +1,144 @@
+package github
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/panagiotisptr/job-scheduler/config"
+)
+
+// kustomizeFiles a base with a cronjob and its configmap, and an
+// overlay of it
+func kustomizeFiles() map[string]string {
+	return map[string]string{
+		"base/kustomization.yaml": "resources:\n  - backup.yaml\n  - settings.yaml\n",
+		"base/backup.yaml":        cronJobManifest("backup"),
+		"base/settings.yaml":      "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: settings\ndata:\n  mode: nightly\n",
+		"overlays/prod/kustomization.yaml": `resources:
+  - ../../base
+namePrefix: prod-
+images:
+  - name: busybox
+    newTag: "1.37"
+`,
+		// not part of the overlay
+		"jobs/other.yaml": cronJobManifest("other"),
+	}
+}
+
+func kustomizeLocation(p string) config.GitHubRepositoryArgs {
+	location := testLocation(p)
+	location.Kustomize = true
+
+	return location
+}
+
+func TestSyncKustomization(t *testing.T) {
+	gh := newFakeGitHub(t, kustomizeFiles())
+	cfg := syncConfig(kustomizeLocation("overlays/prod"))
+	r := newTestRepository(t, gh, cfg, nil)
+	ctx := context.Background()
+
+	if err := r.sync(ctx, cfg.GitHubConfig.Locations); err != nil {
+		t.Fatalf("sync: %s", err)
+	}
+	if got := cronJobNames(t, r); !reflect.DeepEqual(got, []string{"prod-backup"}) {
+		t.Errorf("cronjobs = %v, want [prod-backup]", got)
+	}
+	cj, err := r.GetCronJob(ctx, "prod-backup")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if image := cj.Spec.JobTemplate.Spec.Template.Spec.Containers[0].Image; image != "busybox:1.37" {
+		t.Errorf("image = %s, want the tag of the overlay", image)
+	}
+	companions, err := r.GetCompanions(ctx, "prod-backup")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(companions) != 1 || companions[0].GetName() != "prod-settings" {
+		t.Errorf("companions = %v, want prod-settings", companions)
+	}
+	source, err := r.GetCronJobSource(ctx, "prod-backup")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if source.Path != "overlays/prod" || source.Commit != testSHA {
+		t.Errorf("source = %+v, want overlays/prod at %s", source, testSHA)
+	}
+
+	// the location keeps its manifests while the overlay is broken
+	gh.setFile("overlays/prod/kustomization.yaml", "resources:\n  - missing.yaml\n")
+	gh.setSHA(strings.Repeat("1", 40))
+	err = r.sync(ctx, cfg.GitHubConfig.Locations)
+	if err == nil || !strings.Contains(err.Error(), "overlays/prod") {
+		t.Errorf("sync error = %v, want one listing overlays/prod", err)
+	}
+	if got := cronJobNames(t, r); !reflect.DeepEqual(got, []string{"prod-backup"}) {
+		t.Errorf("cronjobs = %v, want the ones of the last sync", got)
+	}
+	if got := parseFailures(t, r); got != 1 {
+		t.Errorf("parse failures = %v, want 1", got)
+	}
+}
+
+func TestRenderCache(t *testing.T) {
+	gh := newFakeGitHub(t, kustomizeFiles())
+	location := kustomizeLocation("overlays/prod")
+	cfg := syncConfig(location)
+	r := newTestRepository(t, gh, cfg, nil)
+	ctx := context.Background()
+
+	if err := r.sync(ctx, cfg.GitHubConfig.Locations); err != nil {
+		t.Fatalf("sync: %s", err)
+	}
+	if n := gh.requested("/archive/"); n != 1 {
+		t.Fatalf("downloaded the repository %d times, want once", n)
+	}
+
+	// requests for the cronjob read the build of the sync
+	if _, err := r.GetCronJob(ctx, "prod-backup"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.GetCompanions(ctx, "prod-backup"); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.sync(ctx, cfg.GitHubConfig.Locations); err != nil {
+		t.Fatalf("sync: %s", err)
+	}
+	if n := gh.requested("/archive/"); n != 1 {
+		t.Errorf("downloaded the repository %d times at the same commit, want once", n)
+	}
+
+	// a branch can move, it is built every time
+	for i := 0; i < 2; i++ {
+		if _, err := r.parseFile(ctx, location, "main"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := gh.requested("/archive/"); n != 3 {
+		t.Errorf("downloaded the repository %d times, want the branch built twice", n)
+	}
+
+	// a new commit is built again and the build of the old one is
+	// dropped
+	newSHA := strings.Repeat("2", 40)
+	gh.setSHA(newSHA)
+	if err := r.sync(ctx, cfg.GitHubConfig.Locations); err != nil {
+		t.Fatalf("sync: %s", err)
+	}
+	if n := gh.requested("/archive/"); n != 4 {
+		t.Errorf("downloaded the repository %d times, want the new commit built", n)
+	}
+	r.renderMu.Lock()
+	keys := []string{}
+	for key := range r.rendered {
+		keys = append(keys, key)
+	}
+	r.renderMu.Unlock()
+	if len(keys) != 1 || keys[0] != renderKey(location, newSHA) {
+		t.Errorf("cached builds = %v, want the one of %s", keys, newSHA)
+	}
+}
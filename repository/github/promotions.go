@@ -0,0 +1,226 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/google/go-github/v48/github"
+	"github.com/panagiotisptr/job-scheduler/apperror"
+	"github.com/panagiotisptr/job-scheduler/repository"
+	"github.com/panagiotisptr/job-scheduler/store"
+	"github.com/panagiotisptr/job-scheduler/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	pinBucket       = "github-pins"
+	promotionBucket = "github-promotions"
+	// how many promotions are kept in the history
+	maxPromotionHistory = 500
+)
+
+// pin the promoted revision of a cronjob. Hash is the hash of its
+// spec at Commit
+type pin struct {
+	Commit      string            `json:"commit"`
+	Hash        string            `json:"hash"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// cronJobEntry the index entry of the cronjob, at its promoted
+// revision if it is pinned
+func (r *GitHubCronJobRepository) cronJobEntry(name string) (manifestEntry, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	entry, ok := r.cronJobs[name]
+	if !ok {
+		return entry, false
+	}
+	if p, ok := r.pins[name]; ok {
+		entry.commit = p.Commit
+		entry.hash = p.Hash
+		entry.annotations = p.Annotations
+		entry.pinned = true
+	}
+
+	return entry, true
+}
+
+func (r *GitHubCronJobRepository) PinCronJob(
+	ctx context.Context,
+	name string,
+	commit string,
+) (string, error) {
+	ctx, span := r.tracer.Start(
+		ctx,
+		"GitHubCronJobRepository.PinCronJob",
+		trace.WithAttributes(
+			attribute.String("job.name", name),
+			attribute.String("vcs.commit", commit),
+		),
+	)
+	defer span.End()
+
+	r.mu.RLock()
+	entry, ok := r.cronJobs[name]
+	r.mu.RUnlock()
+	if !ok {
+		err := apperror.NotFound("could not find cronjob with name: %s", name)
+		tracing.RecordError(span, err)
+		return "", err
+	}
+
+	sha, err := r.commitSHA(ctx, entry.location, commit)
+	if err != nil {
+		err = commitError(err, commit)
+		tracing.RecordError(span, err)
+		return "", err
+	}
+	manifests, err := r.parseFile(ctx, entry.location, sha)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return "", err
+	}
+	var p *pin
+	for _, cj := range manifests.CronJobs {
+		if cj.Name != name {
+			continue
+		}
+		p = &pin{
+			Commit:      sha,
+			Hash:        hashManifest(cj),
+			Annotations: cj.Annotations,
+		}
+	}
+	if p == nil {
+		err := apperror.NotFound(
+			"could not find cronjob %s in %s at %s",
+			name,
+			entry.location.Path,
+			sha,
+		)
+		tracing.RecordError(span, err)
+		return "", err
+	}
+
+	if err := store.PutJSON(ctx, r.store, pinBucket, name, p); err != nil {
+		tracing.RecordError(span, err)
+		return "", err
+	}
+	r.mu.Lock()
+	r.pins[name] = *p
+	r.mu.Unlock()
+
+	return sha, nil
+}
+
+func (r *GitHubCronJobRepository) UnpinCronJob(
+	ctx context.Context,
+	name string,
+) error {
+	r.mu.RLock()
+	_, ok := r.pins[name]
+	r.mu.RUnlock()
+	if !ok {
+		return apperror.NotFound("the cronjob %s is not pinned", name)
+	}
+
+	if err := r.store.Delete(ctx, pinBucket, name); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	delete(r.pins, name)
+	r.mu.Unlock()
+
+	return nil
+}
+
+// loadPins restores the pins persisted before a restart
+func (r *GitHubCronJobRepository) loadPins(ctx context.Context) error {
+	entries, err := r.store.List(ctx, pinBucket)
+	if err != nil {
+		return err
+	}
+	pins := make(map[string]pin)
+	for _, e := range entries {
+		var p pin
+		if err := json.Unmarshal(e.Value, &p); err != nil {
+			r.logger.Sugar().Warn("skipping invalid pin ", e.Key, ": ", err)
+			continue
+		}
+		pins[e.Key] = p
+	}
+
+	r.mu.Lock()
+	r.pins = pins
+	r.mu.Unlock()
+
+	return nil
+}
+
+// RecordPromotion appends the promotion to the history, dropping the
+// oldest record once the history is full
+func (r *GitHubCronJobRepository) RecordPromotion(
+	ctx context.Context,
+	promotion repository.Promotion,
+) (repository.Promotion, error) {
+	seq, err := r.store.NextSequence(ctx, promotionBucket)
+	if err != nil {
+		return promotion, err
+	}
+	promotion.ID = seq
+	err = store.PutJSON(ctx, r.store, promotionBucket, store.SequenceKey(seq), promotion)
+	if err != nil {
+		return promotion, err
+	}
+	if seq > maxPromotionHistory {
+		err = r.store.Delete(ctx, promotionBucket, store.SequenceKey(seq-maxPromotionHistory))
+	}
+
+	return promotion, err
+}
+
+func (r *GitHubCronJobRepository) DeletePromotion(
+	ctx context.Context,
+	id uint64,
+) error {
+	return r.store.Delete(ctx, promotionBucket, store.SequenceKey(id))
+}
+
+// GetPromotions returns the recorded promotions, newest first
+func (r *GitHubCronJobRepository) GetPromotions(
+	ctx context.Context,
+) ([]repository.Promotion, error) {
+	entries, err := r.store.List(ctx, promotionBucket)
+	if err != nil {
+		return nil, err
+	}
+
+	res := []repository.Promotion{}
+	for i := len(entries) - 1; i >= 0; i-- {
+		var promotion repository.Promotion
+		if err := json.Unmarshal(entries[i].Value, &promotion); err != nil {
+			continue
+		}
+		res = append(res, promotion)
+	}
+
+	return res, nil
+}
+
+// commitError tells a commit that doesn't exist apart from GitHub
+// being unavailable
+func commitError(err error, commit string) error {
+	var errRes *github.ErrorResponse
+	if errors.As(err, &errRes) && errRes.Response != nil &&
+		(errRes.Response.StatusCode == http.StatusNotFound ||
+			errRes.Response.StatusCode == http.StatusUnprocessableEntity) {
+		return apperror.NotFound("could not find commit %s", commit)
+	}
+
+	return apperror.Wrap(apperror.CodeUnavailable, err)
+}
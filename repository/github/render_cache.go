@@ -0,0 +1,88 @@
+package github
+
+import (
+	"context"
+	"regexp"
+
+	"github.com/panagiotisptr/job-scheduler/config"
+	"github.com/panagiotisptr/job-scheduler/parser"
+)
+
+// commitPattern a full commit SHA. Only manifests read at one are
+// cached since a branch or tag can move
+var commitPattern = regexp.MustCompile("^[0-9a-f]{40}$")
+
+// renderKey the location and commit a render is cached under
+func renderKey(location config.GitHubRepositoryArgs, commit string) string {
+	return locationLabel(location) + "#" + commit
+}
+
+// renderFunc builds or renders a location as a whole, downloading the repository
+type renderFunc func(
+	ctx context.Context,
+	location config.GitHubRepositoryArgs,
+	ref string,
+) (parser.Result, error)
+
+// cachedRender renders the location once per commit. Requests for a
+// cronjob read the render of the sync instead of downloading the
+// repository again, concurrent misses share one download
+func (r *GitHubCronJobRepository) cachedRender(
+	ctx context.Context,
+	location config.GitHubRepositoryArgs,
+	ref string,
+	render renderFunc,
+) (parser.Result, error) {
+	if !commitPattern.MatchString(ref) {
+		return render(ctx, location, ref)
+	}
+
+	key := renderKey(location, ref)
+	r.renderMu.Lock()
+	cached, ok := r.rendered[key]
+	r.renderMu.Unlock()
+	if ok {
+		return cached, nil
+	}
+
+	v, err, _ := r.renders.Do(key, func() (interface{}, error) {
+		manifests, err := render(ctx, location, ref)
+		if err != nil {
+			return parser.Result{}, err
+		}
+		r.renderMu.Lock()
+		r.rendered[key] = manifests
+		r.renderMu.Unlock()
+
+		return manifests, nil
+	})
+	if err != nil {
+		return parser.Result{}, err
+	}
+
+	return v.(parser.Result), nil
+}
+
+// pruneRendered drops the renders no indexed or pinned entry refers
+// to anymore. Has to be called with mu held
+func (r *GitHubCronJobRepository) pruneRendered() {
+	used := make(map[string]struct{})
+	for _, index := range []map[string]manifestEntry{r.cronJobs, r.tasks} {
+		for _, entry := range index {
+			used[renderKey(entry.location, entry.commit)] = struct{}{}
+		}
+	}
+	for name, p := range r.pins {
+		if entry, ok := r.cronJobs[name]; ok {
+			used[renderKey(entry.location, p.Commit)] = struct{}{}
+		}
+	}
+
+	r.renderMu.Lock()
+	defer r.renderMu.Unlock()
+	for key := range r.rendered {
+		if _, ok := used[key]; !ok {
+			delete(r.rendered, key)
+		}
+	}
+}
@@ -0,0 +1,184 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/panagiotisptr/job-scheduler/config"
+	"github.com/panagiotisptr/job-scheduler/repository"
+	"github.com/panagiotisptr/job-scheduler/store"
+)
+
+const (
+	indexBucket = "github-index"
+	taskBucket  = "github-tasks"
+	syncBucket  = "github-syncs"
+	// how many syncs are kept in the history
+	maxSyncHistory = 100
+)
+
+// storedEntry the persisted form of a manifestEntry
+type storedEntry struct {
+	Location  config.GitHubRepositoryArgs `json:"location"`
+	Commit    string                      `json:"commit"`
+	Namespace string                      `json:"namespace"`
+	Hash      string                      `json:"hash"`
+	// Annotations missing from indexes persisted by older versions
+	// until the next sync
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// loadState restores the index, the pins and the time of the last sync
+// persisted before a restart
+func (r *GitHubCronJobRepository) loadState(ctx context.Context) error {
+	index, err := r.loadEntries(ctx, indexBucket)
+	if err != nil {
+		return err
+	}
+	taskIndex, err := r.loadEntries(ctx, taskBucket)
+	if err != nil {
+		return err
+	}
+
+	history, err := r.GetSyncHistory(ctx)
+	if err != nil {
+		return err
+	}
+	if err := r.loadPins(ctx); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cronJobs = index
+	r.tasks = taskIndex
+	if len(history) > 0 {
+		r.lastSync = history[0].FinishedAt
+	}
+	r.metrics.IndexedManifests.Set(float64(len(index) + len(taskIndex)))
+
+	return nil
+}
+
+func (r *GitHubCronJobRepository) loadEntries(
+	ctx context.Context,
+	bucket string,
+) (map[string]manifestEntry, error) {
+	entries, err := r.store.List(ctx, bucket)
+	if err != nil {
+		return nil, err
+	}
+	index := make(map[string]manifestEntry)
+	for _, e := range entries {
+		var se storedEntry
+		if err := json.Unmarshal(e.Value, &se); err != nil {
+			r.logger.Sugar().Warn("skipping invalid index entry ", e.Key, ": ", err)
+			continue
+		}
+		index[e.Key] = manifestEntry{
+			location:    se.Location,
+			commit:      se.Commit,
+			namespace:   se.Namespace,
+			hash:        se.Hash,
+			annotations: se.Annotations,
+		}
+	}
+
+	return index, nil
+}
+
+func (r *GitHubCronJobRepository) saveIndex(
+	ctx context.Context,
+	index map[string]manifestEntry,
+	taskIndex map[string]manifestEntry,
+) error {
+	if err := r.saveEntries(ctx, indexBucket, index); err != nil {
+		return err
+	}
+
+	return r.saveEntries(ctx, taskBucket, taskIndex)
+}
+
+func (r *GitHubCronJobRepository) saveEntries(
+	ctx context.Context,
+	bucket string,
+	index map[string]manifestEntry,
+) error {
+	entries := []store.KeyValue{}
+	for name, entry := range index {
+		b, err := json.Marshal(storedEntry{
+			Location:    entry.location,
+			Commit:      entry.commit,
+			Namespace:   entry.namespace,
+			Hash:        entry.hash,
+			Annotations: entry.annotations,
+		})
+		if err != nil {
+			return err
+		}
+		entries = append(entries, store.KeyValue{Key: name, Value: b})
+	}
+
+	return r.store.Replace(ctx, bucket, entries)
+}
+
+// recordSync appends the sync to the history, dropping the oldest
+// record once the history is full
+func (r *GitHubCronJobRepository) recordSync(
+	ctx context.Context,
+	record repository.SyncRecord,
+) error {
+	seq, err := r.store.NextSequence(ctx, syncBucket)
+	if err != nil {
+		return err
+	}
+	record.ID = seq
+	if err = store.PutJSON(ctx, r.store, syncBucket, store.SequenceKey(seq), record); err != nil {
+		return err
+	}
+	if seq > maxSyncHistory {
+		return r.store.Delete(ctx, syncBucket, store.SequenceKey(seq-maxSyncHistory))
+	}
+
+	return nil
+}
+
+// GetSyncHistory returns the recorded syncs, newest first
+func (r *GitHubCronJobRepository) GetSyncHistory(
+	ctx context.Context,
+) ([]repository.SyncRecord, error) {
+	entries, err := r.store.List(ctx, syncBucket)
+	if err != nil {
+		return nil, err
+	}
+
+	res := []repository.SyncRecord{}
+	for i := len(entries) - 1; i >= 0; i-- {
+		var record repository.SyncRecord
+		if err := json.Unmarshal(entries[i].Value, &record); err != nil {
+			continue
+		}
+		res = append(res, record)
+	}
+
+	return res, nil
+}
+
+func syncRecord(
+	start time.Time,
+	manifests int,
+	failed []config.GitHubRepositoryArgs,
+) repository.SyncRecord {
+	record := repository.SyncRecord{
+		StartedAt:   start.UTC(),
+		FinishedAt:  time.Now().UTC(),
+		Manifests:   manifests,
+		FailedPaths: []string{},
+	}
+	for _, f := range failed {
+		record.FailedPaths = append(record.FailedPaths, locationLabel(f))
+	}
+
+	return record
+}
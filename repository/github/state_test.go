@@ -0,0 +1,143 @@
+package github
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/panagiotisptr/job-scheduler/repository"
+	"github.com/panagiotisptr/job-scheduler/store"
+	boltStore "github.com/panagiotisptr/job-scheduler/store/bolt"
+)
+
+func TestLoadState(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "state.db")
+	annotated := strings.Replace(
+		cronJobManifest("report"),
+		"  name: report\n",
+		"  name: report\n  annotations:\n    job-scheduler/notify: ops\n",
+		1,
+	)
+	gh := newFakeGitHub(t, map[string]string{
+		"jobs/backup.yml": cronJobManifest("backup"),
+		"jobs/report.yml": annotated,
+	})
+	cfg := syncConfig(testLocation("jobs"))
+
+	st, err := boltStore.NewStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := newTestRepository(t, gh, cfg, st)
+	if err := r.sync(ctx, cfg.GitHubConfig.Locations); err != nil {
+		t.Fatal(err)
+	}
+	const pinned = "89abcdef0123456789abcdef0123456789abcdef"
+	if err := store.PutJSON(ctx, st, pinBucket, "backup", pin{Commit: pinned, Hash: "pinned-hash"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := st.Put(ctx, indexBucket, "broken", []byte("{")); err != nil {
+		t.Fatal(err)
+	}
+	if err := st.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// a restart, GitHub is down until the first sync
+	st, err = boltStore.NewStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { st.Close() })
+	gh.fail("")
+	restarted := newTestRepository(t, gh, cfg, st)
+
+	if err := restarted.checkSynced(ctx); err != nil {
+		t.Errorf("the persisted sync is not restored: %s", err)
+	}
+	if got := cronJobNames(t, restarted); !reflect.DeepEqual(got, []string{"backup", "report"}) {
+		t.Errorf("cronjobs = %v, want the persisted index without the invalid entry", got)
+	}
+	annotations, err := restarted.GetCronJobAnnotations(ctx, "report")
+	if err != nil || annotations["job-scheduler/notify"] != "ops" {
+		t.Errorf("annotations = %v, %v, want the persisted ones", annotations, err)
+	}
+	for name, commit := range map[string]string{"backup": pinned, "report": testSHA} {
+		source, err := restarted.GetCronJobSource(ctx, name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if source.Commit != commit || source.Path != "jobs/"+name+".yml" {
+			t.Errorf("source of %s = %+v, want jobs/%s.yml at %s", name, source, name, commit)
+		}
+	}
+	history, err := restarted.GetSyncHistory(ctx)
+	if err != nil || len(history) != 1 || history[0].Manifests != 2 {
+		t.Errorf("history = %+v, %v, want the sync before the restart", history, err)
+	}
+}
+
+func TestLoadStateOfAnEmptyStore(t *testing.T) {
+	gh := newFakeGitHub(t, map[string]string{})
+	r := newTestRepository(t, gh, syncConfig(), nil)
+
+	if err := r.checkSynced(context.Background()); err == nil {
+		t.Error("synced without any sync")
+	}
+	if got := cronJobNames(t, r); len(got) != 0 {
+		t.Errorf("cronjobs = %v, want none", got)
+	}
+}
+
+func TestSyncHistoryIsCapped(t *testing.T) {
+	ctx := context.Background()
+	r := newTestRepository(t, newFakeGitHub(t, map[string]string{}), syncConfig(), nil)
+	for i := 1; i <= maxSyncHistory+5; i++ {
+		if err := r.recordSync(ctx, repository.SyncRecord{Manifests: i}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	history, err := r.GetSyncHistory(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != maxSyncHistory {
+		t.Fatalf("%d syncs, want %d", len(history), maxSyncHistory)
+	}
+	newest, oldest := history[0], history[len(history)-1]
+	if newest.ID != maxSyncHistory+5 || newest.Manifests != maxSyncHistory+5 {
+		t.Errorf("newest sync = %+v", newest)
+	}
+	if oldest.ID != 6 {
+		t.Errorf("oldest sync = %+v, want 6", oldest)
+	}
+}
+
+func TestPromotionHistoryIsCapped(t *testing.T) {
+	ctx := context.Background()
+	r := newTestRepository(t, newFakeGitHub(t, map[string]string{}), syncConfig(), nil)
+	for i := 1; i <= maxPromotionHistory+5; i++ {
+		promotion, err := r.RecordPromotion(ctx, repository.Promotion{JobName: "backup", From: "staging"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if promotion.ID != uint64(i) {
+			t.Fatalf("promotion %d has the ID %d", i, promotion.ID)
+		}
+	}
+
+	promotions, err := r.GetPromotions(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(promotions) != maxPromotionHistory {
+		t.Fatalf("%d promotions, want %d", len(promotions), maxPromotionHistory)
+	}
+	if newest, oldest := promotions[0], promotions[len(promotions)-1]; newest.ID != maxPromotionHistory+5 || oldest.ID != 6 {
+		t.Errorf("promotions go from %d to %d, want %d to 6", newest.ID, oldest.ID, maxPromotionHistory+5)
+	}
+}
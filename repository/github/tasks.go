@@ -0,0 +1,82 @@
+package github
+
+import (
+	"context"
+	"sort"
+
+	"github.com/panagiotisptr/job-scheduler/apperror"
+	"github.com/panagiotisptr/job-scheduler/repository"
+	"github.com/panagiotisptr/job-scheduler/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	batchv1 "k8s.io/api/batch/v1"
+)
+
+func (r *GitHubCronJobRepository) GetTaskNames(
+	ctx context.Context,
+) ([]string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	names := []string{}
+	for name := range r.tasks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names, nil
+}
+
+func (r *GitHubCronJobRepository) GetTaskSource(
+	ctx context.Context,
+	name string,
+) (*repository.Source, error) {
+	r.mu.RLock()
+	entry, ok := r.tasks[name]
+	r.mu.RUnlock()
+	if !ok {
+		return nil, apperror.NotFound("could not find task with name: %s", name)
+	}
+
+	return entrySource(entry), nil
+}
+
+func (r *GitHubCronJobRepository) GetTask(
+	ctx context.Context,
+	name string,
+) (*batchv1.Job, error) {
+	ctx, span := r.tracer.Start(
+		ctx,
+		"GitHubCronJobRepository.GetTask",
+		trace.WithAttributes(attribute.String("task.name", name)),
+	)
+	defer span.End()
+
+	r.mu.RLock()
+	entry, ok := r.tasks[name]
+	r.mu.RUnlock()
+	if !ok {
+		err := apperror.NotFound("could not find task with name: %s", name)
+		tracing.RecordError(span, err)
+		return nil, err
+	}
+
+	manifests, err := r.readManifests(ctx, entry)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return nil, err
+	}
+	for _, task := range manifests.Jobs {
+		if task.Name == name {
+			return &task, nil
+		}
+	}
+
+	err = apperror.NotFound(
+		"failed to find task with name: %s",
+		name,
+	)
+	tracing.RecordError(span, err)
+
+	return nil, err
+}
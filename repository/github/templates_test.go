@@ -0,0 +1,112 @@
+package github
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/panagiotisptr/job-scheduler/config"
+)
+
+const templatedCronJob = `apiVersion: batch/v1
+kind: CronJob
+metadata:
+  name: {{ .Values.name }}
+spec:
+  schedule: {{ .Values.schedule | quote }}
+  jobTemplate:
+    spec:
+      template:
+        spec:
+          restartPolicy: Never
+          containers:
+            - name: main
+              image: ghcr.io/acme/backup:{{ .Values.image.tag }}
+              env:
+                - name: LOG_LEVEL
+                  value: {{ index .Values "logLevel" | default "info" | quote }}
+`
+
+// parseFailures the value of the parse_failures gauge
+func parseFailures(t *testing.T, r *GitHubCronJobRepository) float64 {
+	t.Helper()
+	families, err := r.metrics.Registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range families {
+		if f.GetName() == "job_scheduler_parse_failures" {
+			return f.GetMetric()[0].GetGauge().GetValue()
+		}
+	}
+	t.Fatal("parse_failures is not registered")
+
+	return 0
+}
+
+func TestSyncTemplates(t *testing.T) {
+	gh := newFakeGitHub(t, map[string]string{
+		"team-a/backup.yaml.tmpl": templatedCronJob,
+		"team-a/values.yaml": `name: backup
+schedule: "0 3 * * *"
+image:
+  tag: "1.0"
+`,
+		// the values files only apply to their directory, this one
+		// has no name
+		"team-b/values.yml":        "schedule: \"0 4 * * *\"\n",
+		"team-b/cleanup.yaml.tmpl": templatedCronJob,
+		"team-b/plain.yaml":        cronJobManifest("plain"),
+	})
+	cfg := syncConfig(testLocation("team-a"), testLocation("team-b"))
+	cfg.Templates.Values = []config.TemplateValue{
+		{Key: "image.tag", Value: "2.0"},
+		{Key: "logLevel", Value: "debug"},
+	}
+	r := newTestRepository(t, gh, cfg, nil)
+
+	if err := r.sync(context.Background(), cfg.GitHubConfig.Locations); err != nil {
+		t.Fatalf("sync: %s", err)
+	}
+	// team-b/cleanup.yaml.tmpl fails to render
+	if got, want := cronJobNames(t, r), []string{"backup", "plain"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("cronjobs = %v, want %v", got, want)
+	}
+	if got := parseFailures(t, r); got != 1 {
+		t.Errorf("parse failures = %v, want 1", got)
+	}
+
+	cj, err := r.GetCronJob(context.Background(), "backup")
+	if err != nil {
+		t.Fatal(err)
+	}
+	container := cj.Spec.JobTemplate.Spec.Template.Spec.Containers[0]
+	if cj.Spec.Schedule != "0 3 * * *" {
+		t.Errorf("schedule = %s, want the one of the values file", cj.Spec.Schedule)
+	}
+	if container.Image != "ghcr.io/acme/backup:2.0" {
+		t.Errorf("image = %s, want the tag of the config", container.Image)
+	}
+	if len(container.Env) != 1 || container.Env[0].Value != "debug" {
+		t.Errorf("env = %+v, want LOG_LEVEL of the config", container.Env)
+	}
+	source, err := r.GetCronJobSource(context.Background(), "backup")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if source.Path != "team-a/backup.yaml.tmpl" {
+		t.Errorf("source path = %s, want the template", source.Path)
+	}
+
+	// a change of the values file changes the manifest
+	gh.setFile("team-a/values.yaml", "name: backup\nschedule: \"0 5 * * *\"\nimage:\n  tag: \"1.1\"\n")
+	if err := r.sync(context.Background(), cfg.GitHubConfig.Locations); err != nil {
+		t.Fatalf("sync: %s", err)
+	}
+	if cj, err = r.GetCronJob(context.Background(), "backup"); err != nil {
+		t.Fatal(err)
+	}
+	if cj.Spec.Schedule != "0 5 * * *" {
+		t.Errorf("schedule = %s, want the one of the changed values file", cj.Spec.Schedule)
+	}
+}
@@ -2,19 +2,87 @@ package repository
 
 import (
 	"context"
+	"io"
+	"time"
 
 	batchv1 "k8s.io/api/batch/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 )
 
+// LogOptions selects the job of a cron job and the part of its
+// logs to return
+type LogOptions struct {
+	// Job a job spawned by the cron job, the most recent one if empty
+	Job string
+	// TailLines the number of lines from the end, all of them if 0
+	TailLines int64
+	Follow    bool
+}
+
+// JobLogs the logs of the pod of a job
+type JobLogs struct {
+	Job string
+	Pod string
+	io.ReadCloser
+}
+
+// TaskRunStatus the state of a job created from a task
+type TaskRunStatus string
+
+const (
+	TaskRunRunning   TaskRunStatus = "running"
+	TaskRunSucceeded TaskRunStatus = "succeeded"
+	TaskRunFailed    TaskRunStatus = "failed"
+)
+
+// TaskRun a job created from a task
+type TaskRun struct {
+	JobName        string        `json:"jobName"`
+	Status         TaskRunStatus `json:"status"`
+	CreatedAt      time.Time     `json:"createdAt"`
+	StartTime      *time.Time    `json:"startTime,omitempty"`
+	CompletionTime *time.Time    `json:"completionTime,omitempty"`
+	// Message why the job failed
+	Message string `json:"message,omitempty"`
+}
+
 // KubernetesRepository a repository to interface with the
 // kubernetes client
 type KubernetesRepository interface {
-	// StartJob Start a cron job
+	// StartCronJob creates the cron job or, if it exists, replaces
+	// its spec with the one of cj and merges in its labels and
+	// annotations, then unsuspends it
 	StartCronJob(ctx context.Context, cj *batchv1.CronJob) error
 
+	// ApplyCompanions create or update the companion resources of a
+	// cron job that is in the cluster
+	ApplyCompanions(ctx context.Context, cronJobName string, companions []unstructured.Unstructured) error
+
 	// StopJob Stop a cron job
 	StopCronJob(ctx context.Context, cj *batchv1.CronJob) error
 
+	// DeleteCronJob Delete a cron job and the jobs it spawned
+	DeleteCronJob(ctx context.Context, name string) error
+
+	// GetCronJob get a cron job as it is in the cluster
+	GetCronJob(ctx context.Context, name string) (*batchv1.CronJob, error)
+
+	// RunCronJob create a job from the template of a cron job
+	RunCronJob(ctx context.Context, cj *batchv1.CronJob) (*batchv1.Job, error)
+
+	// GetJobLogs get the logs of the latest pod of a job spawned
+	// by a cron job
+	GetJobLogs(ctx context.Context, cronJobName string, opts LogOptions) (*JobLogs, error)
+
+	// RunTask create a job from a task with a unique name
+	RunTask(ctx context.Context, task *batchv1.Job) (*batchv1.Job, error)
+
+	// GetTaskRuns get the jobs created from a task, newest first
+	GetTaskRuns(ctx context.Context, taskName string) ([]TaskRun, error)
+
 	// GetRunningJobs get list of names of running jobs
 	GetRunningCronJobs(ctx context.Context) ([]string, error)
+
+	// GetNamespace get the namespace cron jobs are managed in
+	GetNamespace() string
 }
@@ -0,0 +1,108 @@
+package kubernetes
+
+import (
+	"context"
+
+	"github.com/panagiotisptr/job-scheduler/apperror"
+	"github.com/panagiotisptr/job-scheduler/companion"
+	"github.com/panagiotisptr/job-scheduler/managed"
+	"github.com/panagiotisptr/job-scheduler/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// fieldManagerPrefix every cron job applies its companions as its
+// own field manager, so that a resource shared by the cron jobs of
+// a file keeps the owner reference of each of them
+const fieldManagerPrefix = "job-scheduler-"
+
+// ApplyCompanions creates or updates the companions of the cron job
+// with server-side apply
+func (r *KubernetesRepository) ApplyCompanions(
+	ctx context.Context,
+	cronJobName string,
+	companions []unstructured.Unstructured,
+) error {
+	ctx, span := r.tracer.Start(
+		ctx,
+		"KubernetesRepository.ApplyCompanions",
+		trace.WithAttributes(
+			attribute.String("job.name", cronJobName),
+			attribute.Int("companions", len(companions)),
+		),
+	)
+	defer span.End()
+
+	var owner *metav1.OwnerReference
+	if r.companions.DeleteWithCronJob {
+		live, err := r.GetCronJob(ctx, cronJobName)
+		if err != nil {
+			tracing.RecordError(span, err)
+			return err
+		}
+		owner = &metav1.OwnerReference{
+			APIVersion: "batch/v1",
+			Kind:       "CronJob",
+			Name:       live.Name,
+			UID:        live.UID,
+		}
+	}
+
+	for i := range companions {
+		err := r.applyCompanion(ctx, cronJobName, companions[i].DeepCopy(), owner)
+		if err != nil {
+			tracing.RecordError(span, err)
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *KubernetesRepository) applyCompanion(
+	ctx context.Context,
+	cronJobName string,
+	obj *unstructured.Unstructured,
+	owner *metav1.OwnerReference,
+) error {
+	gvk := obj.GroupVersionKind()
+	mapping, err := r.mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if err != nil {
+		if meta.IsNoMatchError(err) {
+			return apperror.Invalid("%s: the cluster has no %s", companion.Ref(obj), gvk)
+		}
+		return err
+	}
+	if mapping.Scope.Name() != meta.RESTScopeNameNamespace {
+		return apperror.Invalid("%s: only namespaced resources can be companions", companion.Ref(obj))
+	}
+
+	obj.SetNamespace(r.GetNamespace())
+	obj.SetResourceVersion("")
+	labels := obj.GetLabels()
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	labels[managed.Label] = managed.ManagedBy
+	obj.SetLabels(labels)
+	if owner != nil {
+		obj.SetOwnerReferences([]metav1.OwnerReference{*owner})
+	}
+
+	return r.callResource(ctx, mapping.Resource.Resource, "apply", obj.GetName(), func(ctx context.Context) error {
+		_, err := r.dynamic.Resource(mapping.Resource).Namespace(r.GetNamespace()).Apply(
+			ctx,
+			obj.GetName(),
+			obj,
+			metav1.ApplyOptions{
+				FieldManager: fieldManagerPrefix + cronJobName,
+				Force:        true,
+			},
+		)
+
+		return err
+	})
+}
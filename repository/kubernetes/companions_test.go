@@ -0,0 +1,206 @@
+package kubernetes
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/panagiotisptr/job-scheduler/apperror"
+	"github.com/panagiotisptr/job-scheduler/config"
+	"github.com/panagiotisptr/job-scheduler/managed"
+	"github.com/panagiotisptr/job-scheduler/metrics"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	batchv1 "k8s.io/api/batch/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+// newTestRepository a repository of a cluster knowing ConfigMaps and
+// Namespaces, with the cronjobs. It returns the patches applied
+// through the dynamic client
+func newTestRepository(
+	t *testing.T,
+	cfg config.CompanionsConfig,
+	cronJobs ...runtime.Object,
+) (*KubernetesRepository, func() []k8stesting.PatchAction) {
+	m, err := metrics.ProvideMetrics()
+	if err != nil {
+		t.Fatal(err)
+	}
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}, meta.RESTScopeNamespace)
+	mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "Namespace"}, meta.RESTScopeRoot)
+
+	dynamicClient := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme())
+	// the object tracker can't apply, the patches are recorded and
+	// echoed back instead
+	dynamicClient.PrependReactor("patch", "*", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		obj := &unstructured.Unstructured{}
+		err := json.Unmarshal(action.(k8stesting.PatchAction).GetPatch(), &obj.Object)
+
+		return true, obj, err
+	})
+	patches := func() []k8stesting.PatchAction {
+		res := []k8stesting.PatchAction{}
+		for _, a := range dynamicClient.Actions() {
+			if p, ok := a.(k8stesting.PatchAction); ok {
+				res = append(res, p)
+			}
+		}
+
+		return res
+	}
+
+	return &KubernetesRepository{
+		logger:     zap.NewNop(),
+		client:     fake.NewSimpleClientset(cronJobs...),
+		dynamic:    dynamicClient,
+		mapper:     mapper,
+		companions: cfg,
+		metrics:    m,
+		tracer:     trace.NewNoopTracerProvider().Tracer(""),
+	}, patches
+}
+
+func configMap(name string) unstructured.Unstructured {
+	obj := unstructured.Unstructured{}
+	obj.SetAPIVersion("v1")
+	obj.SetKind("ConfigMap")
+	obj.SetName(name)
+	// set in the manifest, the namespace of the scheduler is used
+	obj.SetNamespace("elsewhere")
+	obj.SetLabels(map[string]string{"team": "a"})
+	obj.SetResourceVersion("42")
+	_ = unstructured.SetNestedField(obj.Object, "nightly", "data", "mode")
+
+	return obj
+}
+
+func TestApplyCompanions(t *testing.T) {
+	t.Setenv("POD_NAMESPACE", "jobs")
+	cronJob := &batchv1.CronJob{ObjectMeta: metav1.ObjectMeta{
+		Name:      "backup",
+		Namespace: "jobs",
+		UID:       types.UID("backup-uid"),
+	}}
+	owner := []metav1.OwnerReference{{
+		APIVersion: "batch/v1",
+		Kind:       "CronJob",
+		Name:       "backup",
+		UID:        "backup-uid",
+	}}
+
+	for _, tt := range []struct {
+		name   string
+		cfg    config.CompanionsConfig
+		owners []metav1.OwnerReference
+	}{
+		{name: "kept when the cronjob is deleted"},
+		{name: "deleted with the cronjob", cfg: config.CompanionsConfig{DeleteWithCronJob: true}, owners: owner},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			r, patches := newTestRepository(t, tt.cfg, cronJob)
+			companions := []unstructured.Unstructured{configMap("settings"), configMap("scripts")}
+			if err := r.ApplyCompanions(context.Background(), "backup", companions); err != nil {
+				t.Fatal(err)
+			}
+			if companions[0].GetNamespace() != "elsewhere" {
+				t.Error("the companions passed in were modified")
+			}
+
+			applied := patches()
+			if len(applied) != 2 {
+				t.Fatalf("applied %d companions, want 2", len(applied))
+			}
+			for i, p := range applied {
+				if p.GetPatchType() != types.ApplyPatchType {
+					t.Errorf("patch type = %s, want server-side apply", p.GetPatchType())
+				}
+				if p.GetNamespace() != "jobs" || p.GetResource().Resource != "configmaps" {
+					t.Errorf("applied %s in %s, want configmaps in jobs", p.GetResource(), p.GetNamespace())
+				}
+				obj := &unstructured.Unstructured{}
+				if err := json.Unmarshal(p.GetPatch(), &obj.Object); err != nil {
+					t.Fatal(err)
+				}
+				if obj.GetName() != companions[i].GetName() || obj.GetNamespace() != "jobs" {
+					t.Errorf("applied %s/%s, want jobs/%s", obj.GetNamespace(), obj.GetName(), companions[i].GetName())
+				}
+				if obj.GetResourceVersion() != "" {
+					t.Errorf("resourceVersion = %s, want it unset", obj.GetResourceVersion())
+				}
+				want := map[string]string{"team": "a", managed.Label: managed.ManagedBy}
+				if !reflect.DeepEqual(obj.GetLabels(), want) {
+					t.Errorf("labels = %v, want %v", obj.GetLabels(), want)
+				}
+				if mode, _, _ := unstructured.NestedString(obj.Object, "data", "mode"); mode != "nightly" {
+					t.Errorf("data.mode = %q, want the one of the manifest", mode)
+				}
+				if got := obj.GetOwnerReferences(); !reflect.DeepEqual(got, tt.owners) {
+					t.Errorf("owners = %+v, want %+v", got, tt.owners)
+				}
+			}
+		})
+	}
+}
+
+func TestApplyCompanionsErrors(t *testing.T) {
+	t.Setenv("POD_NAMESPACE", "jobs")
+	cronJob := &batchv1.CronJob{ObjectMeta: metav1.ObjectMeta{Name: "backup", Namespace: "jobs"}}
+	object := func(apiVersion string, kind string) unstructured.Unstructured {
+		obj := unstructured.Unstructured{}
+		obj.SetAPIVersion(apiVersion)
+		obj.SetKind(kind)
+		obj.SetName("other")
+
+		return obj
+	}
+
+	for _, tt := range []struct {
+		name       string
+		cfg        config.CompanionsConfig
+		cronJob    string
+		companions []unstructured.Unstructured
+		want       apperror.Code
+	}{
+		{
+			name:       "unknown kind",
+			cronJob:    "backup",
+			companions: []unstructured.Unstructured{object("example.com/v1", "Widget")},
+			want:       apperror.CodeInvalid,
+		},
+		{
+			name:       "cluster scoped",
+			cronJob:    "backup",
+			companions: []unstructured.Unstructured{object("v1", "Namespace")},
+			want:       apperror.CodeInvalid,
+		},
+		{
+			name:       "owner not in the cluster",
+			cfg:        config.CompanionsConfig{DeleteWithCronJob: true},
+			cronJob:    "report",
+			companions: []unstructured.Unstructured{configMap("settings")},
+			want:       apperror.CodeNotFound,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			r, patches := newTestRepository(t, tt.cfg, cronJob)
+			err := r.ApplyCompanions(context.Background(), tt.cronJob, tt.companions)
+			if got := apperror.CodeOf(err); got != tt.want {
+				t.Errorf("error = %v with code %s, want %s", err, got, tt.want)
+			}
+			if n := len(patches()); n != 0 {
+				t.Errorf("applied %d companions, want none", n)
+			}
+		})
+	}
+}
@@ -0,0 +1,35 @@
+package kubernetes
+
+import (
+	"github.com/panagiotisptr/job-scheduler/apperror"
+	"k8s.io/apimachinery/pkg/api/errors"
+)
+
+// fromKubernetesError attaches an apperror code to errors of the
+// Kubernetes API. The original error stays in the chain so that
+// errors.IsNotFound and friends keep working
+func fromKubernetesError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	switch {
+	case errors.IsNotFound(err):
+		return apperror.Wrap(apperror.CodeNotFound, err)
+	case errors.IsConflict(err), errors.IsAlreadyExists(err):
+		return apperror.Wrap(apperror.CodeConflict, err)
+	case errors.IsInvalid(err), errors.IsBadRequest(err):
+		return apperror.Wrap(apperror.CodeInvalid, err)
+	case errors.IsServerTimeout(err),
+		errors.IsTimeout(err),
+		errors.IsTooManyRequests(err),
+		errors.IsServiceUnavailable(err),
+		errors.IsInternalError(err),
+		errors.IsUnexpectedServerError(err):
+		return apperror.Wrap(apperror.CodeUnavailable, err)
+	}
+
+	// anything else, including the service account lacking
+	// permissions, is a problem with the deployment
+	return err
+}
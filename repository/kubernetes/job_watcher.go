@@ -0,0 +1,227 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/panagiotisptr/job-scheduler/events"
+	"github.com/panagiotisptr/job-scheduler/health"
+	"github.com/panagiotisptr/job-scheduler/managed"
+	"github.com/panagiotisptr/job-scheduler/namespace"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	batchlisters "k8s.io/client-go/listers/batch/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+// JobWatcher watches the jobs spawned by cron jobs and publishes
+// an event when they are created and when they finish. Jobs
+// created from tasks only publish an event when they finish. Jobs of
+// cron jobs the scheduler didn't create are ignored
+type JobWatcher struct {
+	logger    *zap.Logger
+	bus       *events.Bus
+	cronJobs  batchlisters.CronJobLister
+	startedAt time.Time
+}
+
+func RegisterJobWatcher(
+	lc fx.Lifecycle,
+	logger *zap.Logger,
+	client *kubernetes.Clientset,
+	bus *events.Bus,
+	checker *health.Checker,
+) {
+	factory := informers.NewSharedInformerFactoryWithOptions(
+		client,
+		0,
+		informers.WithNamespace(namespace.Current()),
+	)
+	cronJobInformer := factory.Batch().V1().CronJobs()
+	w := &JobWatcher{
+		logger:   logger,
+		bus:      bus,
+		cronJobs: cronJobInformer.Lister(),
+	}
+	informer := factory.Batch().V1().Jobs().Informer()
+	informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    w.onAdd,
+		UpdateFunc: w.onUpdate,
+	})
+	checker.Register("job-informer", func(ctx context.Context) error {
+		if !informer.HasSynced() {
+			return fmt.Errorf("job informer has not synced")
+		}
+		if !cronJobInformer.Informer().HasSynced() {
+			return fmt.Errorf("cronjob informer has not synced")
+		}
+
+		return nil
+	})
+
+	stop := make(chan struct{})
+	lc.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			w.startedAt = time.Now()
+			factory.Start(stop)
+
+			return nil
+		},
+
+		OnStop: func(ctx context.Context) error {
+			close(stop)
+
+			return nil
+		},
+	})
+}
+
+// cronJobOwner returns the name of the cron job that spawned the job.
+// Jobs run through the API before their cron job was started are
+// only labelled with it
+func cronJobOwner(job *batchv1.Job) (string, bool) {
+	for _, ref := range job.OwnerReferences {
+		if ref.Kind == "CronJob" {
+			return ref.Name, true
+		}
+	}
+	if name, ok := job.Labels[cronJobLabel]; ok {
+		return name, true
+	}
+
+	return "", false
+}
+
+// managedOwner returns the name of the cron job that spawned the job
+// if the scheduler created it
+func (w *JobWatcher) managedOwner(job *batchv1.Job) (string, bool) {
+	name, ok := cronJobOwner(job)
+	if !ok {
+		return "", false
+	}
+	if _, labelled := job.Labels[cronJobLabel]; labelled {
+		return name, true
+	}
+	cj, err := w.cronJobs.CronJobs(job.Namespace).Get(name)
+	if err != nil {
+		// deleted, or not seen by the informer yet
+		return "", false
+	}
+
+	return name, managed.IsManaged(cj)
+}
+
+func findCondition(
+	job *batchv1.Job,
+	t batchv1.JobConditionType,
+) *batchv1.JobCondition {
+	for i, c := range job.Status.Conditions {
+		if c.Type == t && c.Status == corev1.ConditionTrue {
+			return &job.Status.Conditions[i]
+		}
+	}
+
+	return nil
+}
+
+func hasCondition(job *batchv1.Job, t batchv1.JobConditionType) bool {
+	return findCondition(job, t) != nil
+}
+
+func timePtr(t *metav1.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	v := t.Time.UTC()
+
+	return &v
+}
+
+func (w *JobWatcher) publish(
+	t events.Type,
+	cronJobName string,
+	job *batchv1.Job,
+) {
+	message := ""
+	if c := findCondition(job, batchv1.JobFailed); c != nil {
+		message = c.Message
+	}
+
+	w.bus.Publish(events.Event{
+		Type:           t,
+		JobName:        cronJobName,
+		Namespace:      job.Namespace,
+		ChildJobName:   job.Name,
+		StartTime:      timePtr(job.Status.StartTime),
+		CompletionTime: timePtr(job.Status.CompletionTime),
+		Message:        message,
+	})
+}
+
+func (w *JobWatcher) onAdd(obj interface{}) {
+	job, ok := obj.(*batchv1.Job)
+	if !ok {
+		return
+	}
+	cronJobName, ok := w.managedOwner(job)
+	if !ok {
+		return
+	}
+
+	// the initial list returns every existing job, we only care
+	// about the ones created while we are watching
+	if job.CreationTimestamp.Time.Before(w.startedAt) {
+		return
+	}
+
+	w.publish(events.ChildJobCreated, cronJobName, job)
+}
+
+func (w *JobWatcher) onUpdate(oldObj, newObj interface{}) {
+	oldJob, ok := oldObj.(*batchv1.Job)
+	if !ok {
+		return
+	}
+	job, ok := newObj.(*batchv1.Job)
+	if !ok {
+		return
+	}
+	if taskName, ok := job.Labels[taskLabel]; ok {
+		w.onTaskUpdate(taskName, oldJob, job)
+		return
+	}
+	cronJobName, ok := w.managedOwner(job)
+	if !ok {
+		return
+	}
+
+	if hasCondition(job, batchv1.JobComplete) &&
+		!hasCondition(oldJob, batchv1.JobComplete) {
+		w.publish(events.ChildJobSucceeded, cronJobName, job)
+	}
+	if hasCondition(job, batchv1.JobFailed) &&
+		!hasCondition(oldJob, batchv1.JobFailed) {
+		w.publish(events.ChildJobFailed, cronJobName, job)
+	}
+}
+
+func (w *JobWatcher) onTaskUpdate(
+	taskName string,
+	oldJob *batchv1.Job,
+	job *batchv1.Job,
+) {
+	if hasCondition(job, batchv1.JobComplete) &&
+		!hasCondition(oldJob, batchv1.JobComplete) {
+		w.publish(events.TaskSucceeded, taskName, job)
+	}
+	if hasCondition(job, batchv1.JobFailed) &&
+		!hasCondition(oldJob, batchv1.JobFailed) {
+		w.publish(events.TaskFailed, taskName, job)
+	}
+}
@@ -0,0 +1,110 @@
+package kubernetes
+
+import (
+	"testing"
+	"time"
+
+	"github.com/panagiotisptr/job-scheduler/events"
+	"github.com/panagiotisptr/job-scheduler/managed"
+	"go.uber.org/zap"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	batchlisters "k8s.io/client-go/listers/batch/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+func newTestWatcher(t *testing.T, cronJobs ...*batchv1.CronJob) (*JobWatcher, <-chan events.Event) {
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	for _, cj := range cronJobs {
+		if err := indexer.Add(cj); err != nil {
+			t.Fatal(err)
+		}
+	}
+	bus := events.ProvideBus(zap.NewNop())
+	ch, unsubscribe := bus.Subscribe()
+	t.Cleanup(unsubscribe)
+
+	return &JobWatcher{
+		logger:   zap.NewNop(),
+		bus:      bus,
+		cronJobs: batchlisters.NewCronJobLister(indexer),
+	}, ch
+}
+
+func ownedJob(name string, owner string) *batchv1.Job {
+	return &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              name,
+			Namespace:         "jobs",
+			CreationTimestamp: metav1.Now(),
+			OwnerReferences:   []metav1.OwnerReference{{Kind: "CronJob", Name: owner}},
+		},
+	}
+}
+
+func failed(job *batchv1.Job) *batchv1.Job {
+	job = job.DeepCopy()
+	job.Status.Conditions = append(job.Status.Conditions, batchv1.JobCondition{
+		Type:    batchv1.JobFailed,
+		Status:  corev1.ConditionTrue,
+		Message: "BackoffLimitExceeded",
+	})
+
+	return job
+}
+
+func TestJobWatcherOnlyPublishesManagedCronJobs(t *testing.T) {
+	cronJob := func(name string) *batchv1.CronJob {
+		return &batchv1.CronJob{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "jobs"}}
+	}
+	w, ch := newTestWatcher(
+		t,
+		managed.Mark(cronJob("backup"), ""),
+		// created by someone else in the same namespace
+		cronJob("other"),
+	)
+
+	run := &batchv1.Job{ObjectMeta: metav1.ObjectMeta{
+		Name:              "report-manual",
+		Namespace:         "jobs",
+		CreationTimestamp: metav1.Now(),
+		Labels:            map[string]string{cronJobLabel: "report"},
+	}}
+	for _, job := range []*batchv1.Job{
+		ownedJob("other-1", "other"),
+		ownedJob("unknown-1", "unknown"),
+		ownedJob("backup-1", "backup"),
+		// run through the API, the cronjob doesn't have to exist
+		run,
+	} {
+		w.onAdd(job)
+		w.onUpdate(job, failed(job))
+	}
+
+	want := []struct {
+		t     events.Type
+		job   string
+		child string
+	}{
+		{events.ChildJobCreated, "backup", "backup-1"},
+		{events.ChildJobFailed, "backup", "backup-1"},
+		{events.ChildJobCreated, "report", "report-manual"},
+		{events.ChildJobFailed, "report", "report-manual"},
+	}
+	for _, w := range want {
+		select {
+		case e := <-ch:
+			if e.Type != w.t || e.JobName != w.job || e.ChildJobName != w.child {
+				t.Errorf("event = %s %s %s, want %s %s %s", e.Type, e.JobName, e.ChildJobName, w.t, w.job, w.child)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("no %s event for %s", w.t, w.child)
+		}
+	}
+	select {
+	case e := <-ch:
+		t.Errorf("unexpected event %s of %s", e.Type, e.JobName)
+	default:
+	}
+}
@@ -0,0 +1,249 @@
+package kubernetes
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/panagiotisptr/job-scheduler/apperror"
+	"github.com/panagiotisptr/job-scheduler/repository"
+	"github.com/panagiotisptr/job-scheduler/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// cronJobLabel marks the jobs created from a cron job through the
+// API, they are only owned by the cron job if it is in the cluster
+const cronJobLabel = "job-scheduler/cronjob"
+
+// RunCronJob creates a job from the job template of the cron job,
+// the same way `kubectl create job --from=cronjob/<name>` does
+func (r *KubernetesRepository) RunCronJob(
+	ctx context.Context,
+	cj *batchv1.CronJob,
+) (*batchv1.Job, error) {
+	ctx, span := r.tracer.Start(
+		ctx,
+		"KubernetesRepository.RunCronJob",
+		trace.WithAttributes(attribute.String("job.name", cj.Name)),
+	)
+	defer span.End()
+
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: cj.Name + "-manual-",
+			Namespace:    r.GetNamespace(),
+			Labels:       map[string]string{cronJobLabel: cj.Name},
+			Annotations: map[string]string{
+				"cronjob.kubernetes.io/instantiate": "manual",
+			},
+		},
+		Spec: *cj.Spec.JobTemplate.Spec.DeepCopy(),
+	}
+	for k, v := range cj.Spec.JobTemplate.Labels {
+		job.Labels[k] = v
+	}
+	for k, v := range cj.Spec.JobTemplate.Annotations {
+		job.Annotations[k] = v
+	}
+
+	// owned by the cron job so that it is garbage collected with it
+	// and shows up in the job events
+	live, err := r.GetCronJob(ctx, cj.Name)
+	if err != nil && !errors.IsNotFound(err) {
+		tracing.RecordError(span, err)
+		return nil, err
+	}
+	if err == nil {
+		controller := true
+		job.OwnerReferences = []metav1.OwnerReference{
+			{
+				APIVersion: "batch/v1",
+				Kind:       "CronJob",
+				Name:       live.Name,
+				UID:        live.UID,
+				Controller: &controller,
+			},
+		}
+	}
+
+	var created *batchv1.Job
+	err = r.callResource(ctx, "jobs", "create", cj.Name, func(ctx context.Context) error {
+		var err error
+		created, err = r.client.BatchV1().Jobs(r.GetNamespace()).Create(
+			ctx,
+			job,
+			metav1.CreateOptions{},
+		)
+
+		return err
+	})
+	if err != nil {
+		tracing.RecordError(span, err)
+		return nil, err
+	}
+
+	return created, nil
+}
+
+// GetJobLogs streams the logs of the most recent pod of a job
+// spawned by the cron job
+func (r *KubernetesRepository) GetJobLogs(
+	ctx context.Context,
+	cronJobName string,
+	opts repository.LogOptions,
+) (*repository.JobLogs, error) {
+	ctx, span := r.tracer.Start(
+		ctx,
+		"KubernetesRepository.GetJobLogs",
+		trace.WithAttributes(attribute.String("job.name", cronJobName)),
+	)
+	defer span.End()
+
+	logs, err := r.getJobLogs(ctx, cronJobName, opts)
+	if err != nil && apperror.CodeOf(err) != apperror.CodeNotFound {
+		tracing.RecordError(span, err)
+	}
+
+	return logs, err
+}
+
+func (r *KubernetesRepository) getJobLogs(
+	ctx context.Context,
+	cronJobName string,
+	opts repository.LogOptions,
+) (*repository.JobLogs, error) {
+	jobName := opts.Job
+	if jobName == "" {
+		var err error
+		jobName, err = r.latestJob(ctx, cronJobName)
+		if err != nil {
+			return nil, err
+		}
+	} else if err := r.checkJobOwner(ctx, cronJobName, jobName); err != nil {
+		return nil, err
+	}
+
+	var pods *corev1.PodList
+	selector := labels.SelectorFromSet(labels.Set{"job-name": jobName}).String()
+	err := r.callResource(ctx, "pods", "list", jobName, func(ctx context.Context) error {
+		var err error
+		pods, err = r.client.CoreV1().Pods(r.GetNamespace()).List(
+			ctx,
+			metav1.ListOptions{LabelSelector: selector},
+		)
+
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(pods.Items) == 0 {
+		return nil, apperror.NotFound("job %s has no pods", jobName)
+	}
+	sort.Slice(pods.Items, func(i, j int) bool {
+		return pods.Items[j].CreationTimestamp.Before(&pods.Items[i].CreationTimestamp)
+	})
+	pod := pods.Items[0]
+
+	podLogOptions := &corev1.PodLogOptions{Follow: opts.Follow}
+	if opts.TailLines > 0 {
+		podLogOptions.TailLines = &opts.TailLines
+	}
+	logs := &repository.JobLogs{
+		Job: jobName,
+		Pod: pod.Name,
+	}
+	err = r.callResource(ctx, "pods/log", "get", pod.Name, func(ctx context.Context) error {
+		var err error
+		logs.ReadCloser, err = r.client.CoreV1().Pods(r.GetNamespace()).GetLogs(
+			pod.Name,
+			podLogOptions,
+		).Stream(ctx)
+
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return logs, nil
+}
+
+// checkJobOwner makes sure that the job was spawned by the cron job,
+// so that its logs can't be read by whoever can view any cron job
+func (r *KubernetesRepository) checkJobOwner(
+	ctx context.Context,
+	cronJobName string,
+	jobName string,
+) error {
+	if errs := validation.IsDNS1123Subdomain(jobName); len(errs) > 0 {
+		return apperror.Invalid("invalid job name %s: %s", jobName, strings.Join(errs, ", "))
+	}
+
+	var job *batchv1.Job
+	err := r.callResource(ctx, "jobs", "get", jobName, func(ctx context.Context) error {
+		var err error
+		job, err = r.client.BatchV1().Jobs(r.GetNamespace()).Get(
+			ctx,
+			jobName,
+			metav1.GetOptions{},
+		)
+
+		return err
+	})
+	if apperror.CodeOf(err) == apperror.CodeNotFound {
+		return apperror.NotFound("could not find job %s of cronjob %s", jobName, cronJobName)
+	}
+	if err != nil {
+		return err
+	}
+	if owner, ok := cronJobOwner(job); !ok || owner != cronJobName {
+		return apperror.NotFound("could not find job %s of cronjob %s", jobName, cronJobName)
+	}
+
+	return nil
+}
+
+// latestJob returns the name of the most recently created job of
+// the cron job
+func (r *KubernetesRepository) latestJob(
+	ctx context.Context,
+	cronJobName string,
+) (string, error) {
+	var jobs *batchv1.JobList
+	err := r.callResource(ctx, "jobs", "list", cronJobName, func(ctx context.Context) error {
+		var err error
+		jobs, err = r.client.BatchV1().Jobs(r.GetNamespace()).List(
+			ctx,
+			metav1.ListOptions{},
+		)
+
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+
+	var latest *batchv1.Job
+	for i, job := range jobs.Items {
+		owner, ok := cronJobOwner(&jobs.Items[i])
+		if !ok || owner != cronJobName {
+			continue
+		}
+		if latest == nil || latest.CreationTimestamp.Before(&job.CreationTimestamp) {
+			latest = &jobs.Items[i]
+		}
+	}
+	if latest == nil {
+		return "", apperror.NotFound("cronjob %s has no jobs", cronJobName)
+	}
+
+	return latest.Name, nil
+}
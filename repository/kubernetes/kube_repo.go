@@ -2,43 +2,126 @@ package kubernetes
 
 import (
 	"context"
-	"os"
+	"time"
 
+	"github.com/panagiotisptr/job-scheduler/config"
+	"github.com/panagiotisptr/job-scheduler/health"
+	"github.com/panagiotisptr/job-scheduler/metrics"
+	"github.com/panagiotisptr/job-scheduler/namespace"
 	"github.com/panagiotisptr/job-scheduler/repository"
+	"github.com/panagiotisptr/job-scheduler/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 	batchv1 "k8s.io/api/batch/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/restmapper"
 )
 
 type KubernetesRepository struct {
-	logger *zap.Logger
-	client *kubernetes.Clientset
+	logger     *zap.Logger
+	client     kubernetes.Interface
+	dynamic    dynamic.Interface
+	mapper     meta.RESTMapper
+	companions config.CompanionsConfig
+	metrics    *metrics.Metrics
+	tracer     trace.Tracer
 }
 
 func ProvideKubernetesRepository(
 	logger *zap.Logger,
+	cfg *config.Config,
 	client *kubernetes.Clientset,
+	dynamicClient dynamic.Interface,
+	m *metrics.Metrics,
+	tp trace.TracerProvider,
+	checker *health.Checker,
 ) (repository.KubernetesRepository, error) {
 	repo := &KubernetesRepository{
-		logger: logger,
-		client: client,
+		logger:  logger,
+		client:  client,
+		dynamic: dynamicClient,
+		// resolves the resources of companions, the discovery is
+		// refreshed when a kind is not found
+		mapper: restmapper.NewDeferredDiscoveryRESTMapper(
+			memory.NewMemCacheClient(client.Discovery()),
+		),
+		companions: cfg.Companions,
+		metrics:    m,
+		tracer:     tp.Tracer("github.com/panagiotisptr/job-scheduler/repository/kubernetes"),
 	}
+	checker.Register("kubernetes-api", repo.checkAPI)
 
 	return repo, nil
 }
 
+// checkAPI fails if the Kubernetes API server can't be reached
+func (r *KubernetesRepository) checkAPI(ctx context.Context) error {
+	return r.client.Discovery().RESTClient().Get().AbsPath("/version").Do(ctx).Error()
+}
+
+// call wraps a single Kubernetes API call on cron jobs in a client
+// span and records its latency and outcome
+func (r *KubernetesRepository) call(
+	ctx context.Context,
+	verb string,
+	name string,
+	f func(ctx context.Context) error,
+) error {
+	return r.callResource(ctx, "cronjobs", verb, name, f)
+}
+
+func (r *KubernetesRepository) callResource(
+	ctx context.Context,
+	resource string,
+	verb string,
+	name string,
+	f func(ctx context.Context) error,
+) error {
+	ctx, span := r.tracer.Start(
+		ctx,
+		"kubernetes."+verb,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("k8s.verb", verb),
+			attribute.String("k8s.resource", resource),
+			attribute.String("k8s.namespace", r.GetNamespace()),
+			attribute.String("k8s.name", name),
+		),
+	)
+	defer span.End()
+
+	start := time.Now()
+	err := f(ctx)
+	r.metrics.ObserveKubernetesRequest(verb, start, err)
+	if err != nil && !errors.IsNotFound(err) {
+		tracing.RecordError(span, err)
+	}
+
+	return fromKubernetesError(err)
+}
+
 func (r *KubernetesRepository) getCronJob(
 	ctx context.Context,
 	cj *batchv1.CronJob,
 	createIfNotFound bool,
 ) (*batchv1.CronJob, error) {
-	cronJob, err := r.client.BatchV1().CronJobs(r.getNamespace()).Get(
-		ctx,
-		cj.Name,
-		metav1.GetOptions{},
-	)
+	var cronJob *batchv1.CronJob
+	err := r.call(ctx, "get", cj.Name, func(ctx context.Context) error {
+		var err error
+		cronJob, err = r.client.BatchV1().CronJobs(r.GetNamespace()).Get(
+			ctx,
+			cj.Name,
+			metav1.GetOptions{},
+		)
+
+		return err
+	})
 	if err == nil {
 		return cronJob, nil
 	}
@@ -50,31 +133,69 @@ func (r *KubernetesRepository) getCronJob(
 		return cronJob, err
 	}
 
-	return r.client.BatchV1().CronJobs(r.getNamespace()).Create(
+	err = r.call(ctx, "create", cj.Name, func(ctx context.Context) error {
+		var err error
+		cronJob, err = r.client.BatchV1().CronJobs(r.GetNamespace()).Create(
+			ctx,
+			cj,
+			metav1.CreateOptions{},
+		)
+
+		return err
+	})
+
+	return cronJob, err
+}
+
+func (r *KubernetesRepository) GetCronJob(
+	ctx context.Context,
+	name string,
+) (*batchv1.CronJob, error) {
+	ctx, span := r.tracer.Start(
 		ctx,
-		cj,
-		metav1.CreateOptions{},
+		"KubernetesRepository.GetCronJob",
+		trace.WithAttributes(attribute.String("job.name", name)),
 	)
-}
+	defer span.End()
 
-func (r *KubernetesRepository) getNamespace() string {
-	envNamespace := os.Getenv("POD_NAMESPACE")
-	if envNamespace != "" {
-		return envNamespace
+	cj, err := r.getCronJob(
+		ctx,
+		&batchv1.CronJob{ObjectMeta: metav1.ObjectMeta{Name: name}},
+		false,
+	)
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			tracing.RecordError(span, err)
+		}
+		return nil, err
 	}
 
-	return metav1.NamespaceDefault
+	return cj, nil
+}
+
+func (r *KubernetesRepository) GetNamespace() string {
+	return namespace.Current()
 }
 
 func (r *KubernetesRepository) GetRunningCronJobs(
 	ctx context.Context,
 ) ([]string, error) {
+	ctx, span := r.tracer.Start(ctx, "KubernetesRepository.GetRunningCronJobs")
+	defer span.End()
+
 	names := []string{}
-	cronJobs, err := r.client.BatchV1().CronJobs(r.getNamespace()).List(
-		ctx,
-		metav1.ListOptions{},
-	)
+	var cronJobs *batchv1.CronJobList
+	err := r.call(ctx, "list", "", func(ctx context.Context) error {
+		var err error
+		cronJobs, err = r.client.BatchV1().CronJobs(r.GetNamespace()).List(
+			ctx,
+			metav1.ListOptions{},
+		)
+
+		return err
+	})
 	if err != nil {
+		tracing.RecordError(span, err)
 		return names, err
 	}
 	for _, cj := range cronJobs.Items {
@@ -90,6 +211,23 @@ func (r *KubernetesRepository) GetRunningCronJobs(
 func (r *KubernetesRepository) StartCronJob(
 	ctx context.Context,
 	cj *batchv1.CronJob,
+) error {
+	ctx, span := r.tracer.Start(
+		ctx,
+		"KubernetesRepository.StartCronJob",
+		trace.WithAttributes(attribute.String("job.name", cj.Name)),
+	)
+	defer span.End()
+
+	err := r.startCronJob(ctx, cj)
+	tracing.RecordError(span, err)
+
+	return err
+}
+
+func (r *KubernetesRepository) startCronJob(
+	ctx context.Context,
+	cj *batchv1.CronJob,
 ) error {
 	cronJob, err := r.getCronJob(ctx, cj, true)
 	if err != nil {
@@ -103,11 +241,13 @@ func (r *KubernetesRepository) StartCronJob(
 	// this cronjob was previously stopped and the spec says
 	// it should be running. Delete and recreate it
 	if isSuspended(cronJob) && !isSuspended(cj) {
-		err = r.client.BatchV1().CronJobs(r.getNamespace()).Delete(
-			ctx,
-			cronJob.Name,
-			metav1.DeleteOptions{},
-		)
+		err = r.call(ctx, "delete", cronJob.Name, func(ctx context.Context) error {
+			return r.client.BatchV1().CronJobs(r.GetNamespace()).Delete(
+				ctx,
+				cronJob.Name,
+				metav1.DeleteOptions{},
+			)
+		})
 		if err != nil {
 			return err
 		}
@@ -115,36 +255,95 @@ func (r *KubernetesRepository) StartCronJob(
 		// This is safe - it won't get stuck in a loop
 		// we just deleted the object so next time isSuspended
 		// will match cj
-		return r.StartCronJob(ctx, cj)
+		return r.startCronJob(ctx, cj)
+	}
+	// apply the manifest in case it changed since the cronjob
+	// was created
+	cronJob.Spec = cj.Spec
+	if cronJob.Labels == nil {
+		cronJob.Labels = map[string]string{}
+	}
+	for k, v := range cj.Labels {
+		cronJob.Labels[k] = v
+	}
+	if cronJob.Annotations == nil {
+		cronJob.Annotations = map[string]string{}
+	}
+	for k, v := range cj.Annotations {
+		cronJob.Annotations[k] = v
 	}
 	t := false
 	cronJob.Spec.Suspend = &t
 
-	_, err = r.client.BatchV1().CronJobs(r.getNamespace()).Update(
-		ctx,
-		cronJob,
-		metav1.UpdateOptions{},
-	)
+	return r.call(ctx, "update", cronJob.Name, func(ctx context.Context) error {
+		_, err := r.client.BatchV1().CronJobs(r.GetNamespace()).Update(
+			ctx,
+			cronJob,
+			metav1.UpdateOptions{},
+		)
 
-	return err
+		return err
+	})
 }
 
 func (r *KubernetesRepository) StopCronJob(
 	ctx context.Context,
 	cj *batchv1.CronJob,
 ) error {
+	ctx, span := r.tracer.Start(
+		ctx,
+		"KubernetesRepository.StopCronJob",
+		trace.WithAttributes(attribute.String("job.name", cj.Name)),
+	)
+	defer span.End()
+
 	c, err := r.getCronJob(ctx, cj, false)
 	if err != nil {
+		tracing.RecordError(span, err)
 		return err
 	}
 	t := true
 	c.Spec.Suspend = &t
 
-	_, err = r.client.BatchV1().CronJobs(r.getNamespace()).Update(
+	err = r.call(ctx, "update", c.Name, func(ctx context.Context) error {
+		_, err := r.client.BatchV1().CronJobs(r.GetNamespace()).Update(
+			ctx,
+			c,
+			metav1.UpdateOptions{},
+		)
+
+		return err
+	})
+	tracing.RecordError(span, err)
+
+	return err
+}
+
+func (r *KubernetesRepository) DeleteCronJob(
+	ctx context.Context,
+	name string,
+) error {
+	ctx, span := r.tracer.Start(
 		ctx,
-		c,
-		metav1.UpdateOptions{},
+		"KubernetesRepository.DeleteCronJob",
+		trace.WithAttributes(attribute.String("job.name", name)),
 	)
+	defer span.End()
+
+	// Background propagation so that the jobs spawned by the
+	// cron job are garbage collected as well
+	propagation := metav1.DeletePropagationBackground
+
+	err := r.call(ctx, "delete", name, func(ctx context.Context) error {
+		return r.client.BatchV1().CronJobs(r.GetNamespace()).Delete(
+			ctx,
+			name,
+			metav1.DeleteOptions{
+				PropagationPolicy: &propagation,
+			},
+		)
+	})
+	tracing.RecordError(span, err)
 
 	return err
 }
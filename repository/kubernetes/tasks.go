@@ -0,0 +1,122 @@
+package kubernetes
+
+import (
+	"context"
+	"sort"
+
+	"github.com/panagiotisptr/job-scheduler/repository"
+	"github.com/panagiotisptr/job-scheduler/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	batchv1 "k8s.io/api/batch/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// taskLabel marks the jobs created from a task
+const taskLabel = "job-scheduler/task"
+
+// RunTask creates a job from the task manifest. The name of the
+// job gets a random suffix so that a task can run more than once
+func (r *KubernetesRepository) RunTask(
+	ctx context.Context,
+	task *batchv1.Job,
+) (*batchv1.Job, error) {
+	ctx, span := r.tracer.Start(
+		ctx,
+		"KubernetesRepository.RunTask",
+		trace.WithAttributes(attribute.String("task.name", task.Name)),
+	)
+	defer span.End()
+
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: task.Name + "-",
+			Namespace:    r.GetNamespace(),
+			Labels:       map[string]string{},
+			Annotations:  map[string]string{},
+		},
+		Spec: *task.Spec.DeepCopy(),
+	}
+	for k, v := range task.Labels {
+		job.Labels[k] = v
+	}
+	for k, v := range task.Annotations {
+		job.Annotations[k] = v
+	}
+	job.Labels[taskLabel] = task.Name
+
+	var created *batchv1.Job
+	err := r.callResource(ctx, "jobs", "create", task.Name, func(ctx context.Context) error {
+		var err error
+		created, err = r.client.BatchV1().Jobs(r.GetNamespace()).Create(
+			ctx,
+			job,
+			metav1.CreateOptions{},
+		)
+
+		return err
+	})
+	if err != nil {
+		tracing.RecordError(span, err)
+		return nil, err
+	}
+
+	return created, nil
+}
+
+// GetTaskRuns lists the jobs created from the task
+func (r *KubernetesRepository) GetTaskRuns(
+	ctx context.Context,
+	taskName string,
+) ([]repository.TaskRun, error) {
+	ctx, span := r.tracer.Start(
+		ctx,
+		"KubernetesRepository.GetTaskRuns",
+		trace.WithAttributes(attribute.String("task.name", taskName)),
+	)
+	defer span.End()
+
+	var jobs *batchv1.JobList
+	err := r.callResource(ctx, "jobs", "list", taskName, func(ctx context.Context) error {
+		var err error
+		jobs, err = r.client.BatchV1().Jobs(r.GetNamespace()).List(
+			ctx,
+			metav1.ListOptions{LabelSelector: taskLabel + "=" + taskName},
+		)
+
+		return err
+	})
+	if err != nil {
+		tracing.RecordError(span, err)
+		return nil, err
+	}
+
+	runs := []repository.TaskRun{}
+	for i := range jobs.Items {
+		runs = append(runs, taskRun(&jobs.Items[i]))
+	}
+	sort.Slice(runs, func(i, j int) bool {
+		return runs[j].CreatedAt.Before(runs[i].CreatedAt)
+	})
+
+	return runs, nil
+}
+
+func taskRun(job *batchv1.Job) repository.TaskRun {
+	run := repository.TaskRun{
+		JobName:        job.Name,
+		Status:         repository.TaskRunRunning,
+		CreatedAt:      job.CreationTimestamp.Time.UTC(),
+		StartTime:      timePtr(job.Status.StartTime),
+		CompletionTime: timePtr(job.Status.CompletionTime),
+	}
+	if hasCondition(job, batchv1.JobComplete) {
+		run.Status = repository.TaskRunSucceeded
+	}
+	if c := findCondition(job, batchv1.JobFailed); c != nil {
+		run.Status = repository.TaskRunFailed
+		run.Message = c.Message
+	}
+
+	return run
+}
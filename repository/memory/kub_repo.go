@@ -2,15 +2,29 @@ package memory
 
 import (
 	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
 
+	"github.com/panagiotisptr/job-scheduler/apperror"
+	"github.com/panagiotisptr/job-scheduler/namespace"
 	"github.com/panagiotisptr/job-scheduler/repository"
 	"go.uber.org/zap"
 	batchv1 "k8s.io/api/batch/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 )
 
 type KubernetesMemoryRepository struct {
-	jobs   map[string]struct{}
-	logger *zap.Logger
+	mu    sync.Mutex
+	jobs  map[string]*batchv1.CronJob
+	runs  map[string][]string
+	tasks map[string][]repository.TaskRun
+	// companions the companions applied with each cron job
+	companions map[string][]unstructured.Unstructured
+	logger     *zap.Logger
 }
 
 func ProvideKubernetesMemoryRepository(
@@ -18,8 +32,11 @@ func ProvideKubernetesMemoryRepository(
 ) repository.KubernetesRepository {
 	logger.Sugar().Info("using in-memory kubernetes repository. Changes are not applied to the cluster")
 	return &KubernetesMemoryRepository{
-		jobs:   make(map[string]struct{}),
-		logger: logger,
+		jobs:       make(map[string]*batchv1.CronJob),
+		runs:       make(map[string][]string),
+		tasks:      make(map[string][]repository.TaskRun),
+		companions: make(map[string][]unstructured.Unstructured),
+		logger:     logger,
 	}
 }
 
@@ -27,7 +44,30 @@ func (r *KubernetesMemoryRepository) StartCronJob(
 	ctx context.Context,
 	cj *batchv1.CronJob,
 ) error {
-	r.jobs[cj.Name] = struct{}{}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.jobs[cj.Name] = cj.DeepCopy()
+	return nil
+}
+
+func (r *KubernetesMemoryRepository) ApplyCompanions(
+	ctx context.Context,
+	cronJobName string,
+	companions []unstructured.Unstructured,
+) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.jobs[cronJobName]; !ok {
+		return apperror.NotFound("could not find cronjob in the cluster: %s", cronJobName)
+	}
+	applied := []unstructured.Unstructured{}
+	for i := range companions {
+		applied = append(applied, *companions[i].DeepCopy())
+	}
+	r.companions[cronJobName] = applied
+
 	return nil
 }
 
@@ -35,13 +75,138 @@ func (r *KubernetesMemoryRepository) StopCronJob(
 	ctx context.Context,
 	cj *batchv1.CronJob,
 ) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
 	delete(r.jobs, cj.Name)
 	return nil
 }
 
+func (r *KubernetesMemoryRepository) DeleteCronJob(
+	ctx context.Context,
+	name string,
+) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.jobs, name)
+	delete(r.runs, name)
+	delete(r.companions, name)
+	return nil
+}
+
+func (r *KubernetesMemoryRepository) GetCronJob(
+	ctx context.Context,
+	name string,
+) (*batchv1.CronJob, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	cj, ok := r.jobs[name]
+	if !ok {
+		return nil, apperror.NotFound("could not find cronjob in the cluster: %s", name)
+	}
+
+	return cj.DeepCopy(), nil
+}
+
+// RunCronJob only records the name of the job, nothing runs
+func (r *KubernetesMemoryRepository) RunCronJob(
+	ctx context.Context,
+	cj *batchv1.CronJob,
+) (*batchv1.Job, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	name := fmt.Sprintf("%s-manual-%d", cj.Name, len(r.runs[cj.Name])+1)
+	r.runs[cj.Name] = append(r.runs[cj.Name], name)
+
+	return &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: r.GetNamespace(),
+		},
+		Spec: *cj.Spec.JobTemplate.Spec.DeepCopy(),
+	}, nil
+}
+
+func (r *KubernetesMemoryRepository) GetJobLogs(
+	ctx context.Context,
+	cronJobName string,
+	opts repository.LogOptions,
+) (*repository.JobLogs, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	runs := r.runs[cronJobName]
+	if len(runs) == 0 {
+		return nil, apperror.NotFound("cronjob %s has no jobs", cronJobName)
+	}
+	job := opts.Job
+	if job == "" {
+		job = runs[len(runs)-1]
+	} else if !contains(runs, job) {
+		return nil, apperror.NotFound("could not find job %s of cronjob %s", job, cronJobName)
+	}
+
+	return &repository.JobLogs{
+		Job: job,
+		Pod: job,
+		ReadCloser: io.NopCloser(strings.NewReader(
+			"jobs don't run with the in-memory kubernetes repository\n",
+		)),
+	}, nil
+}
+
+// RunTask records a run of the task that succeeds immediately,
+// nothing runs
+func (r *KubernetesMemoryRepository) RunTask(
+	ctx context.Context,
+	task *batchv1.Job,
+) (*batchv1.Job, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now().UTC()
+	name := fmt.Sprintf("%s-%d", task.Name, len(r.tasks[task.Name])+1)
+	r.tasks[task.Name] = append(r.tasks[task.Name], repository.TaskRun{
+		JobName:        name,
+		Status:         repository.TaskRunSucceeded,
+		CreatedAt:      now,
+		StartTime:      &now,
+		CompletionTime: &now,
+	})
+
+	return &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: r.GetNamespace(),
+		},
+		Spec: *task.Spec.DeepCopy(),
+	}, nil
+}
+
+func (r *KubernetesMemoryRepository) GetTaskRuns(
+	ctx context.Context,
+	taskName string,
+) ([]repository.TaskRun, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	runs := []repository.TaskRun{}
+	for i := len(r.tasks[taskName]) - 1; i >= 0; i-- {
+		runs = append(runs, r.tasks[taskName][i])
+	}
+
+	return runs, nil
+}
+
 func (r *KubernetesMemoryRepository) GetRunningCronJobs(
 	ctx context.Context,
 ) ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
 	names := []string{}
 	for n := range r.jobs {
 		names = append(names, n)
@@ -49,3 +214,17 @@ func (r *KubernetesMemoryRepository) GetRunningCronJobs(
 
 	return names, nil
 }
+
+func (r *KubernetesMemoryRepository) GetNamespace() string {
+	return namespace.Current()
+}
+
+func contains(list []string, v string) bool {
+	for _, l := range list {
+		if l == v {
+			return true
+		}
+	}
+
+	return false
+}
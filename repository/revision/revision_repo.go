@@ -0,0 +1,93 @@
+package revision
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/panagiotisptr/job-scheduler/apperror"
+	"github.com/panagiotisptr/job-scheduler/repository"
+	"github.com/panagiotisptr/job-scheduler/store"
+)
+
+const (
+	// bucketPrefix the revisions of every cronjob are kept in their
+	// own bucket so that they are numbered separately
+	bucketPrefix = "revisions/"
+	// how many revisions of a cronjob are kept
+	maxRevisions = 50
+)
+
+// StoreRevisionRepository keeps the revisions in the state store
+type StoreRevisionRepository struct {
+	store store.Store
+}
+
+func ProvideRevisionRepository(
+	st store.Store,
+) repository.RevisionRepository {
+	return &StoreRevisionRepository{
+		store: st,
+	}
+}
+
+// AddRevision appends the revision to the ones of its cronjob,
+// dropping the oldest one once there are too many
+func (r *StoreRevisionRepository) AddRevision(
+	ctx context.Context,
+	revision repository.Revision,
+) (repository.Revision, error) {
+	bucket := bucketPrefix + revision.JobName
+	seq, err := r.store.NextSequence(ctx, bucket)
+	if err != nil {
+		return revision, err
+	}
+	revision.Number = seq
+	err = store.PutJSON(ctx, r.store, bucket, store.SequenceKey(seq), revision)
+	if err != nil {
+		return revision, err
+	}
+	if seq > maxRevisions {
+		err = r.store.Delete(ctx, bucket, store.SequenceKey(seq-maxRevisions))
+	}
+
+	return revision, err
+}
+
+func (r *StoreRevisionRepository) GetRevisions(
+	ctx context.Context,
+	jobName string,
+) ([]repository.Revision, error) {
+	entries, err := r.store.List(ctx, bucketPrefix+jobName)
+	if err != nil {
+		return nil, err
+	}
+
+	res := []repository.Revision{}
+	for i := len(entries) - 1; i >= 0; i-- {
+		var revision repository.Revision
+		if err := json.Unmarshal(entries[i].Value, &revision); err != nil {
+			continue
+		}
+		res = append(res, revision)
+	}
+
+	return res, nil
+}
+
+func (r *StoreRevisionRepository) GetRevision(
+	ctx context.Context,
+	jobName string,
+	number uint64,
+) (*repository.Revision, error) {
+	var revision repository.Revision
+	err := store.GetJSON(ctx, r.store, bucketPrefix+jobName, store.SequenceKey(number), &revision)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, apperror.NotFound("could not find revision %d of cronjob %s", number, jobName)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &revision, nil
+}
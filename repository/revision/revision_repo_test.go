@@ -0,0 +1,82 @@
+package revision
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/panagiotisptr/job-scheduler/apperror"
+	"github.com/panagiotisptr/job-scheduler/repository"
+	"github.com/panagiotisptr/job-scheduler/store/memory"
+)
+
+func TestRevisions(t *testing.T) {
+	ctx := context.Background()
+	r := ProvideRevisionRepository(memory.NewStore())
+	add := func(jobName string, commit string) repository.Revision {
+		t.Helper()
+		revision, err := r.AddRevision(ctx, repository.Revision{JobName: jobName, Commit: commit})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return revision
+	}
+
+	// every cronjob is numbered from 1 in its own bucket
+	for i := 1; i <= maxRevisions+2; i++ {
+		if revision := add("backup", fmt.Sprintf("backup-%d", i)); revision.Number != uint64(i) {
+			t.Fatalf("revision %d of backup numbered %d", i, revision.Number)
+		}
+	}
+	if revision := add("report", "report-1"); revision.Number != 1 {
+		t.Errorf("first revision of report numbered %d, want 1", revision.Number)
+	}
+
+	// the newest ones are kept, newest first
+	revisions, err := r.GetRevisions(ctx, "backup")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(revisions) != maxRevisions {
+		t.Fatalf("kept %d revisions of backup, want %d", len(revisions), maxRevisions)
+	}
+	for i, revision := range revisions {
+		want := uint64(maxRevisions + 2 - i)
+		if revision.Number != want || revision.Commit != fmt.Sprintf("backup-%d", want) {
+			t.Errorf("revisions[%d] = %d at %s, want %d", i, revision.Number, revision.Commit, want)
+		}
+	}
+	revisions, err = r.GetRevisions(ctx, "report")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(revisions) != 1 || revisions[0].Commit != "report-1" {
+		t.Errorf("revisions of report = %+v, want only its own", revisions)
+	}
+	revisions, err = r.GetRevisions(ctx, "archive")
+	if err != nil || len(revisions) != 0 {
+		t.Errorf("revisions of a cronjob never applied = %v, %v, want none", revisions, err)
+	}
+
+	revision, err := r.GetRevision(ctx, "backup", 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if revision.Commit != "backup-3" {
+		t.Errorf("revision 3 at %s, want backup-3", revision.Commit)
+	}
+	for _, tt := range []struct {
+		jobName string
+		number  uint64
+	}{
+		// dropped, over the cap
+		{"backup", 2},
+		{"backup", maxRevisions + 3},
+		{"report", 2},
+		{"archive", 1},
+	} {
+		if _, err := r.GetRevision(ctx, tt.jobName, tt.number); apperror.CodeOf(err) != apperror.CodeNotFound {
+			t.Errorf("revision %d of %s: error = %v, want not found", tt.number, tt.jobName, err)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// Revision a spec of a cronjob applied to the cluster. Revisions are
+// numbered from 1 for every cronjob
+type Revision struct {
+	Number   uint64 `json:"number"`
+	JobName  string `json:"jobName"`
+	SpecHash string `json:"specHash"`
+	// Commit the SHA the manifest was read at, empty if unknown
+	Commit    string    `json:"commit,omitempty"`
+	Actor     string    `json:"actor"`
+	AppliedAt time.Time `json:"appliedAt"`
+	// RollbackOf the revision that was applied again, 0 if this one
+	// was not a rollback
+	RollbackOf uint64 `json:"rollbackOf,omitempty"`
+	// CronJob and Companions what was applied
+	CronJob    batchv1.CronJob             `json:"cronJob"`
+	Companions []unstructured.Unstructured `json:"companions,omitempty"`
+}
+
+// RevisionRepository stores the specs applied to the cluster so that
+// they can be applied again
+type RevisionRepository interface {
+	// AddRevision store the revision, numbering it
+	AddRevision(ctx context.Context, revision Revision) (Revision, error)
+
+	// GetRevisions get the most recent revisions of the cronjob,
+	// newest first
+	GetRevisions(ctx context.Context, jobName string) ([]Revision, error)
+
+	// GetRevision get a revision of the cronjob by its number
+	GetRevision(ctx context.Context, jobName string, number uint64) (*Revision, error)
+}
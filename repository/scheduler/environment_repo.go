@@ -0,0 +1,108 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/panagiotisptr/job-scheduler/apperror"
+	"github.com/panagiotisptr/job-scheduler/client"
+	"github.com/panagiotisptr/job-scheduler/config"
+	"github.com/panagiotisptr/job-scheduler/managed"
+	"github.com/panagiotisptr/job-scheduler/repository"
+	"github.com/panagiotisptr/job-scheduler/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// EnvironmentRepository reads from the API of the schedulers of other
+// environments
+type EnvironmentRepository struct {
+	// clients the schedulers of the environments, nil for the ones
+	// without a URL
+	clients map[string]*client.Client
+	tracer  trace.Tracer
+}
+
+func ProvideEnvironmentRepository(
+	cfg *config.Config,
+	tp trace.TracerProvider,
+) (repository.EnvironmentRepository, error) {
+	r := &EnvironmentRepository{
+		clients: make(map[string]*client.Client),
+		tracer:  tp.Tracer("github.com/panagiotisptr/job-scheduler/repository/scheduler"),
+	}
+	for _, source := range cfg.Promotions.From {
+		if source.Name == "" {
+			return nil, fmt.Errorf("promotion source without a name")
+		}
+		if source.URL == "" {
+			r.clients[source.Name] = nil
+			continue
+		}
+		c, err := client.New(source.URL, client.WithToken(source.Token))
+		if err != nil {
+			return nil, fmt.Errorf("promotion source %s: %w", source.Name, err)
+		}
+		r.clients[source.Name] = c
+	}
+
+	return r, nil
+}
+
+// GetAppliedCommit reads the commit annotation of the cronjob in the
+// cluster of the environment, rather than the commit its index is at,
+// which can be ahead of the cluster
+func (r *EnvironmentRepository) GetAppliedCommit(
+	ctx context.Context,
+	environment string,
+	name string,
+) (string, error) {
+	ctx, span := r.tracer.Start(
+		ctx,
+		"EnvironmentRepository.GetAppliedCommit",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("job.name", name),
+			attribute.String("promotion.from", environment),
+		),
+	)
+	defer span.End()
+
+	c, ok := r.clients[environment]
+	if !ok {
+		err := apperror.Invalid("cronjobs can't be promoted from %s", environment)
+		tracing.RecordError(span, err)
+		return "", err
+	}
+	if c == nil {
+		err := apperror.Invalid("the scheduler of %s is unknown, a commit is required", environment)
+		tracing.RecordError(span, err)
+		return "", err
+	}
+
+	diff, err := c.DiffJob(ctx, name)
+	var clientErr *client.Error
+	if errors.As(err, &clientErr) && clientErr.Code == apperror.CodeNotFound {
+		err = apperror.NotFound("could not find cronjob %s in %s", name, environment)
+	} else if err != nil {
+		err = apperror.Unavailable("failed to get cronjob %s from %s: %s", name, environment, err)
+	}
+	if err != nil {
+		tracing.RecordError(span, err)
+		return "", err
+	}
+	if diff.Live == nil {
+		err := apperror.NotFound("cronjob %s has not been applied in %s, a commit is required", name, environment)
+		tracing.RecordError(span, err)
+		return "", err
+	}
+	commit := diff.Live.Annotations[managed.CommitAnnotation]
+	if commit == "" {
+		err := apperror.Unavailable("%s doesn't know the commit cronjob %s was applied at", environment, name)
+		tracing.RecordError(span, err)
+		return "", err
+	}
+
+	return commit, nil
+}
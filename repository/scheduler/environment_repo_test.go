@@ -0,0 +1,83 @@
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/panagiotisptr/job-scheduler/apperror"
+	"github.com/panagiotisptr/job-scheduler/config"
+	"github.com/panagiotisptr/job-scheduler/managed"
+	"github.com/panagiotisptr/job-scheduler/types"
+	"go.opentelemetry.io/otel/trace"
+	batchv1 "k8s.io/api/batch/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestGetAppliedCommit(t *testing.T) {
+	// the scheduler of staging: backup was started at a commit, report
+	// before commits were recorded and archive was never started
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		diff := types.JobDiffResponse{}
+		switch r.URL.Path {
+		case "/api/v1/cluster/jobs/backup/diff":
+			diff.Live = &batchv1.CronJob{ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{managed.CommitAnnotation: "abc123"},
+			}}
+		case "/api/v1/cluster/jobs/report/diff":
+			diff.Live = &batchv1.CronJob{}
+		case "/api/v1/cluster/jobs/archive/diff":
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_ = json.NewEncoder(w).Encode(types.ErrorResponse{Code: "not_found", Message: "not found"})
+			return
+		}
+		_ = json.NewEncoder(w).Encode(diff)
+	}))
+	t.Cleanup(srv.Close)
+
+	cfg := &config.Config{Promotions: config.PromotionsConfig{
+		From: []config.PromotionSource{
+			{Name: "staging", URL: srv.URL},
+			{Name: "qa"},
+		},
+	}}
+	r, err := ProvideEnvironmentRepository(cfg, trace.NewNoopTracerProvider())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		environment string
+		job         string
+		want        string
+		wantCode    apperror.Code
+	}{
+		{name: "applied", environment: "staging", job: "backup", want: "abc123"},
+		{name: "without a commit", environment: "staging", job: "report", wantCode: apperror.CodeUnavailable},
+		{name: "not applied", environment: "staging", job: "archive", wantCode: apperror.CodeNotFound},
+		{name: "unknown cronjob", environment: "staging", job: "missing", wantCode: apperror.CodeNotFound},
+		{name: "scheduler without a URL", environment: "qa", job: "backup", wantCode: apperror.CodeInvalid},
+		{name: "unknown environment", environment: "dev", job: "backup", wantCode: apperror.CodeInvalid},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := r.GetAppliedCommit(context.Background(), tt.environment, tt.job)
+			if tt.wantCode != "" {
+				if apperror.CodeOf(err) != tt.wantCode {
+					t.Errorf("error = %v, want %s", err, tt.wantCode)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("commit = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package requestid
+
+import (
+	"context"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
+
+// fromMetadata reuses the request ID sent by the caller or generates
+// a new one and returns it in the response headers
+func fromMetadata(ctx context.Context) context.Context {
+	id := ""
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(strings.ToLower(Header)); len(values) > 0 {
+			id = values[0]
+		}
+	}
+	if !valid(id) {
+		id = New()
+	}
+	_ = grpc.SetHeader(ctx, metadata.Pairs(strings.ToLower(Header), id))
+
+	return WithRequestID(ctx, id)
+}
+
+func UnaryServerInterceptor(
+	ctx context.Context,
+	req interface{},
+	info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler,
+) (interface{}, error) {
+	return handler(fromMetadata(ctx), req)
+}
+
+func StreamServerInterceptor(
+	srv interface{},
+	ss grpc.ServerStream,
+	info *grpc.StreamServerInfo,
+	handler grpc.StreamHandler,
+) error {
+	return handler(srv, &serverStream{
+		ServerStream: ss,
+		ctx:          fromMetadata(ss.Context()),
+	})
+}
@@ -0,0 +1,140 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/panagiotisptr/job-scheduler/auth"
+	"github.com/panagiotisptr/job-scheduler/config"
+	"github.com/panagiotisptr/job-scheduler/requestid"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/reflection"
+)
+
+// GRPCServer the gRPC server, listening on its own port.
+// It is disabled when service.grpcPort is not set
+type GRPCServer struct {
+	logger          *zap.Logger
+	server          *grpc.Server
+	addr            string
+	shutdownTimeout time.Duration
+	mu              sync.Mutex
+	onShutdown      []func()
+}
+
+func ProvideGRPCServer(
+	lc fx.Lifecycle,
+	cfg *config.Config,
+	logger *zap.Logger,
+	a *auth.Auth,
+) (*GRPCServer, error) {
+	sc := cfg.Service
+	tlsConfig, err := newTLSConfig(sc.TLS)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(
+			requestid.UnaryServerInterceptor,
+			a.UnaryServerInterceptor(),
+		),
+		grpc.ChainStreamInterceptor(
+			requestid.StreamServerInterceptor,
+			a.StreamServerInterceptor(),
+		),
+	}
+	if tlsConfig != nil {
+		opts = append(opts, grpc.Creds(credentials.NewTLS(tlsConfig)))
+	}
+
+	s := &GRPCServer{
+		logger:          logger,
+		server:          grpc.NewServer(opts...),
+		shutdownTimeout: durationOrDefault(sc.ShutdownTimeout, defaultShutdownTimeout),
+	}
+	reflection.Register(s.server)
+	if sc.GRPCPort == 0 {
+		logger.Sugar().Info("gRPC server is disabled")
+		return s, nil
+	}
+	s.addr = fmt.Sprintf(":%d", sc.GRPCPort)
+
+	lc.Append(fx.Hook{
+		OnStart: s.start,
+		OnStop:  s.stop,
+	})
+
+	return s, nil
+}
+
+// RegisterService registers a service implementation
+func (s *GRPCServer) RegisterService(
+	desc *grpc.ServiceDesc,
+	impl interface{},
+) {
+	s.server.RegisterService(desc, impl)
+}
+
+// RegisterOnShutdown registers a function to call when the server
+// starts shutting down, used to end streams
+func (s *GRPCServer) RegisterOnShutdown(f func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.onShutdown = append(s.onShutdown, f)
+}
+
+func (s *GRPCServer) start(ctx context.Context) error {
+	ln, err := net.Listen("tcp", s.addr)
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		s.logger.Sugar().Info("serving gRPC on ", s.addr)
+		if err := s.Serve(ln); err != nil {
+			s.logger.Sugar().Error("grpc server failed: ", err)
+		}
+	}()
+
+	return nil
+}
+
+// Serve serves gRPC on the listener until the server stops
+func (s *GRPCServer) Serve(ln net.Listener) error {
+	return s.server.Serve(ln)
+}
+
+// stop waits for in-flight calls to complete and cancels the ones
+// still running after the shutdown timeout
+func (s *GRPCServer) stop(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, s.shutdownTimeout)
+	defer cancel()
+
+	s.logger.Sugar().Info("shutting down grpc server")
+	s.mu.Lock()
+	for _, f := range s.onShutdown {
+		f()
+	}
+	s.mu.Unlock()
+
+	stopped := make(chan struct{})
+	go func() {
+		s.server.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+		return nil
+	case <-ctx.Done():
+		s.server.Stop()
+		return fmt.Errorf("failed to shut down grpc server gracefully: %w", ctx.Err())
+	}
+}
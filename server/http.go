@@ -0,0 +1,162 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/panagiotisptr/job-scheduler/config"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultReadTimeout       = time.Second * 30
+	defaultReadHeaderTimeout = time.Second * 10
+	defaultWriteTimeout      = time.Second * 30
+	defaultIdleTimeout       = time.Minute * 2
+	defaultShutdownTimeout   = time.Second * 25
+)
+
+func durationOrDefault(d time.Duration, def time.Duration) time.Duration {
+	if d == 0 {
+		return def
+	}
+
+	return d
+}
+
+// HTTPServer the HTTP server serving the mux router
+type HTTPServer struct {
+	logger          *zap.Logger
+	server          *http.Server
+	shutdownTimeout time.Duration
+}
+
+func newTLSConfig(cfg config.TLSConfig) (*tls.Config, error) {
+	if cfg.CertFile == "" && cfg.KeyFile == "" {
+		return nil, nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load TLS certificate: %w", err)
+	}
+	tlsConfig := &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		Certificates: []tls.Certificate{cert},
+	}
+	if cfg.ClientCAFile == "" {
+		return tlsConfig, nil
+	}
+
+	b, err := os.ReadFile(cfg.ClientCAFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read client CA file: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(b) {
+		return nil, fmt.Errorf("no certificates found in client CA file: %s", cfg.ClientCAFile)
+	}
+	tlsConfig.ClientCAs = pool
+
+	switch cfg.ClientAuth {
+	case "", "require":
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	case "optional":
+		tlsConfig.ClientAuth = tls.VerifyClientCertIfGiven
+	default:
+		return nil, fmt.Errorf("unknown client auth mode: %s", cfg.ClientAuth)
+	}
+
+	return tlsConfig, nil
+}
+
+func ProvideHTTPServer(
+	lc fx.Lifecycle,
+	cfg *config.Config,
+	logger *zap.Logger,
+	r *mux.Router,
+) (*HTTPServer, error) {
+	sc := cfg.Service
+	tlsConfig, err := newTLSConfig(sc.TLS)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &HTTPServer{
+		logger: logger,
+		server: &http.Server{
+			Addr:              fmt.Sprintf(":%d", sc.Port),
+			Handler:           r,
+			TLSConfig:         tlsConfig,
+			ReadTimeout:       durationOrDefault(sc.ReadTimeout, defaultReadTimeout),
+			ReadHeaderTimeout: durationOrDefault(sc.ReadHeaderTimeout, defaultReadHeaderTimeout),
+			WriteTimeout:      durationOrDefault(sc.WriteTimeout, defaultWriteTimeout),
+			IdleTimeout:       durationOrDefault(sc.IdleTimeout, defaultIdleTimeout),
+			ErrorLog:          zap.NewStdLog(logger),
+		},
+		shutdownTimeout: durationOrDefault(sc.ShutdownTimeout, defaultShutdownTimeout),
+	}
+
+	lc.Append(fx.Hook{
+		OnStart: s.start,
+		OnStop:  s.stop,
+	})
+
+	return s, nil
+}
+
+// RegisterOnShutdown registers a function to call when the server
+// starts shutting down. Used to end long lived requests like event
+// streams which would otherwise keep the shutdown waiting
+func (s *HTTPServer) RegisterOnShutdown(f func()) {
+	s.server.RegisterOnShutdown(f)
+}
+
+// start binds the port before returning so that a port which is
+// already in use fails the startup instead of being logged
+func (s *HTTPServer) start(ctx context.Context) error {
+	ln, err := net.Listen("tcp", s.server.Addr)
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		var err error
+		if s.server.TLSConfig != nil {
+			s.logger.Sugar().Info("serving HTTPS on ", s.server.Addr)
+			// certificates are already loaded in the TLS config
+			err = s.server.ServeTLS(ln, "", "")
+		} else {
+			s.logger.Sugar().Info("serving HTTP on ", s.server.Addr)
+			err = s.server.Serve(ln)
+		}
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			s.logger.Sugar().Error("http server failed: ", err)
+		}
+	}()
+
+	return nil
+}
+
+// stop waits for in-flight requests to complete
+func (s *HTTPServer) stop(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, s.shutdownTimeout)
+	defer cancel()
+
+	s.logger.Sugar().Info("shutting down http server")
+	err := s.server.Shutdown(ctx)
+	if err != nil {
+		s.logger.Sugar().Error("failed to shut down http server gracefully: ", err)
+	}
+
+	return err
+}
@@ -4,34 +4,145 @@ import (
 	"context"
 
 	"github.com/panagiotisptr/job-scheduler/repository"
+	"github.com/panagiotisptr/job-scheduler/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 	batchv1 "k8s.io/api/batch/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 )
 
 type CronJobService struct {
 	repo   repository.CronJobRepository
 	logger *zap.Logger
+	tracer trace.Tracer
 }
 
 func ProvideCronJobService(
 	repo repository.CronJobRepository,
 	logger *zap.Logger,
+	tp trace.TracerProvider,
 ) (*CronJobService, error) {
 	return &CronJobService{
 		repo:   repo,
 		logger: logger,
+		tracer: tp.Tracer("github.com/panagiotisptr/job-scheduler/service"),
 	}, nil
 }
 
 func (s *CronJobService) ListAvailableCronJobs(
 	ctx context.Context,
 ) ([]string, error) {
-	return s.repo.GetCronJobNames(ctx)
+	ctx, span := s.tracer.Start(ctx, "CronJobService.ListAvailableCronJobs")
+	defer span.End()
+
+	names, err := s.repo.GetCronJobNames(ctx)
+	tracing.RecordError(span, err)
+
+	return names, err
 }
 
 func (s *CronJobService) GetCronJob(
 	ctx context.Context,
 	name string,
 ) (*batchv1.CronJob, error) {
-	return s.repo.GetCronJob(ctx, name)
+	ctx, span := s.tracer.Start(
+		ctx,
+		"CronJobService.GetCronJob",
+		trace.WithAttributes(attribute.String("job.name", name)),
+	)
+	defer span.End()
+
+	cj, err := s.repo.GetCronJob(ctx, name)
+	tracing.RecordError(span, err)
+
+	return cj, err
+}
+
+// GetCronJobAnnotations the annotations of the cronjob from the index,
+// cheap enough to be looked up for every event
+func (s *CronJobService) GetCronJobAnnotations(
+	ctx context.Context,
+	name string,
+) (map[string]string, error) {
+	return s.repo.GetCronJobAnnotations(ctx, name)
+}
+
+func (s *CronJobService) GetCronJobSource(
+	ctx context.Context,
+	name string,
+) (*repository.Source, error) {
+	return s.repo.GetCronJobSource(ctx, name)
+}
+
+func (s *CronJobService) GetLastCommit(
+	ctx context.Context,
+	source *repository.Source,
+) (*repository.Commit, error) {
+	return s.repo.GetLastCommit(ctx, source)
+}
+
+func (s *CronJobService) GetCompanions(
+	ctx context.Context,
+	name string,
+) ([]unstructured.Unstructured, error) {
+	ctx, span := s.tracer.Start(
+		ctx,
+		"CronJobService.GetCompanions",
+		trace.WithAttributes(attribute.String("job.name", name)),
+	)
+	defer span.End()
+
+	companions, err := s.repo.GetCompanions(ctx, name)
+	tracing.RecordError(span, err)
+
+	return companions, err
+}
+
+func (s *CronJobService) ListAvailableTasks(
+	ctx context.Context,
+) ([]string, error) {
+	ctx, span := s.tracer.Start(ctx, "CronJobService.ListAvailableTasks")
+	defer span.End()
+
+	names, err := s.repo.GetTaskNames(ctx)
+	tracing.RecordError(span, err)
+
+	return names, err
+}
+
+func (s *CronJobService) GetTask(
+	ctx context.Context,
+	name string,
+) (*batchv1.Job, error) {
+	ctx, span := s.tracer.Start(
+		ctx,
+		"CronJobService.GetTask",
+		trace.WithAttributes(attribute.String("task.name", name)),
+	)
+	defer span.End()
+
+	task, err := s.repo.GetTask(ctx, name)
+	tracing.RecordError(span, err)
+
+	return task, err
+}
+
+func (s *CronJobService) GetTaskSource(
+	ctx context.Context,
+	name string,
+) (*repository.Source, error) {
+	return s.repo.GetTaskSource(ctx, name)
+}
+
+func (s *CronJobService) GetSyncHistory(
+	ctx context.Context,
+) ([]repository.SyncRecord, error) {
+	ctx, span := s.tracer.Start(ctx, "CronJobService.GetSyncHistory")
+	defer span.End()
+
+	history, err := s.repo.GetSyncHistory(ctx)
+	tracing.RecordError(span, err)
+
+	return history, err
 }
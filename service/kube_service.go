@@ -2,43 +2,345 @@ package service
 
 import (
 	"context"
+	"time"
 
+	"github.com/panagiotisptr/job-scheduler/apperror"
+	"github.com/panagiotisptr/job-scheduler/companion"
+	"github.com/panagiotisptr/job-scheduler/config"
+	"github.com/panagiotisptr/job-scheduler/managed"
 	"github.com/panagiotisptr/job-scheduler/repository"
+	"github.com/panagiotisptr/job-scheduler/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 	batchv1 "k8s.io/api/batch/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 )
 
 type KubernetesService struct {
-	repo   repository.KubernetesRepository
-	logger *zap.Logger
+	repo       repository.KubernetesRepository
+	revisions  repository.RevisionRepository
+	companions config.CompanionsConfig
+	logger     *zap.Logger
+	tracer     trace.Tracer
 }
 
 func ProvideKubernetesService(
 	repo repository.KubernetesRepository,
+	revisions repository.RevisionRepository,
+	cfg *config.Config,
 	logger *zap.Logger,
+	tp trace.TracerProvider,
 ) (*KubernetesService, error) {
 	return &KubernetesService{
-		repo:   repo,
-		logger: logger,
+		repo:       repo,
+		revisions:  revisions,
+		companions: cfg.Companions,
+		logger:     logger,
+		tracer:     tp.Tracer("github.com/panagiotisptr/job-scheduler/service"),
 	}, nil
 }
 
 func (s *KubernetesService) ListRunningCronJobs(
 	ctx context.Context,
 ) ([]string, error) {
-	return s.repo.GetRunningCronJobs(ctx)
+	ctx, span := s.tracer.Start(ctx, "KubernetesService.ListRunningCronJobs")
+	defer span.End()
+
+	names, err := s.repo.GetRunningCronJobs(ctx)
+	tracing.RecordError(span, err)
+
+	return names, err
 }
 
+// StartCronJob starts the cronjob and applies its companions, see
+// admit for the ones that are not applied. What was applied is
+// recorded as a revision, commit is the SHA the manifest was read at
 func (s *KubernetesService) StartCronJob(
 	ctx context.Context,
 	cj *batchv1.CronJob,
+	companions []unstructured.Unstructured,
+	commit string,
+	actor string,
 ) error {
-	return s.repo.StartCronJob(ctx, cj)
+	ctx, span := s.tracer.Start(
+		ctx,
+		"KubernetesService.StartCronJob",
+		trace.WithAttributes(attribute.String("job.name", cj.Name)),
+	)
+	defer span.End()
+
+	companions = s.admit(cj, companions)
+	if err := s.apply(ctx, cj, commit, companions); err != nil {
+		tracing.RecordError(span, err)
+		return err
+	}
+	s.recordRevision(ctx, repository.Revision{
+		JobName:    cj.Name,
+		Commit:     commit,
+		Actor:      actor,
+		CronJob:    *cj,
+		Companions: companions,
+	})
+
+	return nil
+}
+
+// admit returns the companions of the cronjob to apply, the ones of
+// an allowed kind. The others, e.g. the Service of a rendered chart,
+// are skipped and logged
+func (s *KubernetesService) admit(
+	cj *batchv1.CronJob,
+	companions []unstructured.Unstructured,
+) []unstructured.Unstructured {
+	if len(s.companions.AllowedKinds) == 0 {
+		return nil
+	}
+	admitted := []unstructured.Unstructured{}
+	for i := range companions {
+		c := &companions[i]
+		if !companion.Allowed(s.companions.AllowedKinds, c.GroupVersionKind()) {
+			s.logger.Sugar().Infow(
+				"skipping companion of a kind that is not allowed",
+				"job", cj.Name,
+				"companion", companion.Ref(c),
+				"kind", c.GroupVersionKind().GroupKind().String(),
+			)
+			continue
+		}
+		admitted = append(admitted, *c)
+	}
+
+	return admitted
+}
+
+// RollbackCronJob applies the spec and companions of an earlier
+// revision of the cronjob again and records it as a new revision. The
+// revision is admitted by the current allowed kinds like a start, it
+// may predate a change of them
+func (s *KubernetesService) RollbackCronJob(
+	ctx context.Context,
+	name string,
+	number uint64,
+	actor string,
+) (*repository.Revision, error) {
+	ctx, span := s.tracer.Start(
+		ctx,
+		"KubernetesService.RollbackCronJob",
+		trace.WithAttributes(
+			attribute.String("job.name", name),
+			attribute.Int64("job.revision", int64(number)),
+		),
+	)
+	defer span.End()
+
+	target, err := s.revisions.GetRevision(ctx, name, number)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return nil, err
+	}
+	companions := s.admit(&target.CronJob, target.Companions)
+	if err := s.apply(ctx, &target.CronJob, target.Commit, companions); err != nil {
+		tracing.RecordError(span, err)
+		return nil, err
+	}
+
+	revision := s.recordRevision(ctx, repository.Revision{
+		JobName:    name,
+		Commit:     target.Commit,
+		Actor:      actor,
+		RollbackOf: target.Number,
+		CronJob:    target.CronJob,
+		Companions: companions,
+	})
+
+	return &revision, nil
+}
+
+// ListRevisions the revisions of the cronjob, newest first
+func (s *KubernetesService) ListRevisions(
+	ctx context.Context,
+	name string,
+) ([]repository.Revision, error) {
+	ctx, span := s.tracer.Start(
+		ctx,
+		"KubernetesService.ListRevisions",
+		trace.WithAttributes(attribute.String("job.name", name)),
+	)
+	defer span.End()
+
+	revisions, err := s.revisions.GetRevisions(ctx, name)
+	tracing.RecordError(span, err)
+
+	return revisions, err
+}
+
+// apply starts the cronjob annotated with the commit it was read at
+// and applies the companions that were already checked against the
+// allowed kinds
+func (s *KubernetesService) apply(
+	ctx context.Context,
+	cj *batchv1.CronJob,
+	commit string,
+	companions []unstructured.Unstructured,
+) error {
+	// marked so that the cronjobs created by the scheduler can be
+	// told apart and checked for changes
+	err := s.repo.StartCronJob(ctx, managed.Mark(cj, commit))
+	if err != nil {
+		return err
+	}
+	if len(companions) == 0 {
+		return nil
+	}
+
+	// applied after the cronjob so that they can be owned by it
+	return s.repo.ApplyCompanions(ctx, cj.Name, companions)
+}
+
+// recordRevision stores the revision. The cluster is updated already
+// so a failure is only logged
+func (s *KubernetesService) recordRevision(
+	ctx context.Context,
+	revision repository.Revision,
+) repository.Revision {
+	revision.SpecHash = managed.SpecHash(&revision.CronJob)
+	revision.AppliedAt = time.Now().UTC()
+	revision, err := s.revisions.AddRevision(ctx, revision)
+	if err != nil {
+		s.logger.Sugar().Errorw(
+			"failed to record revision",
+			"job", revision.JobName,
+			"hash", revision.SpecHash,
+			"error", err,
+		)
+	}
+
+	return revision
 }
 
 func (s *KubernetesService) StopCronJob(
 	ctx context.Context,
 	cj *batchv1.CronJob,
 ) error {
-	return s.repo.StopCronJob(ctx, cj)
+	ctx, span := s.tracer.Start(
+		ctx,
+		"KubernetesService.StopCronJob",
+		trace.WithAttributes(attribute.String("job.name", cj.Name)),
+	)
+	defer span.End()
+
+	err := s.repo.StopCronJob(ctx, cj)
+	tracing.RecordError(span, err)
+
+	return err
+}
+
+func (s *KubernetesService) DeleteCronJob(
+	ctx context.Context,
+	name string,
+) error {
+	ctx, span := s.tracer.Start(
+		ctx,
+		"KubernetesService.DeleteCronJob",
+		trace.WithAttributes(attribute.String("job.name", name)),
+	)
+	defer span.End()
+
+	err := s.repo.DeleteCronJob(ctx, name)
+	tracing.RecordError(span, err)
+
+	return err
+}
+
+func (s *KubernetesService) GetCronJob(
+	ctx context.Context,
+	name string,
+) (*batchv1.CronJob, error) {
+	ctx, span := s.tracer.Start(
+		ctx,
+		"KubernetesService.GetCronJob",
+		trace.WithAttributes(attribute.String("job.name", name)),
+	)
+	defer span.End()
+
+	cj, err := s.repo.GetCronJob(ctx, name)
+	if apperror.CodeOf(err) != apperror.CodeNotFound {
+		tracing.RecordError(span, err)
+	}
+
+	return cj, err
+}
+
+func (s *KubernetesService) RunCronJob(
+	ctx context.Context,
+	cj *batchv1.CronJob,
+) (*batchv1.Job, error) {
+	ctx, span := s.tracer.Start(
+		ctx,
+		"KubernetesService.RunCronJob",
+		trace.WithAttributes(attribute.String("job.name", cj.Name)),
+	)
+	defer span.End()
+
+	job, err := s.repo.RunCronJob(ctx, cj)
+	tracing.RecordError(span, err)
+
+	return job, err
+}
+
+func (s *KubernetesService) GetJobLogs(
+	ctx context.Context,
+	cronJobName string,
+	opts repository.LogOptions,
+) (*repository.JobLogs, error) {
+	ctx, span := s.tracer.Start(
+		ctx,
+		"KubernetesService.GetJobLogs",
+		trace.WithAttributes(attribute.String("job.name", cronJobName)),
+	)
+	defer span.End()
+
+	logs, err := s.repo.GetJobLogs(ctx, cronJobName, opts)
+	tracing.RecordError(span, err)
+
+	return logs, err
+}
+
+func (s *KubernetesService) RunTask(
+	ctx context.Context,
+	task *batchv1.Job,
+) (*batchv1.Job, error) {
+	ctx, span := s.tracer.Start(
+		ctx,
+		"KubernetesService.RunTask",
+		trace.WithAttributes(attribute.String("task.name", task.Name)),
+	)
+	defer span.End()
+
+	job, err := s.repo.RunTask(ctx, task)
+	tracing.RecordError(span, err)
+
+	return job, err
+}
+
+func (s *KubernetesService) GetTaskRuns(
+	ctx context.Context,
+	taskName string,
+) ([]repository.TaskRun, error) {
+	ctx, span := s.tracer.Start(
+		ctx,
+		"KubernetesService.GetTaskRuns",
+		trace.WithAttributes(attribute.String("task.name", taskName)),
+	)
+	defer span.End()
+
+	runs, err := s.repo.GetTaskRuns(ctx, taskName)
+	tracing.RecordError(span, err)
+
+	return runs, err
+}
+
+func (s *KubernetesService) GetNamespace() string {
+	return s.repo.GetNamespace()
 }
@@ -0,0 +1,183 @@
+package service
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/panagiotisptr/job-scheduler/apperror"
+	"github.com/panagiotisptr/job-scheduler/config"
+	"github.com/panagiotisptr/job-scheduler/managed"
+	"github.com/panagiotisptr/job-scheduler/repository"
+	"github.com/panagiotisptr/job-scheduler/repository/memory"
+	"github.com/panagiotisptr/job-scheduler/repository/revision"
+	memoryStore "github.com/panagiotisptr/job-scheduler/store/memory"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	batchv1 "k8s.io/api/batch/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// companionsRepository records the companions applied with each
+// cronjob
+type companionsRepository struct {
+	repository.KubernetesRepository
+	applied map[string][]string
+}
+
+func (r *companionsRepository) ApplyCompanions(
+	ctx context.Context,
+	cronJobName string,
+	companions []unstructured.Unstructured,
+) error {
+	refs := []string{}
+	for i := range companions {
+		refs = append(refs, companions[i].GetKind()+"/"+companions[i].GetName())
+	}
+	r.applied[cronJobName] = refs
+
+	return r.KubernetesRepository.ApplyCompanions(ctx, cronJobName, companions)
+}
+
+func newTestKubernetesService(
+	t *testing.T,
+	allowedKinds ...string,
+) (*KubernetesService, *companionsRepository) {
+	logger := zap.NewNop()
+	repo := &companionsRepository{
+		KubernetesRepository: memory.ProvideKubernetesMemoryRepository(logger),
+		applied:              map[string][]string{},
+	}
+	cfg := &config.Config{Companions: config.CompanionsConfig{AllowedKinds: allowedKinds}}
+	s, err := ProvideKubernetesService(
+		repo,
+		revision.ProvideRevisionRepository(memoryStore.NewStore()),
+		cfg,
+		logger,
+		trace.NewNoopTracerProvider(),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return s, repo
+}
+
+func testCronJob(name string) *batchv1.CronJob {
+	return &batchv1.CronJob{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec:       batchv1.CronJobSpec{Schedule: "0 3 * * *"},
+	}
+}
+
+func testCompanion(apiVersion string, kind string, name string) unstructured.Unstructured {
+	obj := unstructured.Unstructured{}
+	obj.SetAPIVersion(apiVersion)
+	obj.SetKind(kind)
+	obj.SetName(name)
+
+	return obj
+}
+
+func TestStartCronJobCompanions(t *testing.T) {
+	// e.g. the documents of a rendered chart
+	companions := []unstructured.Unstructured{
+		testCompanion("v1", "ConfigMap", "settings"),
+		testCompanion("v1", "Service", "backup"),
+		testCompanion("apps/v1", "Deployment", "backup"),
+		testCompanion("v1", "ServiceAccount", "backup"),
+	}
+
+	tests := []struct {
+		name         string
+		allowedKinds []string
+		want         []string
+	}{
+		{
+			name:         "allowed kinds",
+			allowedKinds: []string{"ConfigMap", "ServiceAccount"},
+			want:         []string{"ConfigMap/settings", "ServiceAccount/backup"},
+		},
+		{
+			name:         "no allowed kinds",
+			allowedKinds: nil,
+		},
+		{
+			name:         "none of the allowed kinds",
+			allowedKinds: []string{"PersistentVolumeClaim"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, repo := newTestKubernetesService(t, tt.allowedKinds...)
+			ctx := context.Background()
+			err := s.StartCronJob(ctx, testCronJob("backup"), companions, "abc123", "alice")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := s.GetCronJob(ctx, "backup"); err != nil {
+				t.Errorf("the cronjob was not started: %s", err)
+			}
+
+			if got := repo.applied["backup"]; !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("applied %v, want %v", got, tt.want)
+			}
+
+			revisions, err := s.ListRevisions(ctx, "backup")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(revisions) != 1 || len(revisions[0].Companions) != len(tt.want) {
+				t.Errorf("revisions = %+v, want one with the %d applied companions", revisions, len(tt.want))
+			}
+		})
+	}
+}
+
+func TestRollbackCronJobCompanions(t *testing.T) {
+	s, repo := newTestKubernetesService(t, "ConfigMap", "ServiceAccount")
+	ctx := context.Background()
+	companions := []unstructured.Unstructured{
+		testCompanion("v1", "ConfigMap", "settings"),
+		testCompanion("v1", "ServiceAccount", "backup"),
+	}
+	if err := s.StartCronJob(ctx, testCronJob("backup"), companions, "abc123", "alice"); err != nil {
+		t.Fatal(err)
+	}
+	updated := testCronJob("backup")
+	updated.Spec.Schedule = "0 4 * * *"
+	if err := s.StartCronJob(ctx, updated, nil, "def456", "alice"); err != nil {
+		t.Fatal(err)
+	}
+
+	// ServiceAccount was allowed when the revision was applied
+	s.companions.AllowedKinds = []string{"ConfigMap"}
+	delete(repo.applied, "backup")
+	revision, err := s.RollbackCronJob(ctx, "backup", 1, "bob")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := repo.applied["backup"], []string{"ConfigMap/settings"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("applied %v, want %v", got, want)
+	}
+	if revision.Number != 3 || revision.RollbackOf != 1 || revision.Commit != "abc123" ||
+		revision.Actor != "bob" || len(revision.Companions) != 1 {
+		t.Errorf("revision = %+v, want 3 rolling back to 1 with the applied companion", revision)
+	}
+
+	live, err := s.GetCronJob(ctx, "backup")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if live.Spec.Schedule != "0 3 * * *" {
+		t.Errorf("schedule = %s, want the one of revision 1", live.Spec.Schedule)
+	}
+	if commit := live.Annotations[managed.CommitAnnotation]; commit != "abc123" {
+		t.Errorf("commit annotation = %q, want the one of revision 1", commit)
+	}
+
+	if _, err := s.RollbackCronJob(ctx, "backup", 9, "bob"); apperror.CodeOf(err) != apperror.CodeNotFound {
+		t.Errorf("rollback to a missing revision: error = %v, want not found", err)
+	}
+}
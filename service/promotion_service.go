@@ -0,0 +1,182 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/panagiotisptr/job-scheduler/apperror"
+	"github.com/panagiotisptr/job-scheduler/config"
+	"github.com/panagiotisptr/job-scheduler/repository"
+	"github.com/panagiotisptr/job-scheduler/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+// PromotionService pins cronjobs to the commit they run at in the
+// environments they are promoted from
+type PromotionService struct {
+	repo         repository.CronJobRepository
+	environments repository.EnvironmentRepository
+	environment  string
+	// from the environments promotions are accepted from
+	from   map[string]struct{}
+	logger *zap.Logger
+	tracer trace.Tracer
+}
+
+func ProvidePromotionService(
+	cfg *config.Config,
+	repo repository.CronJobRepository,
+	environments repository.EnvironmentRepository,
+	logger *zap.Logger,
+	tp trace.TracerProvider,
+) (*PromotionService, error) {
+	s := &PromotionService{
+		repo:         repo,
+		environments: environments,
+		environment:  cfg.Promotions.Environment,
+		from:         make(map[string]struct{}),
+		logger:       logger,
+		tracer:       tp.Tracer("github.com/panagiotisptr/job-scheduler/service"),
+	}
+	for _, source := range cfg.Promotions.From {
+		s.from[source.Name] = struct{}{}
+	}
+
+	return s, nil
+}
+
+// Promote pins the cronjob to the commit, or when it is empty to the
+// one the cronjob was last applied at in the cluster of the
+// environment it is promoted from
+func (s *PromotionService) Promote(
+	ctx context.Context,
+	jobName string,
+	from string,
+	commit string,
+	actor string,
+) (*repository.Promotion, error) {
+	ctx, span := s.tracer.Start(
+		ctx,
+		"PromotionService.Promote",
+		trace.WithAttributes(
+			attribute.String("job.name", jobName),
+			attribute.String("promotion.from", from),
+		),
+	)
+	defer span.End()
+
+	if _, ok := s.from[from]; !ok {
+		err := apperror.Invalid("cronjobs can't be promoted from %s", from)
+		tracing.RecordError(span, err)
+		return nil, err
+	}
+	if commit == "" {
+		applied, err := s.environments.GetAppliedCommit(ctx, from, jobName)
+		if err != nil {
+			tracing.RecordError(span, err)
+			return nil, err
+		}
+		commit = applied
+	}
+
+	previous, err := s.repo.GetCronJobSource(ctx, jobName)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return nil, err
+	}
+	sha, err := s.repo.PinCronJob(ctx, jobName, commit)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return nil, err
+	}
+
+	promotion, err := s.repo.RecordPromotion(ctx, repository.Promotion{
+		JobName:        jobName,
+		From:           from,
+		To:             s.environment,
+		Commit:         sha,
+		PreviousCommit: previous.Commit,
+		PreviousPinned: previous.Pinned,
+		Actor:          actor,
+		CreatedAt:      time.Now().UTC(),
+	})
+	if err != nil {
+		// the cronjob is pinned already, only the history misses it
+		s.logger.Sugar().Errorw(
+			"failed to record promotion",
+			"job", jobName,
+			"commit", sha,
+			"error", err,
+		)
+	}
+
+	return &promotion, nil
+}
+
+// Revert undoes a promotion that could not be applied: the cronjob is
+// pinned to its previous commit again, or follows its ref if it wasn't
+// pinned, and the promotion is removed from the history
+func (s *PromotionService) Revert(
+	ctx context.Context,
+	promotion *repository.Promotion,
+) error {
+	ctx, span := s.tracer.Start(
+		ctx,
+		"PromotionService.Revert",
+		trace.WithAttributes(
+			attribute.String("job.name", promotion.JobName),
+			attribute.String("vcs.commit", promotion.PreviousCommit),
+		),
+	)
+	defer span.End()
+
+	var err error
+	if promotion.PreviousPinned {
+		_, err = s.repo.PinCronJob(ctx, promotion.JobName, promotion.PreviousCommit)
+	} else {
+		err = s.repo.UnpinCronJob(ctx, promotion.JobName)
+	}
+	if err != nil {
+		tracing.RecordError(span, err)
+		return err
+	}
+	// the promotion has no ID if it failed to be recorded
+	if promotion.ID != 0 {
+		err = s.repo.DeletePromotion(ctx, promotion.ID)
+	}
+	tracing.RecordError(span, err)
+
+	return err
+}
+
+// Release makes the cronjob follow its ref again
+func (s *PromotionService) Release(
+	ctx context.Context,
+	jobName string,
+) error {
+	ctx, span := s.tracer.Start(
+		ctx,
+		"PromotionService.Release",
+		trace.WithAttributes(attribute.String("job.name", jobName)),
+	)
+	defer span.End()
+
+	err := s.repo.UnpinCronJob(ctx, jobName)
+	tracing.RecordError(span, err)
+
+	return err
+}
+
+func (s *PromotionService) ListPromotions(
+	ctx context.Context,
+) ([]repository.Promotion, error) {
+	ctx, span := s.tracer.Start(ctx, "PromotionService.ListPromotions")
+	defer span.End()
+
+	promotions, err := s.repo.GetPromotions(ctx)
+	tracing.RecordError(span, err)
+
+	return promotions, err
+}
@@ -0,0 +1,232 @@
+package service
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/panagiotisptr/job-scheduler/apperror"
+	"github.com/panagiotisptr/job-scheduler/config"
+	"github.com/panagiotisptr/job-scheduler/repository"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+// pinRepository keeps the pins and promotions of the cronjob backup,
+// pinned to pinned if it is set
+type pinRepository struct {
+	repository.CronJobRepository
+	pinned     string
+	promotions []repository.Promotion
+	deleted    []uint64
+}
+
+func (r *pinRepository) GetCronJobSource(ctx context.Context, name string) (*repository.Source, error) {
+	if name != "backup" {
+		return nil, apperror.NotFound("could not find cronjob with name: %s", name)
+	}
+	source := &repository.Source{Ref: "main", Commit: "head"}
+	if r.pinned != "" {
+		source.Ref, source.Commit, source.Pinned = r.pinned, r.pinned, true
+	}
+
+	return source, nil
+}
+
+func (r *pinRepository) PinCronJob(ctx context.Context, name string, commit string) (string, error) {
+	if _, err := r.GetCronJobSource(ctx, name); err != nil {
+		return "", err
+	}
+	r.pinned = commit
+
+	return commit, nil
+}
+
+func (r *pinRepository) UnpinCronJob(ctx context.Context, name string) error {
+	if r.pinned == "" {
+		return apperror.NotFound("the cronjob %s is not pinned", name)
+	}
+	r.pinned = ""
+
+	return nil
+}
+
+func (r *pinRepository) RecordPromotion(ctx context.Context, promotion repository.Promotion) (repository.Promotion, error) {
+	promotion.ID = uint64(len(r.promotions) + 1)
+	r.promotions = append(r.promotions, promotion)
+
+	return promotion, nil
+}
+
+func (r *pinRepository) DeletePromotion(ctx context.Context, id uint64) error {
+	r.deleted = append(r.deleted, id)
+
+	return nil
+}
+
+// appliedCommits the commits cronjobs were applied at in staging
+type appliedCommits map[string]string
+
+func (e appliedCommits) GetAppliedCommit(ctx context.Context, environment string, name string) (string, error) {
+	commit, ok := e[name]
+	if !ok {
+		return "", apperror.NotFound("cronjob %s has not been applied in %s, a commit is required", name, environment)
+	}
+
+	return commit, nil
+}
+
+func newTestPromotionService(t *testing.T, repo *pinRepository) *PromotionService {
+	cfg := &config.Config{Promotions: config.PromotionsConfig{
+		Environment: "production",
+		From:        []config.PromotionSource{{Name: "staging"}},
+	}}
+	s, err := ProvidePromotionService(
+		cfg,
+		repo,
+		appliedCommits{"backup": "applied"},
+		zap.NewNop(),
+		trace.NewNoopTracerProvider(),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return s
+}
+
+func TestPromote(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name     string
+		pinned   string
+		job      string
+		from     string
+		commit   string
+		want     repository.Promotion
+		wantCode apperror.Code
+	}{
+		{
+			name: "applied commit",
+			job:  "backup",
+			from: "staging",
+			want: repository.Promotion{
+				ID:             1,
+				JobName:        "backup",
+				From:           "staging",
+				To:             "production",
+				Commit:         "applied",
+				PreviousCommit: "head",
+				Actor:          "alice",
+			},
+		},
+		{
+			name:   "given commit of a pinned cronjob",
+			pinned: "old",
+			job:    "backup",
+			from:   "staging",
+			commit: "given",
+			want: repository.Promotion{
+				ID:             1,
+				JobName:        "backup",
+				From:           "staging",
+				To:             "production",
+				Commit:         "given",
+				PreviousCommit: "old",
+				PreviousPinned: true,
+				Actor:          "alice",
+			},
+		},
+		{name: "unknown environment", job: "backup", from: "qa", wantCode: apperror.CodeInvalid},
+		{name: "not applied in the environment", job: "report", from: "staging", wantCode: apperror.CodeNotFound},
+		{name: "unknown cronjob", job: "report", from: "staging", commit: "given", wantCode: apperror.CodeNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &pinRepository{pinned: tt.pinned}
+			s := newTestPromotionService(t, repo)
+
+			promotion, err := s.Promote(ctx, tt.job, tt.from, tt.commit, "alice")
+			if tt.wantCode != "" {
+				if apperror.CodeOf(err) != tt.wantCode {
+					t.Fatalf("error = %v, want %s", err, tt.wantCode)
+				}
+				if repo.pinned != tt.pinned || len(repo.promotions) != 0 {
+					t.Errorf("pinned %q with promotions %v, want nothing changed", repo.pinned, repo.promotions)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if promotion.CreatedAt.IsZero() {
+				t.Error("the promotion has no time")
+			}
+			promotion.CreatedAt = tt.want.CreatedAt
+			if !reflect.DeepEqual(*promotion, tt.want) {
+				t.Errorf("promotion = %+v, want %+v", *promotion, tt.want)
+			}
+			if repo.pinned != tt.want.Commit {
+				t.Errorf("pinned to %q, want %q", repo.pinned, tt.want.Commit)
+			}
+		})
+	}
+}
+
+func TestRevert(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name        string
+		promotion   repository.Promotion
+		wantPinned  string
+		wantDeleted []uint64
+	}{
+		{
+			name:        "pinned before",
+			promotion:   repository.Promotion{ID: 2, JobName: "backup", Commit: "new", PreviousCommit: "old", PreviousPinned: true},
+			wantPinned:  "old",
+			wantDeleted: []uint64{2},
+		},
+		{
+			name:        "following its ref before",
+			promotion:   repository.Promotion{ID: 2, JobName: "backup", Commit: "new", PreviousCommit: "head"},
+			wantDeleted: []uint64{2},
+		},
+		{
+			name:      "not recorded",
+			promotion: repository.Promotion{JobName: "backup", Commit: "new", PreviousCommit: "head"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &pinRepository{pinned: "new"}
+			s := newTestPromotionService(t, repo)
+
+			if err := s.Revert(ctx, &tt.promotion); err != nil {
+				t.Fatal(err)
+			}
+			if repo.pinned != tt.wantPinned {
+				t.Errorf("pinned to %q, want %q", repo.pinned, tt.wantPinned)
+			}
+			if !reflect.DeepEqual(repo.deleted, tt.wantDeleted) {
+				t.Errorf("deleted promotions %v, want %v", repo.deleted, tt.wantDeleted)
+			}
+		})
+	}
+}
+
+func TestRelease(t *testing.T) {
+	ctx := context.Background()
+	repo := &pinRepository{pinned: "new"}
+	s := newTestPromotionService(t, repo)
+
+	if err := s.Release(ctx, "backup"); err != nil {
+		t.Fatal(err)
+	}
+	if repo.pinned != "" {
+		t.Errorf("pinned to %q, want the ref followed again", repo.pinned)
+	}
+	if err := s.Release(ctx, "backup"); apperror.CodeOf(err) != apperror.CodeNotFound {
+		t.Errorf("releasing again: error = %v, want not found", err)
+	}
+}
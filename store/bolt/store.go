@@ -0,0 +1,164 @@
+package bolt
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/panagiotisptr/job-scheduler/store"
+	bolt "go.etcd.io/bbolt"
+)
+
+// how long to wait for the lock on the database file, held by
+// another process e.g. the previous pod during a rollout
+const openTimeout = time.Second * 10
+
+// Store keeps the state in a bbolt database file
+type Store struct {
+	db *bolt.DB
+}
+
+func NewStore(path string) (*Store, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, fmt.Errorf("failed to create store directory: %w", err)
+	}
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: openTimeout})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open store: %w", err)
+	}
+
+	return &Store{db: db}, nil
+}
+
+func (s *Store) Get(
+	ctx context.Context,
+	bucket string,
+	key string,
+) ([]byte, error) {
+	var value []byte
+	err := s.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(bucket))
+		if b == nil {
+			return store.ErrNotFound
+		}
+		v := b.Get([]byte(key))
+		if v == nil {
+			return store.ErrNotFound
+		}
+		// values are only valid during the transaction
+		value = append([]byte{}, v...)
+
+		return nil
+	})
+
+	return value, err
+}
+
+func (s *Store) Put(
+	ctx context.Context,
+	bucket string,
+	key string,
+	value []byte,
+) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b, err := tx.CreateBucketIfNotExists([]byte(bucket))
+		if err != nil {
+			return err
+		}
+
+		return b.Put([]byte(key), value)
+	})
+}
+
+func (s *Store) Delete(
+	ctx context.Context,
+	bucket string,
+	key string,
+) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(bucket))
+		if b == nil {
+			return nil
+		}
+
+		return b.Delete([]byte(key))
+	})
+}
+
+func (s *Store) List(
+	ctx context.Context,
+	bucket string,
+) ([]store.KeyValue, error) {
+	res := []store.KeyValue{}
+	err := s.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(bucket))
+		if b == nil {
+			return nil
+		}
+
+		return b.ForEach(func(k, v []byte) error {
+			res = append(res, store.KeyValue{
+				Key:   string(k),
+				Value: append([]byte{}, v...),
+			})
+			return nil
+		})
+	})
+
+	return res, err
+}
+
+func (s *Store) Replace(
+	ctx context.Context,
+	bucket string,
+	entries []store.KeyValue,
+) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		var seq uint64
+		if b := tx.Bucket([]byte(bucket)); b != nil {
+			seq = b.Sequence()
+			if err := tx.DeleteBucket([]byte(bucket)); err != nil {
+				return err
+			}
+		}
+		b, err := tx.CreateBucket([]byte(bucket))
+		if err != nil {
+			return err
+		}
+		// keep handing out increasing sequence numbers
+		if err = b.SetSequence(seq); err != nil {
+			return err
+		}
+		for _, e := range entries {
+			if err = b.Put([]byte(e.Key), e.Value); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func (s *Store) NextSequence(
+	ctx context.Context,
+	bucket string,
+) (uint64, error) {
+	var seq uint64
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		b, err := tx.CreateBucketIfNotExists([]byte(bucket))
+		if err != nil {
+			return err
+		}
+		seq, err = b.NextSequence()
+
+		return err
+	})
+
+	return seq, err
+}
+
+func (s *Store) Close() error {
+	return s.db.Close()
+}
@@ -0,0 +1,54 @@
+package bolt
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/panagiotisptr/job-scheduler/store/storetest"
+)
+
+func TestStore(t *testing.T) {
+	s, err := NewStore(filepath.Join(t.TempDir(), "state.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	storetest.TestStore(t, s)
+}
+
+// TestReopen the state survives closing the database, as it does a
+// restart of the service
+func TestReopen(t *testing.T) {
+	ctx := context.Background()
+	// the directory is created
+	path := filepath.Join(t.TempDir(), "nested", "state.db")
+	s, err := NewStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Put(ctx, "index", "backup", []byte("entry")); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		if _, err := s.NextSequence(ctx, "syncs"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err = NewStore(path)
+	if err != nil {
+		t.Fatalf("reopening the store: %s", err)
+	}
+	defer s.Close()
+	if v, err := s.Get(ctx, "index", "backup"); err != nil || string(v) != "entry" {
+		t.Errorf("Get = %q, %v, want the entry put before closing", v, err)
+	}
+	if seq, err := s.NextSequence(ctx, "syncs"); err != nil || seq != 4 {
+		t.Errorf("NextSequence = %d, %v, want 4", seq, err)
+	}
+}
@@ -0,0 +1,126 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/panagiotisptr/job-scheduler/store"
+)
+
+// Store keeps everything in memory. State is lost on restart
+type Store struct {
+	mu        sync.RWMutex
+	buckets   map[string]map[string][]byte
+	sequences map[string]uint64
+}
+
+func NewStore() *Store {
+	return &Store{
+		buckets:   make(map[string]map[string][]byte),
+		sequences: make(map[string]uint64),
+	}
+}
+
+func copyBytes(b []byte) []byte {
+	return append([]byte{}, b...)
+}
+
+func (s *Store) Get(
+	ctx context.Context,
+	bucket string,
+	key string,
+) ([]byte, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	v, ok := s.buckets[bucket][key]
+	if !ok {
+		return nil, store.ErrNotFound
+	}
+
+	return copyBytes(v), nil
+}
+
+func (s *Store) Put(
+	ctx context.Context,
+	bucket string,
+	key string,
+	value []byte,
+) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b, ok := s.buckets[bucket]
+	if !ok {
+		b = make(map[string][]byte)
+		s.buckets[bucket] = b
+	}
+	b[key] = copyBytes(value)
+
+	return nil
+}
+
+func (s *Store) Delete(
+	ctx context.Context,
+	bucket string,
+	key string,
+) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.buckets[bucket], key)
+
+	return nil
+}
+
+func (s *Store) List(
+	ctx context.Context,
+	bucket string,
+) ([]store.KeyValue, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	res := []store.KeyValue{}
+	for k, v := range s.buckets[bucket] {
+		res = append(res, store.KeyValue{Key: k, Value: copyBytes(v)})
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Key < res[j].Key
+	})
+
+	return res, nil
+}
+
+func (s *Store) Replace(
+	ctx context.Context,
+	bucket string,
+	entries []store.KeyValue,
+) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b := make(map[string][]byte)
+	for _, e := range entries {
+		b[e.Key] = copyBytes(e.Value)
+	}
+	s.buckets[bucket] = b
+
+	return nil
+}
+
+func (s *Store) NextSequence(
+	ctx context.Context,
+	bucket string,
+) (uint64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sequences[bucket]++
+
+	return s.sequences[bucket], nil
+}
+
+func (s *Store) Close() error {
+	return nil
+}
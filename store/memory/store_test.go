@@ -0,0 +1,11 @@
+package memory
+
+import (
+	"testing"
+
+	"github.com/panagiotisptr/job-scheduler/store/storetest"
+)
+
+func TestStore(t *testing.T) {
+	storetest.TestStore(t, NewStore())
+}
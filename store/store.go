@@ -0,0 +1,71 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// ErrNotFound returned when a key does not exist
+var ErrNotFound = errors.New("key not found")
+
+// KeyValue an entry of a bucket
+type KeyValue struct {
+	Key   string
+	Value []byte
+}
+
+// Store a durable key value store. Keys are grouped in buckets which
+// are created on first write
+type Store interface {
+	// Get returns ErrNotFound if the key does not exist
+	Get(ctx context.Context, bucket string, key string) ([]byte, error)
+	Put(ctx context.Context, bucket string, key string, value []byte) error
+	// Delete is a no-op if the key does not exist
+	Delete(ctx context.Context, bucket string, key string) error
+	// List returns the entries of the bucket ordered by key
+	List(ctx context.Context, bucket string) ([]KeyValue, error)
+	// Replace atomically replaces every entry of the bucket
+	Replace(ctx context.Context, bucket string, entries []KeyValue) error
+	// NextSequence returns a unique, increasing number for the bucket
+	NextSequence(ctx context.Context, bucket string) (uint64, error)
+	Close() error
+}
+
+// GetJSON decodes the value of the key into v
+func GetJSON(
+	ctx context.Context,
+	s Store,
+	bucket string,
+	key string,
+	v interface{},
+) error {
+	b, err := s.Get(ctx, bucket, key)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(b, v)
+}
+
+// PutJSON stores v encoded as JSON
+func PutJSON(
+	ctx context.Context,
+	s Store,
+	bucket string,
+	key string,
+	v interface{},
+) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	return s.Put(ctx, bucket, key, b)
+}
+
+// SequenceKey formats a sequence number so that keys sort in order
+func SequenceKey(seq uint64) string {
+	return fmt.Sprintf("%020d", seq)
+}
@@ -0,0 +1,164 @@
+// Package storetest checks that a store.Store implementation behaves
+// as the rest of the service expects
+package storetest
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/panagiotisptr/job-scheduler/store"
+)
+
+// TestStore runs the checks against an empty store
+func TestStore(t *testing.T, s store.Store) {
+	ctx := context.Background()
+
+	t.Run("get", func(t *testing.T) {
+		if _, err := s.Get(ctx, "get", "missing"); !errors.Is(err, store.ErrNotFound) {
+			t.Errorf("Get of a missing bucket = %v, want ErrNotFound", err)
+		}
+		mustPut(t, s, "get", "key", "value")
+		if _, err := s.Get(ctx, "get", "missing"); !errors.Is(err, store.ErrNotFound) {
+			t.Errorf("Get of a missing key = %v, want ErrNotFound", err)
+		}
+		v, err := s.Get(ctx, "get", "key")
+		if err != nil || string(v) != "value" {
+			t.Fatalf("Get = %q, %v, want value", v, err)
+		}
+		// values are copies
+		v[0] = 'V'
+		if v, _ := s.Get(ctx, "get", "key"); string(v) != "value" {
+			t.Errorf("changing a returned value changed the stored one to %q", v)
+		}
+	})
+
+	t.Run("put overwrites", func(t *testing.T) {
+		mustPut(t, s, "put", "key", "old")
+		mustPut(t, s, "put", "key", "new")
+		if v, _ := s.Get(ctx, "put", "key"); string(v) != "new" {
+			t.Errorf("Get = %q, want new", v)
+		}
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		if err := s.Delete(ctx, "delete", "missing"); err != nil {
+			t.Errorf("Delete of a missing bucket: %s", err)
+		}
+		mustPut(t, s, "delete", "key", "value")
+		if err := s.Delete(ctx, "delete", "missing"); err != nil {
+			t.Errorf("Delete of a missing key: %s", err)
+		}
+		if err := s.Delete(ctx, "delete", "key"); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := s.Get(ctx, "delete", "key"); !errors.Is(err, store.ErrNotFound) {
+			t.Errorf("Get of a deleted key = %v, want ErrNotFound", err)
+		}
+	})
+
+	t.Run("list", func(t *testing.T) {
+		entries, err := s.List(ctx, "list")
+		if err != nil || entries == nil || len(entries) != 0 {
+			t.Errorf("List of a missing bucket = %v, %v, want an empty list", entries, err)
+		}
+		for _, k := range []string{"b", "c", "a"} {
+			mustPut(t, s, "list", k, k+"-value")
+		}
+		mustPut(t, s, "list-other", "d", "d-value")
+		if got := list(t, s, "list"); !reflect.DeepEqual(got, []string{"a=a-value", "b=b-value", "c=c-value"}) {
+			t.Errorf("List = %v, want the entries ordered by key", got)
+		}
+	})
+
+	t.Run("replace", func(t *testing.T) {
+		mustPut(t, s, "replace", "old", "value")
+		seq, err := s.NextSequence(ctx, "replace")
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = s.Replace(ctx, "replace", []store.KeyValue{
+			{Key: "b", Value: []byte("2")},
+			{Key: "a", Value: []byte("1")},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := list(t, s, "replace"); !reflect.DeepEqual(got, []string{"a=1", "b=2"}) {
+			t.Errorf("List = %v, want only the replaced entries", got)
+		}
+		if next, _ := s.NextSequence(ctx, "replace"); next <= seq {
+			t.Errorf("NextSequence = %d after %d, sequences have to keep increasing after Replace", next, seq)
+		}
+		if err := s.Replace(ctx, "replace", nil); err != nil {
+			t.Fatal(err)
+		}
+		if got := list(t, s, "replace"); len(got) != 0 {
+			t.Errorf("List = %v, want no entries", got)
+		}
+	})
+
+	t.Run("sequences", func(t *testing.T) {
+		// every bucket is numbered separately
+		for _, next := range []struct {
+			bucket string
+			want   uint64
+		}{
+			{"seq-a", 1},
+			{"seq-a", 2},
+			{"seq-b", 1},
+			{"seq-a", 3},
+		} {
+			seq, err := s.NextSequence(ctx, next.bucket)
+			if err != nil || seq != next.want {
+				t.Errorf("NextSequence(%s) = %d, %v, want %d", next.bucket, seq, err, next.want)
+			}
+		}
+		if got := store.SequenceKey(2); got >= store.SequenceKey(10) {
+			t.Errorf("SequenceKey(2) = %s sorts after SequenceKey(10)", got)
+		}
+	})
+
+	t.Run("json", func(t *testing.T) {
+		type value struct {
+			Name  string `json:"name"`
+			Count int    `json:"count"`
+		}
+		if err := store.PutJSON(ctx, s, "json", "key", value{Name: "backup", Count: 2}); err != nil {
+			t.Fatal(err)
+		}
+		var got value
+		if err := store.GetJSON(ctx, s, "json", "key", &got); err != nil {
+			t.Fatal(err)
+		}
+		if got != (value{Name: "backup", Count: 2}) {
+			t.Errorf("GetJSON = %+v", got)
+		}
+		if err := store.GetJSON(ctx, s, "json", "missing", &got); !errors.Is(err, store.ErrNotFound) {
+			t.Errorf("GetJSON of a missing key = %v, want ErrNotFound", err)
+		}
+	})
+}
+
+func mustPut(t *testing.T, s store.Store, bucket string, key string, value string) {
+	t.Helper()
+	if err := s.Put(context.Background(), bucket, key, []byte(value)); err != nil {
+		t.Fatalf("Put(%s, %s): %s", bucket, key, err)
+	}
+}
+
+// list the entries of the bucket as key=value
+func list(t *testing.T, s store.Store, bucket string) []string {
+	t.Helper()
+	entries, err := s.List(context.Background(), bucket)
+	if err != nil {
+		t.Fatalf("List(%s): %s", bucket, err)
+	}
+	res := []string{}
+	for _, e := range entries {
+		res = append(res, e.Key+"="+string(e.Value))
+	}
+
+	return res
+}
@@ -0,0 +1,133 @@
+package tracing
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/panagiotisptr/job-scheduler/requestid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.12.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/panagiotisptr/job-scheduler/tracing"
+
+type statusRecorder struct {
+	http.ResponseWriter
+	code int
+}
+
+func (r *statusRecorder) WriteHeader(code int) {
+	r.code = code
+	r.ResponseWriter.WriteHeader(code)
+}
+
+// Unwrap allows http.ResponseController to reach the underlying writer
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+func (r *statusRecorder) Flush() {
+	if f, ok := r.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Middleware continues the trace found in the incoming request headers
+// (W3C trace context) and wraps the request in a server span
+func (t *Tracing) Middleware(next http.Handler) http.Handler {
+	tracer := t.provider.Tracer(instrumentationName)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := t.propagator.Extract(
+			r.Context(),
+			propagation.HeaderCarrier(r.Header),
+		)
+
+		route := r.URL.Path
+		if cr := mux.CurrentRoute(r); cr != nil {
+			if tpl, err := cr.GetPathTemplate(); err == nil {
+				route = tpl
+			}
+		}
+		ctx, span := tracer.Start(
+			ctx,
+			fmt.Sprintf("%s %s", r.Method, route),
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPMethodKey.String(r.Method),
+				semconv.HTTPRouteKey.String(route),
+				semconv.HTTPTargetKey.String(r.URL.RequestURI()),
+				attribute.String("http.request_id", requestid.FromContext(r.Context())),
+			),
+		)
+		defer span.End()
+
+		rec := &statusRecorder{
+			ResponseWriter: w,
+			code:           http.StatusOK,
+		}
+		next.ServeHTTP(rec, r.WithContext(ctx))
+
+		span.SetAttributes(semconv.HTTPStatusCodeKey.Int(rec.code))
+		if rec.code >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(rec.code))
+		}
+	})
+}
+
+// spanView a flattened span as returned by the spans endpoint
+type spanView struct {
+	Name         string            `json:"name"`
+	TraceID      string            `json:"traceId"`
+	SpanID       string            `json:"spanId"`
+	ParentSpanID string            `json:"parentSpanId,omitempty"`
+	Kind         string            `json:"kind"`
+	StartTime    time.Time         `json:"startTime"`
+	EndTime      time.Time         `json:"endTime"`
+	Status       string            `json:"status"`
+	Description  string            `json:"description,omitempty"`
+	Attributes   map[string]string `json:"attributes,omitempty"`
+}
+
+func attributesMap(kvs []attribute.KeyValue) map[string]string {
+	res := make(map[string]string, len(kvs))
+	for _, kv := range kvs {
+		res[string(kv.Key)] = kv.Value.Emit()
+	}
+
+	return res
+}
+
+func marshalSpans(spans tracetest.SpanStubs) ([]byte, error) {
+	views := make([]spanView, 0, len(spans))
+	for _, s := range spans {
+		v := spanView{
+			Name:        s.Name,
+			TraceID:     s.SpanContext.TraceID().String(),
+			SpanID:      s.SpanContext.SpanID().String(),
+			Kind:        s.SpanKind.String(),
+			StartTime:   s.StartTime,
+			EndTime:     s.EndTime,
+			Status:      s.Status.Code.String(),
+			Description: s.Status.Description,
+			Attributes:  attributesMap(s.Attributes),
+		}
+		if s.Parent.HasSpanID() {
+			v.ParentSpanID = s.Parent.SpanID().String()
+		}
+		views = append(views, v)
+	}
+
+	return json.Marshal(struct {
+		Spans []spanView `json:"spans"`
+	}{
+		Spans: views,
+	})
+}
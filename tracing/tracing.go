@@ -0,0 +1,178 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/panagiotisptr/job-scheduler/config"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.12.0"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+)
+
+const (
+	exporterOTLP   = "otlp"
+	exporterStdout = "stdout"
+	exporterMemory = "memory"
+
+	defaultServiceName = "job-scheduler"
+)
+
+// Tracing owns the tracer provider used by every layer of the service
+type Tracing struct {
+	logger     *zap.Logger
+	provider   trace.TracerProvider
+	propagator propagation.TextMapPropagator
+	// memory is only set when using the in-memory exporter
+	memory *tracetest.InMemoryExporter
+}
+
+func newExporter(
+	ctx context.Context,
+	cfg config.TracingConfig,
+) (sdktrace.SpanExporter, *tracetest.InMemoryExporter, error) {
+	switch cfg.Exporter {
+	case exporterOTLP:
+		opts := []otlptracehttp.Option{}
+		if cfg.Endpoint != "" {
+			opts = append(opts, otlptracehttp.WithEndpoint(cfg.Endpoint))
+		}
+		if cfg.Insecure {
+			opts = append(opts, otlptracehttp.WithInsecure())
+		}
+		if len(cfg.Headers) > 0 {
+			opts = append(opts, otlptracehttp.WithHeaders(cfg.Headers))
+		}
+		exporter, err := otlptracehttp.New(ctx, opts...)
+
+		return exporter, nil, err
+	case exporterStdout:
+		exporter, err := stdouttrace.New(
+			stdouttrace.WithWriter(os.Stdout),
+		)
+
+		return exporter, nil, err
+	case exporterMemory:
+		exporter := tracetest.NewInMemoryExporter()
+
+		return exporter, exporter, nil
+	}
+
+	return nil, nil, fmt.Errorf("unknown tracing exporter: %s", cfg.Exporter)
+}
+
+func ProvideTracing(
+	lc fx.Lifecycle,
+	cfg *config.Config,
+	logger *zap.Logger,
+) (*Tracing, error) {
+	propagator := propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	)
+	otel.SetTextMapPropagator(propagator)
+
+	t := &Tracing{
+		logger:     logger,
+		provider:   trace.NewNoopTracerProvider(),
+		propagator: propagator,
+	}
+	if cfg.Tracing.Exporter == "" {
+		return t, nil
+	}
+
+	exporter, memory, err := newExporter(context.Background(), cfg.Tracing)
+	if err != nil {
+		return nil, err
+	}
+
+	serviceName := cfg.Tracing.ServiceName
+	if serviceName == "" {
+		serviceName = defaultServiceName
+	}
+	ratio := cfg.Tracing.SampleRatio
+	if ratio <= 0 {
+		ratio = 1
+	}
+
+	opts := []sdktrace.TracerProviderOption{
+		sdktrace.WithResource(resource.NewWithAttributes(
+			semconv.SchemaURL,
+			semconv.ServiceNameKey.String(serviceName),
+		)),
+		sdktrace.WithSampler(sdktrace.ParentBased(
+			sdktrace.TraceIDRatioBased(ratio),
+		)),
+	}
+	// export synchronously so that spans show up in memory straight away
+	if memory != nil {
+		opts = append(opts, sdktrace.WithSyncer(exporter))
+	} else {
+		opts = append(opts, sdktrace.WithBatcher(exporter))
+	}
+	provider := sdktrace.NewTracerProvider(opts...)
+	otel.SetTracerProvider(provider)
+
+	t.provider = provider
+	t.memory = memory
+	lc.Append(fx.Hook{
+		OnStop: func(ctx context.Context) error {
+			return provider.Shutdown(ctx)
+		},
+	})
+	logger.Sugar().Info("tracing enabled with exporter: ", cfg.Tracing.Exporter)
+
+	return t, nil
+}
+
+func ProvideTracerProvider(
+	t *Tracing,
+) trace.TracerProvider {
+	return t.provider
+}
+
+// RecordError marks the span as failed. It does nothing if err is nil
+func RecordError(span trace.Span, err error) {
+	if err == nil {
+		return
+	}
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
+
+// SpansHandler serves the spans recorded by the in-memory exporter.
+// Returns false if a different exporter is used
+func (t *Tracing) SpansHandler() (http.Handler, bool) {
+	if t.memory == nil {
+		return nil, false
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method == http.MethodDelete {
+			t.memory.Reset()
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		b, err := marshalSpans(t.memory.GetSpans())
+		if err != nil {
+			t.logger.Sugar().Error("failed to marshal spans: ", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		if _, err = w.Write(b); err != nil {
+			t.logger.Sugar().Error("failed to write to response: ", err)
+		}
+	}), true
+}
@@ -0,0 +1,162 @@
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/panagiotisptr/job-scheduler/config"
+	"go.uber.org/fx/fxtest"
+	"go.uber.org/zap"
+)
+
+const (
+	parentTraceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	parentSpanID  = "00f067aa0ba902b7"
+)
+
+func newTracing(t *testing.T, exporter string) *Tracing {
+	t.Helper()
+	lc := fxtest.NewLifecycle(t)
+	cfg := &config.Config{
+		Tracing: config.TracingConfig{Exporter: exporter},
+	}
+	tr, err := ProvideTracing(lc, cfg, zap.NewNop())
+	if err != nil {
+		t.Fatalf("ProvideTracing: %s", err)
+	}
+	lc.RequireStart()
+	t.Cleanup(lc.RequireStop)
+
+	return tr
+}
+
+// getSpans reads the spans served by the spans handler
+func getSpans(t *testing.T, spans http.Handler) []spanView {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	spans.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/traces", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	if got := rec.Header().Get("Content-Type"); got != "application/json" {
+		t.Errorf("Content-Type = %q, want application/json", got)
+	}
+	var body struct {
+		Spans []spanView `json:"spans"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("failed to decode the spans: %s", err)
+	}
+
+	return body.Spans
+}
+
+func TestUnknownExporter(t *testing.T) {
+	cfg := &config.Config{
+		Tracing: config.TracingConfig{Exporter: "zipkin"},
+	}
+	if _, err := ProvideTracing(fxtest.NewLifecycle(t), cfg, zap.NewNop()); err == nil {
+		t.Fatal("expected an error for an unknown exporter")
+	}
+}
+
+func TestSpansHandlerNeedsTheMemoryExporter(t *testing.T) {
+	for _, exporter := range []string{"", "stdout"} {
+		if _, ok := newTracing(t, exporter).SpansHandler(); ok {
+			t.Errorf("exporter %q serves spans", exporter)
+		}
+	}
+}
+
+func TestMemoryExporter(t *testing.T) {
+	tr := newTracing(t, exporterMemory)
+	spans, ok := tr.SpansHandler()
+	if !ok {
+		t.Fatal("the memory exporter doesn't serve spans")
+	}
+
+	tracer := ProvideTracerProvider(tr).Tracer("test")
+	r := mux.NewRouter()
+	r.Use(tr.Middleware)
+	r.HandleFunc("/jobs/{jobName}", func(w http.ResponseWriter, r *http.Request) {
+		_, span := tracer.Start(r.Context(), "App.GetJob")
+		RecordError(span, errors.New("boom"))
+		span.End()
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/jobs/backup?verbose=true", nil)
+	req.Header.Set("traceparent", "00-"+parentTraceID+"-"+parentSpanID+"-01")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	got := getSpans(t, spans)
+	if len(got) != 2 {
+		t.Fatalf("got %d spans, want 2: %+v", len(got), got)
+	}
+	// spans are exported as they end, the inner one first
+	child, server := got[0], got[1]
+
+	if server.Name != "GET /jobs/{jobName}" {
+		t.Errorf("server span name = %q", server.Name)
+	}
+	if server.Kind != "server" {
+		t.Errorf("server span kind = %q", server.Kind)
+	}
+	if server.TraceID != parentTraceID || server.ParentSpanID != parentSpanID {
+		t.Errorf(
+			"server span continues %s/%s, want %s/%s",
+			server.TraceID,
+			server.ParentSpanID,
+			parentTraceID,
+			parentSpanID,
+		)
+	}
+	if server.Status != "Error" {
+		t.Errorf("server span status = %q, want Error", server.Status)
+	}
+	for key, want := range map[string]string{
+		"http.method":      "GET",
+		"http.route":       "/jobs/{jobName}",
+		"http.target":      "/jobs/backup?verbose=true",
+		"http.status_code": "500",
+	} {
+		if server.Attributes[key] != want {
+			t.Errorf("server span %s = %q, want %q", key, server.Attributes[key], want)
+		}
+	}
+
+	if child.TraceID != parentTraceID || child.ParentSpanID != server.SpanID {
+		t.Errorf("child span is not a child of the server span: %+v", child)
+	}
+	if child.Status != "Error" || child.Description != "boom" {
+		t.Errorf("child span status = %q %q, want Error boom", child.Status, child.Description)
+	}
+
+	rec := httptest.NewRecorder()
+	spans.ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, "/debug/traces", nil))
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("DELETE status = %d, want %d", rec.Code, http.StatusNoContent)
+	}
+	if got := getSpans(t, spans); len(got) != 0 {
+		t.Errorf("got %d spans after clearing them, want 0", len(got))
+	}
+}
+
+func TestRecordErrorIgnoresNil(t *testing.T) {
+	tr := newTracing(t, exporterMemory)
+	spans, _ := tr.SpansHandler()
+
+	_, span := ProvideTracerProvider(tr).Tracer("test").Start(context.Background(), "ok")
+	RecordError(span, nil)
+	span.End()
+
+	got := getSpans(t, spans)
+	if len(got) != 1 || got[0].Status != "Unset" {
+		t.Errorf("spans = %+v, want one span with status Unset", got)
+	}
+}
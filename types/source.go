@@ -0,0 +1,89 @@
+package types
+
+import (
+	"strings"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+)
+
+// The annotations GET /static/jobs/{jobName} adds to the cronjob with
+// the source of its manifest. The last commit ones are left out if
+// GitHub can't be reached
+const (
+	// SourceRepositoryAnnotation the repository as owner/name
+	SourceRepositoryAnnotation = "job-scheduler/source-repository"
+	SourcePathAnnotation       = "job-scheduler/source-path"
+	SourceBranchAnnotation     = "job-scheduler/source-branch"
+	SourceRefAnnotation        = "job-scheduler/source-ref"
+	SourceCommitAnnotation     = "job-scheduler/source-commit"
+	// SourcePinnedAnnotation set to "true" if the commit was promoted
+	SourcePinnedAnnotation = "job-scheduler/source-pinned"
+
+	LastCommitSHAAnnotation     = "job-scheduler/last-commit-sha"
+	LastCommitAuthorAnnotation  = "job-scheduler/last-commit-author"
+	LastCommitMessageAnnotation = "job-scheduler/last-commit-message"
+	// LastCommitDateAnnotation the date in RFC 3339
+	LastCommitDateAnnotation = "job-scheduler/last-commit-date"
+)
+
+// WithSource returns a copy of the cronjob annotated with the source
+func WithSource(cj *batchv1.CronJob, source *Source) *batchv1.CronJob {
+	annotated := cj.DeepCopy()
+	if annotated.Annotations == nil {
+		annotated.Annotations = map[string]string{}
+	}
+	set := func(key string, value string) {
+		if value != "" {
+			annotated.Annotations[key] = value
+		}
+	}
+	set(SourceRepositoryAnnotation, source.Owner+"/"+source.Name)
+	set(SourcePathAnnotation, source.Path)
+	set(SourceBranchAnnotation, source.Branch)
+	set(SourceRefAnnotation, source.Ref)
+	set(SourceCommitAnnotation, source.Commit)
+	if source.Pinned {
+		set(SourcePinnedAnnotation, "true")
+	}
+	if c := source.LastCommit; c != nil {
+		set(LastCommitSHAAnnotation, c.SHA)
+		set(LastCommitAuthorAnnotation, c.Author)
+		set(LastCommitMessageAnnotation, c.Message)
+		if !c.Date.IsZero() {
+			set(LastCommitDateAnnotation, c.Date.Format(time.RFC3339))
+		}
+	}
+
+	return annotated
+}
+
+// SourceOf reads the source from the annotations of a cronjob returned
+// by GET /static/jobs/{jobName}, nil if it has none
+func SourceOf(cj *batchv1.CronJob) *Source {
+	a := cj.Annotations
+	repo, ok := a[SourceRepositoryAnnotation]
+	if !ok {
+		return nil
+	}
+	owner, name, _ := strings.Cut(repo, "/")
+	source := &Source{
+		Owner:  owner,
+		Name:   name,
+		Path:   a[SourcePathAnnotation],
+		Branch: a[SourceBranchAnnotation],
+		Ref:    a[SourceRefAnnotation],
+		Commit: a[SourceCommitAnnotation],
+		Pinned: a[SourcePinnedAnnotation] == "true",
+	}
+	if sha, ok := a[LastCommitSHAAnnotation]; ok {
+		source.LastCommit = &Commit{
+			SHA:     sha,
+			Author:  a[LastCommitAuthorAnnotation],
+			Message: a[LastCommitMessageAnnotation],
+		}
+		source.LastCommit.Date, _ = time.Parse(time.RFC3339, a[LastCommitDateAnnotation])
+	}
+
+	return source
+}
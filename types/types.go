@@ -0,0 +1,116 @@
+// Package types holds the request and response bodies of the v1 API.
+// Keep openapi.json in sync when changing them
+package types
+
+import (
+	_ "embed"
+
+	"github.com/panagiotisptr/job-scheduler/apperror"
+	"github.com/panagiotisptr/job-scheduler/audit"
+	"github.com/panagiotisptr/job-scheduler/authz"
+	"github.com/panagiotisptr/job-scheduler/events"
+	"github.com/panagiotisptr/job-scheduler/notifier"
+	"github.com/panagiotisptr/job-scheduler/repository"
+	batchv1 "k8s.io/api/batch/v1"
+)
+
+// OpenAPI the OpenAPI 3 document describing the v1 API
+//
+//go:embed openapi.json
+var OpenAPI []byte
+
+// ErrorResponse the body of every failed request
+type ErrorResponse = apperror.Body
+
+// Event the data of the server-sent events of the event stream
+type Event = events.Event
+
+type JobNamesResponse struct {
+	JobNames []string `json:"jobNames"`
+}
+
+// Source the location and revision of a manifest
+type Source = repository.Source
+
+// Commit the last commit that changed a manifest
+type Commit = repository.Commit
+
+type SuccessResponse struct {
+	Success bool `json:"success"`
+}
+
+type RunJobResponse struct {
+	// JobName the name of the job created from the cronjob or task
+	JobName string `json:"jobName"`
+}
+
+// JobDiffResponse the labels, annotations and spec of a cronjob in
+// GitHub and in the cluster. Live is null when it is not deployed
+type JobDiffResponse struct {
+	JobName string           `json:"jobName"`
+	Desired *batchv1.CronJob `json:"desired"`
+	Live    *batchv1.CronJob `json:"live"`
+}
+
+type TaskNamesResponse struct {
+	TaskNames []string `json:"taskNames"`
+}
+
+// TaskRun a job created from a task
+type TaskRun = repository.TaskRun
+
+type TaskRunsResponse struct {
+	Runs []TaskRun `json:"runs"`
+}
+
+type SyncsResponse struct {
+	Syncs []repository.SyncRecord `json:"syncs"`
+}
+
+// PromotionRequest pins a cronjob to the commit it runs at in the
+// environment From, or to Commit if it is set
+type PromotionRequest struct {
+	JobName string `json:"jobName"`
+	From    string `json:"from"`
+	Commit  string `json:"commit,omitempty"`
+}
+
+// Promotion a cronjob pinned to a commit of another environment
+type Promotion = repository.Promotion
+
+type PromotionsResponse struct {
+	Promotions []Promotion `json:"promotions"`
+}
+
+// Revision a spec of a cronjob applied to the cluster
+type Revision = repository.Revision
+
+type RevisionsResponse struct {
+	Revisions []Revision `json:"revisions"`
+}
+
+type DeliveriesResponse struct {
+	Deliveries []notifier.Delivery `json:"deliveries"`
+}
+
+type AuditRecordsResponse struct {
+	Records []audit.Record `json:"records"`
+}
+
+// AuthorizationCheckRequest Subject and Groups default to the
+// caller, Namespace and Location to the ones of the job
+type AuthorizationCheckRequest struct {
+	Subject   string   `json:"subject"`
+	Groups    []string `json:"groups"`
+	Operation string   `json:"operation"`
+	Job       string   `json:"job"`
+	Namespace string   `json:"namespace"`
+	Location  string   `json:"location"`
+}
+
+type AuthorizationCheckResponse struct {
+	Subject  string         `json:"subject"`
+	Groups   []string       `json:"groups"`
+	Request  authz.Request  `json:"request"`
+	Decision authz.Decision `json:"decision"`
+}
@@ -0,0 +1,313 @@
+// Package validation checks cronjob and task manifests before they
+// reach the cluster
+package validation
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/panagiotisptr/job-scheduler/companion"
+	"github.com/panagiotisptr/job-scheduler/config"
+	"github.com/panagiotisptr/job-scheduler/parser"
+	"github.com/robfig/cron/v3"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+const (
+	// maxNameLength the name of a cronjob is limited to 52 characters
+	// since its jobs are named <cronjob>-<11 character suffix>
+	maxNameLength = 52
+	// maxTaskNameLength the runs of a task are named <task>-<5
+	// character suffix> and have to fit in a 63 character label
+	maxTaskNameLength = 57
+)
+
+// addFunc records a diagnostic
+type addFunc func(severity parser.Severity, format string, args ...interface{})
+
+// Validator checks cronjobs and tasks against the rules of Kubernetes that
+// can be checked without a cluster and the configured policy
+type Validator struct {
+	policy     config.PolicyConfig
+	companions config.CompanionsConfig
+}
+
+func ProvideValidator(
+	cfg *config.Config,
+) *Validator {
+	return NewValidator(cfg.Policy, cfg.Companions)
+}
+
+func NewValidator(
+	policy config.PolicyConfig,
+	companions config.CompanionsConfig,
+) *Validator {
+	return &Validator{
+		policy:     policy,
+		companions: companions,
+	}
+}
+
+// Validate returns the problems of a single cronjob
+func (v *Validator) Validate(cj *batchv1.CronJob) []parser.Diagnostic {
+	diagnostics := []parser.Diagnostic{}
+	add := collect(&diagnostics, cj.Name)
+
+	checkMeta(add, "cronjob", cj.ObjectMeta, maxNameLength)
+	if cj.Spec.Schedule == "" {
+		add(parser.SeverityError, "the schedule is empty")
+	} else if _, err := cron.ParseStandard(cj.Spec.Schedule); err != nil {
+		add(parser.SeverityError, "invalid schedule %q: %s", cj.Spec.Schedule, err)
+	}
+	if tz := cj.Spec.TimeZone; tz != nil {
+		if _, err := time.LoadLocation(*tz); err != nil {
+			add(parser.SeverityError, "invalid time zone %q: %s", *tz, err)
+		}
+	}
+
+	v.checkPodSpec(add, cj.Spec.JobTemplate.Spec.Template.Spec)
+	v.checkLabels(add, cj.Labels)
+
+	return diagnostics
+}
+
+// ValidateJob returns the problems of a single task
+func (v *Validator) ValidateJob(task *batchv1.Job) []parser.Diagnostic {
+	diagnostics := []parser.Diagnostic{}
+	add := collect(&diagnostics, task.Name)
+
+	checkMeta(add, "task", task.ObjectMeta, maxTaskNameLength)
+	if task.Spec.Selector != nil {
+		add(parser.SeverityError, "the selector of a task is generated for every run and cannot be set")
+	}
+	v.checkPodSpec(add, task.Spec.Template.Spec)
+	v.checkLabels(add, task.Labels)
+
+	return diagnostics
+}
+
+func collect(diagnostics *[]parser.Diagnostic, job string) addFunc {
+	return func(severity parser.Severity, format string, args ...interface{}) {
+		*diagnostics = append(*diagnostics, parser.Diagnostic{
+			Job:      job,
+			Severity: severity,
+			Message:  fmt.Sprintf(format, args...),
+		})
+	}
+}
+
+// checkMeta checks the name and namespace of a manifest
+func checkMeta(
+	add addFunc,
+	kind string,
+	meta metav1.ObjectMeta,
+	maxLength int,
+) {
+	if meta.Name == "" {
+		add(parser.SeverityError, "the %s has no name", kind)
+	} else {
+		for _, msg := range validation.IsDNS1123Subdomain(meta.Name) {
+			add(parser.SeverityError, "invalid name: %s", msg)
+		}
+		if len(meta.Name) > maxLength {
+			add(parser.SeverityError, "the name is longer than %d characters", maxLength)
+		}
+	}
+	if meta.Namespace != "" {
+		add(
+			parser.SeverityWarning,
+			"the namespace is set to %s, it has to match the namespace of the scheduler",
+			meta.Namespace,
+		)
+	}
+}
+
+// checkPodSpec checks the pod template against the rules of
+// Kubernetes and the policy
+func (v *Validator) checkPodSpec(
+	add addFunc,
+	podSpec corev1.PodSpec,
+) {
+	if p := podSpec.RestartPolicy; p != corev1.RestartPolicyOnFailure && p != corev1.RestartPolicyNever {
+		add(parser.SeverityError, "the restartPolicy of the pod has to be OnFailure or Never")
+	}
+	if len(podSpec.Containers) == 0 {
+		add(parser.SeverityError, "the pod has no containers")
+	}
+	containers := append([]corev1.Container{}, podSpec.InitContainers...)
+	containers = append(containers, podSpec.Containers...)
+	for _, c := range containers {
+		if c.Image == "" {
+			add(parser.SeverityError, "container %s has no image", c.Name)
+			continue
+		}
+		if len(v.policy.AllowedRegistries) > 0 && !hasAnyPrefix(c.Image, v.policy.AllowedRegistries) {
+			add(parser.SeverityError, "the image of container %s is not from an allowed registry: %s", c.Name, c.Image)
+		}
+		if v.policy.ForbidLatestTag && usesLatestTag(c.Image) {
+			add(parser.SeverityError, "container %s has to use a pinned image, not %s", c.Name, c.Image)
+		}
+		if v.policy.RequireResourceLimits {
+			_, cpu := c.Resources.Limits[corev1.ResourceCPU]
+			_, memory := c.Resources.Limits[corev1.ResourceMemory]
+			if !cpu || !memory {
+				add(parser.SeverityError, "container %s has to set cpu and memory limits", c.Name)
+			}
+		}
+	}
+}
+
+func (v *Validator) checkLabels(
+	add addFunc,
+	labels map[string]string,
+) {
+	for _, l := range v.policy.RequiredLabels {
+		if _, ok := labels[l]; !ok {
+			add(parser.SeverityError, "missing required label %s", l)
+		}
+	}
+}
+
+func hasAnyPrefix(s string, prefixes []string) bool {
+	for _, p := range prefixes {
+		if strings.HasPrefix(s, p) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// usesLatestTag whether the image has no tag or digest, or the
+// latest tag
+func usesLatestTag(image string) bool {
+	if strings.Contains(image, "@") {
+		return false
+	}
+	name := image[strings.LastIndex(image, "/")+1:]
+	i := strings.LastIndex(name, ":")
+	if i < 0 {
+		return true
+	}
+
+	return name[i+1:] == "latest"
+}
+
+// ValidateCompanions returns the problems of the companions of a
+// file. Companions are not checked when none are allowed since they
+// are ignored
+func (v *Validator) ValidateCompanions(res parser.Result) []parser.Diagnostic {
+	diagnostics := []parser.Diagnostic{}
+	add := collect(&diagnostics, "")
+	if len(v.companions.AllowedKinds) == 0 {
+		return diagnostics
+	}
+
+	if len(res.Companions) > 0 && len(res.CronJobs) == 0 {
+		add(parser.SeverityWarning, "the file has no cronjob, its other resources are never applied")
+	}
+	for i := range res.Companions {
+		c := &res.Companions[i]
+		if c.GetName() == "" {
+			add(parser.SeverityError, "a %s has no name", c.GetKind())
+			continue
+		}
+		if !companion.Allowed(v.companions.AllowedKinds, c.GroupVersionKind()) {
+			add(parser.SeverityWarning, "%s: the kind is not in companions.allowedKinds, it is not applied", companion.Ref(c))
+		}
+		if c.GetNamespace() != "" {
+			add(
+				parser.SeverityWarning,
+				"%s: the namespace is set to %s, it has to match the namespace of the scheduler",
+				companion.Ref(c),
+				c.GetNamespace(),
+			)
+		}
+	}
+
+	return diagnostics
+}
+
+// File a parsed manifest file
+type File struct {
+	Path   string
+	Result parser.Result
+}
+
+// Diagnostic a problem found in a file
+type Diagnostic struct {
+	Path string `json:"path"`
+	parser.Diagnostic
+}
+
+func (d Diagnostic) String() string {
+	return d.Path + ": " + d.Diagnostic.String()
+}
+
+// ValidateFiles returns the problems found while parsing the files,
+// the ones of every cronjob, task and companion and the cronjobs and
+// tasks defined more than once
+func (v *Validator) ValidateFiles(files []File) []Diagnostic {
+	diagnostics := []Diagnostic{}
+	cronJobsIn := map[string]string{}
+	tasksIn := map[string]string{}
+	for _, f := range files {
+		add := func(ds []parser.Diagnostic) {
+			for _, d := range ds {
+				diagnostics = append(diagnostics, Diagnostic{Path: f.Path, Diagnostic: d})
+			}
+		}
+		add(f.Result.Diagnostics)
+		add(v.ValidateCompanions(f.Result))
+		for i := range f.Result.CronJobs {
+			cj := &f.Result.CronJobs[i]
+			add(v.Validate(cj))
+			add(checkDuplicate(cronJobsIn, "cronjob", cj.Name, f.Path))
+		}
+		for i := range f.Result.Jobs {
+			task := &f.Result.Jobs[i]
+			add(v.ValidateJob(task))
+			add(checkDuplicate(tasksIn, "task", task.Name, f.Path))
+		}
+	}
+
+	return diagnostics
+}
+
+// checkDuplicate records where a manifest is defined and reports it
+// if it was already defined in another file
+func checkDuplicate(
+	definedIn map[string]string,
+	kind string,
+	name string,
+	path string,
+) []parser.Diagnostic {
+	if name == "" {
+		return nil
+	}
+	if other, ok := definedIn[name]; ok {
+		return []parser.Diagnostic{{
+			Job:      name,
+			Severity: parser.SeverityError,
+			Message:  fmt.Sprintf("the %s is also defined in %s", kind, other),
+		}}
+	}
+	definedIn[name] = path
+
+	return nil
+}
+
+// HasErrors whether any of the diagnostics is an error
+func HasErrors(diagnostics []Diagnostic) bool {
+	for _, d := range diagnostics {
+		if d.Severity == parser.SeverityError {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,437 @@
+package validation
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/panagiotisptr/job-scheduler/config"
+	"github.com/panagiotisptr/job-scheduler/parser"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// testCronJob a cronjob that passes testPolicy
+func testCronJob() *batchv1.CronJob {
+	return &batchv1.CronJob{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   "backup",
+			Labels: map[string]string{"team": "a"},
+		},
+		Spec: batchv1.CronJobSpec{
+			Schedule: "0 3 * * *",
+			JobTemplate: batchv1.JobTemplateSpec{
+				Spec: testJobSpec(),
+			},
+		},
+	}
+}
+
+func testJobSpec() batchv1.JobSpec {
+	return batchv1.JobSpec{
+		Template: corev1.PodTemplateSpec{
+			Spec: corev1.PodSpec{
+				RestartPolicy: corev1.RestartPolicyNever,
+				Containers: []corev1.Container{{
+					Name:  "backup",
+					Image: "ghcr.io/acme/backup:1.0.0",
+					Resources: corev1.ResourceRequirements{
+						Limits: corev1.ResourceList{
+							corev1.ResourceCPU:    resource.MustParse("100m"),
+							corev1.ResourceMemory: resource.MustParse("64Mi"),
+						},
+					},
+				}},
+			},
+		},
+	}
+}
+
+var testPolicy = config.PolicyConfig{
+	AllowedRegistries:     []string{"ghcr.io/acme/"},
+	ForbidLatestTag:       true,
+	RequireResourceLimits: true,
+	RequiredLabels:        []string{"team"},
+}
+
+// messages the diagnostics as severity: message
+func messages(diagnostics []parser.Diagnostic) []string {
+	res := []string{}
+	for _, d := range diagnostics {
+		res = append(res, string(d.Severity)+": "+d.Message)
+	}
+
+	return res
+}
+
+// checkMessages every diagnostic has to contain the message at the
+// same index
+func checkMessages(t *testing.T, diagnostics []parser.Diagnostic, want []string) {
+	t.Helper()
+	got := messages(diagnostics)
+	if len(got) != len(want) {
+		t.Fatalf("diagnostics = %q, want %q", got, want)
+	}
+	for i := range want {
+		if !strings.Contains(got[i], want[i]) {
+			t.Errorf("diagnostic %d = %q, want %q", i, got[i], want[i])
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy config.PolicyConfig
+		change func(cj *batchv1.CronJob)
+		want   []string
+	}{
+		{
+			name:   "valid",
+			policy: testPolicy,
+			change: func(cj *batchv1.CronJob) {},
+		},
+		{
+			name:   "no name",
+			change: func(cj *batchv1.CronJob) { cj.Name = "" },
+			want:   []string{"error: the cronjob has no name"},
+		},
+		{
+			name:   "invalid name",
+			change: func(cj *batchv1.CronJob) { cj.Name = "Nightly_Backup" },
+			want:   []string{"error: invalid name: a lowercase RFC 1123 subdomain"},
+		},
+		{
+			name:   "52 character name",
+			change: func(cj *batchv1.CronJob) { cj.Name = strings.Repeat("a", 52) },
+		},
+		{
+			name:   "53 character name",
+			change: func(cj *batchv1.CronJob) { cj.Name = strings.Repeat("a", 53) },
+			want:   []string{"error: the name is longer than 52 characters"},
+		},
+		{
+			name:   "namespace",
+			change: func(cj *batchv1.CronJob) { cj.Namespace = "jobs" },
+			want:   []string{"warning: the namespace is set to jobs"},
+		},
+		{
+			name:   "no schedule",
+			change: func(cj *batchv1.CronJob) { cj.Spec.Schedule = "" },
+			want:   []string{"error: the schedule is empty"},
+		},
+		{
+			name:   "invalid schedule",
+			change: func(cj *batchv1.CronJob) { cj.Spec.Schedule = "every hour" },
+			want:   []string{`error: invalid schedule "every hour"`},
+		},
+		{
+			name:   "schedule macro",
+			change: func(cj *batchv1.CronJob) { cj.Spec.Schedule = "@hourly" },
+		},
+		{
+			name: "time zone",
+			change: func(cj *batchv1.CronJob) {
+				tz := "Europe/London"
+				cj.Spec.TimeZone = &tz
+			},
+		},
+		{
+			name: "invalid time zone",
+			change: func(cj *batchv1.CronJob) {
+				tz := "Mars/Olympus"
+				cj.Spec.TimeZone = &tz
+			},
+			want: []string{`error: invalid time zone "Mars/Olympus"`},
+		},
+		{
+			name: "restart policy",
+			change: func(cj *batchv1.CronJob) {
+				cj.Spec.JobTemplate.Spec.Template.Spec.RestartPolicy = corev1.RestartPolicyAlways
+			},
+			want: []string{"error: the restartPolicy of the pod has to be OnFailure or Never"},
+		},
+		{
+			name:   "no containers",
+			change: func(cj *batchv1.CronJob) { cj.Spec.JobTemplate.Spec.Template.Spec.Containers = nil },
+			want:   []string{"error: the pod has no containers"},
+		},
+		{
+			name:   "no image",
+			change: func(cj *batchv1.CronJob) { cj.Spec.JobTemplate.Spec.Template.Spec.Containers[0].Image = "" },
+			want:   []string{"error: container backup has no image"},
+		},
+		{
+			name:   "registry",
+			policy: testPolicy,
+			change: func(cj *batchv1.CronJob) {
+				cj.Spec.JobTemplate.Spec.Template.Spec.Containers[0].Image = "docker.io/acme/backup:1.0.0"
+			},
+			want: []string{"error: the image of container backup is not from an allowed registry"},
+		},
+		{
+			name:   "init containers are checked too",
+			policy: testPolicy,
+			change: func(cj *batchv1.CronJob) {
+				spec := &cj.Spec.JobTemplate.Spec.Template.Spec
+				init := spec.Containers[0]
+				init.Name = "init"
+				init.Image = "busybox:1.36"
+				spec.InitContainers = []corev1.Container{init}
+			},
+			want: []string{"error: the image of container init is not from an allowed registry"},
+		},
+		{
+			name:   "latest tag",
+			policy: testPolicy,
+			change: func(cj *batchv1.CronJob) {
+				cj.Spec.JobTemplate.Spec.Template.Spec.Containers[0].Image = "ghcr.io/acme/backup:latest"
+			},
+			want: []string{"error: container backup has to use a pinned image"},
+		},
+		{
+			name:   "latest tag is allowed without the policy",
+			change: func(cj *batchv1.CronJob) { cj.Spec.JobTemplate.Spec.Template.Spec.Containers[0].Image = "backup" },
+		},
+		{
+			name:   "resource limits",
+			policy: testPolicy,
+			change: func(cj *batchv1.CronJob) {
+				delete(cj.Spec.JobTemplate.Spec.Template.Spec.Containers[0].Resources.Limits, corev1.ResourceMemory)
+			},
+			want: []string{"error: container backup has to set cpu and memory limits"},
+		},
+		{
+			name:   "required labels",
+			policy: testPolicy,
+			change: func(cj *batchv1.CronJob) { cj.Labels = nil },
+			want:   []string{"error: missing required label team"},
+		},
+		{
+			name: "every problem is reported",
+			change: func(cj *batchv1.CronJob) {
+				cj.Name = ""
+				cj.Spec.Schedule = ""
+			},
+			want: []string{"error: the cronjob has no name", "error: the schedule is empty"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cj := testCronJob()
+			tt.change(cj)
+			checkMessages(t, NewValidator(tt.policy, config.CompanionsConfig{}).Validate(cj), tt.want)
+		})
+	}
+}
+
+func TestUsesLatestTag(t *testing.T) {
+	tests := map[string]bool{
+		"backup":                        true,
+		"backup:latest":                 true,
+		"ghcr.io/acme/backup":           true,
+		"ghcr.io/acme/backup:latest":    true,
+		"ghcr.io/acme/backup:1.0.0":     false,
+		"localhost:5000/backup":         true,
+		"localhost:5000/backup:1.0.0":   false,
+		"ghcr.io/acme/backup@sha256:ab": false,
+	}
+	for image, want := range tests {
+		if got := usesLatestTag(image); got != want {
+			t.Errorf("usesLatestTag(%q) = %t, want %t", image, got, want)
+		}
+	}
+}
+
+func TestValidateJob(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(task *batchv1.Job)
+		want   []string
+	}{
+		{
+			name:   "valid",
+			change: func(task *batchv1.Job) {},
+		},
+		{
+			name:   "57 character name",
+			change: func(task *batchv1.Job) { task.Name = strings.Repeat("a", 57) },
+		},
+		{
+			name:   "58 character name",
+			change: func(task *batchv1.Job) { task.Name = strings.Repeat("a", 58) },
+			want:   []string{"error: the name is longer than 57 characters"},
+		},
+		{
+			name:   "no name",
+			change: func(task *batchv1.Job) { task.Name = "" },
+			want:   []string{"error: the task has no name"},
+		},
+		{
+			name: "selector",
+			change: func(task *batchv1.Job) {
+				task.Spec.Selector = &metav1.LabelSelector{MatchLabels: map[string]string{"app": "migrate"}}
+			},
+			want: []string{"error: the selector of a task is generated for every run"},
+		},
+		{
+			name:   "pod spec",
+			change: func(task *batchv1.Job) { task.Spec.Template.Spec.Containers[0].Image = "docker.io/acme/migrate:1.0.0" },
+			want:   []string{"error: the image of container backup is not from an allowed registry"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			task := &batchv1.Job{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   "migrate",
+					Labels: map[string]string{"team": "a"},
+				},
+				Spec: testJobSpec(),
+			}
+			tt.change(task)
+			checkMessages(t, NewValidator(testPolicy, config.CompanionsConfig{}).ValidateJob(task), tt.want)
+		})
+	}
+}
+
+func companionOf(apiVersion string, kind string, name string) unstructured.Unstructured {
+	u := unstructured.Unstructured{}
+	u.SetAPIVersion(apiVersion)
+	u.SetKind(kind)
+	u.SetName(name)
+
+	return u
+}
+
+func TestValidateCompanions(t *testing.T) {
+	companions := config.CompanionsConfig{
+		AllowedKinds: []string{"ConfigMap", "ExternalSecret.external-secrets.io"},
+	}
+	namespaced := companionOf("v1", "ConfigMap", "backup")
+	namespaced.SetNamespace("jobs")
+
+	tests := []struct {
+		name       string
+		companions config.CompanionsConfig
+		res        parser.Result
+		want       []string
+	}{
+		{
+			name:       "allowed kinds",
+			companions: companions,
+			res: parser.Result{
+				CronJobs: []batchv1.CronJob{*testCronJob()},
+				Companions: []unstructured.Unstructured{
+					companionOf("v1", "ConfigMap", "backup"),
+					companionOf("external-secrets.io/v1beta1", "ExternalSecret", "backup"),
+				},
+			},
+		},
+		{
+			name:       "kind that is not allowed",
+			companions: companions,
+			res: parser.Result{
+				CronJobs:   []batchv1.CronJob{*testCronJob()},
+				Companions: []unstructured.Unstructured{companionOf("v1", "Secret", "backup")},
+			},
+			want: []string{"warning: Secret/backup: the kind is not in companions.allowedKinds, it is not applied"},
+		},
+		{
+			name:       "no name",
+			companions: companions,
+			res: parser.Result{
+				CronJobs:   []batchv1.CronJob{*testCronJob()},
+				Companions: []unstructured.Unstructured{companionOf("v1", "ConfigMap", "")},
+			},
+			want: []string{"error: a ConfigMap has no name"},
+		},
+		{
+			name:       "namespace",
+			companions: companions,
+			res: parser.Result{
+				CronJobs:   []batchv1.CronJob{*testCronJob()},
+				Companions: []unstructured.Unstructured{namespaced},
+			},
+			want: []string{"warning: ConfigMap/backup: the namespace is set to jobs"},
+		},
+		{
+			name:       "file without a cronjob",
+			companions: companions,
+			res: parser.Result{
+				Companions: []unstructured.Unstructured{companionOf("v1", "ConfigMap", "backup")},
+			},
+			want: []string{"warning: the file has no cronjob"},
+		},
+		{
+			name: "companions are ignored when none are allowed",
+			res: parser.Result{
+				Companions: []unstructured.Unstructured{companionOf("v1", "Secret", "")},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkMessages(t, NewValidator(config.PolicyConfig{}, tt.companions).ValidateCompanions(tt.res), tt.want)
+		})
+	}
+}
+
+func TestValidateFiles(t *testing.T) {
+	invalid := testCronJob()
+	invalid.Name = "report"
+	invalid.Spec.Schedule = "every hour"
+	task := batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{Name: "migrate"},
+		Spec:       testJobSpec(),
+	}
+	files := []File{
+		{
+			Path: "a.yaml",
+			Result: parser.Result{
+				CronJobs: []batchv1.CronJob{*testCronJob(), *invalid},
+				Jobs:     []batchv1.Job{task},
+			},
+		},
+		{
+			Path: "b.yaml",
+			Result: parser.Result{
+				CronJobs: []batchv1.CronJob{*testCronJob()},
+				Jobs:     []batchv1.Job{task},
+				Diagnostics: []parser.Diagnostic{{
+					Document: 3,
+					Severity: parser.SeverityError,
+					Message:  "failed to decode yaml config",
+				}},
+			},
+		},
+	}
+
+	diagnostics := NewValidator(config.PolicyConfig{}, config.CompanionsConfig{}).ValidateFiles(files)
+	got := []string{}
+	for _, d := range diagnostics {
+		got = append(got, d.String())
+	}
+	want := []string{
+		`a.yaml: error: report: invalid schedule "every hour"`,
+		"b.yaml: document 3: error: failed to decode yaml config",
+		"b.yaml: error: backup: the cronjob is also defined in a.yaml",
+		"b.yaml: error: migrate: the task is also defined in a.yaml",
+	}
+	if len(got) != len(want) {
+		t.Fatalf("diagnostics = %q, want %q", got, want)
+	}
+	for i := range want {
+		if !strings.HasPrefix(got[i], want[i]) {
+			t.Errorf("diagnostic %d = %q, want %q", i, got[i], want[i])
+		}
+	}
+	if !HasErrors(diagnostics) {
+		t.Error("HasErrors = false")
+	}
+	if HasErrors(nil) {
+		t.Error("HasErrors(nil) = true")
+	}
+}